@@ -0,0 +1,41 @@
+package alarm
+
+import (
+	"sagooiot/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+type AlarmEscalationListReq struct {
+	g.Meta `path:"/escalation/list" method:"get" summary:"升级策略列表" tags:"告警"`
+	*model.AlarmEscalationListInput
+}
+type AlarmEscalationListRes struct {
+	*model.AlarmEscalationListOutput
+}
+
+type AlarmEscalationDetailReq struct {
+	g.Meta `path:"/escalation/detail" method:"get" summary:"升级策略详情" tags:"告警"`
+	Id     uint64 `json:"id" dc:"升级策略ID" v:"required#升级策略ID不能为空"`
+}
+type AlarmEscalationDetailRes struct {
+	Data *model.AlarmEscalationOutput `json:"data" dc:"升级策略详情"`
+}
+
+type AlarmEscalationAddReq struct {
+	g.Meta `path:"/escalation/add" method:"post" summary:"新增升级策略" tags:"告警"`
+	*model.AlarmEscalationAddInput
+}
+type AlarmEscalationAddRes struct{}
+
+type AlarmEscalationEditReq struct {
+	g.Meta `path:"/escalation/edit" method:"put" summary:"编辑升级策略" tags:"告警"`
+	*model.AlarmEscalationEditInput
+}
+type AlarmEscalationEditRes struct{}
+
+type AlarmEscalationDelReq struct {
+	g.Meta `path:"/escalation/del" method:"delete" summary:"删除升级策略" tags:"告警"`
+	Id     uint64 `json:"id" dc:"升级策略ID" v:"required#升级策略ID不能为空"`
+}
+type AlarmEscalationDelRes struct{}
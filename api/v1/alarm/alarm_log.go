@@ -24,6 +24,14 @@ type AlarmLogListRes struct {
 	*model.AlarmLogListOutput
 }
 
+type AlarmLogActionReq struct {
+	g.Meta `path:"/log/action" method:"get" summary:"告警设备控制记录" tags:"告警"`
+	LogId  uint64 `json:"logId" dc:"告警日志ID" v:"required#告警日志ID不能为空"`
+}
+type AlarmLogActionRes struct {
+	*model.AlarmActionLogListOutput
+}
+
 type AlarmLogHandleReq struct {
 	g.Meta  `path:"/log/handle" method:"post" summary:"告警处理" tags:"告警"`
 	Id      uint64 `json:"id" dc:"告警日志ID" v:"required#告警日志ID不能为空"`
@@ -45,3 +45,12 @@ type DeviceAlarmLogDataRes struct {
 	Data interface{}
 	common.PaginationRes
 }
+
+// TsdQueryReq 按查询条件查询时序数据
+type TsdQueryReq struct {
+	g.Meta `path:"/tsdQuery" method:"post" summary:"时序数据查询" tags:"IOT数据分析"`
+	*model.TsdQueryInput
+}
+type TsdQueryRes struct {
+	*model.TsdQueryOutput
+}
@@ -0,0 +1,102 @@
+package network
+
+import (
+	"sagooiot/api/v1/common"
+	"sagooiot/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 获取北向消息出口列表api
+type GetNetworkNorthSinkListReq struct {
+	g.Meta     `path:"/north/sink/list" method:"get" summary:"获取北向消息出口列表" tags:"网络组件管理"`
+	Types      string `json:"types" dc:"出口类型：mqtt、webhook"`
+	ProductKey string `json:"productKey" dc:"产品标识"`
+	*common.PaginationReq
+}
+type GetNetworkNorthSinkListRes struct {
+	Data []*model.NetworkNorthSinkOut
+	common.PaginationRes
+}
+
+// 获取指定ID的北向消息出口api
+type GetNetworkNorthSinkByIdReq struct {
+	g.Meta `path:"/north/sink/get" method:"get" summary:"获取单个北向消息出口" tags:"网络组件管理"`
+	Id     int `json:"id" description:"id" v:"required#id不能为空"`
+}
+type GetNetworkNorthSinkByIdRes struct {
+	Data *model.NetworkNorthSinkOut
+}
+
+// 添加北向消息出口api
+type AddNetworkNorthSinkReq struct {
+	g.Meta        `path:"/north/sink/add" method:"post" summary:"添加北向消息出口" tags:"网络组件管理"`
+	Name          string `json:"name"          description:"名称" v:"required#名称不能为空"`
+	Types         string `json:"types"         description:"出口类型：mqtt、webhook" v:"required|in:mqtt,webhook#类型不能为空|类型只能是mqtt或webhook"`
+	ProductKey    string `json:"productKey"    description:"产品标识，为空时匹配全部产品"`
+	Topics        string `json:"topics"        description:"消息主题，多个用逗号分隔，为空时匹配全部主题"`
+	Config        string `json:"config"        description:"出口配置，mqtt:{topicPrefix}，webhook:{url,secret,headers,timeout}"`
+	RetryTimes    int    `json:"retryTimes"    description:"重试次数" v:"min:0#重试次数不能小于0"`
+	RetryInterval int    `json:"retryInterval" description:"首次重试间隔（秒）" v:"min:0#重试间隔不能小于0"`
+	Status        int    `json:"status"        description:"状态：0=停用，1=启用"`
+	Remark        string `json:"remark"        description:"备注"`
+}
+type AddNetworkNorthSinkRes struct{}
+
+// 编辑北向消息出口api
+type EditNetworkNorthSinkReq struct {
+	g.Meta        `path:"/north/sink/edit" method:"put" summary:"编辑北向消息出口" tags:"网络组件管理"`
+	Id            int    `json:"id"            description:"id" v:"required#id不能为空"`
+	Name          string `json:"name"          description:"名称" v:"required#名称不能为空"`
+	Types         string `json:"types"         description:"出口类型：mqtt、webhook" v:"required|in:mqtt,webhook#类型不能为空|类型只能是mqtt或webhook"`
+	ProductKey    string `json:"productKey"    description:"产品标识，为空时匹配全部产品"`
+	Topics        string `json:"topics"        description:"消息主题，多个用逗号分隔，为空时匹配全部主题"`
+	Config        string `json:"config"        description:"出口配置，mqtt:{topicPrefix}，webhook:{url,secret,headers,timeout}"`
+	RetryTimes    int    `json:"retryTimes"    description:"重试次数" v:"min:0#重试次数不能小于0"`
+	RetryInterval int    `json:"retryInterval" description:"首次重试间隔（秒）" v:"min:0#重试间隔不能小于0"`
+	Status        int    `json:"status"        description:"状态：0=停用，1=启用"`
+	Remark        string `json:"remark"        description:"备注"`
+}
+type EditNetworkNorthSinkRes struct{}
+
+// 删除北向消息出口api
+type DeleteNetworkNorthSinkReq struct {
+	g.Meta `path:"/north/sink/delete" method:"delete" summary:"删除北向消息出口" tags:"网络组件管理"`
+	Ids    []int `json:"ids" description:"ids" v:"required#ids不能为空"`
+}
+type DeleteNetworkNorthSinkRes struct{}
+
+// 北向消息出口状态api
+type SetNetworkNorthSinkStatusReq struct {
+	g.Meta `path:"/north/sink/status" method:"post" summary:"修改北向消息出口状态" tags:"网络组件管理"`
+	Id     int `json:"id"     description:"id" v:"required#id不能为空"`
+	Status int `json:"status" description:"status" v:"in:0,1#状态只能是0或1"`
+}
+type SetNetworkNorthSinkStatusRes struct{}
+
+// 获取投递失败的北向消息列表api
+type GetNetworkNorthDeadLetterListReq struct {
+	g.Meta    `path:"/north/deadLetter/list" method:"get" summary:"获取投递失败的北向消息列表" tags:"网络组件管理"`
+	SinkId    int    `json:"sinkId" dc:"消息出口ID"`
+	Topic     string `json:"topic" dc:"消息主题"`
+	DeviceKey string `json:"deviceKey" dc:"设备标识"`
+	*common.PaginationReq
+}
+type GetNetworkNorthDeadLetterListRes struct {
+	Data []*model.NetworkNorthDeadLetterOut
+	common.PaginationRes
+}
+
+// 重新投递北向消息api
+type RetryNetworkNorthDeadLetterReq struct {
+	g.Meta `path:"/north/deadLetter/retry" method:"post" summary:"重新投递失败的北向消息" tags:"网络组件管理"`
+	Ids    []int `json:"ids" description:"ids" v:"required#ids不能为空"`
+}
+type RetryNetworkNorthDeadLetterRes struct{}
+
+// 删除投递失败的北向消息api
+type DeleteNetworkNorthDeadLetterReq struct {
+	g.Meta `path:"/north/deadLetter/delete" method:"delete" summary:"删除投递失败的北向消息" tags:"网络组件管理"`
+	Ids    []int `json:"ids" description:"ids" v:"required#ids不能为空"`
+}
+type DeleteNetworkNorthDeadLetterRes struct{}
@@ -25,6 +25,7 @@ type GetNoticeConfigByIdReq struct {
 type GetNoticeConfigByIdRes struct {
 	SendGateway string `json:"sendGateway"          description:""`
 	Types       string `json:"types"          description:""`
+	Config      string `json:"config"      description:"通道配置，JSON格式，内置的邮件、Webhook、钉钉、企业微信、飞书通道使用，密码和密钥显示为******"`
 	CreatedAt   string `json:"createdAt"          description:""`
 	Id          string `json:"id"          description:""`
 	Title       string `json:"title"          description:""`
@@ -36,6 +37,7 @@ type AddNoticeConfigReq struct {
 	Title       string `json:"title"          description:""`
 	SendGateway string `json:"sendGateway"          description:""`
 	Types       string `json:"types"          description:""`
+	Config      string `json:"config"      description:"通道配置，JSON格式，内置的邮件、Webhook、钉钉、企业微信、飞书通道使用"`
 	CreatedAt   string `json:"createdAt"          description:""`
 }
 type AddNoticeConfigRes struct{}
@@ -47,6 +49,7 @@ type EditNoticeConfigReq struct {
 	Title       string `json:"title"          description:""`
 	SendGateway string `json:"sendGateway"          description:""`
 	Types       string `json:"types"          description:""`
+	Config      string `json:"config"      description:"通道配置，JSON格式，内置的邮件、Webhook、钉钉、企业微信、飞书通道使用，密码和密钥为******时保持原值"`
 	CreatedAt   string `json:"createdAt"          description:""`
 }
 type EditNoticeConfigRes struct{}
@@ -0,0 +1,47 @@
+package product
+
+import (
+	"sagooiot/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+type SaveDeviceConfigReq struct {
+	g.Meta `path:"/config/save" method:"post" summary:"保存远程配置" tags:"远程配置"`
+	*model.DeviceConfigSaveInput
+}
+type SaveDeviceConfigRes struct {
+	Data *model.DeviceConfigOutput
+}
+
+type DetailDeviceConfigReq struct {
+	g.Meta `path:"/config/detail" method:"get" summary:"远程配置详情" tags:"远程配置"`
+	*model.DeviceConfigDetailInput
+}
+type DetailDeviceConfigRes struct {
+	Data *model.DeviceConfigOutput
+}
+
+type HistoryDeviceConfigReq struct {
+	g.Meta `path:"/config/history" method:"get" summary:"远程配置版本列表" tags:"远程配置"`
+	*model.DeviceConfigHistoryInput
+}
+type HistoryDeviceConfigRes struct {
+	*model.DeviceConfigHistoryOutput
+}
+
+type PushDeviceConfigReq struct {
+	g.Meta `path:"/config/push" method:"post" summary:"下发远程配置" tags:"远程配置"`
+	*model.DeviceConfigPushInput
+}
+type PushDeviceConfigRes struct {
+	*model.DeviceConfigPushOutput
+}
+
+type PushListDeviceConfigReq struct {
+	g.Meta `path:"/config/push/list" method:"get" summary:"远程配置下发记录" tags:"远程配置"`
+	*model.DeviceConfigPushListInput
+}
+type PushListDeviceConfigRes struct {
+	*model.DeviceConfigPushListOutput
+}
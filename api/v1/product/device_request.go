@@ -0,0 +1,15 @@
+package product
+
+import (
+	"sagooiot/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+type ListDeviceRequestReq struct {
+	g.Meta `path:"/device/request/list" method:"get" summary:"设备同步请求记录" tags:"设备"`
+	*model.DeviceRequestListInput
+}
+type ListDeviceRequestRes struct {
+	*model.DeviceRequestListOutput
+}
@@ -0,0 +1,29 @@
+package product
+
+import (
+	"sagooiot/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+type GetDeviceShadowReq struct {
+	g.Meta    `path:"/device/shadow/get" method:"get" summary:"获取设备影子" tags:"设备影子"`
+	DeviceKey string `json:"deviceKey" dc:"设备标识" v:"required#设备标识不能为空"`
+}
+type GetDeviceShadowRes struct {
+	Data *model.DeviceShadow
+}
+
+type UpdateDeviceShadowReq struct {
+	g.Meta `path:"/device/shadow/update" method:"put" summary:"更新设备影子期望属性" tags:"设备影子"`
+	*model.DeviceShadowUpdateInput
+}
+type UpdateDeviceShadowRes struct {
+	Data *model.DeviceShadow
+}
+
+type DeleteDeviceShadowReq struct {
+	g.Meta `path:"/device/shadow/delete" method:"delete" summary:"删除设备影子" tags:"设备影子"`
+	*model.DeviceShadowDeleteInput
+}
+type DeleteDeviceShadowRes struct{}
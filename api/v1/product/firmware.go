@@ -0,0 +1,87 @@
+package product
+
+import (
+	"sagooiot/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+type AddFirmwareReq struct {
+	g.Meta `path:"/firmware/add" method:"post" summary:"添加固件" tags:"固件升级"`
+	File   *ghttp.UploadFile `json:"file" type:"file" dc:"固件文件" v:"required#请上传固件文件"`
+	*model.FirmwareAddInput
+}
+type AddFirmwareRes struct{}
+
+type EditFirmwareReq struct {
+	g.Meta `path:"/firmware/edit" method:"put" summary:"编辑固件" tags:"固件升级"`
+	*model.FirmwareEditInput
+}
+type EditFirmwareRes struct{}
+
+type DelFirmwareReq struct {
+	g.Meta `path:"/firmware/del" method:"delete" summary:"删除固件" tags:"固件升级"`
+	Id     uint64 `json:"id" dc:"固件ID" v:"required#固件ID不能为空"`
+}
+type DelFirmwareRes struct{}
+
+type DetailFirmwareReq struct {
+	g.Meta `path:"/firmware/detail" method:"get" summary:"固件详情" tags:"固件升级"`
+	Id     uint64 `json:"id" dc:"固件ID" v:"required#固件ID不能为空"`
+}
+type DetailFirmwareRes struct {
+	Data *model.FirmwareOutput
+}
+
+type ListFirmwareReq struct {
+	g.Meta `path:"/firmware/list" method:"get" summary:"固件列表" tags:"固件升级"`
+	*model.FirmwareListInput
+}
+type ListFirmwareRes struct {
+	*model.FirmwareListOutput
+}
+
+type AddFirmwareTaskReq struct {
+	g.Meta `path:"/firmware/task/add" method:"post" summary:"创建升级任务" tags:"固件升级"`
+	*model.FirmwareTaskAddInput
+}
+type AddFirmwareTaskRes struct {
+	Id uint64 `json:"id" dc:"升级任务ID"`
+}
+
+type DetailFirmwareTaskReq struct {
+	g.Meta `path:"/firmware/task/detail" method:"get" summary:"升级任务详情" tags:"固件升级"`
+	Id     uint64 `json:"id" dc:"升级任务ID" v:"required#升级任务ID不能为空"`
+}
+type DetailFirmwareTaskRes struct {
+	Data *model.FirmwareTaskOutput
+}
+
+type ListFirmwareTaskReq struct {
+	g.Meta `path:"/firmware/task/list" method:"get" summary:"升级任务列表" tags:"固件升级"`
+	*model.FirmwareTaskListInput
+}
+type ListFirmwareTaskRes struct {
+	*model.FirmwareTaskListOutput
+}
+
+type StartFirmwareTaskReq struct {
+	g.Meta `path:"/firmware/task/start" method:"post" summary:"启动升级任务" tags:"固件升级"`
+	Id     uint64 `json:"id" dc:"升级任务ID" v:"required#升级任务ID不能为空"`
+}
+type StartFirmwareTaskRes struct{}
+
+type CancelFirmwareTaskReq struct {
+	g.Meta `path:"/firmware/task/cancel" method:"post" summary:"取消升级任务" tags:"固件升级"`
+	Id     uint64 `json:"id" dc:"升级任务ID" v:"required#升级任务ID不能为空"`
+}
+type CancelFirmwareTaskRes struct{}
+
+type ListFirmwareDeviceReq struct {
+	g.Meta `path:"/firmware/task/device" method:"get" summary:"设备升级记录" tags:"固件升级"`
+	*model.FirmwareDeviceListInput
+}
+type ListFirmwareDeviceRes struct {
+	*model.FirmwareDeviceListOutput
+}
@@ -87,6 +87,14 @@ type UpdateScriptInfoReq struct {
 }
 type UpdateScriptInfoRes struct{}
 
+type DebugScriptReq struct {
+	g.Meta `path:"/script/debug" method:"post" summary:"脚本调试" tags:"产品"`
+	*model.ScriptDebugInput
+}
+type DebugScriptRes struct {
+	*model.ScriptDebugOutput
+}
+
 type ConnectIntroReq struct {
 	g.Meta     `path:"/connect_intro" method:"get" summary:"获取设备接入信息" tags:"产品"`
 	ProductKey string `json:"productKey" dc:"产品标识" v:"required#产品标识不能为空"`
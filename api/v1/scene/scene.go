@@ -0,0 +1,72 @@
+package scene
+
+import (
+	"sagooiot/api/v1/common"
+	"sagooiot/internal/model"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+type SceneListReq struct {
+	g.Meta    `path:"/list" method:"get" summary:"场景列表" tags:"场景联动"`
+	SceneType string `json:"sceneType" dc:"场景类型：manual、timer、device"`
+	Status    string `json:"status" dc:"状态：0=未启用，1=已启用"`
+	common.PaginationReq
+}
+type SceneListRes struct {
+	*model.SceneListOutput
+}
+
+type SceneAddReq struct {
+	g.Meta `path:"/add" method:"post" summary:"新增场景" tags:"场景联动"`
+	*model.SceneAddInput
+}
+type SceneAddRes struct{}
+
+type SceneEditReq struct {
+	g.Meta `path:"/edit" method:"put" summary:"编辑场景" tags:"场景联动"`
+	*model.SceneEditInput
+}
+type SceneEditRes struct{}
+
+type SceneDetailReq struct {
+	g.Meta `path:"/detail" method:"get" summary:"场景详情" tags:"场景联动"`
+	Id     uint64 `json:"id" dc:"场景ID" v:"required#场景ID不能为空"`
+}
+type SceneDetailRes struct {
+	Data *model.SceneOutput `json:"data" dc:"场景详情"`
+}
+
+type SceneDeployReq struct {
+	g.Meta `path:"/deploy" method:"post" summary:"启用" tags:"场景联动"`
+	Id     uint64 `json:"id" dc:"场景ID" v:"required#场景ID不能为空"`
+}
+type SceneDeployRes struct{}
+
+type SceneUndeployReq struct {
+	g.Meta `path:"/undeploy" method:"post" summary:"禁用" tags:"场景联动"`
+	Id     uint64 `json:"id" dc:"场景ID" v:"required#场景ID不能为空"`
+}
+type SceneUndeployRes struct{}
+
+type SceneDelReq struct {
+	g.Meta `path:"/del" method:"delete" summary:"删除" tags:"场景联动"`
+	Id     uint64 `json:"id" dc:"场景ID" v:"required#场景ID不能为空"`
+}
+type SceneDelRes struct{}
+
+type SceneRunReq struct {
+	g.Meta `path:"/run" method:"post" summary:"手动执行场景" tags:"场景联动"`
+	Id     uint64 `json:"id" dc:"场景ID" v:"required#场景ID不能为空"`
+}
+type SceneRunRes struct{}
+
+type SceneLogListReq struct {
+	g.Meta  `path:"/log/list" method:"get" summary:"场景执行日志" tags:"场景联动"`
+	SceneId uint64 `json:"sceneId" dc:"场景ID"`
+	Status  string `json:"status" dc:"执行结果：0=失败，1=成功"`
+	common.PaginationReq
+}
+type SceneLogListRes struct {
+	*model.SceneLogListOutput
+}
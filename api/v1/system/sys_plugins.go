@@ -75,3 +75,11 @@ type GetSysPluginsTypesAllReq struct {
 type GetSysPluginsTypesAllRes struct {
 	Data []*model.SysPluginsInfoRes
 }
+
+type GetSysPluginsStatusReq struct {
+	g.Meta `path:"/plugins/status" method:"get" summary:"获取插件运行状态" tags:"插件管理"`
+	Types  string `json:"types"            description:"功能类型 协议(protocol)或者通知(notice)，为空时获取全部"`
+}
+type GetSysPluginsStatusRes struct {
+	Data []*model.SysPluginsStatusRes
+}
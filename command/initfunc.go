@@ -24,7 +24,10 @@ var InitFuncNoDeferListForIotCore = []NoDeferFunc{
 	{service.DevInit().InitDeviceForTd, "时序库设备表初始化"},
 	{service.DevDevice().CacheDeviceDetailList, "缓存设备信息"},
 	{service.AlarmRule().CacheAllAlarmRule, "缓存告警规则"},
+	{service.Scene().LoadScene, "场景联动"},
+	{service.NetworkNorth().LoadNorthSinks, "北向消息出口"},
 	{network.ReloadNetwork, "网络服务"},
+	{service.DevFirmware().ResumeTasks, "固件升级任务"},
 }
 
 var InitFuncNoDeferListWebAdmin = []NoDeferFunc{
@@ -48,7 +51,10 @@ func InitSystem(ctx context.Context, noDeferFuncList []NoDeferFunc) error {
 
 var initFuncWithDeferList = []DeferFunc{
 	{RunQueue, "消息队列"},
+	{runMqttBroker, "内置mqtt服务"},
 	{wrapperMqtt, "mqtt连接"},
+	{runDeviceOfflineCheck, "设备离线检测"},
+	{runPluginSupervisor, "插件守护"},
 }
 
 func InitSystemDeferFunc(ctx context.Context) ([]func(context.Context) error, error) {
@@ -6,6 +6,9 @@ import (
 	"sagooiot/internal/queues"
 	_ "sagooiot/internal/queues"
 	"sagooiot/module"
+	"sagooiot/network/core/broker"
+	"sagooiot/pkg/dcache"
+	"sagooiot/pkg/plugins"
 	"sagooiot/pkg/worker"
 )
 
@@ -16,6 +19,11 @@ func RunQueue(ctx context.Context) (error, func(context.Context) error) {
 	return nil, nil
 }
 
+func runMqttBroker(ctx context.Context) (error, func(context.Context) error) {
+	stop, err := broker.Start(ctx)
+	return err, stop
+}
+
 func wrapperMqtt(ctx context.Context) (error, func(context.Context) error) {
 	if err := mqtt.InitSystemMqtt(); err != nil {
 		return err, nil
@@ -26,6 +34,14 @@ func wrapperMqtt(ctx context.Context) (error, func(context.Context) error) {
 	}
 }
 
+func runDeviceOfflineCheck(ctx context.Context) (error, func(context.Context) error) {
+	return nil, dcache.StartOfflineCheck(ctx)
+}
+
+func runPluginSupervisor(ctx context.Context) (error, func(context.Context) error) {
+	return nil, plugins.StartSupervisor(ctx)
+}
+
 type DeferFunc struct {
 	F    func(ctx context.Context) (error, func(context.Context) error)
 	Desc string
@@ -7,7 +7,9 @@ import (
 	networkController "sagooiot/internal/controller/network"
 	noticeController "sagooiot/internal/controller/notice"
 	productController "sagooiot/internal/controller/product"
+	sceneController "sagooiot/internal/controller/scene"
 	tdengineController "sagooiot/internal/controller/tdengine"
+	"sagooiot/internal/webscoket/privater"
 
 	"sagooiot/internal/service"
 )
@@ -26,6 +28,7 @@ func Iot(ctx context.Context, group *ghttp.RouterGroup) {
 			productController.DeviceLog,      // 设备日志
 			productController.DeviceFunction, // 设备功能执行
 			productController.DeviceProperty, // 设备属性设置
+			productController.DeviceRequest,  // 设备同步请求记录
 			productController.TSLDataType,    // 物模型：数据类型
 			productController.TSLProperty,    // 物模型：属性
 			productController.TSLFunction,    // 物模型：功能
@@ -33,6 +36,9 @@ func Iot(ctx context.Context, group *ghttp.RouterGroup) {
 			productController.TSLTag,         // 物模型：标签
 			productController.DeviceTree,     // 设备树
 			productController.TSLImport,      // 物模型：导入/导出
+			productController.Firmware,       // 固件升级
+			productController.DeviceConfig,   // 远程配置
+			productController.DeviceShadow,   // 设备影子
 		)
 	})
 
@@ -40,9 +46,18 @@ func Iot(ctx context.Context, group *ghttp.RouterGroup) {
 	group.Group("/alarm", func(group *ghttp.RouterGroup) {
 		group.Middleware(service.Middleware().Auth)
 		group.Bind(
-			alarmController.AlarmLevel, // 告警级别
-			alarmController.AlarmRule,  // 告警规则
-			alarmController.AlarmLog,   // 告警日志
+			alarmController.AlarmLevel,      // 告警级别
+			alarmController.AlarmRule,       // 告警规则
+			alarmController.AlarmLog,        // 告警日志
+			alarmController.AlarmEscalation, // 告警升级策略
+		)
+	})
+
+	// 场景联动相关路由
+	group.Group("/scene", func(group *ghttp.RouterGroup) {
+		group.Middleware(service.Middleware().Auth)
+		group.Bind(
+			sceneController.Scene, // 场景联动
 		)
 	})
 
@@ -52,7 +67,7 @@ func Iot(ctx context.Context, group *ghttp.RouterGroup) {
 		group.Bind(
 			networkController.Tunnel, // 通讯通道管理
 			networkController.Server, // 通讯服务管理
-
+			networkController.North,  // 北向消息出口管理
 		)
 	})
 
@@ -64,6 +79,12 @@ func Iot(ctx context.Context, group *ghttp.RouterGroup) {
 		)
 	})
 
+	//设备实时数据订阅，连接后通过 device.subscribe 命令订阅
+	group.Group("/websocket", func(group *ghttp.RouterGroup) {
+		group.Middleware(service.Middleware().Auth)
+		group.GET("/device", privater.Handler)
+	})
+
 	//通知服务相关路由
 	group.Group("/notice", func(group *ghttp.RouterGroup) {
 		group.Middleware(service.Middleware().Auth)
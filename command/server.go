@@ -10,6 +10,7 @@ import (
 	"sagooiot/internal/service"
 	"sagooiot/internal/sse"
 	"sagooiot/module"
+	"sagooiot/pkg/tsd"
 	"sagooiot/pkg/utility"
 	"syscall"
 	"time"
@@ -148,6 +149,9 @@ func RunServer(ctx context.Context, stopSignal chan os.Signal, s *ghttp.Server)
 			}
 		}
 
+		// 释放时序数据库连接和后台任务
+		tsd.Shutdown()
+
 		// 5. 给协程一些时间完成清理
 		time.Sleep(2 * time.Second)
 
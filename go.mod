@@ -36,6 +36,8 @@ require (
 	github.com/xuri/excelize/v2 v2.8.0
 	go.opentelemetry.io/otel v1.18.0
 	golang.org/x/oauth2 v0.27.0
+	google.golang.org/grpc v1.56.1
+	google.golang.org/protobuf v1.32.0
 )
 
 require (
@@ -105,8 +107,6 @@ require (
 	golang.org/x/text v0.22.0 // indirect
 	golang.org/x/time v0.5.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20230629202037-9506855d4529 // indirect
-	google.golang.org/grpc v1.56.1 // indirect
-	google.golang.org/protobuf v1.32.0 // indirect
 	gopkg.in/fsnotify.v1 v1.4.7 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/sourcemap.v1 v1.0.5 // indirect
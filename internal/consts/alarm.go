@@ -27,3 +27,19 @@ const (
 	AlarmRuleStatusOff int = iota // 告警规则状态：未启用
 	AlarmRuleStatusOn             // 告警规则状态：已启用
 )
+
+const (
+	AlarmEscalationStatusOff int = iota // 升级策略状态：未启用
+	AlarmEscalationStatusOn             // 升级策略状态：已启用
+)
+
+const (
+	AlarmDeviceActionFunction = "function" // 设备控制动作：调用功能
+	AlarmDeviceActionProperty = "property" // 设备控制动作：设置属性
+)
+
+const (
+	AlarmActionLogStatusFail    = iota // 设备控制动作执行状态：失败
+	AlarmActionLogStatusSuccess        // 设备控制动作执行状态：成功
+	AlarmActionLogStatusLimited        // 设备控制动作执行状态：受冷却或频率限制未执行
+)
@@ -8,11 +8,25 @@ const (
 	DeviceOnlineTimeOut = 120
 	// 设备状态缓存KEY前缀
 	DeviceStatusPrefix = "deviceStatus:"
+	// 设备离线截止时间有序集合KEY
+	DeviceOfflineDeadlineKey = "deviceOfflineDeadline"
+	// 设备离线检测主节点KEY
+	DeviceOfflineLeaderKey = "deviceOfflineLeader"
 
 	//设备告警规则缓存KEY前缀
 	DeviceAlarmRulePrefix = "deviceAlarmRule:"
 	// 设备告警日志缓存KEY前缀
 	DeviceAlarmLogPrefix = "deviceAlarmLog:"
+	// 设备告警状态缓存KEY前缀，按告警规则和设备记录告警是否持续中
+	DeviceAlarmStatePrefix = "deviceAlarmState:"
+	// 设备告警防抖状态缓存KEY前缀
+	DeviceAlarmDebouncePrefix = "deviceAlarmDebounce:"
+	// 告警设备控制动作限制状态缓存KEY前缀
+	DeviceAlarmActionPrefix = "deviceAlarmAction:"
+	// 设备影子缓存KEY前缀
+	DeviceShadowPrefix = "deviceShadow:"
+	// 设备场景联动缓存KEY前缀
+	DeviceScenePrefix = "deviceScene:"
 
 	// 系统配置参数缓存KEY前缀
 	SystemConfigPrefix = "systemConfig:"
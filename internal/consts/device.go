@@ -5,3 +5,16 @@ const (
 	DeviceStatueOffline = 1
 	DeviceStatueOnline  = 2
 )
+
+// 设备认证方式
+const (
+	DeviceAuthTypeBasic       = 1
+	DeviceAuthTypeAccessToken = 2
+	DeviceAuthTypeCertificate = 3
+)
+
+// 设备属性设置结果
+const (
+	DevicePropertySetSent    = "sent"    // 已下发到设备
+	DevicePropertySetPending = "pending" // 设备离线，已写入设备影子，设备上线后下发
+)
@@ -0,0 +1,13 @@
+package consts
+
+const (
+	DeviceConfigSignMethod   = "Sha256"  // 设备远程配置的签名方法
+	DeviceConfigGetType      = "content" // 配置内容随报文下发
+	DeviceConfigScopeProduct = "product" // 设备请求产品配置
+)
+
+const (
+	DeviceConfigPushStatusSent    int = iota // 配置下发状态：已下发
+	DeviceConfigPushStatusSuccess            // 配置下发状态：成功
+	DeviceConfigPushStatusFail               // 配置下发状态：失败
+)
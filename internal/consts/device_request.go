@@ -0,0 +1,9 @@
+package consts
+
+// 设备同步请求状态
+const (
+	DeviceRequestStatusWait    = 0 // 等待响应
+	DeviceRequestStatusReplied = 1 // 已响应
+	DeviceRequestStatusTimeout = 2 // 超时
+	DeviceRequestStatusFail    = 3 // 失败
+)
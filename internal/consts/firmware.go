@@ -0,0 +1,35 @@
+package consts
+
+const (
+	FirmwareModuleDefault = "default" // 默认模块
+
+	FirmwareSignMethodMd5    = "MD5"
+	FirmwareSignMethodSha256 = "SHA256"
+
+	// 升级范围
+	FirmwareTargetProduct = "product" // 产品全部设备
+	FirmwareTargetDevice  = "device"  // 指定设备
+	FirmwareTargetTag     = "tag"     // 设备标签
+
+	// 设备下载固件时每个分片的最大长度
+	FirmwareFileBlockMaxSize = 128 * 1024
+
+	// 升级任务推送锁，多个实例只由一个实例推送同一任务
+	FirmwareRolloutLockPrefix = "firmwareRolloutLock:"
+)
+
+const (
+	FirmwareTaskStatusWait    int = iota // 升级任务状态：待执行
+	FirmwareTaskStatusRunning            // 升级任务状态：执行中
+	FirmwareTaskStatusDone               // 升级任务状态：已完成
+	FirmwareTaskStatusCancel             // 升级任务状态：已取消
+)
+
+const (
+	FirmwareDeviceStatusWait      int = iota // 设备升级状态：待推送
+	FirmwareDeviceStatusPushed               // 设备升级状态：已推送
+	FirmwareDeviceStatusUpgrading            // 设备升级状态：升级中
+	FirmwareDeviceStatusSuccess              // 设备升级状态：升级成功
+	FirmwareDeviceStatusFail                 // 设备升级状态：升级失败
+	FirmwareDeviceStatusCancel               // 设备升级状态：已取消
+)
@@ -0,0 +1,16 @@
+package consts
+
+// 北向消息出口类型
+const (
+	NorthSinkTypeMqtt    = "mqtt"
+	NorthSinkTypeWebhook = "webhook"
+)
+
+// 北向消息出口状态
+const (
+	NorthSinkStatusDisable = iota
+	NorthSinkStatusEnable
+)
+
+// NorthSinkReloadChannel 北向消息出口变更的通知频道，多个实例收到后重新加载
+const NorthSinkReloadChannel = "northSinkReload"
@@ -6,4 +6,6 @@ const (
 	QueueDeviceAlarmLogTopic    = "device_alarm_log"               // 设备日志
 	QueueDeviceDataSaveTopic    = "task.device.data.save"          // 设备数据保存
 	QueueDeviceStatusInfoUpdate = "task.device.status.info.update" // 设备信息更新
+	QueueAlarmEscalationTopic   = "task.alarm.escalation"          // 告警升级通知
+	QueueSceneCronTopic         = "task.scene.cron"                // 定时场景执行
 )
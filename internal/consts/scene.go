@@ -22,3 +22,18 @@ const (
 	SceneActionTypeDelayExecution     = "delayExecution"
 	SceneActionTypeTriggerCustomEvent = "triggerCustomEvent"
 )
+
+const (
+	SceneStatusOff int = iota // 场景状态：未启用
+	SceneStatusOn             // 场景状态：已启用
+)
+
+const (
+	SceneDeviceOutputFunction = "function" // 设备输出：调用功能
+	SceneDeviceOutputProperty = "property" // 设备输出：设置属性
+)
+
+const (
+	SceneLogStatusFail    int = iota // 场景执行结果：失败
+	SceneLogStatusSuccess            // 场景执行结果：成功
+)
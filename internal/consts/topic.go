@@ -22,6 +22,9 @@ const (
 
 	MsgTypeDeviceInForm         = "设备上报版本信息"
 	MsgTypeDeviceUpgradeProcess = "设备更新进度"
+	MsgTypeUpgradeCommand       = "推送升级包"
+	MsgTypeFirmwareGet          = "设备请求升级包"
+	MsgTypeFileDownload         = "设备下载文件"
 
 	MsgTypeConfigPush      = "设置远程配置下发"
 	MsgTypeConfigPushReply = "设置远程配置下发回复"
@@ -77,6 +80,9 @@ func GetTopicTypes() []string {
 		MsgTypeUnRegister,
 		MsgTypeDeviceInForm,
 		MsgTypeDeviceUpgradeProcess,
+		MsgTypeUpgradeCommand,
+		MsgTypeFirmwareGet,
+		MsgTypeFileDownload,
 
 		MsgTypeConfigPush,
 		MsgTypeConfigPushReply,
@@ -0,0 +1,47 @@
+package alarm
+
+import (
+	"context"
+	"sagooiot/api/v1/alarm"
+	"sagooiot/internal/service"
+)
+
+var AlarmEscalation = cAlarmEscalation{}
+
+type cAlarmEscalation struct{}
+
+func (c *cAlarmEscalation) List(ctx context.Context, req *alarm.AlarmEscalationListReq) (res *alarm.AlarmEscalationListRes, err error) {
+	out, err := service.AlarmEscalation().List(ctx, req.AlarmEscalationListInput)
+	if err != nil {
+		return
+	}
+	res = &alarm.AlarmEscalationListRes{
+		AlarmEscalationListOutput: out,
+	}
+	return
+}
+
+func (c *cAlarmEscalation) Detail(ctx context.Context, req *alarm.AlarmEscalationDetailReq) (res *alarm.AlarmEscalationDetailRes, err error) {
+	out, err := service.AlarmEscalation().Detail(ctx, req.Id)
+	if err != nil || out == nil {
+		return
+	}
+	res = new(alarm.AlarmEscalationDetailRes)
+	res.Data = out
+	return
+}
+
+func (c *cAlarmEscalation) Add(ctx context.Context, req *alarm.AlarmEscalationAddReq) (res *alarm.AlarmEscalationAddRes, err error) {
+	err = service.AlarmEscalation().Add(ctx, req.AlarmEscalationAddInput)
+	return
+}
+
+func (c *cAlarmEscalation) Edit(ctx context.Context, req *alarm.AlarmEscalationEditReq) (res *alarm.AlarmEscalationEditRes, err error) {
+	err = service.AlarmEscalation().Edit(ctx, req.AlarmEscalationEditInput)
+	return
+}
+
+func (c *cAlarmEscalation) Del(ctx context.Context, req *alarm.AlarmEscalationDelReq) (res *alarm.AlarmEscalationDelRes, err error) {
+	err = service.AlarmEscalation().Del(ctx, req.Id)
+	return
+}
@@ -38,3 +38,14 @@ func (c *cAlarmLog) Handle(ctx context.Context, req *alarm.AlarmLogHandleReq) (r
 	err = service.AlarmLog().Handle(ctx, reqData)
 	return
 }
+
+func (c *cAlarmLog) Action(ctx context.Context, req *alarm.AlarmLogActionReq) (res *alarm.AlarmLogActionRes, err error) {
+	out, err := service.AlarmLog().ActionLog(ctx, req.LogId)
+	if err != nil {
+		return
+	}
+	res = &alarm.AlarmLogActionRes{
+		AlarmActionLogListOutput: out,
+	}
+	return
+}
@@ -62,6 +62,18 @@ func (c *cDeviceData) GetDeviceDataForTsd(ctx context.Context, req *analysis.Dev
 
 }
 
+// QueryTsd 按查询条件查询时序数据
+func (c *cDeviceData) QueryTsd(ctx context.Context, req *analysis.TsdQueryReq) (res *analysis.TsdQueryRes, err error) {
+	out, err := service.AnalysisDeviceDataTsd().Query(ctx, req.TsdQueryInput)
+	if err != nil {
+		return
+	}
+	res = &analysis.TsdQueryRes{
+		TsdQueryOutput: out,
+	}
+	return
+}
+
 // GetDeviceAlarmLogData 获取设备告警日志数据
 func (c *cDeviceData) GetDeviceAlarmLogData(ctx context.Context, req *analysis.DeviceAlarmLogDataReq) (res *analysis.DeviceAlarmLogDataRes, err error) {
 	var reqData = new(general.SelectReq)
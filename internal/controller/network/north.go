@@ -0,0 +1,104 @@
+package network
+
+import (
+	"context"
+	"sagooiot/api/v1/network"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+var North = cNetworkNorth{}
+
+type cNetworkNorth struct{}
+
+// GetNorthSinkList 获取北向消息出口列表
+func (u *cNetworkNorth) GetNorthSinkList(ctx context.Context, req *network.GetNetworkNorthSinkListReq) (res *network.GetNetworkNorthSinkListRes, err error) {
+	var input *model.GetNetworkNorthSinkListInput
+	if err = gconv.Scan(req, &input); err != nil {
+		return
+	}
+	total, out, err := service.NetworkNorth().GetSinkList(ctx, input)
+	if err != nil {
+		return
+	}
+	res = new(network.GetNetworkNorthSinkListRes)
+	res.Total = total
+	res.CurrentPage = req.PageNum
+	res.Data = out
+	return
+}
+
+// GetNorthSinkById 获取指定ID的北向消息出口
+func (u *cNetworkNorth) GetNorthSinkById(ctx context.Context, req *network.GetNetworkNorthSinkByIdReq) (res *network.GetNetworkNorthSinkByIdRes, err error) {
+	out, err := service.NetworkNorth().GetSinkById(ctx, req.Id)
+	if err != nil {
+		return
+	}
+	res = &network.GetNetworkNorthSinkByIdRes{
+		Data: out,
+	}
+	return
+}
+
+// AddNorthSink 添加北向消息出口
+func (u *cNetworkNorth) AddNorthSink(ctx context.Context, req *network.AddNetworkNorthSinkReq) (res *network.AddNetworkNorthSinkRes, err error) {
+	var data = model.NetworkNorthSinkAddInput{}
+	if err = gconv.Scan(req, &data); err != nil {
+		return
+	}
+	err = service.NetworkNorth().AddSink(ctx, data)
+	return
+}
+
+// EditNorthSink 修改北向消息出口
+func (u *cNetworkNorth) EditNorthSink(ctx context.Context, req *network.EditNetworkNorthSinkReq) (res *network.EditNetworkNorthSinkRes, err error) {
+	var data = model.NetworkNorthSinkEditInput{}
+	if err = gconv.Scan(req, &data); err != nil {
+		return
+	}
+	err = service.NetworkNorth().EditSink(ctx, data)
+	return
+}
+
+// DeleteNorthSink 删除北向消息出口
+func (u *cNetworkNorth) DeleteNorthSink(ctx context.Context, req *network.DeleteNetworkNorthSinkReq) (res *network.DeleteNetworkNorthSinkRes, err error) {
+	err = service.NetworkNorth().DeleteSink(ctx, req.Ids)
+	return
+}
+
+// SetNorthSinkStatus 修改北向消息出口状态
+func (u *cNetworkNorth) SetNorthSinkStatus(ctx context.Context, req *network.SetNetworkNorthSinkStatusReq) (res *network.SetNetworkNorthSinkStatusRes, err error) {
+	err = service.NetworkNorth().SetSinkStatus(ctx, req.Id, req.Status)
+	return
+}
+
+// GetNorthDeadLetterList 获取投递失败的北向消息列表
+func (u *cNetworkNorth) GetNorthDeadLetterList(ctx context.Context, req *network.GetNetworkNorthDeadLetterListReq) (res *network.GetNetworkNorthDeadLetterListRes, err error) {
+	var input *model.GetNetworkNorthDeadLetterListInput
+	if err = gconv.Scan(req, &input); err != nil {
+		return
+	}
+	total, out, err := service.NetworkNorth().GetDeadLetterList(ctx, input)
+	if err != nil {
+		return
+	}
+	res = new(network.GetNetworkNorthDeadLetterListRes)
+	res.Total = total
+	res.CurrentPage = req.PageNum
+	res.Data = out
+	return
+}
+
+// RetryNorthDeadLetter 重新投递失败的北向消息
+func (u *cNetworkNorth) RetryNorthDeadLetter(ctx context.Context, req *network.RetryNetworkNorthDeadLetterReq) (res *network.RetryNetworkNorthDeadLetterRes, err error) {
+	err = service.NetworkNorth().RetryDeadLetter(ctx, req.Ids)
+	return
+}
+
+// DeleteNorthDeadLetter 删除投递失败的北向消息
+func (u *cNetworkNorth) DeleteNorthDeadLetter(ctx context.Context, req *network.DeleteNetworkNorthDeadLetterReq) (res *network.DeleteNetworkNorthDeadLetterRes, err error) {
+	err = service.NetworkNorth().DeleteDeadLetter(ctx, req.Ids)
+	return
+}
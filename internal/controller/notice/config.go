@@ -7,6 +7,7 @@ import (
 	"sagooiot/api/v1/notice"
 	"sagooiot/internal/model"
 	"sagooiot/internal/service"
+	noticeSender "sagooiot/pkg/notice"
 )
 
 var NoticeConfig = cNoticeNoticeConfig{}
@@ -20,6 +21,9 @@ func (u *cNoticeNoticeConfig) GetNoticeConfigList(ctx context.Context, req *noti
 	total, currentPage, dataList, err := service.NoticeConfig().GetNoticeConfigList(ctx, reqData)
 	res = new(notice.GetNoticeConfigListRes)
 	err = gconv.Scan(dataList, &res.Data)
+	for i := range res.Data {
+		res.Data[i].Config = noticeSender.MaskConfig(res.Data[i].Config)
+	}
 	res.PaginationRes.Total = total
 	res.PaginationRes.CurrentPage = currentPage
 	return
@@ -30,6 +34,7 @@ func (u *cNoticeNoticeConfig) GetNoticeConfigById(ctx context.Context, req *noti
 	data, err := service.NoticeConfig().GetNoticeConfigById(ctx, req.Id)
 	res = new(notice.GetNoticeConfigByIdRes)
 	err = gconv.Scan(data, &res)
+	res.Config = noticeSender.MaskConfig(res.Config)
 	return
 }
 
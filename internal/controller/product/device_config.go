@@ -0,0 +1,41 @@
+package product
+
+import (
+	"context"
+	"sagooiot/api/v1/product"
+	"sagooiot/internal/service"
+)
+
+var DeviceConfig = cDeviceConfig{}
+
+type cDeviceConfig struct{}
+
+func (c *cDeviceConfig) Save(ctx context.Context, req *product.SaveDeviceConfigReq) (res *product.SaveDeviceConfigRes, err error) {
+	out, err := service.DevConfig().Save(ctx, req.DeviceConfigSaveInput)
+	res = &product.SaveDeviceConfigRes{Data: out}
+	return
+}
+
+func (c *cDeviceConfig) Detail(ctx context.Context, req *product.DetailDeviceConfigReq) (res *product.DetailDeviceConfigRes, err error) {
+	out, err := service.DevConfig().Detail(ctx, req.DeviceConfigDetailInput)
+	res = &product.DetailDeviceConfigRes{Data: out}
+	return
+}
+
+func (c *cDeviceConfig) History(ctx context.Context, req *product.HistoryDeviceConfigReq) (res *product.HistoryDeviceConfigRes, err error) {
+	out, err := service.DevConfig().History(ctx, req.DeviceConfigHistoryInput)
+	res = &product.HistoryDeviceConfigRes{DeviceConfigHistoryOutput: out}
+	return
+}
+
+func (c *cDeviceConfig) Push(ctx context.Context, req *product.PushDeviceConfigReq) (res *product.PushDeviceConfigRes, err error) {
+	out, err := service.DevConfig().Push(ctx, req.DeviceConfigPushInput)
+	res = &product.PushDeviceConfigRes{DeviceConfigPushOutput: out}
+	return
+}
+
+func (c *cDeviceConfig) PushList(ctx context.Context, req *product.PushListDeviceConfigReq) (res *product.PushListDeviceConfigRes, err error) {
+	out, err := service.DevConfig().PushList(ctx, req.DeviceConfigPushListInput)
+	res = &product.PushListDeviceConfigRes{DeviceConfigPushListOutput: out}
+	return
+}
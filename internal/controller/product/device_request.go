@@ -0,0 +1,17 @@
+package product
+
+import (
+	"context"
+	"sagooiot/api/v1/product"
+	"sagooiot/internal/service"
+)
+
+var DeviceRequest = cDeviceRequest{}
+
+type cDeviceRequest struct{}
+
+func (c *cDeviceRequest) List(ctx context.Context, req *product.ListDeviceRequestReq) (res *product.ListDeviceRequestRes, err error) {
+	out, err := service.DevDeviceRequest().List(ctx, req.DeviceRequestListInput)
+	res = &product.ListDeviceRequestRes{DeviceRequestListOutput: out}
+	return
+}
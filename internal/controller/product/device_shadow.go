@@ -0,0 +1,28 @@
+package product
+
+import (
+	"context"
+	"sagooiot/api/v1/product"
+	"sagooiot/internal/service"
+)
+
+var DeviceShadow = cDeviceShadow{}
+
+type cDeviceShadow struct{}
+
+func (c *cDeviceShadow) Get(ctx context.Context, req *product.GetDeviceShadowReq) (res *product.GetDeviceShadowRes, err error) {
+	out, err := service.DevDeviceShadow().Get(ctx, req.DeviceKey)
+	res = &product.GetDeviceShadowRes{Data: out}
+	return
+}
+
+func (c *cDeviceShadow) Update(ctx context.Context, req *product.UpdateDeviceShadowReq) (res *product.UpdateDeviceShadowRes, err error) {
+	out, err := service.DevDeviceShadow().Update(ctx, req.DeviceShadowUpdateInput)
+	res = &product.UpdateDeviceShadowRes{Data: out}
+	return
+}
+
+func (c *cDeviceShadow) Delete(ctx context.Context, req *product.DeleteDeviceShadowReq) (res *product.DeleteDeviceShadowRes, err error) {
+	err = service.DevDeviceShadow().Delete(ctx, req.DeviceShadowDeleteInput)
+	return
+}
@@ -0,0 +1,72 @@
+package product
+
+import (
+	"context"
+	"sagooiot/api/v1/product"
+	"sagooiot/internal/service"
+)
+
+var Firmware = cFirmware{}
+
+type cFirmware struct{}
+
+func (c *cFirmware) Add(ctx context.Context, req *product.AddFirmwareReq) (res *product.AddFirmwareRes, err error) {
+	err = service.DevFirmware().Add(ctx, req.FirmwareAddInput, req.File)
+	return
+}
+
+func (c *cFirmware) Edit(ctx context.Context, req *product.EditFirmwareReq) (res *product.EditFirmwareRes, err error) {
+	err = service.DevFirmware().Edit(ctx, req.FirmwareEditInput)
+	return
+}
+
+func (c *cFirmware) Del(ctx context.Context, req *product.DelFirmwareReq) (res *product.DelFirmwareRes, err error) {
+	err = service.DevFirmware().Del(ctx, req.Id)
+	return
+}
+
+func (c *cFirmware) Detail(ctx context.Context, req *product.DetailFirmwareReq) (res *product.DetailFirmwareRes, err error) {
+	out, err := service.DevFirmware().Detail(ctx, req.Id)
+	res = &product.DetailFirmwareRes{Data: out}
+	return
+}
+
+func (c *cFirmware) List(ctx context.Context, req *product.ListFirmwareReq) (res *product.ListFirmwareRes, err error) {
+	out, err := service.DevFirmware().List(ctx, req.FirmwareListInput)
+	res = &product.ListFirmwareRes{FirmwareListOutput: out}
+	return
+}
+
+func (c *cFirmware) AddTask(ctx context.Context, req *product.AddFirmwareTaskReq) (res *product.AddFirmwareTaskRes, err error) {
+	id, err := service.DevFirmware().AddTask(ctx, req.FirmwareTaskAddInput)
+	res = &product.AddFirmwareTaskRes{Id: id}
+	return
+}
+
+func (c *cFirmware) TaskDetail(ctx context.Context, req *product.DetailFirmwareTaskReq) (res *product.DetailFirmwareTaskRes, err error) {
+	out, err := service.DevFirmware().TaskDetail(ctx, req.Id)
+	res = &product.DetailFirmwareTaskRes{Data: out}
+	return
+}
+
+func (c *cFirmware) TaskList(ctx context.Context, req *product.ListFirmwareTaskReq) (res *product.ListFirmwareTaskRes, err error) {
+	out, err := service.DevFirmware().TaskList(ctx, req.FirmwareTaskListInput)
+	res = &product.ListFirmwareTaskRes{FirmwareTaskListOutput: out}
+	return
+}
+
+func (c *cFirmware) StartTask(ctx context.Context, req *product.StartFirmwareTaskReq) (res *product.StartFirmwareTaskRes, err error) {
+	err = service.DevFirmware().StartTask(ctx, req.Id)
+	return
+}
+
+func (c *cFirmware) CancelTask(ctx context.Context, req *product.CancelFirmwareTaskReq) (res *product.CancelFirmwareTaskRes, err error) {
+	err = service.DevFirmware().CancelTask(ctx, req.Id)
+	return
+}
+
+func (c *cFirmware) DeviceList(ctx context.Context, req *product.ListFirmwareDeviceReq) (res *product.ListFirmwareDeviceRes, err error) {
+	out, err := service.DevFirmware().DeviceList(ctx, req.FirmwareDeviceListInput)
+	res = &product.ListFirmwareDeviceRes{FirmwareDeviceListOutput: out}
+	return
+}
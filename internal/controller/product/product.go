@@ -131,6 +131,15 @@ func (c *cProduct) UpdateScriptInfo(ctx context.Context, req *product.UpdateScri
 	return
 }
 
+// DebugScript 脚本调试
+func (c *cProduct) DebugScript(ctx context.Context, req *product.DebugScriptReq) (res *product.DebugScriptRes, err error) {
+	out, err := service.DevProduct().DebugScript(ctx, req.ScriptDebugInput)
+	res = &product.DebugScriptRes{
+		ScriptDebugOutput: out,
+	}
+	return
+}
+
 // ConnectIntro 获取设备接入信息
 func (c *cProduct) ConnectIntro(ctx context.Context, req *product.ConnectIntroReq) (res *product.ConnectIntroRes, err error) {
 	data, err := service.DevProduct().ConnectIntro(ctx, req.ProductKey)
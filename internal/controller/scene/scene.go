@@ -0,0 +1,76 @@
+package scene
+
+import (
+	"context"
+	"github.com/gogf/gf/v2/util/gconv"
+	"sagooiot/api/v1/scene"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+)
+
+var Scene = cScene{}
+
+type cScene struct{}
+
+func (c *cScene) List(ctx context.Context, req *scene.SceneListReq) (res *scene.SceneListRes, err error) {
+	var reqData = new(model.SceneListInput)
+	if err = gconv.Scan(req, &reqData); err != nil {
+		return
+	}
+	out, err := service.Scene().List(ctx, reqData)
+	res = &scene.SceneListRes{
+		SceneListOutput: out,
+	}
+	return
+}
+
+func (c *cScene) Add(ctx context.Context, req *scene.SceneAddReq) (res *scene.SceneAddRes, err error) {
+	err = service.Scene().Add(ctx, req.SceneAddInput)
+	return
+}
+
+func (c *cScene) Edit(ctx context.Context, req *scene.SceneEditReq) (res *scene.SceneEditRes, err error) {
+	err = service.Scene().Edit(ctx, req.SceneEditInput)
+	return
+}
+
+func (c *cScene) Detail(ctx context.Context, req *scene.SceneDetailReq) (res *scene.SceneDetailRes, err error) {
+	out, err := service.Scene().Detail(ctx, req.Id)
+	if err != nil || out == nil {
+		return
+	}
+	res = &scene.SceneDetailRes{Data: out}
+	return
+}
+
+func (c *cScene) Deploy(ctx context.Context, req *scene.SceneDeployReq) (res *scene.SceneDeployRes, err error) {
+	err = service.Scene().Deploy(ctx, req.Id)
+	return
+}
+
+func (c *cScene) Undeploy(ctx context.Context, req *scene.SceneUndeployReq) (res *scene.SceneUndeployRes, err error) {
+	err = service.Scene().Undeploy(ctx, req.Id)
+	return
+}
+
+func (c *cScene) Del(ctx context.Context, req *scene.SceneDelReq) (res *scene.SceneDelRes, err error) {
+	err = service.Scene().Del(ctx, req.Id)
+	return
+}
+
+func (c *cScene) Run(ctx context.Context, req *scene.SceneRunReq) (res *scene.SceneRunRes, err error) {
+	err = service.Scene().Run(ctx, req.Id)
+	return
+}
+
+func (c *cScene) LogList(ctx context.Context, req *scene.SceneLogListReq) (res *scene.SceneLogListRes, err error) {
+	var reqData = new(model.SceneLogListInput)
+	if err = gconv.Scan(req, &reqData); err != nil {
+		return
+	}
+	out, err := service.Scene().LogList(ctx, reqData)
+	res = &scene.SceneLogListRes{
+		SceneLogListOutput: out,
+	}
+	return
+}
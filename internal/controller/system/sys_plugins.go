@@ -93,3 +93,16 @@ func (u *cSystemSysPlugins) GetSysPluginsTypesAll(ctx context.Context, req *syst
 	}
 	return
 }
+
+// GetSysPluginsStatus 获取插件运行状态
+func (u *cSystemSysPlugins) GetSysPluginsStatus(ctx context.Context, req *system.GetSysPluginsStatusReq) (res *system.GetSysPluginsStatusRes, err error) {
+	out, err := service.SysPlugins().GetSysPluginsStatus(ctx, req.Types)
+	if err != nil {
+		return
+	}
+	res = new(system.GetSysPluginsStatusRes)
+	if len(out) > 0 {
+		err = gconv.Scan(out, &res.Data)
+	}
+	return
+}
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalAlarmActionLogDao is internal type for wrapping internal DAO implements.
+type internalAlarmActionLogDao = *internal.AlarmActionLogDao
+
+// alarmActionLogDao is the data access object for table alarm_action_log.
+// You can define custom methods on it to extend its functionality as you wish.
+type alarmActionLogDao struct {
+	internalAlarmActionLogDao
+}
+
+var (
+	// AlarmActionLog is globally public accessible object for table alarm_action_log operations.
+	AlarmActionLog = alarmActionLogDao{
+		internal.NewAlarmActionLogDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalAlarmEscalationDao is internal type for wrapping internal DAO implements.
+type internalAlarmEscalationDao = *internal.AlarmEscalationDao
+
+// alarmEscalationDao is the data access object for table alarm_escalation.
+// You can define custom methods on it to extend its functionality as you wish.
+type alarmEscalationDao struct {
+	internalAlarmEscalationDao
+}
+
+var (
+	// AlarmEscalation is globally public accessible object for table alarm_escalation operations.
+	AlarmEscalation = alarmEscalationDao{
+		internal.NewAlarmEscalationDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalDevConfigDao is internal type for wrapping internal DAO implements.
+type internalDevConfigDao = *internal.DevConfigDao
+
+// devConfigDao is the data access object for table dev_config.
+// You can define custom methods on it to extend its functionality as you wish.
+type devConfigDao struct {
+	internalDevConfigDao
+}
+
+var (
+	// DevConfig is globally public accessible object for table dev_config operations.
+	DevConfig = devConfigDao{
+		internal.NewDevConfigDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalDevConfigPushDao is internal type for wrapping internal DAO implements.
+type internalDevConfigPushDao = *internal.DevConfigPushDao
+
+// devConfigPushDao is the data access object for table dev_config_push.
+// You can define custom methods on it to extend its functionality as you wish.
+type devConfigPushDao struct {
+	internalDevConfigPushDao
+}
+
+var (
+	// DevConfigPush is globally public accessible object for table dev_config_push operations.
+	DevConfigPush = devConfigPushDao{
+		internal.NewDevConfigPushDao(),
+	}
+)
+
+// Fill with you ideas below.
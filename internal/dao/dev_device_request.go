@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalDevDeviceRequestDao is internal type for wrapping internal DAO implements.
+type internalDevDeviceRequestDao = *internal.DevDeviceRequestDao
+
+// devDeviceRequestDao is the data access object for table dev_device_request.
+// You can define custom methods on it to extend its functionality as you wish.
+type devDeviceRequestDao struct {
+	internalDevDeviceRequestDao
+}
+
+var (
+	// DevDeviceRequest is globally public accessible object for table dev_device_request operations.
+	DevDeviceRequest = devDeviceRequestDao{
+		internal.NewDevDeviceRequestDao(),
+	}
+)
+
+// Fill with you ideas below.
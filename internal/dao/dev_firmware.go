@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalDevFirmwareDao is internal type for wrapping internal DAO implements.
+type internalDevFirmwareDao = *internal.DevFirmwareDao
+
+// devFirmwareDao is the data access object for table dev_firmware.
+// You can define custom methods on it to extend its functionality as you wish.
+type devFirmwareDao struct {
+	internalDevFirmwareDao
+}
+
+var (
+	// DevFirmware is globally public accessible object for table dev_firmware operations.
+	DevFirmware = devFirmwareDao{
+		internal.NewDevFirmwareDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalDevFirmwareDeviceDao is internal type for wrapping internal DAO implements.
+type internalDevFirmwareDeviceDao = *internal.DevFirmwareDeviceDao
+
+// devFirmwareDeviceDao is the data access object for table dev_firmware_device.
+// You can define custom methods on it to extend its functionality as you wish.
+type devFirmwareDeviceDao struct {
+	internalDevFirmwareDeviceDao
+}
+
+var (
+	// DevFirmwareDevice is globally public accessible object for table dev_firmware_device operations.
+	DevFirmwareDevice = devFirmwareDeviceDao{
+		internal.NewDevFirmwareDeviceDao(),
+	}
+)
+
+// Fill with you ideas below.
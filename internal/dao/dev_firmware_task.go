@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalDevFirmwareTaskDao is internal type for wrapping internal DAO implements.
+type internalDevFirmwareTaskDao = *internal.DevFirmwareTaskDao
+
+// devFirmwareTaskDao is the data access object for table dev_firmware_task.
+// You can define custom methods on it to extend its functionality as you wish.
+type devFirmwareTaskDao struct {
+	internalDevFirmwareTaskDao
+}
+
+var (
+	// DevFirmwareTask is globally public accessible object for table dev_firmware_task operations.
+	DevFirmwareTask = devFirmwareTaskDao{
+		internal.NewDevFirmwareTaskDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -20,14 +20,16 @@ type AlarmLevelDao struct {
 
 // AlarmLevelColumns defines and stores column names for table alarm_level.
 type AlarmLevelColumns struct {
-	Level string // 告警级别
-	Name  string // 名称
+	Level        string // 告警级别
+	Name         string // 名称
+	EscalationId string // 升级策略ID
 }
 
 // alarmLevelColumns holds the columns for table alarm_level.
 var alarmLevelColumns = AlarmLevelColumns{
-	Level: "level",
-	Name:  "name",
+	Level:        "level",
+	Name:         "name",
+	EscalationId: "escalation_id",
 }
 
 // NewAlarmLevelDao creates and returns a new DAO object for table data access.
@@ -30,11 +30,13 @@ type AlarmLogColumns struct {
 	Expression string // 触发告警的表达式
 	ProductKey string // 产品标识
 	DeviceKey  string // 设备标识
-	Status     string // 告警状态：0=未处理，1=已处理
+	Status     string // 告警状态：0=未处理，1=已处理，2=忽略，3=已恢复
 	CreatedAt  string // 告警时间
 	UpdatedBy  string // 告警处理人员
 	UpdatedAt  string // 处理时间
 	Content    string // 处理意见
+	RecoverAt  string // 恢复时间
+	Duration   string // 告警持续时长，单位秒
 }
 
 // alarmLogColumns holds the columns for table alarm_log.
@@ -54,6 +56,8 @@ var alarmLogColumns = AlarmLogColumns{
 	UpdatedBy:  "updated_by",
 	UpdatedAt:  "updated_at",
 	Content:    "content",
+	RecoverAt:  "recover_at",
+	Duration:   "duration",
 }
 
 // NewAlarmLogDao creates and returns a new DAO object for table data access.
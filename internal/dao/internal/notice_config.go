@@ -25,6 +25,7 @@ type NoticeConfigColumns struct {
 	Title       string //
 	SendGateway string //
 	Types       string //
+	Config      string // 通道配置，JSON格式
 	CreatedAt   string //
 }
 
@@ -35,6 +36,7 @@ var noticeConfigColumns = NoticeConfigColumns{
 	Title:       "title",
 	SendGateway: "send_gateway",
 	Types:       "types",
+	Config:      "config",
 	CreatedAt:   "created_at",
 }
 
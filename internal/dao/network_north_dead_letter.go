@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalNetworkNorthDeadLetterDao is internal type for wrapping internal DAO implements.
+type internalNetworkNorthDeadLetterDao = *internal.NetworkNorthDeadLetterDao
+
+// networkNorthDeadLetterDao is the data access object for table network_north_dead_letter.
+// You can define custom methods on it to extend its functionality as you wish.
+type networkNorthDeadLetterDao struct {
+	internalNetworkNorthDeadLetterDao
+}
+
+var (
+	// NetworkNorthDeadLetter is globally public accessible object for table network_north_dead_letter operations.
+	NetworkNorthDeadLetter = networkNorthDeadLetterDao{
+		internal.NewNetworkNorthDeadLetterDao(),
+	}
+)
+
+// Fill with you ideas below.
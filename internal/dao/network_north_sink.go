@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalNetworkNorthSinkDao is internal type for wrapping internal DAO implements.
+type internalNetworkNorthSinkDao = *internal.NetworkNorthSinkDao
+
+// networkNorthSinkDao is the data access object for table network_north_sink.
+// You can define custom methods on it to extend its functionality as you wish.
+type networkNorthSinkDao struct {
+	internalNetworkNorthSinkDao
+}
+
+var (
+	// NetworkNorthSink is globally public accessible object for table network_north_sink operations.
+	NetworkNorthSink = networkNorthSinkDao{
+		internal.NewNetworkNorthSinkDao(),
+	}
+)
+
+// Fill with you ideas below.
@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalSceneDefinitionDao is internal type for wrapping internal DAO implements.
+type internalSceneDefinitionDao = *internal.SceneDefinitionDao
+
+// sceneDefinitionDao is the data access object for table scene_definition.
+// You can define custom methods on it to extend its functionality as you wish.
+type sceneDefinitionDao struct {
+	internalSceneDefinitionDao
+}
+
+var (
+	// SceneDefinition is globally public accessible object for table scene_definition operations.
+	SceneDefinition = sceneDefinitionDao{
+		internal.NewSceneDefinitionDao(),
+	}
+)
+
+// Fill with you ideas below.
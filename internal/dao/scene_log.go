@@ -0,0 +1,27 @@
+// =================================================================================
+// This is auto-generated by GoFrame CLI tool only once. Fill this file as you wish.
+// =================================================================================
+
+package dao
+
+import (
+	"sagooiot/internal/dao/internal"
+)
+
+// internalSceneLogDao is internal type for wrapping internal DAO implements.
+type internalSceneLogDao = *internal.SceneLogDao
+
+// sceneLogDao is the data access object for table scene_log.
+// You can define custom methods on it to extend its functionality as you wish.
+type sceneLogDao struct {
+	internalSceneLogDao
+}
+
+var (
+	// SceneLog is globally public accessible object for table scene_log operations.
+	SceneLog = sceneLogDao{
+		internal.NewSceneLogDao(),
+	}
+)
+
+// Fill with you ideas below.
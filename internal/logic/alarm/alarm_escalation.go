@@ -0,0 +1,360 @@
+package alarm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/do"
+	"sagooiot/internal/queues"
+	"sagooiot/internal/service"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// escalationTaskTimeout 升级通知任务的超时时间，单位秒
+const escalationTaskTimeout = 60
+
+type sAlarmEscalation struct{}
+
+func init() {
+	service.RegisterAlarmEscalation(alarmEscalationNew())
+}
+
+func alarmEscalationNew() *sAlarmEscalation {
+	return &sAlarmEscalation{}
+}
+
+func (s *sAlarmEscalation) List(ctx context.Context, in *model.AlarmEscalationListInput) (out *model.AlarmEscalationListOutput, err error) {
+	out = new(model.AlarmEscalationListOutput)
+	c := dao.AlarmEscalation.Columns()
+	m := dao.AlarmEscalation.Ctx(ctx).OrderDesc(c.Id)
+
+	if in.KeyWord != "" {
+		m = m.WhereLike(c.Name, "%"+in.KeyWord+"%")
+	}
+	if in.Status != -1 {
+		m = m.Where(c.Status, in.Status)
+	}
+
+	out.Total, _ = m.Count()
+	out.CurrentPage = in.PageNum
+	if err = m.Page(in.PageNum, in.PageSize).Scan(&out.List); err != nil {
+		return
+	}
+	for i, v := range out.List {
+		if v.Steps != "" {
+			if err = json.Unmarshal([]byte(v.Steps), &out.List[i].StepList); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Detail 获取升级策略详情
+func (s *sAlarmEscalation) Detail(ctx context.Context, id uint64) (out *model.AlarmEscalationOutput, err error) {
+	err = dao.AlarmEscalation.Ctx(ctx).Where(dao.AlarmEscalation.Columns().Id, id).Scan(&out)
+	if err != nil || out == nil {
+		return
+	}
+	if out.Steps != "" {
+		err = json.Unmarshal([]byte(out.Steps), &out.StepList)
+	}
+	return
+}
+
+func (s *sAlarmEscalation) Add(ctx context.Context, in *model.AlarmEscalationAddInput) (err error) {
+	if err = checkEscalation(in); err != nil {
+		return
+	}
+	steps, err := json.Marshal(in.Steps)
+	if err != nil {
+		return
+	}
+
+	_, err = dao.AlarmEscalation.Ctx(ctx).Data(do.AlarmEscalation{
+		DeptId:         service.Context().GetUserDeptId(ctx),
+		Name:           in.Name,
+		Steps:          steps,
+		RepeatInterval: in.RepeatInterval,
+		RepeatTimes:    in.RepeatTimes,
+		QuietStart:     in.QuietStart,
+		QuietEnd:       in.QuietEnd,
+		Status:         in.Status,
+		Remark:         in.Remark,
+		CreatedBy:      uint(service.Context().GetUserId(ctx)),
+	}).Insert()
+	return
+}
+
+func (s *sAlarmEscalation) Edit(ctx context.Context, in *model.AlarmEscalationEditInput) (err error) {
+	p, err := s.Detail(ctx, in.Id)
+	if err != nil {
+		return
+	}
+	if p == nil {
+		return gerror.New("升级策略不存在")
+	}
+	if err = checkEscalation(&in.AlarmEscalationAddInput); err != nil {
+		return
+	}
+	steps, err := json.Marshal(in.Steps)
+	if err != nil {
+		return
+	}
+
+	_, err = dao.AlarmEscalation.Ctx(ctx).Data(do.AlarmEscalation{
+		Name:           in.Name,
+		Steps:          steps,
+		RepeatInterval: in.RepeatInterval,
+		RepeatTimes:    in.RepeatTimes,
+		QuietStart:     in.QuietStart,
+		QuietEnd:       in.QuietEnd,
+		Status:         in.Status,
+		Remark:         in.Remark,
+		UpdatedBy:      uint(service.Context().GetUserId(ctx)),
+	}).Where(dao.AlarmEscalation.Columns().Id, in.Id).Update()
+	return
+}
+
+// Del 删除升级策略，同时解除告警级别的关联，已排期的升级任务在执行时自动停止
+func (s *sAlarmEscalation) Del(ctx context.Context, id uint64) (err error) {
+	p, err := s.Detail(ctx, id)
+	if err != nil {
+		return
+	}
+	if p == nil {
+		return gerror.New("升级策略不存在")
+	}
+
+	_, err = dao.AlarmEscalation.Ctx(ctx).
+		Data(do.AlarmEscalation{
+			DeletedBy: uint(service.Context().GetUserId(ctx)),
+			DeletedAt: gtime.Now(),
+		}).
+		Where(dao.AlarmEscalation.Columns().Id, id).
+		Unscoped().
+		Update()
+	if err != nil {
+		return
+	}
+	_, err = dao.AlarmLevel.Ctx(ctx).
+		Data(do.AlarmLevel{EscalationId: 0}).
+		Where(dao.AlarmLevel.Columns().EscalationId, id).
+		Update()
+	return
+}
+
+// Start 告警产生后按升级策略排期第一步通知，告警规则未设置策略时使用告警级别的策略
+func (s *sAlarmEscalation) Start(ctx context.Context, rule model.AlarmRuleOutput, logId uint64, expression string, deviceKey string, param any) (err error) {
+	id := rule.PerformAction.EscalationId
+	if id == 0 {
+		v, err := dao.AlarmLevel.Ctx(ctx).
+			Where(dao.AlarmLevel.Columns().Level, rule.Level).
+			Value(dao.AlarmLevel.Columns().EscalationId)
+		if err != nil {
+			return err
+		}
+		id = v.Uint64()
+	}
+	if id == 0 || logId == 0 {
+		return
+	}
+
+	p, err := s.Detail(ctx, id)
+	if err != nil || p == nil || p.Status != consts.AlarmEscalationStatusOn || len(p.StepList) == 0 {
+		return
+	}
+
+	now := time.Now()
+	task := &model.AlarmEscalationTask{
+		LogId:        logId,
+		EscalationId: id,
+		AlarmAt:      now.Unix(),
+		Expression:   expression,
+		DeviceKey:    deviceKey,
+		Param:        param,
+	}
+	return schedule(ctx, task, now.Add(time.Duration(p.StepList[0].After)*time.Minute))
+}
+
+// Notify 执行升级任务：告警仍未处理时通知当前步骤的收信人，并排期下一步或重复通知
+func (s *sAlarmEscalation) Notify(ctx context.Context, task *model.AlarmEscalationTask) (err error) {
+	log, err := service.AlarmLog().Detail(ctx, task.LogId)
+	if err != nil {
+		return
+	}
+	if log == nil || log.AlarmLog == nil || log.Status != model.AlarmLogStatusUnhandle {
+		return
+	}
+	p, err := s.Detail(ctx, task.EscalationId)
+	if err != nil {
+		return
+	}
+	if p == nil || p.Status != consts.AlarmEscalationStatusOn || task.Step >= len(p.StepList) {
+		return
+	}
+	rule, err := service.AlarmRule().Detail(ctx, log.RuleId)
+	if err != nil || rule == nil {
+		return
+	}
+
+	now := time.Now()
+	// 免打扰期间推迟到结束时间
+	if end, ok := quietEnd(p, now); ok {
+		return schedule(ctx, task, end)
+	}
+
+	// 先排期下一步再发送通知，排期失败时任务重试不会重复发送
+	if next, at, ok := nextEscalation(p, task, now); ok {
+		if err = schedule(ctx, next, at); err != nil {
+			return
+		}
+	}
+
+	step := p.StepList[task.Step]
+	alarmRuleNew().sendNotice(ctx, *rule, stepActions(step, now), task.Expression, task.DeviceKey, task.Param, map[string]any{
+		"AlarmTime":  gtime.NewFromTimeStamp(task.AlarmAt).Format("Y-m-d H:i:s"),
+		"Escalation": p.Name,
+		"Step":       task.Step + 1,
+		"Round":      task.Round + 1,
+	})
+	return
+}
+
+// schedule 将升级任务加入任务队列，在 at 时间执行
+func schedule(ctx context.Context, task *model.AlarmEscalationTask, at time.Time) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	uid := fmt.Sprintf("alarmEscalation:%d:%d:%d:%d", task.LogId, task.Step, task.Round, at.Unix())
+	err = queues.AlarmEscalationWorker.PushAt(ctx, consts.QueueAlarmEscalationTopic, uid, data, at, escalationTaskTimeout)
+	if err != nil {
+		g.Log().Errorf(ctx, "告警升级任务排期 - %d：%s", task.LogId, err)
+	}
+	return err
+}
+
+// nextEscalation 计算下一个升级任务：还有下一步时在告警产生 After 分钟后执行，
+// 已是最后一步时按重复间隔重复通知，直到达到重复次数
+func nextEscalation(p *model.AlarmEscalationOutput, task *model.AlarmEscalationTask, now time.Time) (next *model.AlarmEscalationTask, at time.Time, ok bool) {
+	n := *task
+	if task.Step+1 < len(p.StepList) {
+		n.Step, n.Round = task.Step+1, 0
+		at = time.Unix(task.AlarmAt, 0).Add(time.Duration(p.StepList[n.Step].After) * time.Minute)
+		if at.Before(now) {
+			at = now
+		}
+		return &n, at, true
+	}
+	if p.RepeatInterval <= 0 || (p.RepeatTimes > 0 && task.Round >= p.RepeatTimes) {
+		return
+	}
+	n.Round = task.Round + 1
+	return &n, now.Add(time.Duration(p.RepeatInterval) * time.Minute), true
+}
+
+// quietEnd 当前处于免打扰时段时返回时段的结束时间，支持跨零点的时段
+func quietEnd(p *model.AlarmEscalationOutput, now time.Time) (end time.Time, ok bool) {
+	start, err1 := parseClock(p.QuietStart)
+	stop, err2 := parseClock(p.QuietEnd)
+	if err1 != nil || err2 != nil || start == stop {
+		return
+	}
+	cur := now.Hour()*60 + now.Minute()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch {
+	case start < stop && cur >= start && cur < stop:
+		return day.Add(time.Duration(stop) * time.Minute), true
+	case start > stop && cur >= start:
+		return day.AddDate(0, 0, 1).Add(time.Duration(stop) * time.Minute), true
+	case start > stop && cur < stop:
+		return day.Add(time.Duration(stop) * time.Minute), true
+	}
+	return
+}
+
+// parseClock 解析 HH:MM 格式的时间，返回当天的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// onCallGroup 获取当前的值班组
+func onCallGroup(c *model.AlarmOnCall, now time.Time) []string {
+	if c == nil || len(c.Groups) == 0 {
+		return nil
+	}
+	start, err := time.ParseInLocation("2006-01-02 15:04", c.Start, now.Location())
+	if err != nil || c.Period <= 0 || now.Before(start) {
+		return c.Groups[0]
+	}
+	i := int(now.Sub(start) / (time.Duration(c.Period) * time.Hour))
+	return c.Groups[i%len(c.Groups)]
+}
+
+// stepActions 获取升级步骤的通知动作，当前值班组追加到每个动作的收信人中
+func stepActions(step model.AlarmEscalationStep, now time.Time) []model.AlarmAction {
+	group := onCallGroup(step.OnCall, now)
+	actions := make([]model.AlarmAction, len(step.Action))
+	for i, v := range step.Action {
+		addressee := append([]string{}, v.Addressee...)
+		for _, a := range group {
+			exists := false
+			for _, b := range addressee {
+				if a == b {
+					exists = true
+					break
+				}
+			}
+			if !exists {
+				addressee = append(addressee, a)
+			}
+		}
+		v.Addressee = addressee
+		actions[i] = v
+	}
+	return actions
+}
+
+// checkEscalation 校验升级策略
+func checkEscalation(in *model.AlarmEscalationAddInput) error {
+	if len(in.Steps) == 0 {
+		return gerror.New("请添加升级步骤")
+	}
+	for i, v := range in.Steps {
+		if v.After < 0 || (i > 0 && v.After < in.Steps[i-1].After) {
+			return gerror.Newf("第%d步的通知时间不正确，需按时间先后排列", i+1)
+		}
+		if len(v.Action) == 0 {
+			return gerror.Newf("第%d步未设置通知动作", i+1)
+		}
+		if c := v.OnCall; c != nil {
+			if len(c.Groups) == 0 || c.Period <= 0 {
+				return gerror.Newf("第%d步的值班轮换设置不正确", i+1)
+			}
+			if _, err := time.Parse("2006-01-02 15:04", c.Start); err != nil {
+				return gerror.Newf("第%d步的轮换开始时间格式不正确", i+1)
+			}
+		}
+	}
+	if in.QuietStart != "" || in.QuietEnd != "" {
+		if _, err := parseClock(in.QuietStart); err != nil {
+			return gerror.New("免打扰开始时间格式不正确")
+		}
+		if _, err := parseClock(in.QuietEnd); err != nil {
+			return gerror.New("免打扰结束时间格式不正确")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package alarm
+
+import (
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/entity"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newEscalation(e entity.AlarmEscalation, steps ...int) *model.AlarmEscalationOutput {
+	out := &model.AlarmEscalationOutput{AlarmEscalation: &e}
+	for _, after := range steps {
+		out.StepList = append(out.StepList, model.AlarmEscalationStep{After: after})
+	}
+	return out
+}
+
+func TestQuietEnd(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+
+	cases := []struct {
+		start, end string
+		now        time.Time
+		want       time.Time
+		ok         bool
+	}{
+		{"12:00", "13:30", at(12, 10), at(13, 30), true},
+		{"12:00", "13:30", at(13, 30), time.Time{}, false},
+		{"22:00", "07:00", at(23, 0), at(24+7, 0), true},
+		{"22:00", "07:00", at(6, 59), at(7, 0), true},
+		{"22:00", "07:00", at(12, 0), time.Time{}, false},
+		{"", "", at(12, 0), time.Time{}, false},
+		{"08:00", "08:00", at(8, 0), time.Time{}, false},
+	}
+	for _, c := range cases {
+		p := newEscalation(entity.AlarmEscalation{QuietStart: c.start, QuietEnd: c.end})
+		end, ok := quietEnd(p, c.now)
+		if ok != c.ok || !end.Equal(c.want) {
+			t.Errorf("quietEnd(%s-%s, %s) = %s, %v", c.start, c.end, c.now.Format("15:04"), end, ok)
+		}
+	}
+}
+
+func TestOnCallGroup(t *testing.T) {
+	c := &model.AlarmOnCall{
+		Start:  "2024-05-01 08:00",
+		Period: 12,
+		Groups: [][]string{{"a"}, {"b"}, {"c"}},
+	}
+	start, _ := time.ParseInLocation("2006-01-02 15:04", c.Start, time.Local)
+	for h, want := range map[int]string{-1: "a", 0: "a", 11: "a", 12: "b", 30: "c", 36: "a"} {
+		if got := onCallGroup(c, start.Add(time.Duration(h)*time.Hour)); got[0] != want {
+			t.Errorf("hour %d: got %v, want %s", h, got, want)
+		}
+	}
+	if onCallGroup(nil, start) != nil {
+		t.Error("nil on-call should have no group")
+	}
+
+	actions := stepActions(model.AlarmEscalationStep{
+		Action: []model.AlarmAction{{NoticeTemplate: "t1", Addressee: []string{"x", "b"}}},
+		OnCall: c,
+	}, start.Add(12*time.Hour))
+	if got := strings.Join(actions[0].Addressee, ","); got != "x,b" {
+		t.Errorf("unexpected addressee %s", got)
+	}
+}
+
+func TestNextEscalation(t *testing.T) {
+	alarmAt := time.Date(2024, 5, 1, 8, 0, 0, 0, time.Local)
+	task := &model.AlarmEscalationTask{LogId: 1, AlarmAt: alarmAt.Unix()}
+
+	// 下一步按告警产生时间计算
+	p := newEscalation(entity.AlarmEscalation{RepeatInterval: 30, RepeatTimes: 2}, 5, 15)
+	now := alarmAt.Add(5 * time.Minute)
+	next, at, ok := nextEscalation(p, task, now)
+	if !ok || next.Step != 1 || next.Round != 0 || !at.Equal(alarmAt.Add(15*time.Minute)) {
+		t.Fatalf("unexpected next step %+v %s %v", next, at, ok)
+	}
+	// 推迟执行后不会排期到过去的时间
+	now = alarmAt.Add(time.Hour)
+	if _, at, _ = nextEscalation(p, task, now); !at.Equal(now) {
+		t.Fatalf("expected now, got %s", at)
+	}
+
+	// 最后一步按间隔重复，达到次数后停止
+	task.Step = 1
+	for round := 1; round <= 2; round++ {
+		next, at, ok = nextEscalation(p, task, now)
+		if !ok || next.Step != 1 || next.Round != round || !at.Equal(now.Add(30*time.Minute)) {
+			t.Fatalf("unexpected repeat %+v %s %v", next, at, ok)
+		}
+		task = next
+	}
+	if _, _, ok = nextEscalation(p, task, now); ok {
+		t.Fatal("expected stop after repeat times")
+	}
+
+	// 不重复
+	p = newEscalation(entity.AlarmEscalation{}, 0)
+	if _, _, ok = nextEscalation(p, &model.AlarmEscalationTask{}, now); ok {
+		t.Fatal("expected stop without repeat interval")
+	}
+	// 重复次数为0时一直重复
+	p = newEscalation(entity.AlarmEscalation{RepeatInterval: 10}, 0)
+	if next, _, ok = nextEscalation(p, &model.AlarmEscalationTask{Round: 100}, now); !ok || next.Round != 101 {
+		t.Fatal("expected unlimited repeat")
+	}
+}
+
+func TestCheckEscalation(t *testing.T) {
+	action := []model.AlarmAction{{NoticeTemplate: "t1"}}
+	valid := model.AlarmEscalationAddInput{
+		Name:       "p",
+		Steps:      []model.AlarmEscalationStep{{After: 0, Action: action}, {After: 10, Action: action}},
+		QuietStart: "22:00",
+		QuietEnd:   "07:00",
+	}
+	if err := checkEscalation(&valid); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []func(in *model.AlarmEscalationAddInput){
+		func(in *model.AlarmEscalationAddInput) { in.Steps = nil },
+		func(in *model.AlarmEscalationAddInput) { in.Steps[1].After = -1 },
+		func(in *model.AlarmEscalationAddInput) { in.Steps[0].After = 20 },
+		func(in *model.AlarmEscalationAddInput) { in.Steps[0].Action = nil },
+		func(in *model.AlarmEscalationAddInput) { in.QuietEnd = "7点" },
+		func(in *model.AlarmEscalationAddInput) {
+			in.Steps[0].OnCall = &model.AlarmOnCall{Start: "2024-05-01", Period: 24, Groups: [][]string{{"a"}}}
+		},
+	}
+	for i, f := range invalid {
+		in := valid
+		in.Steps = append([]model.AlarmEscalationStep{}, valid.Steps...)
+		f(&in)
+		if err := checkEscalation(&in); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
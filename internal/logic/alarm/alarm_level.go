@@ -51,7 +51,8 @@ func (s *sAlarmLevel) All(ctx context.Context) (out *model.AlarmLevelListOutput,
 func (s *sAlarmLevel) Edit(ctx context.Context, in []*model.AlarmLevelEditInput) (err error) {
 	for _, v := range in {
 		_, err = dao.AlarmLevel.Ctx(ctx).Data(g.Map{
-			"name": v.Name,
+			"name":          v.Name,
+			"escalation_id": v.EscalationId,
 		}).
 			Where(dao.AlarmLevel.Columns().Level, v.Level).
 			Update()
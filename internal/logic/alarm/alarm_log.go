@@ -11,6 +11,7 @@ import (
 	"sagooiot/internal/model"
 	"sagooiot/internal/model/do"
 	"sagooiot/internal/service"
+	"sagooiot/internal/webscoket/realtime"
 	"sagooiot/pkg/cache"
 	"strconv"
 	"time"
@@ -53,6 +54,7 @@ func (s *sAlarmLog) Add(ctx context.Context, in *model.AlarmLogAddInput) (id uin
 		RuleName:   in.RuleName,
 		Level:      in.Level,
 		Data:       in.Data,
+		Expression: in.Expression,
 		ProductKey: in.ProductKey,
 		DeviceKey:  in.DeviceKey,
 		Status:     0,
@@ -64,6 +66,16 @@ func (s *sAlarmLog) Add(ctx context.Context, in *model.AlarmLogAddInput) (id uin
 	}
 	newId, err := rs.LastInsertId()
 	id = uint64(newId)
+	if err == nil {
+		realtime.Publish(ctx, realtime.TypeAlarm, in.ProductKey, in.DeviceKey, g.Map{
+			"id":       id,
+			"type":     in.Type,
+			"ruleId":   in.RuleId,
+			"ruleName": in.RuleName,
+			"level":    in.Level,
+			"data":     in.Data,
+		})
+	}
 	return
 }
 
@@ -114,6 +126,49 @@ func (s *sAlarmLog) Handle(ctx context.Context, in *model.AlarmLogHandleInput) (
 	return
 }
 
+// Recover 告警恢复，记录恢复时间和持续时长，未处理的告警自动关闭
+func (s *sAlarmLog) Recover(ctx context.Context, id uint64) (out *model.AlarmLogOutput, err error) {
+	out, err = s.Detail(ctx, id)
+	if err != nil || out == nil {
+		return
+	}
+	if out.RecoverAt != nil {
+		return nil, nil
+	}
+
+	now := gtime.Now()
+	var duration int64
+	if out.CreatedAt != nil {
+		duration = now.Unix() - out.CreatedAt.Unix()
+	}
+	status := out.Status
+	if status == model.AlarmLogStatusUnhandle {
+		status = model.AlarmLogStatusRecover
+	}
+
+	c := dao.AlarmLog.Columns()
+	rs, err := dao.AlarmLog.Ctx(ctx).Data(g.Map{
+		c.Status:    status,
+		c.RecoverAt: now,
+		c.Duration:  duration,
+	}).Where(c.Id, id).WhereNull(c.RecoverAt).Update()
+	if err != nil {
+		return
+	}
+	// 已被其他节点恢复
+	if n, _ := rs.RowsAffected(); n == 0 {
+		return nil, nil
+	}
+	out.Status = status
+	out.RecoverAt = now
+	out.Duration = duration
+
+	//更新缓存，停止告警通知
+	key := consts.DeviceAlarmLogPrefix + out.ProductKey + out.DeviceKey + out.Expression
+	err = cache.Instance().Set(ctx, key, out, time.Minute*10)
+	return
+}
+
 func (s *sAlarmLog) TotalForLevel(ctx context.Context) (total []model.AlarmLogLevelTotal, err error) {
 	//TODO 缓存时间需要优化 ====================
 	rs, err := dao.AlarmLog.Ctx(ctx).Cache(gdb.CacheOption{
@@ -155,5 +210,19 @@ func (s *sAlarmLog) TotalForLevel(ctx context.Context) (total []model.AlarmLogLe
 // ClearLogByDays 按日期删除日志
 func (s *sAlarmLog) ClearLogByDays(ctx context.Context, days int) (err error) {
 	_, err = dao.AlarmLog.Ctx(ctx).Delete("to_days(now())-to_days(`created_at`) > ?", days+1)
+	if err != nil {
+		return
+	}
+	_, err = dao.AlarmActionLog.Ctx(ctx).Delete("to_days(now())-to_days(`created_at`) > ?", days+1)
+	return
+}
+
+// ActionLog 获取告警的设备控制动作执行记录
+func (s *sAlarmLog) ActionLog(ctx context.Context, logId uint64) (out *model.AlarmActionLogListOutput, err error) {
+	out = new(model.AlarmActionLogListOutput)
+	err = dao.AlarmActionLog.Ctx(ctx).
+		Where(dao.AlarmActionLog.Columns().LogId, logId).
+		OrderAsc(dao.AlarmActionLog.Columns().Id).
+		Scan(&out.List)
 	return
 }
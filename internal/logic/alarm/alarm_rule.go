@@ -214,6 +214,9 @@ func (s *sAlarmRule) Add(ctx context.Context, in *model.AlarmRuleAddInput) (err
 	if param.TriggerCondition, err = json.Marshal(in.AlarmTriggerCondition); err != nil {
 		return
 	}
+	if err = checkPerformAction(ctx, in.AlarmPerformAction); err != nil {
+		return
+	}
 	if param.Action, err = json.Marshal(in.AlarmPerformAction); err != nil {
 		return
 	}
@@ -283,6 +286,9 @@ func (s *sAlarmRule) Edit(ctx context.Context, in *model.AlarmRuleEditInput) (er
 	if param.TriggerCondition, err = json.Marshal(in.AlarmTriggerCondition); err != nil {
 		return
 	}
+	if err = checkPerformAction(ctx, in.AlarmPerformAction); err != nil {
+		return
+	}
 	if param.Action, err = json.Marshal(in.AlarmPerformAction); err != nil {
 		return
 	}
@@ -362,6 +368,9 @@ func (s *sAlarmRule) Undeploy(ctx context.Context, id uint64) (err error) {
 	if err != nil {
 		return
 	}
+	if err = clearAlarmStates(ctx, id); err != nil {
+		return
+	}
 
 	//更新缓存
 	err = s.cacheProductAlarmRuleChange(ctx, p.ProductKey)
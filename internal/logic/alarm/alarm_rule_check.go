@@ -4,19 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/Knetic/govaluate"
-	"github.com/gogf/gf/v2/errors/gerror"
 	"github.com/gogf/gf/v2/frame/g"
-	"github.com/gogf/gf/v2/os/gtime"
-	"github.com/gogf/gf/v2/text/gstr"
 	"github.com/gogf/gf/v2/util/gconv"
 	"sagooiot/internal/consts"
 	"sagooiot/internal/model"
-	"sagooiot/internal/queues"
+	"sagooiot/internal/service"
 	"sagooiot/pkg/cache"
+	"sagooiot/pkg/condition"
 	"sagooiot/pkg/dcache"
-	"sagooiot/pkg/iotModel"
-	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -33,11 +30,16 @@ func (s *sAlarmRule) Check(ctx context.Context, productKey string, deviceKey str
 	}
 
 	//重组param数据
-	eventKey, data, err := s.getParamData(ctx, param)
+	eventKey, data, err := condition.ParamData(param)
 	if data == nil || err != nil {
 		return
 	}
 
+	// 设备上下线时，恢复相反状态的告警
+	if opposite, ok := oppositeTriggerType[triggerType]; ok {
+		s.recoverOpposite(ctx, productKey, deviceKey, opposite, param)
+	}
+
 	//获取规则列表
 	rules, err := s.getAlarmRuleList(ctx, productKey, deviceKey, triggerType, eventKey)
 	if len(rules) == 0 || err != nil {
@@ -50,116 +52,279 @@ func (s *sAlarmRule) Check(ctx context.Context, productKey string, deviceKey str
 		return
 	}
 
-	// 填充告警触发时间
-	if ts, ok := data["CreateTime"]; ok {
-		switch tt := ts.(type) {
-		case string:
-			if t, err := time.Parse("2006-01-02 15:04:05", tt); err == nil {
-				data["CreateTime"] = t.Unix()
-			} else {
-				data["CreateTime"] = time.Now().Unix()
-			}
-		case int, int32, int64, float32, float64:
-			data["CreateTime"] = tt.(int64)
-		}
-	} else {
-		data["CreateTime"] = time.Now().Unix()
-	}
-
 	for _, r := range rules {
 		go func(rule model.AlarmRuleOutput) {
-			exp := s.expression(ctx, rule)
-			if exp != "" {
-				gov, err := govaluate.NewEvaluableExpression(exp)
-				if err != nil {
-					g.Log().Errorf(ctx, "告警表达式 - %s - %s - %s：%s", productKey, deviceKey, exp, err)
+			exp := condition.Expression(rule.Condition.TriggerCondition)
+			if exp == "" || productKey == "" {
+				return
+			}
+			triggered, err := condition.Evaluate(exp, data)
+			if err != nil {
+				g.Log().Errorf(ctx, "告警表达式 - %s - %s - %s：%s - %v", productKey, deviceKey, exp, err, data)
+				return
+			}
+			withState := stateful(rule)
+			var state *model.AlarmState
+			if withState {
+				if state, err = s.alarmState(ctx, rule.Id, deviceKey); err != nil {
+					g.Log().Errorf(ctx, "告警状态 - %s - %s：%s", productKey, deviceKey, err)
 					return
 				}
-				if gov == nil {
+				// 告警持续中，不重复产生告警
+				if triggered && state != nil {
 					return
 				}
-				rs, err := gov.Evaluate(data)
-				if err != nil {
-					g.Log().Errorf(ctx, "告警表达式参数 - %s - %s：%s - %v", productKey, deviceKey, err, data)
-					return
+			}
+
+			// 防抖：持续时长、触发次数和重复抑制
+			fire, err := s.debounce(ctx, rule, deviceKey, triggered, time.Now())
+			if err != nil {
+				g.Log().Errorf(ctx, "告警防抖 - %s - %s：%s", productKey, deviceKey, err)
+				return
+			}
+			if triggered {
+				if fire {
+					s.trigger(ctx, rule, exp, productKey, deviceKey, string(logData), param, withState)
 				}
-				if rs == nil {
+				return
+			}
+			if state == nil {
+				return
+			}
+
+			// 未设置恢复条件时，属性上报不满足触发条件即恢复
+			recovered := rule.TriggerType == consts.AlarmTriggerTypeProperty
+			if len(rule.Condition.RecoverCondition) > 0 {
+				rexp := condition.Expression(rule.Condition.RecoverCondition)
+				if recovered, err = condition.Evaluate(rexp, data); err != nil {
+					g.Log().Errorf(ctx, "告警恢复表达式 - %s - %s - %s：%s - %v", productKey, deviceKey, rexp, err, data)
 					return
 				}
-				if y, ok := rs.(bool); ok && y {
-					// 写告警日志
-					log := model.AlarmLogAddInput{
-						Type:       1,
-						RuleId:     rule.Id,
-						RuleName:   rule.Name,
-						Level:      rule.Level,
-						Data:       string(logData),
-						Expression: exp,
-						ProductKey: productKey,
-						DeviceKey:  deviceKey,
-					}
+			}
+			if recovered {
+				s.recover(ctx, rule, state, deviceKey, param)
+			}
+		}(r)
+	}
+	return
+}
+
+// oppositeTriggerType 上下线互为恢复条件
+var oppositeTriggerType = map[int]int{
+	consts.AlarmTriggerTypeOnline:  consts.AlarmTriggerTypeOffline,
+	consts.AlarmTriggerTypeOffline: consts.AlarmTriggerTypeOnline,
+}
+
+// stateful 是否跟踪告警状态：属性上报和上下线告警可恢复，事件上报告警设置了恢复条件时可恢复
+func stateful(rule model.AlarmRuleOutput) bool {
+	if rule.TriggerType == consts.AlarmTriggerTypeEvent {
+		return len(rule.Condition.RecoverCondition) > 0
+	}
+	return true
+}
 
-					if log.ProductKey == "" {
-						return
-					}
+// recoverOpposite 恢复相反上下线状态的告警
+func (s *sAlarmRule) recoverOpposite(ctx context.Context, productKey, deviceKey string, triggerType int, param any) {
+	rules, err := s.getAlarmRuleList(ctx, productKey, deviceKey, triggerType, "")
+	if err != nil {
+		return
+	}
+	for _, rule := range rules {
+		state, err := s.alarmState(ctx, rule.Id, deviceKey)
+		if err != nil || state == nil {
+			continue
+		}
+		go s.recover(ctx, rule, state, deviceKey, param)
+	}
+}
 
-					// 写入队列
-					logData, _ := json.Marshal(log)
-					err = queues.ScheduledDeviceAlarmLog.Push(ctx, consts.QueueDeviceAlarmLogTopic, logData, 10)
+// alarmStateKey 告警状态缓存KEY
+func alarmStateKey(ruleId uint64, deviceKey string) string {
+	return fmt.Sprintf("%s%d:%s", consts.DeviceAlarmStatePrefix, ruleId, deviceKey)
+}
 
-					key := consts.DeviceAlarmLogPrefix + productKey + deviceKey + exp
-					err = cache.Instance().Set(ctx, key, log, 60*time.Second)
-					if err != nil {
-						return
-					}
-					//告警执行动作
-					s.doAction(ctx, rule, exp, deviceKey, param)
+// clearAlarmStates 删除告警规则在所有设备上的告警状态，规则停用后不再检测恢复，告警状态不再需要
+func clearAlarmStates(ctx context.Context, ruleId uint64) error {
+	prefix := fmt.Sprintf("%s%d:", consts.DeviceAlarmStatePrefix, ruleId)
+	if useRedisCache(ctx) {
+		cursor := "0"
+		for {
+			v, err := g.Redis().Do(ctx, "SCAN", cursor, "MATCH", prefix+"*", "COUNT", 500)
+			if err != nil {
+				return err
+			}
+			rs := v.Vars()
+			if len(rs) != 2 {
+				return nil
+			}
+			if keys := rs[1].Strings(); len(keys) > 0 {
+				if _, err = g.Redis().Do(ctx, "DEL", gconv.Interfaces(keys)...); err != nil {
+					return err
 				}
 			}
-		}(r)
+			if cursor = rs[0].String(); cursor == "0" {
+				return nil
+			}
+		}
+	}
+	keys, err := cache.Instance().KeyStrings(ctx)
+	if err != nil {
+		return err
+	}
+	var list []interface{}
+	for _, k := range keys {
+		if strings.HasPrefix(k, prefix) {
+			list = append(list, k)
+		}
+	}
+	if len(list) > 0 {
+		stateLock.Lock()
+		_, err = cache.Instance().Remove(ctx, list...)
+		stateLock.Unlock()
+	}
+	return err
+}
+
+// swapAlarmStateScript 告警状态仍为 ARGV[1] 时替换为 ARGV[2]，返回是否替换
+const swapAlarmStateScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then
+  redis.call('SET', KEYS[1], ARGV[2])
+  return 1
+end
+return 0`
+
+// stateLock 缓存不使用redis时，保证告警状态比较替换和删除的原子性
+var stateLock sync.Mutex
+
+// swapAlarmState 告警状态仍为 old 时替换为 state，状态已被恢复或替换时返回 false
+func swapAlarmState(ctx context.Context, key string, old, state model.AlarmState) (bool, error) {
+	if useRedisCache(ctx) {
+		// 缓存中的告警状态以json保存
+		o, err := json.Marshal(old)
+		if err != nil {
+			return false, err
+		}
+		n, err := json.Marshal(state)
+		if err != nil {
+			return false, err
+		}
+		v, err := g.Redis().Do(ctx, "EVAL", swapAlarmStateScript, 1, key, o, n)
+		if err != nil {
+			return false, err
+		}
+		return v.Int() == 1, nil
 	}
+
+	stateLock.Lock()
+	defer stateLock.Unlock()
+	v, err := cache.Instance().Get(ctx, key)
+	if err != nil || v.IsNil() {
+		return false, err
+	}
+	var current model.AlarmState
+	if err = v.Scan(&current); err != nil || current != old {
+		return false, err
+	}
+	return true, cache.Instance().Set(ctx, key, state, 0)
+}
+
+// removeAlarmState 删除告警状态
+func removeAlarmState(ctx context.Context, key string) error {
+	if !useRedisCache(ctx) {
+		stateLock.Lock()
+		defer stateLock.Unlock()
+	}
+	_, err := cache.Instance().Remove(ctx, key)
+	return err
+}
+
+// alarmState 获取告警规则在设备上的告警状态，nil 表示没有持续中的告警
+func (s *sAlarmRule) alarmState(ctx context.Context, ruleId uint64, deviceKey string) (state *model.AlarmState, err error) {
+	v, err := cache.Instance().Get(ctx, alarmStateKey(ruleId, deviceKey))
+	if err != nil || v.IsNil() {
+		return
+	}
+	err = v.Scan(&state)
 	return
 }
 
-// getParamData 获取Param数据 eventKey 事件标识
-func (s *sAlarmRule) getParamData(ctx context.Context, param any) (eventKey string, res map[string]any, err error) {
-	var (
-		data = make(map[string]any) // 上传数据
-	)
-	// 上传数据重组
-	switch pd := param.(type) {
-	case iotModel.ReportPropertyData:
-		for k, v := range pd {
-			vv := gconv.String(v.Value)
-			if gstr.IsNumeric(vv) {
-				data[k] = gconv.Float64(v.Value)
-			} else if gt, err := gtime.StrToTime(vv); err == nil {
-				data[k] = gt.Unix()
-			} else {
-				data[k] = v.Value
-			}
-			data[k+"_time"] = v.CreateTime
+// trigger 产生告警：记录告警状态、写告警日志并执行告警动作，withState 为 false 时不记录告警状态
+func (s *sAlarmRule) trigger(ctx context.Context, rule model.AlarmRuleOutput, exp, productKey, deviceKey, data string, param any, withState bool) {
+	state := model.AlarmState{
+		RuleId:      rule.Id,
+		DeviceKey:   deviceKey,
+		Expression:  exp,
+		TriggeredAt: time.Now().Unix(),
+	}
+	key := alarmStateKey(rule.Id, deviceKey)
+	// 并发上报时只产生一条告警
+	if withState {
+		ok, err := cache.Instance().SetIfNotExist(ctx, key, state, 0)
+		if err != nil || !ok {
+			return
 		}
-	case iotModel.ReportEventData:
-		for k, v := range pd.Param.Value {
-			vv := gconv.String(v)
-			if gstr.IsNumeric(vv) {
-				data[k] = gconv.Float64(v)
-			} else if gt, err := gtime.StrToTime(vv); err == nil {
-				data[k] = gt.Unix()
-			} else {
-				data[k] = v
+	}
+	var err error
+
+	// 写告警日志
+	log := model.AlarmLogAddInput{
+		Type:       1,
+		RuleId:     rule.Id,
+		RuleName:   rule.Name,
+		Level:      rule.Level,
+		Data:       data,
+		Expression: exp,
+		ProductKey: productKey,
+		DeviceKey:  deviceKey,
+	}
+	state.LogId, err = service.AlarmLog().Add(ctx, &log)
+	if err != nil {
+		g.Log().Errorf(ctx, "告警日志记录 - %s - %s：%s", productKey, deviceKey, err)
+		if withState {
+			_ = removeAlarmState(ctx, key)
+		}
+		return
+	}
+	if withState {
+		// 写日志期间告警已被恢复时，关闭本次告警日志，不再执行告警动作
+		pending := state
+		pending.LogId = 0
+		ok, err := swapAlarmState(ctx, key, pending, state)
+		if err != nil {
+			g.Log().Errorf(ctx, "告警状态记录 - %s - %s：%s", productKey, deviceKey, err)
+		} else if !ok {
+			if _, err = service.AlarmLog().Recover(ctx, state.LogId); err != nil {
+				g.Log().Errorf(ctx, "告警恢复 - %s - %d：%s", deviceKey, state.LogId, err)
 			}
+			return
 		}
-		data["CreateTime"] = pd.Param.CreateTime
-		eventKey = pd.Key
-	case iotModel.ReportStatusData:
-		data["Status"] = pd.Status
-		data["CreateTime"] = pd.CreateTime
-	default:
-		return "", nil, gerror.New("数据格式错误")
 	}
-	return eventKey, data, nil
+
+	err = cache.Instance().Set(ctx, consts.DeviceAlarmLogPrefix+productKey+deviceKey+exp, log, 60*time.Second)
+	if err != nil {
+		return
+	}
+	//告警执行动作
+	s.doAction(ctx, rule, state.LogId, exp, deviceKey, param)
+}
+
+// recover 告警恢复：清除告警状态、关闭告警日志并发送恢复通知
+func (s *sAlarmRule) recover(ctx context.Context, rule model.AlarmRuleOutput, state *model.AlarmState, deviceKey string, param any) {
+	if err := removeAlarmState(ctx, alarmStateKey(rule.Id, deviceKey)); err != nil {
+		g.Log().Errorf(ctx, "告警状态清除 - %s：%s", deviceKey, err)
+		return
+	}
+	if state.LogId == 0 {
+		return
+	}
+	alarmLog, err := service.AlarmLog().Recover(ctx, state.LogId)
+	if err != nil {
+		g.Log().Errorf(ctx, "告警恢复 - %s - %d：%s", deviceKey, state.LogId, err)
+		return
+	}
+	if alarmLog == nil {
+		return
+	}
+	s.recoverAction(ctx, rule, alarmLog, param)
 }
 
 // 获取告警规则
@@ -199,77 +364,3 @@ func (s *sAlarmRule) getAlarmRuleList(ctx context.Context, productKey, deviceKey
 
 	return
 }
-
-// expression 告警条件表达式
-func (s *sAlarmRule) expression(ctx context.Context, rule model.AlarmRuleOutput) string {
-	glen := len(rule.Condition.TriggerCondition)
-	var exp string
-	for _, group := range rule.Condition.TriggerCondition {
-		flen := len(group.Filters)
-		var gexp string
-		for _, v := range group.Filters {
-			if (v.Operator == consts.OperatorBet ||
-				v.Operator == consts.OperatorNbet) && len(v.Value) < 2 {
-				continue
-			}
-
-			// 如果条件参数是上报时间，则将参数值转换成时间戳
-			if v.Key == "sysReportTime" {
-				v.Key = "CreateTime"
-				if v.Value[0] != "" {
-					if t, err := time.Parse("2006-01-02 15:04:05", v.Value[0]); err == nil {
-						v.Value[0] = strconv.FormatInt(t.Unix(), 10)
-					}
-				}
-				if len(v.Value) > 1 && v.Value[1] != "" {
-					if t, err := time.Parse("2006-01-02 15:04:05", v.Value[1]); err == nil {
-						v.Value[1] = strconv.FormatInt(t.Unix(), 10)
-					}
-				}
-			}
-
-			// 表达式生成
-			var fexp string
-			switch v.Operator {
-			case consts.OperatorEq:
-				fexp = fmt.Sprintf("(%s %s %s)", v.Key, "==", v.Value[0])
-			case consts.OperatorNe:
-				fexp = fmt.Sprintf("(%s %s %s)", v.Key, "!=", v.Value[0])
-			case consts.OperatorGt:
-				fexp = fmt.Sprintf("(%s %s %s)", v.Key, ">", v.Value[0])
-			case consts.OperatorGte:
-				fexp = fmt.Sprintf("(%s %s %s)", v.Key, ">=", v.Value[0])
-			case consts.OperatorLt:
-				fexp = fmt.Sprintf("(%s %s %s)", v.Key, "<", v.Value[0])
-			case consts.OperatorLte:
-				fexp = fmt.Sprintf("(%s %s %s)", v.Key, "<=", v.Value[0])
-			case consts.OperatorBet:
-				fexp = fmt.Sprintf("(%s >= %s && %s <= %s)", v.Key, v.Value[0], v.Key, v.Value[1])
-			case consts.OperatorNbet:
-				fexp = fmt.Sprintf("(%s < %s && %s > %s)", v.Key, v.Value[0], v.Key, v.Value[1])
-			}
-			if flen > 1 {
-				switch v.AndOr {
-				case 1:
-					fexp = " && " + fexp
-				case 2:
-					fexp = " || " + fexp
-				}
-			}
-			gexp += fexp
-		}
-		if flen > 1 {
-			gexp = "(" + gexp + ")"
-		}
-		if glen > 1 {
-			switch group.AndOr {
-			case 1:
-				gexp = " && " + gexp
-			case 2:
-				gexp = " || " + gexp
-			}
-		}
-		exp += gexp
-	}
-	return exp
-}
@@ -8,11 +8,17 @@ import (
 	_ "sagooiot/internal/logic/product"
 	_ "sagooiot/internal/logic/system"
 	"sagooiot/internal/model"
+	"sagooiot/internal/model/entity"
 	"sagooiot/internal/service"
+	"sagooiot/pkg/cache"
+	"sagooiot/pkg/condition"
+	"sync"
 	"testing"
 	"time"
 
 	_ "github.com/gogf/gf/contrib/drivers/mysql/v2"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcfg"
 	"github.com/gogf/gf/v2/os/gtime"
 )
 
@@ -32,3 +38,128 @@ func TestCheck(t *testing.T) {
 	}
 	time.Sleep(10 * time.Second)
 }
+
+func TestRecoverCondition(t *testing.T) {
+	rule := model.AlarmRuleOutput{
+		AlarmRule: &entity.AlarmRule{TriggerType: consts.AlarmTriggerTypeProperty},
+		Condition: model.AlarmTriggerCondition{
+			TriggerCondition: []model.AlarmCondition{
+				{Filters: []model.AlarmFilters{{Key: "temp", Operator: consts.OperatorGt, Value: []string{"80"}}}},
+			},
+			RecoverCondition: []model.AlarmCondition{
+				{Filters: []model.AlarmFilters{{Key: "temp", Operator: consts.OperatorLt, Value: []string{"60"}}}},
+			},
+		},
+	}
+	exp := condition.Expression(rule.Condition.TriggerCondition)
+	rexp := condition.Expression(rule.Condition.RecoverCondition)
+	cases := []struct {
+		temp               float64
+		triggered, recover bool
+	}{
+		{90, true, false},
+		{70, false, false},
+		{50, false, true},
+	}
+	for _, c := range cases {
+		data := map[string]any{"temp": c.temp}
+		triggered, err := condition.Evaluate(exp, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recovered, err := condition.Evaluate(rexp, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if triggered != c.triggered || recovered != c.recover {
+			t.Fatalf("temp %v got triggered %v recovered %v", c.temp, triggered, recovered)
+		}
+	}
+
+	if !stateful(rule) {
+		t.Fatal("property rule should be stateful")
+	}
+	rule.TriggerType = consts.AlarmTriggerTypeEvent
+	rule.Condition.RecoverCondition = nil
+	if stateful(rule) {
+		t.Fatal("event rule without recover condition should not be stateful")
+	}
+}
+
+func TestClearAlarmStates(t *testing.T) {
+	ctx := initTestCache(t)
+	for _, key := range []string{alarmStateKey(91, "d1"), alarmStateKey(91, "d2"), alarmStateKey(911, "d1")} {
+		if err := cache.Instance().Set(ctx, key, model.AlarmState{LogId: 1}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := clearAlarmStates(ctx, 91); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{alarmStateKey(91, "d1"): false, alarmStateKey(91, "d2"): false, alarmStateKey(911, "d1"): true} {
+		if ok, _ := cache.Instance().Contains(ctx, key); ok != want {
+			t.Errorf("%s exists %v, want %v", key, ok, want)
+		}
+	}
+}
+
+func TestSwapAlarmState(t *testing.T) {
+	ctx := initTestCache(t)
+	key := alarmStateKey(92, "d1")
+	pending := model.AlarmState{RuleId: 92, DeviceKey: "d1", TriggeredAt: 1}
+	state := pending
+	state.LogId = 7
+
+	// 写日志期间告警被恢复，告警状态不再写回
+	if err := cache.Instance().Set(ctx, key, pending, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeAlarmState(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := swapAlarmState(ctx, key, pending, state); err != nil || ok {
+		t.Fatalf("swap after recover got %v %v", ok, err)
+	}
+	if ok, _ := cache.Instance().Contains(ctx, key); ok {
+		t.Fatal("recovered state written back")
+	}
+
+	// 并发的触发和恢复，恢复后不会残留告警状态
+	for i := 0; i < 100; i++ {
+		if err := cache.Instance().Set(ctx, key, pending, 0); err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = swapAlarmState(ctx, key, pending, state)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = removeAlarmState(ctx, key)
+		}()
+		wg.Wait()
+		v, err := cache.Instance().Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !v.IsNil() {
+			t.Fatalf("state %v left after recover", v)
+		}
+	}
+}
+
+// initTestCache 初始化测试使用的缓存，没有配置文件时使用内存缓存
+func initTestCache(t *testing.T) context.Context {
+	ctx := context.Background()
+	if !g.Cfg().Available(ctx) {
+		adapter, err := gcfg.NewAdapterContent(`{"database":{"default":{"link":"mysql:root:@tcp(127.0.0.1:3306)/sagoo"}}}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Cfg().SetAdapter(adapter)
+	}
+	cache.SetAdapter(ctx)
+	return ctx
+}
@@ -20,6 +20,9 @@ func (s *sAlarmRule) AddCronRule(ctx context.Context, in *model.AlarmCronRuleAdd
 	//获取当前登录用户ID
 	loginUserId := service.Context().GetUserId(ctx)
 
+	if err = checkPerformAction(ctx, in.AlarmPerformAction); err != nil {
+		return
+	}
 	triggerCondition, _ := json.Marshal(in.AlarmCronCondition)
 	action, _ := json.Marshal(in.AlarmPerformAction)
 
@@ -63,6 +66,9 @@ func (s *sAlarmRule) EditCronRule(ctx context.Context, in *model.AlarmCronRuleEd
 	param.UpdatedBy = uint(loginUserId)
 	param.Id = nil
 	param.TriggerCondition, _ = json.Marshal(in.AlarmCronCondition)
+	if err = checkPerformAction(ctx, in.AlarmPerformAction); err != nil {
+		return
+	}
 	param.Action, _ = json.Marshal(in.AlarmPerformAction)
 
 	_, err = dao.AlarmRule.Ctx(ctx).Data(param).Where(dao.AlarmRule.Columns().Id, in.Id).Update()
@@ -0,0 +1,63 @@
+package alarm
+
+import (
+	"context"
+	"fmt"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/pkg/cache"
+	"time"
+)
+
+// debounceSinceMargin 持续时长计时的过期余量，超过持续时长加余量没有满足条件的上报时重新计时
+const debounceSinceMargin = 5 * time.Minute
+
+// debounceKey 防抖状态缓存KEY，kind 区分持续时长、触发次数和抑制
+func debounceKey(kind string, ruleId uint64, deviceKey string) string {
+	return fmt.Sprintf("%s%s:%d:%s", consts.DeviceAlarmDebouncePrefix, kind, ruleId, deviceKey)
+}
+
+// debounce 防抖检测，条件满足时判断是否达到触发要求，条件不满足时重置持续时长
+func (s *sAlarmRule) debounce(ctx context.Context, rule model.AlarmRuleOutput, deviceKey string, triggered bool, now time.Time) (fire bool, err error) {
+	d := rule.Condition.Debounce
+	if !triggered {
+		if d.Duration > 0 {
+			_, err = cache.Instance().Remove(ctx, debounceKey("since", rule.Id, deviceKey))
+		}
+		return
+	}
+
+	// 条件持续满足N秒，设备停止上报超过持续时长和余量后重新计时
+	if d.Duration > 0 {
+		key := debounceKey("since", rule.Id, deviceKey)
+		ttl := time.Duration(d.Duration)*time.Second + debounceSinceMargin
+		started, err := cache.Instance().SetIfNotExist(ctx, key, now.Unix(), ttl)
+		if err != nil || started {
+			return false, err
+		}
+		since, err := cache.Instance().Get(ctx, key)
+		if err != nil || since.IsNil() {
+			return false, err
+		}
+		if _, err = cache.Instance().UpdateExpire(ctx, key, ttl); err != nil {
+			return false, err
+		}
+		if now.Unix()-since.Int64() < int64(d.Duration) {
+			return false, nil
+		}
+	}
+
+	// 统计窗口内满足N次，达到次数后重新计数
+	if d.Count > 1 && d.Window > 0 {
+		reached, err := windowReach(ctx, debounceKey("window", rule.Id, deviceKey), now, time.Duration(d.Window)*time.Second, d.Count)
+		if err != nil || !reached {
+			return false, err
+		}
+	}
+
+	// 触发后T秒内不重复告警
+	if d.Suppress > 0 {
+		return cache.Instance().SetIfNotExist(ctx, debounceKey("suppress", rule.Id, deviceKey), now.Unix(), time.Duration(d.Suppress)*time.Second)
+	}
+	return true, nil
+}
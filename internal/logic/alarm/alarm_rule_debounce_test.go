@@ -0,0 +1,87 @@
+package alarm
+
+import (
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/entity"
+	"sagooiot/pkg/cache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebounce(t *testing.T) {
+	ctx := initTestCache(t)
+	s := alarmRuleNew()
+	now := time.Now()
+
+	fire := func(rule model.AlarmRuleOutput, triggered bool, after int) bool {
+		t.Helper()
+		ok, err := s.debounce(ctx, rule, "d1", triggered, now.Add(time.Duration(after)*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	newRule := func(id uint64, d model.AlarmDebounce) model.AlarmRuleOutput {
+		return model.AlarmRuleOutput{
+			AlarmRule: &entity.AlarmRule{Id: id},
+			Condition: model.AlarmTriggerCondition{Debounce: d},
+		}
+	}
+
+	// 条件持续10秒后触发，中途不满足则重新计时
+	rule := newRule(1, model.AlarmDebounce{Duration: 10})
+	if fire(rule, true, 0) || fire(rule, true, 5) {
+		t.Fatal("duration not reached")
+	}
+	if ttl, _ := cache.Instance().GetExpire(ctx, debounceKey("since", 1, "d1")); ttl <= 0 {
+		t.Fatalf("since key ttl got %v", ttl)
+	}
+	fire(rule, false, 6)
+	if fire(rule, true, 12) || !fire(rule, true, 22) {
+		t.Fatal("duration should restart after condition clears")
+	}
+
+	// 60秒内满足3次触发
+	rule = newRule(2, model.AlarmDebounce{Count: 3, Window: 60})
+	if fire(rule, true, 0) || fire(rule, true, 10) {
+		t.Fatal("count not reached")
+	}
+	if !fire(rule, true, 20) {
+		t.Fatal("count reached should fire")
+	}
+	if fire(rule, true, 30) {
+		t.Fatal("count should restart after fire")
+	}
+
+	// 并发满足条件时每满3次只触发一次
+	rule = newRule(5, model.AlarmDebounce{Count: 3, Window: 60})
+	var fired atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 9; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := s.debounce(ctx, rule, "d1", true, now); err == nil && ok {
+				fired.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if fired.Load() != 3 {
+		t.Fatalf("concurrent fires got %d, want 3", fired.Load())
+	}
+
+	// 触发后抑制重复告警
+	rule = newRule(3, model.AlarmDebounce{Suppress: 60})
+	if !fire(rule, true, 0) || fire(rule, true, 1) {
+		t.Fatal("repeat should be suppressed")
+	}
+
+	// 未设置防抖时直接触发
+	rule = newRule(4, model.AlarmDebounce{})
+	if !fire(rule, true, 0) || !fire(rule, true, 0) {
+		t.Fatal("no debounce should always fire")
+	}
+}
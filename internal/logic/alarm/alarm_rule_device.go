@@ -0,0 +1,214 @@
+package alarm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/do"
+	"sagooiot/internal/service"
+	"sagooiot/pkg/cache"
+	"sagooiot/pkg/utility/utils"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// checkPerformAction 校验执行动作中的设备控制动作
+func checkPerformAction(ctx context.Context, in model.AlarmPerformAction) error {
+	for i, a := range in.DeviceAction {
+		err := checkDeviceAction(a)
+		if err == nil && a.DeviceKey != "" {
+			err = service.DevDevice().CheckDataScope(ctx, a.DeviceKey)
+		}
+		if err != nil {
+			return gerror.Wrapf(err, "第%d个设备控制动作", i+1)
+		}
+	}
+	return nil
+}
+
+// checkDeviceAction 校验设备控制动作
+func checkDeviceAction(a model.AlarmDeviceAction) error {
+	switch a.Type {
+	case consts.AlarmDeviceActionFunction:
+		if a.FuncKey == "" {
+			return gerror.New("请选择设备功能")
+		}
+	case consts.AlarmDeviceActionProperty:
+		if len(a.Params) == 0 {
+			return gerror.New("请设置属性值")
+		}
+	default:
+		return gerror.Newf("未知的动作类型：%s", a.Type)
+	}
+	if a.Cooldown < 0 || a.MaxPerHour < 0 {
+		return gerror.New("冷却时长和每小时执行次数不能小于0")
+	}
+	for k, v := range a.Params {
+		if s, ok := v.(string); ok && strings.Contains(s, "{{") {
+			if _, err := template.New(k).Parse(s); err != nil {
+				return gerror.Newf("参数 %s 的模板格式错误：%s", k, err)
+			}
+		}
+	}
+	return nil
+}
+
+// deviceAction 依次执行设备控制动作，并按告警日志记录每个动作的执行结果
+func (s *sAlarmRule) deviceAction(ctx context.Context, rule model.AlarmRuleOutput, logId uint64, expression string, deviceKey string, param any) {
+	data := s.templateData(ctx, rule, expression, deviceKey, param)
+	if data == nil {
+		data = map[string]any{"DeviceKey": deviceKey}
+	}
+
+	for i, a := range rule.PerformAction.DeviceAction {
+		target := a.DeviceKey
+		if target == "" {
+			target = deviceKey
+		}
+		log := do.AlarmActionLog{
+			LogId:      logId,
+			RuleId:     rule.Id,
+			ActionType: a.Type,
+			DeviceKey:  target,
+			FuncKey:    a.FuncKey,
+			Status:     consts.AlarmActionLogStatusSuccess,
+			CreatedAt:  gtime.Now(),
+		}
+
+		params, err := renderParams(a.Params, data)
+		if err == nil {
+			b, _ := json.Marshal(params)
+			log.Params = string(b)
+			var ok bool
+			var reason string
+			if ok, reason, err = actionLimit(ctx, rule.Id, i, target, a, time.Now()); err == nil && !ok {
+				log.Status = consts.AlarmActionLogStatusLimited
+				log.Message = reason
+			}
+		}
+		if err == nil && log.Status == consts.AlarmActionLogStatusSuccess {
+			var result map[string]any
+			if result, err = runDeviceAction(ctx, a, target, params); err == nil && result != nil {
+				b, _ := json.Marshal(result)
+				log.Result = string(b)
+			}
+		}
+		if err != nil {
+			g.Log().Errorf(ctx, "告警设备控制 - %s - %s：%s", rule.Name, target, err)
+			log.Status = consts.AlarmActionLogStatusFail
+			log.Message = err.Error()
+		}
+
+		if _, err = dao.AlarmActionLog.Ctx(ctx).Data(log).Insert(); err != nil {
+			g.Log().Errorf(ctx, "告警设备控制日志记录 - %s：%s", rule.Name, err)
+		}
+	}
+}
+
+// runDeviceAction 调用设备功能或设置设备属性
+func runDeviceAction(ctx context.Context, a model.AlarmDeviceAction, deviceKey string, params map[string]any) (map[string]any, error) {
+	if deviceKey == "" {
+		return nil, gerror.New("未设置执行设备")
+	}
+	switch a.Type {
+	case consts.AlarmDeviceActionFunction:
+		out, err := service.DevDeviceFunction().Do(ctx, &model.DeviceFunctionInput{
+			DeviceKey: deviceKey,
+			FuncKey:   a.FuncKey,
+			Params:    params,
+			Timeout:   a.Timeout,
+		})
+		if err != nil || out == nil {
+			return nil, err
+		}
+		return out.Data, nil
+	case consts.AlarmDeviceActionProperty:
+		out, err := service.DevDeviceProperty().Set(ctx, &model.DevicePropertyInput{
+			DeviceKey: deviceKey,
+			Params:    params,
+			Timeout:   a.Timeout,
+		})
+		if err != nil || out == nil {
+			return nil, err
+		}
+		if out.Status == consts.DevicePropertySetPending {
+			// 设备离线，记录等待下发的属性
+			return map[string]any{"status": out.Status, "pending": out.Data}, nil
+		}
+		return out.Data, nil
+	}
+	return nil, gerror.Newf("未知的动作类型：%s", a.Type)
+}
+
+// renderParams 渲染参数中的模板变量，渲染结果为数字或布尔值时按对应类型下发
+func renderParams(params map[string]any, data map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(params))
+	for k, v := range params {
+		s, ok := v.(string)
+		if !ok || !strings.Contains(s, "{{") {
+			out[k] = v
+			continue
+		}
+		r, err := utils.ReplaceTemplate(s, data)
+		if err != nil {
+			return nil, gerror.Wrapf(err, "参数 %s 模板解析失败", k)
+		}
+		if strings.Contains(r, "<no value>") {
+			return nil, gerror.Newf("参数 %s 的模板变量不存在", k)
+		}
+		out[k] = r
+		dec := json.NewDecoder(strings.NewReader(r))
+		dec.UseNumber()
+		var typed any
+		if dec.Decode(&typed) == nil && !dec.More() {
+			switch typed.(type) {
+			case json.Number, bool:
+				out[k] = typed
+			}
+		}
+	}
+	return out, nil
+}
+
+// actionLimitKey 设备控制动作限制状态缓存KEY，按告警规则、动作序号和执行设备区分
+func actionLimitKey(kind string, ruleId uint64, index int, deviceKey string) string {
+	return fmt.Sprintf("%s%s:%d:%d:%s", consts.DeviceAlarmActionPrefix, kind, ruleId, index, deviceKey)
+}
+
+// actionLimit 检查冷却时长和每小时执行次数，允许执行时记录本次执行，执行失败也计入次数。
+// 冷却状态通过 SetIfNotExist 设置，每小时执行次数使用滑动窗口原子地检查和记录，并发触发时不会超过限制
+func actionLimit(ctx context.Context, ruleId uint64, index int, deviceKey string, a model.AlarmDeviceAction, now time.Time) (ok bool, reason string, err error) {
+	cooldownKey := actionLimitKey("cooldown", ruleId, index, deviceKey)
+	if a.Cooldown > 0 {
+		ok, err = cache.Instance().SetIfNotExist(ctx, cooldownKey, now.Unix(), time.Duration(a.Cooldown)*time.Second)
+		if err != nil {
+			return
+		}
+		if !ok {
+			return false, fmt.Sprintf("冷却中，%d秒内不重复执行", a.Cooldown), nil
+		}
+	}
+
+	if a.MaxPerHour > 0 {
+		n, err := windowAdd(ctx, actionLimitKey("hourly", ruleId, index, deviceKey), now, time.Hour, a.MaxPerHour)
+		if err == nil && n < 0 {
+			reason = fmt.Sprintf("超过每小时最多执行次数%d", a.MaxPerHour)
+		}
+		if err != nil || n < 0 {
+			// 本次未执行，不进入冷却
+			if a.Cooldown > 0 {
+				_, _ = cache.Instance().Remove(ctx, cooldownKey)
+			}
+			return false, reason, err
+		}
+	}
+	return true, "", nil
+}
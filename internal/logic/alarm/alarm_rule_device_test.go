@@ -0,0 +1,113 @@
+package alarm
+
+import (
+	"context"
+	"encoding/json"
+	"sagooiot/internal/model"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRenderParams(t *testing.T) {
+	data := map[string]any{"pressure": 1.25, "DeviceKey": "d1", "on": true}
+	out, err := renderParams(map[string]any{
+		"valve":  false,
+		"target": "{{.pressure}}",
+		"enable": "{{.on}}",
+		"remark": "{{.DeviceKey}} 压力过高",
+		"code":   "{{.DeviceKey}}",
+		"static": "close",
+	}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(out)
+	want := `{"code":"d1","enable":true,"remark":"d1 压力过高","static":"close","target":1.25,"valve":false}`
+	if string(b) != want {
+		t.Fatalf("got %s, want %s", b, want)
+	}
+
+	if _, err = renderParams(map[string]any{"target": "{{.missing}}"}, data); err == nil {
+		t.Fatal("expected missing variable error")
+	}
+}
+
+func TestCheckDeviceAction(t *testing.T) {
+	valid := []model.AlarmDeviceAction{
+		{Type: "function", FuncKey: "close"},
+		{Type: "property", Params: map[string]any{"valve": "{{.pressure}}"}, Cooldown: 60, MaxPerHour: 3},
+	}
+	if err := checkPerformAction(context.Background(), model.AlarmPerformAction{DeviceAction: valid}); err != nil {
+		t.Fatal(err)
+	}
+	invalid := []model.AlarmDeviceAction{
+		{Type: "reboot"},
+		{Type: "function"},
+		{Type: "property"},
+		{Type: "property", Params: map[string]any{"valve": "{{.pressure"}},
+		{Type: "function", FuncKey: "close", Cooldown: -1},
+	}
+	for i, a := range invalid {
+		if err := checkDeviceAction(a); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestActionLimit(t *testing.T) {
+	ctx := initTestCache(t)
+	now := time.Now()
+
+	run := func(ruleId uint64, a model.AlarmDeviceAction, after time.Duration) bool {
+		t.Helper()
+		ok, reason, err := actionLimit(ctx, ruleId, 0, "d1", a, now.Add(after))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok && reason == "" {
+			t.Fatal("expected limit reason")
+		}
+		return ok
+	}
+
+	// 冷却期间不重复执行
+	cooldown := model.AlarmDeviceAction{Cooldown: 60}
+	if !run(9101, cooldown, 0) || run(9101, cooldown, time.Second) {
+		t.Fatal("unexpected cooldown result")
+	}
+
+	// 每小时最多执行2次，超过1小时后重新计数
+	hourly := model.AlarmDeviceAction{MaxPerHour: 2}
+	if !run(9102, hourly, 0) || !run(9102, hourly, time.Minute) || run(9102, hourly, 2*time.Minute) {
+		t.Fatal("unexpected hourly limit result")
+	}
+	if !run(9102, hourly, 61*time.Minute) {
+		t.Fatal("expected execution after an hour")
+	}
+
+	// 并发触发时不超过每小时执行次数
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _, err := actionLimit(ctx, 9104, 0, "d1", model.AlarmDeviceAction{MaxPerHour: 3}, now); err == nil && ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed.Load() != 3 {
+		t.Fatalf("concurrent executions got %d, want 3", allowed.Load())
+	}
+
+	// 不限制
+	for i := 0; i < 5; i++ {
+		if !run(9103, model.AlarmDeviceAction{}, 0) {
+			t.Fatal("unexpected limit")
+		}
+	}
+}
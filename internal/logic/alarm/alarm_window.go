@@ -0,0 +1,108 @@
+package alarm
+
+import (
+	"context"
+	"sagooiot/pkg/cache"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// 滑动窗口计数，缓存使用redis时记录保存在有序集合中，通过Lua脚本原子地清理、计数和记录，多个实例共享；
+// 其他缓存只在单个实例内使用，由进程内的锁保证读写的原子性
+
+const (
+	// windowAddScript 清理窗口外的记录，次数未达到上限时记录一次，返回记录后的次数，已达到上限时返回-1
+	windowAddScript = `redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+local n = redis.call('ZCARD', KEYS[1])
+if tonumber(ARGV[3]) > 0 and n >= tonumber(ARGV[3]) then return -1 end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return n + 1`
+	// windowReachScript 清理窗口外的记录并记录一次，达到次数时清空窗口并返回1，否则返回0
+	windowReachScript = `redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[4])
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[3]) then
+  redis.call('DEL', KEYS[1])
+  return 1
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 0`
+)
+
+var (
+	redisOnce  sync.Once
+	redisCache bool
+	windowLock sync.Mutex
+)
+
+func useRedisCache(ctx context.Context) bool {
+	redisOnce.Do(func() {
+		redisCache = g.Cfg().MustGet(ctx, "cache.adapter").String() == "redis"
+	})
+	return redisCache
+}
+
+// windowAdd 在 now 之前 window 时长的窗口内记录一次，limit 大于0时窗口内已有 limit 次则不记录并返回-1，否则返回记录后窗口内的次数
+func windowAdd(ctx context.Context, key string, now time.Time, window time.Duration, limit int) (int, error) {
+	begin := now.Add(-window).UnixMilli()
+	if useRedisCache(ctx) {
+		v, err := g.Redis().Do(ctx, "EVAL", windowAddScript, 1, key, begin, now.UnixMilli(), limit, guid.S(), window.Milliseconds())
+		if err != nil {
+			return 0, err
+		}
+		return v.Int(), nil
+	}
+
+	windowLock.Lock()
+	defer windowLock.Unlock()
+	v, err := cache.Instance().Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	var matches []int64
+	for _, t := range gconv.Int64s(v.Val()) {
+		if t > begin {
+			matches = append(matches, t)
+		}
+	}
+	if limit > 0 && len(matches) >= limit {
+		return -1, nil
+	}
+	matches = append(matches, now.UnixMilli())
+	return len(matches), cache.Instance().Set(ctx, key, matches, window)
+}
+
+// windowReach 在 now 之前 window 时长的窗口内记录一次，窗口内达到 count 次时清空窗口并返回 true，并发记录时只有一次返回 true
+func windowReach(ctx context.Context, key string, now time.Time, window time.Duration, count int) (bool, error) {
+	begin := now.Add(-window).UnixMilli()
+	if useRedisCache(ctx) {
+		v, err := g.Redis().Do(ctx, "EVAL", windowReachScript, 1, key, begin, now.UnixMilli(), count, guid.S(), window.Milliseconds())
+		if err != nil {
+			return false, err
+		}
+		return v.Int() == 1, nil
+	}
+
+	windowLock.Lock()
+	defer windowLock.Unlock()
+	v, err := cache.Instance().Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	var matches []int64
+	for _, t := range gconv.Int64s(v.Val()) {
+		if t > begin {
+			matches = append(matches, t)
+		}
+	}
+	matches = append(matches, now.UnixMilli())
+	if len(matches) >= count {
+		_, err = cache.Instance().Remove(ctx, key)
+		return err == nil, err
+	}
+	return false, cache.Instance().Set(ctx, key, matches, window)
+}
@@ -3,13 +3,18 @@ package analysis
 import (
 	"context"
 	"fmt"
-	"github.com/gogf/gf/v2/container/gvar"
 	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
 	"github.com/gogf/gf/v2/frame/g"
-	"sagooiot/internal/consts"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/util/gconv"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
 	"sagooiot/internal/service"
+	"sagooiot/pkg/dcache"
 	"sagooiot/pkg/general"
 	"sagooiot/pkg/tsd"
+	"sagooiot/pkg/tsd/comm"
 	"time"
 )
 
@@ -24,59 +29,181 @@ func analysisDeviceDataTsdNew() *sAnalysisDeviceDataTsd {
 	return &sAnalysisDeviceDataTsd{}
 }
 
+// GetDeviceData 查询设备物模型全部属性的时序数据
 func (s *sAnalysisDeviceDataTsd) GetDeviceData(ctx context.Context, reqData general.SelectReq) (rs []interface{}, err error) {
-	// 创建数据库连接
-	db := tsd.GetDB()
-	defer db.Close()
-
-	if reqData.Param["deviceKey"] == nil {
+	deviceKey := gconv.String(reqData.Param["deviceKey"])
+	if deviceKey == "" {
 		err = fmt.Errorf("deviceKey is nil")
 		return
 	}
+	device, err := dcache.GetDeviceDetailInfo(deviceKey)
+	if err != nil {
+		return
+	}
+	if device == nil || device.TSL == nil {
+		return nil, gerror.New("设备不存在")
+	}
+	if len(device.TSL.Properties) == 0 {
+		return
+	}
 
-	sqlStr := fmt.Sprintf("select * from device_%s", reqData.Param["deviceKey"])
-	rows, err := db.Query(sqlStr)
+	in := &model.TsdQueryInput{
+		DeviceKeys: []string{deviceKey},
+		PaginationInput: model.PaginationInput{
+			PageNum:  reqData.PageNum,
+			PageSize: reqData.PageSize,
+		},
+	}
+	for _, v := range device.TSL.Properties {
+		in.Properties = append(in.Properties, v.Key)
+	}
+	if len(reqData.DateRange) == 2 {
+		in.StartTime, in.EndTime = reqData.DateRange[0], reqData.DateRange[1]
+	}
+	out, err := s.Query(ctx, in)
 	if err != nil {
 		return
 	}
-	defer rows.Close()
+	for _, v := range out.List {
+		rs = append(rs, v)
+	}
+	return
+}
 
-	columns, _ := rows.Columns()
+// Query 按查询条件查询时序数据，支持聚合、时间窗口和空窗口填充
+func (s *sAnalysisDeviceDataTsd) Query(ctx context.Context, in *model.TsdQueryInput) (out *model.TsdQueryOutput, err error) {
+	in.Normalize()
+	q, err := tsdQuery(in)
+	if err != nil {
+		return
+	}
 
-	for rows.Next() {
-		values := make([]any, len(columns))
-		for i := range values {
-			values[i] = new(any)
-		}
+	allowed, err := dataScope(ctx, q)
+	if err != nil {
+		return
+	}
+	if !allowed {
+		return &model.TsdQueryOutput{PaginationOutput: model.PaginationOutput{CurrentPage: in.PageNum}}, nil
+	}
 
-		err = rows.Scan(values...)
+	// 查询多个设备时按设备确定所属产品
+	if q.ProductKey == "" && len(q.DeviceKeys) > 1 {
+		var products []gdb.Value
+		products, err = dao.DevDevice.Ctx(ctx).
+			WhereIn(dao.DevDevice.Columns().Key, q.DeviceKeys).
+			Distinct().
+			Array(dao.DevDevice.Columns().ProductKey)
 		if err != nil {
 			return
 		}
+		if len(products) != 1 {
+			return nil, gerror.New("查询的设备需属于同一产品")
+		}
+		q.ProductKey = products[0].String()
+	}
 
-		m := make(gdb.Record, len(columns))
-		for i, c := range columns {
-			// 去除前缀
-			if c[:2] == consts.TdPropertyPrefix {
-				c = c[2:]
-			}
-			m[c] = toTime(gvar.New(values[i]))
+	// influxdb 中没有产品标签，只指定产品时查询产品下所有设备
+	databaseType := g.Cfg().MustGet(ctx, "tsd.database", comm.DBTdEngine).String()
+	if databaseType == comm.DBInfluxdb && len(q.DeviceKeys) == 0 {
+		devices, err := service.DevDevice().GetAllForProduct(ctx, q.ProductKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(devices) == 0 {
+			return &model.TsdQueryOutput{PaginationOutput: model.PaginationOutput{CurrentPage: in.PageNum}}, nil
+		}
+		for _, d := range devices {
+			q.DeviceKeys = append(q.DeviceKeys, d.Key)
 		}
-		rs = append(rs, m)
 	}
+
+	out = new(model.TsdQueryOutput)
+	out.CurrentPage = in.PageNum
+	out.List, out.Total, err = tsd.DB().QueryData(ctx, q)
 	return
 }
 
-// Time REST连接时区处理
-func toTime(v *g.Var) (rs *g.Var) {
-	driver := g.Cfg().MustGet(context.TODO(), "tdengine.type")
-	if driver.String() == "taosRestful" {
-		if t, err := time.Parse("2006-01-02 15:04:05 +0000 UTC", v.String()); err == nil {
-			rs = gvar.New(t.Local().Format("2006-01-02 15:04:05"))
-			return
+// dataScope 按当前用户的数据权限过滤查询的设备：指定设备时需全部在权限内，
+// 只指定产品时改为查询产品下权限内的设备，没有权限内的设备时返回 false
+func dataScope(ctx context.Context, q *comm.Query) (bool, error) {
+	user := service.Context().GetLoginUser(ctx)
+	if user == nil {
+		return false, gerror.New("用户未登录")
+	}
+	all, deptIds, err := service.SysRole().GetDataScope(ctx, user.Id, user.DeptId)
+	if err != nil || all {
+		return all, err
+	}
+	c := dao.DevDevice.Columns()
+	if len(q.DeviceKeys) > 0 {
+		num, err := dao.DevDevice.Ctx(ctx).
+			WhereIn(c.Key, q.DeviceKeys).
+			WhereIn(c.DeptId, deptIds).
+			Count()
+		if err != nil {
+			return false, err
+		}
+		if num < len(q.DeviceKeys) {
+			return false, gerror.New("无权限查询部分设备的数据")
 		}
+		return true, nil
+	}
+	keys, err := dao.DevDevice.Ctx(ctx).
+		Where(c.ProductKey, q.ProductKey).
+		WhereIn(c.DeptId, deptIds).
+		Limit(comm.QueryMaxDevices).
+		Array(c.Key)
+	if err != nil {
+		return false, err
+	}
+	for _, k := range keys {
+		q.DeviceKeys = append(q.DeviceKeys, k.String())
+	}
+	return len(q.DeviceKeys) > 0, nil
+}
+
+// tsdQuery 把查询参数转换为时序数据查询，默认查询最近1小时，查询条件在编译查询语句时校验
+func tsdQuery(in *model.TsdQueryInput) (q *comm.Query, err error) {
+	q = &comm.Query{
+		ProductKey: in.ProductKey,
+		DeviceKeys: in.DeviceKeys,
+		Properties: in.Properties,
+		Aggregate:  in.Aggregate,
+		Fill:       in.Fill,
+		FillValue:  in.FillValue,
+		Asc:        in.Order == "asc",
+		Limit:      in.PageSize,
+		Offset:     (in.PageNum - 1) * in.PageSize,
 	}
 
-	rs = v
+	q.End = time.Now()
+	if in.EndTime != "" {
+		t, err := gtime.StrToTime(in.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		q.End = t.Time
+	}
+	q.Start = q.End.Add(-time.Hour)
+	if in.StartTime != "" {
+		t, err := gtime.StrToTime(in.StartTime)
+		if err != nil {
+			return nil, err
+		}
+		q.Start = t.Time
+	}
+
+	unit := time.Minute
+	switch in.TimeUnit {
+	case 1:
+		unit = time.Second
+	case 2:
+		unit = time.Minute
+	case 3:
+		unit = time.Hour
+	case 4:
+		unit = 24 * time.Hour
+	}
+	q.Interval = time.Duration(in.Interval) * unit
 	return
 }
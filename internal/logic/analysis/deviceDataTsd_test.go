@@ -4,8 +4,10 @@ import (
 	"context"
 	_ "github.com/taosdata/driver-go/v3/taosRestful"
 	_ "github.com/taosdata/driver-go/v3/taosWS"
+	"sagooiot/internal/model"
 	"sagooiot/internal/service"
 	"sagooiot/pkg/general"
+	"time"
 
 	"testing"
 )
@@ -21,3 +23,31 @@ func TestGetDeviceDataByTsd(t *testing.T) {
 	t.Log(gotNumber)
 
 }
+
+func TestTsdQuery(t *testing.T) {
+	in := &model.TsdQueryInput{
+		DeviceKeys: []string{"d1"},
+		Properties: []string{"temp"},
+		EndTime:    "2024-01-01 12:00:00",
+		Aggregate:  "avg",
+		Interval:   5,
+		TimeUnit:   3,
+		Order:      "asc",
+	}
+	in.PageNum, in.PageSize = 3, 20
+	q, err := tsdQuery(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.End.Sub(q.Start) != time.Hour || q.Interval != 5*time.Hour || !q.Asc || q.Limit != 20 || q.Offset != 40 {
+		t.Fatalf("unexpected query %+v", q)
+	}
+	if err = q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	in.StartTime = "2024-01-01"
+	if q, err = tsdQuery(in); err != nil || q.End.Sub(q.Start) != 12*time.Hour {
+		t.Fatalf("unexpected start time %+v, %v", q, err)
+	}
+}
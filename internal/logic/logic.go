@@ -14,6 +14,7 @@ import (
 	_ "sagooiot/internal/logic/notice"
 	_ "sagooiot/internal/logic/oauth"
 	_ "sagooiot/internal/logic/product"
+	_ "sagooiot/internal/logic/scene"
 	_ "sagooiot/internal/logic/system"
 	_ "sagooiot/internal/logic/tdengine"
 )
@@ -0,0 +1,390 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"sagooiot/internal/consts"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/do"
+	"sagooiot/internal/model/entity"
+	"sagooiot/internal/service"
+	"sagooiot/pkg/iotModel/sagooProtocol/north"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/text/gstr"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+type sNetworkNorth struct{}
+
+func sNetworkNorthNew() *sNetworkNorth {
+	return &sNetworkNorth{}
+}
+
+func init() {
+	service.RegisterNetworkNorth(sNetworkNorthNew())
+}
+
+// GetSinkList 获取北向消息出口列表
+func (s *sNetworkNorth) GetSinkList(ctx context.Context, in *model.GetNetworkNorthSinkListInput) (total int, out []*model.NetworkNorthSinkOut, err error) {
+	err = g.Try(ctx, func(ctx context.Context) {
+		if in == nil {
+			in = &model.GetNetworkNorthSinkListInput{}
+		}
+		in.PaginationInput = model.EnsurePaginationInput(in.PaginationInput)
+
+		m := dao.NetworkNorthSink.Ctx(ctx)
+		if in.Types != "" {
+			m = m.Where(dao.NetworkNorthSink.Columns().Types, in.Types)
+		}
+		if in.ProductKey != "" {
+			m = m.Where(dao.NetworkNorthSink.Columns().ProductKey, in.ProductKey)
+		}
+		if in.KeyWord != "" {
+			m = m.WhereLike(dao.NetworkNorthSink.Columns().Name, "%"+in.KeyWord+"%")
+		}
+
+		total, err = m.Count()
+		if err != nil {
+			err = gerror.New("获取总行数失败")
+			return
+		}
+		err = m.Page(in.PageNum, in.PageSize).Order("created_at desc").Scan(&out)
+		if err != nil {
+			err = gerror.New("获取数据失败")
+		}
+	})
+	return
+}
+
+// GetSinkById 获取指定ID的北向消息出口
+func (s *sNetworkNorth) GetSinkById(ctx context.Context, id int) (out *model.NetworkNorthSinkOut, err error) {
+	err = dao.NetworkNorthSink.Ctx(ctx).Where(dao.NetworkNorthSink.Columns().Id, id).Scan(&out)
+	return
+}
+
+// AddSink 添加北向消息出口
+func (s *sNetworkNorth) AddSink(ctx context.Context, in model.NetworkNorthSinkAddInput) (err error) {
+	if _, err = newNorthSink(in.Types, in.Config); err != nil {
+		return
+	}
+	num, err := dao.NetworkNorthSink.Ctx(ctx).Where(dao.NetworkNorthSink.Columns().Name, in.Name).Count()
+	if err != nil {
+		return
+	}
+	if num > 0 {
+		return gerror.New("消息出口名称已存在")
+	}
+	_, err = dao.NetworkNorthSink.Ctx(ctx).Data(do.NetworkNorthSink{
+		DeptId:        service.Context().GetUserDeptId(ctx),
+		Name:          in.Name,
+		Types:         in.Types,
+		ProductKey:    in.ProductKey,
+		Topics:        in.Topics,
+		Config:        in.Config,
+		RetryTimes:    in.RetryTimes,
+		RetryInterval: in.RetryInterval,
+		Status:        in.Status,
+		CreatedBy:     service.Context().GetUserId(ctx),
+		CreatedAt:     gtime.Now(),
+		Remark:        in.Remark,
+	}).Insert()
+	if err != nil {
+		return
+	}
+	return s.reloadNorthSinks(ctx)
+}
+
+// EditSink 修改北向消息出口
+func (s *sNetworkNorth) EditSink(ctx context.Context, in model.NetworkNorthSinkEditInput) (err error) {
+	if _, err = newNorthSink(in.Types, in.Config); err != nil {
+		return
+	}
+	var sink *entity.NetworkNorthSink
+	if err = dao.NetworkNorthSink.Ctx(ctx).Where(dao.NetworkNorthSink.Columns().Id, in.Id).Scan(&sink); err != nil {
+		return
+	}
+	if sink == nil {
+		return gerror.New("ID错误")
+	}
+	num, err := dao.NetworkNorthSink.Ctx(ctx).Where(dao.NetworkNorthSink.Columns().Name, in.Name).WhereNot(dao.NetworkNorthSink.Columns().Id, in.Id).Count()
+	if err != nil {
+		return
+	}
+	if num > 0 {
+		return gerror.New("消息出口名称已存在")
+	}
+
+	var param do.NetworkNorthSink
+	if err = gconv.Scan(in, &param); err != nil {
+		return
+	}
+	param.Id = nil
+	param.UpdatedAt = gtime.Now()
+	if _, err = dao.NetworkNorthSink.Ctx(ctx).Data(param).Where(dao.NetworkNorthSink.Columns().Id, in.Id).Update(); err != nil {
+		return
+	}
+	return s.reloadNorthSinks(ctx)
+}
+
+// DeleteSink 删除北向消息出口
+func (s *sNetworkNorth) DeleteSink(ctx context.Context, ids []int) (err error) {
+	if _, err = dao.NetworkNorthSink.Ctx(ctx).WhereIn(dao.NetworkNorthSink.Columns().Id, ids).Delete(); err != nil {
+		return
+	}
+	return s.reloadNorthSinks(ctx)
+}
+
+// SetSinkStatus 修改北向消息出口状态
+func (s *sNetworkNorth) SetSinkStatus(ctx context.Context, id int, status int) (err error) {
+	var sink *entity.NetworkNorthSink
+	if err = dao.NetworkNorthSink.Ctx(ctx).Where(dao.NetworkNorthSink.Columns().Id, id).Scan(&sink); err != nil {
+		return
+	}
+	if sink == nil {
+		return gerror.New("ID错误")
+	}
+	_, err = dao.NetworkNorthSink.Ctx(ctx).Data(g.Map{
+		dao.NetworkNorthSink.Columns().Status:    status,
+		dao.NetworkNorthSink.Columns().UpdatedAt: gtime.Now(),
+	}).Where(dao.NetworkNorthSink.Columns().Id, id).Update()
+	if err != nil {
+		return
+	}
+	return s.reloadNorthSinks(ctx)
+}
+
+// LoadNorthSinks 加载启用的北向消息出口
+func (s *sNetworkNorth) LoadNorthSinks(ctx context.Context) (err error) {
+	var list []*entity.NetworkNorthSink
+	err = dao.NetworkNorthSink.Ctx(ctx).Where(dao.NetworkNorthSink.Columns().Status, consts.NorthSinkStatusEnable).Scan(&list)
+	if err != nil {
+		return
+	}
+	routes := make([]north.Route, 0, len(list))
+	for _, sink := range list {
+		route, routeErr := northRoute(sink)
+		if routeErr != nil {
+			g.Log().Errorf(ctx, "load north sink error: %v, id:%d", routeErr, sink.Id)
+			continue
+		}
+		routes = append(routes, route)
+	}
+	north.SetDeadLetterHandler(s.saveDeadLetter)
+	north.SetRoutes(routes)
+	if useNorthRedis(ctx) {
+		northSubscribeOnce.Do(func() {
+			go s.subscribeLoop(gctx.NeverDone(ctx))
+		})
+	}
+	return
+}
+
+var (
+	northRedisOnce     sync.Once
+	northRedis         bool
+	northSubscribeOnce sync.Once
+	// northInstance 本实例标识，收到自己发布的变更通知时不再重复加载
+	northInstance = guid.S()
+)
+
+// useNorthRedis 使用redis缓存时可能有多个实例
+func useNorthRedis(ctx context.Context) bool {
+	northRedisOnce.Do(func() {
+		northRedis = g.Cfg().MustGet(ctx, "cache.adapter").String() == "redis"
+	})
+	return northRedis
+}
+
+// reloadNorthSinks 重新加载本实例的北向消息出口，并通知其他实例重新加载
+func (s *sNetworkNorth) reloadNorthSinks(ctx context.Context) (err error) {
+	if err = s.LoadNorthSinks(ctx); err != nil {
+		return
+	}
+	if useNorthRedis(ctx) {
+		if _, err = g.Redis().Do(ctx, "PUBLISH", consts.NorthSinkReloadChannel, northInstance); err != nil {
+			g.Log().Errorf(ctx, "publish north sink reload error: %v", err)
+		}
+	}
+	return
+}
+
+func (s *sNetworkNorth) subscribeLoop(ctx context.Context) {
+	for {
+		if err := s.subscribe(ctx); err != nil {
+			g.Log().Errorf(ctx, "subscribe north sink reload error: %v", err)
+		}
+		time.Sleep(time.Second * 3)
+	}
+}
+
+func (s *sNetworkNorth) subscribe(ctx context.Context) error {
+	conn, err := g.Redis().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+	if _, err = conn.Subscribe(ctx, consts.NorthSinkReloadChannel); err != nil {
+		return err
+	}
+	for {
+		msg, err := conn.ReceiveMessage(ctx)
+		if err != nil {
+			return err
+		}
+		if msg.Payload == northInstance {
+			continue
+		}
+		if err = s.LoadNorthSinks(ctx); err != nil {
+			g.Log().Errorf(ctx, "reload north sinks error: %v", err)
+		}
+	}
+}
+
+// saveDeadLetter 保存投递失败的北向消息
+func (s *sNetworkNorth) saveDeadLetter(ctx context.Context, letter north.DeadLetter) {
+	var errMsg string
+	if letter.Err != nil {
+		errMsg = letter.Err.Error()
+	}
+	_, err := dao.NetworkNorthDeadLetter.Ctx(ctx).Data(do.NetworkNorthDeadLetter{
+		SinkId:     letter.RouteId,
+		Topic:      letter.Topic,
+		ProductKey: letter.ProductKey,
+		DeviceKey:  letter.DeviceKey,
+		Payload:    string(letter.Payload),
+		Error:      errMsg,
+		Retries:    letter.Retries,
+		CreatedAt:  gtime.Now(),
+	}).Insert()
+	if err != nil {
+		g.Log().Errorf(ctx, "save north dead letter error: %v, topic:%s", err, letter.Topic)
+	}
+}
+
+// GetDeadLetterList 获取投递失败的北向消息列表
+func (s *sNetworkNorth) GetDeadLetterList(ctx context.Context, in *model.GetNetworkNorthDeadLetterListInput) (total int, out []*model.NetworkNorthDeadLetterOut, err error) {
+	err = g.Try(ctx, func(ctx context.Context) {
+		if in == nil {
+			in = &model.GetNetworkNorthDeadLetterListInput{}
+		}
+		in.PaginationInput = model.EnsurePaginationInput(in.PaginationInput)
+
+		m := dao.NetworkNorthDeadLetter.Ctx(ctx)
+		if in.SinkId > 0 {
+			m = m.Where(dao.NetworkNorthDeadLetter.Columns().SinkId, in.SinkId)
+		}
+		if in.Topic != "" {
+			m = m.Where(dao.NetworkNorthDeadLetter.Columns().Topic, in.Topic)
+		}
+		if in.DeviceKey != "" {
+			m = m.Where(dao.NetworkNorthDeadLetter.Columns().DeviceKey, in.DeviceKey)
+		}
+
+		total, err = m.Count()
+		if err != nil {
+			err = gerror.New("获取总行数失败")
+			return
+		}
+		err = m.Page(in.PageNum, in.PageSize).Order("created_at desc").Scan(&out)
+		if err != nil {
+			err = gerror.New("获取数据失败")
+		}
+	})
+	return
+}
+
+// RetryDeadLetter 重新投递失败的北向消息，投递成功后删除
+func (s *sNetworkNorth) RetryDeadLetter(ctx context.Context, ids []int) (err error) {
+	var letters []*entity.NetworkNorthDeadLetter
+	if err = dao.NetworkNorthDeadLetter.Ctx(ctx).WhereIn(dao.NetworkNorthDeadLetter.Columns().Id, ids).Scan(&letters); err != nil {
+		return
+	}
+	var failed int
+	for _, letter := range letters {
+		var sink *entity.NetworkNorthSink
+		if err = dao.NetworkNorthSink.Ctx(ctx).Where(dao.NetworkNorthSink.Columns().Id, letter.SinkId).Scan(&sink); err != nil {
+			return
+		}
+		if sink == nil {
+			return gerror.Newf("消息出口%d不存在", letter.SinkId)
+		}
+		route, routeErr := northRoute(sink)
+		if routeErr != nil {
+			return routeErr
+		}
+		// 手动重新投递只尝试一次
+		route.Retry = north.Retry{}
+		var sendErr error
+		north.Deliver(ctx, route, letter.Topic, []byte(letter.Payload), func(_ int, e error) { sendErr = e })
+		if sendErr != nil {
+			failed++
+			_, err = dao.NetworkNorthDeadLetter.Ctx(ctx).Data(g.Map{
+				dao.NetworkNorthDeadLetter.Columns().Error:   sendErr.Error(),
+				dao.NetworkNorthDeadLetter.Columns().Retries: letter.Retries + 1,
+			}).Where(dao.NetworkNorthDeadLetter.Columns().Id, letter.Id).Update()
+		} else {
+			_, err = dao.NetworkNorthDeadLetter.Ctx(ctx).Where(dao.NetworkNorthDeadLetter.Columns().Id, letter.Id).Delete()
+		}
+		if err != nil {
+			return
+		}
+	}
+	if failed > 0 {
+		return gerror.Newf("%d条消息重新投递失败", failed)
+	}
+	return
+}
+
+// DeleteDeadLetter 删除投递失败的北向消息
+func (s *sNetworkNorth) DeleteDeadLetter(ctx context.Context, ids []int) (err error) {
+	_, err = dao.NetworkNorthDeadLetter.Ctx(ctx).WhereIn(dao.NetworkNorthDeadLetter.Columns().Id, ids).Delete()
+	return
+}
+
+// northRoute 根据出口配置创建北向消息路由
+func northRoute(sink *entity.NetworkNorthSink) (route north.Route, err error) {
+	route.Sink, err = newNorthSink(sink.Types, sink.Config)
+	if err != nil {
+		return
+	}
+	route.Id = sink.Id
+	route.ProductKey = sink.ProductKey
+	route.Topics = gstr.SplitAndTrim(sink.Topics, ",")
+	route.Retry = north.Retry{
+		Times:    sink.RetryTimes,
+		Interval: time.Duration(sink.RetryInterval) * time.Second,
+	}
+	return
+}
+
+func newNorthSink(types, config string) (north.Sink, error) {
+	switch types {
+	case consts.NorthSinkTypeMqtt:
+		var conf model.NorthMqttConfig
+		if config != "" {
+			if err := json.Unmarshal([]byte(config), &conf); err != nil {
+				return nil, gerror.Wrap(err, "mqtt出口配置错误")
+			}
+		}
+		return &north.MqttSink{TopicPrefix: conf.TopicPrefix}, nil
+	case consts.NorthSinkTypeWebhook:
+		var conf model.NorthWebhookConfig
+		if err := json.Unmarshal([]byte(config), &conf); err != nil {
+			return nil, gerror.Wrap(err, "webhook出口配置错误")
+		}
+		if conf.Url == "" {
+			return nil, gerror.New("webhook推送地址不能为空")
+		}
+		return north.NewWebhookSink(conf.Url, conf.Secret, conf.Headers, time.Duration(conf.Timeout)*time.Second), nil
+	}
+	return nil, gerror.Newf("不支持的消息出口类型：%s", types)
+}
@@ -11,6 +11,7 @@ import (
 	"sagooiot/internal/model"
 	"sagooiot/internal/model/do"
 	"sagooiot/internal/service"
+	noticeSender "sagooiot/pkg/notice"
 )
 
 type sNoticeConfig struct{}
@@ -64,12 +65,16 @@ func (s *sNoticeConfig) GetNoticeConfigById(ctx context.Context, id string) (out
 
 // AddNoticeConfig 添加数据
 func (s *sNoticeConfig) AddNoticeConfig(ctx context.Context, in model.NoticeConfigAddInput) (err error) {
+	if err = checkConfig(in); err != nil {
+		return
+	}
 	_, err = dao.NoticeConfig.Ctx(ctx).Data(do.NoticeConfig{
 		Id:          guid.S(),
 		DeptId:      service.Context().GetUserDeptId(ctx),
 		Title:       in.Title,
 		SendGateway: in.SendGateway,
 		Types:       in.Types,
+		Config:      in.Config,
 		CreatedAt:   gtime.Now(),
 	}).Insert()
 	return
@@ -84,6 +89,11 @@ func (s *sNoticeConfig) EditNoticeConfig(ctx context.Context, in model.NoticeCon
 	if noticeConfig == nil {
 		return gerror.New("通知配置不存在")
 	}
+	// 查询时密钥已替换为占位符，提交占位符时保持原值
+	in.Config = noticeSender.RestoreConfig(in.Config, noticeConfig.Config)
+	if err = checkConfig(in.NoticeConfigAddInput); err != nil {
+		return
+	}
 
 	_, err = dao.NoticeConfig.Ctx(ctx).FieldsEx(dao.NoticeConfig.Columns().Id).Where(dao.NoticeConfig.Columns().Id, in.Id).Update(in)
 	return
@@ -98,3 +108,12 @@ func (s *sNoticeConfig) DeleteNoticeConfig(ctx context.Context, Ids []string) (e
 	return
 
 }
+
+// checkConfig 检查内置通知通道的配置
+func checkConfig(in model.NoticeConfigAddInput) (err error) {
+	if in.Config == "" || !noticeSender.Supported(in.SendGateway) {
+		return
+	}
+	_, err = noticeSender.New(in.SendGateway, in.Config)
+	return
+}
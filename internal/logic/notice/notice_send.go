@@ -0,0 +1,89 @@
+package notice
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+	noticeSender "sagooiot/pkg/notice"
+	"sagooiot/pkg/plugins"
+	extModel "sagooiot/pkg/plugins/model"
+)
+
+// Send 按通知模板发送通知并记录通知日志，通知配置了内置通道时直接发送，否则使用通知插件发送
+func (s *sNoticeTemplate) Send(ctx context.Context, tpl *model.NoticeTemplateOutput, addressee []string, content string) (err error) {
+	gateway := tpl.SendGateway
+	var config string
+	if tpl.ConfigId != "" {
+		var cfg *model.NoticeConfigOutput
+		if cfg, err = service.NoticeConfig().GetNoticeConfigById(ctx, tpl.ConfigId); err != nil {
+			return
+		}
+		if cfg != nil {
+			config = cfg.Config
+			if gateway == "" {
+				gateway = cfg.SendGateway
+			}
+		}
+	}
+
+	if config != "" && noticeSender.Supported(gateway) {
+		err = noticeSender.Send(ctx, gateway, config, noticeSender.Message{
+			Title:   tpl.Title,
+			Content: content,
+			To:      addressee,
+		})
+	} else {
+		err = sendByPlugin(gateway, tpl, addressee, content)
+	}
+
+	status := 1
+	failMsg := ""
+	if err != nil {
+		status = 0
+		failMsg = err.Error()
+	}
+	if logErr := service.NoticeLog().Add(ctx, &model.NoticeLogAddInput{
+		TemplateId:  tpl.Id,
+		SendGateway: gateway,
+		Addressee:   strings.Join(addressee, ","),
+		Title:       tpl.Title,
+		Content:     content,
+		Status:      status,
+		FailMsg:     failMsg,
+		SendTime:    gtime.Now(),
+	}); logErr != nil {
+		g.Log().Errorf(ctx, "通知日志记录：%v", logErr)
+	}
+	return
+}
+
+// sendByPlugin 使用通知插件发送
+func sendByPlugin(gateway string, tpl *model.NoticeTemplateOutput, addressee []string, content string) error {
+	if plugins.GetNoticePlugin() == nil {
+		return gerror.Newf("通知通道 %s 未配置，且通知插件未启用", gateway)
+	}
+	var msg = extModel.NoticeInfoData{
+		TemplateCode: tpl.Code,
+		MsgTitle:     tpl.Title,
+		MsgBody:      content,
+	}
+	for _, u := range addressee {
+		msg.Totag = append(msg.Totag, extModel.NoticeSendObject{
+			Name:  gateway,
+			Value: u,
+		})
+	}
+	res, err := plugins.GetNoticePlugin().NoticeSend(gateway, msg)
+	if err != nil {
+		return err
+	}
+	if res.Code != 0 {
+		return gerror.New(res.Message)
+	}
+	return nil
+}
@@ -0,0 +1,368 @@
+package product
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/do"
+	"sagooiot/internal/service"
+	dconfig "sagooiot/network/core/logic/model/down/config"
+	"sagooiot/pkg/dcache"
+	"sagooiot/pkg/iotModel"
+	"sagooiot/pkg/iotModel/sagooProtocol"
+	"sagooiot/pkg/iotModel/sagooProtocol/north"
+	"sagooiot/pkg/iotModel/topicModel"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+type sDevConfig struct{}
+
+func init() {
+	service.RegisterDevConfig(devConfigNew())
+}
+
+func devConfigNew() *sDevConfig {
+	return &sDevConfig{}
+}
+
+// Save 保存设备远程配置，每次保存生成新的版本
+func (s *sDevConfig) Save(ctx context.Context, in *model.DeviceConfigSaveInput) (out *model.DeviceConfigOutput, err error) {
+	product, err := service.DevProduct().Detail(ctx, in.ProductKey)
+	if err != nil {
+		return
+	}
+	if product == nil {
+		return nil, gerror.New("产品不存在")
+	}
+	rules := in.Schema
+	// 未设置校验规则时，设备配置使用产品的校验规则，产品配置沿用上一版本的校验规则
+	var schema []byte
+	if len(in.Schema) > 0 {
+		if schema, err = json.Marshal(in.Schema); err != nil {
+			return
+		}
+	} else {
+		prev, err := s.latest(ctx, in.ProductKey, "")
+		if err != nil {
+			return nil, err
+		}
+		if prev != nil && prev.Schema != "" {
+			if err = json.Unmarshal([]byte(prev.Schema), &rules); err != nil {
+				return nil, err
+			}
+			if in.DeviceKey == "" {
+				schema = []byte(prev.Schema)
+			}
+		}
+	}
+	if in.DeviceKey != "" {
+		num, err := dao.DevDevice.Ctx(ctx).Where(g.Map{
+			dao.DevDevice.Columns().Key:        in.DeviceKey,
+			dao.DevDevice.Columns().ProductKey: in.ProductKey,
+		}).Count()
+		if err != nil {
+			return nil, err
+		}
+		if num == 0 {
+			return nil, gerror.New("设备不存在")
+		}
+	}
+	if err = validateConfig(ctx, rules, in.Content); err != nil {
+		return
+	}
+
+	content, err := json.Marshal(in.Content)
+	if err != nil {
+		return
+	}
+
+	c := dao.DevConfig.Columns()
+	var id int64
+	// 锁定产品记录，同一产品的配置串行保存，避免并发保存生成相同的版本号
+	err = dao.DevConfig.Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		if _, err := dao.DevProduct.Ctx(ctx).
+			Fields(dao.DevProduct.Columns().Id).
+			Where(dao.DevProduct.Columns().Key, in.ProductKey).
+			LockUpdate().
+			One(); err != nil {
+			return err
+		}
+		version, err := dao.DevConfig.Ctx(ctx).Where(c.ProductKey, in.ProductKey).Where(c.DeviceKey, in.DeviceKey).Max(c.Version)
+		if err != nil {
+			return err
+		}
+		id, err = dao.DevConfig.Ctx(ctx).Data(do.DevConfig{
+			DeptId:     service.Context().GetUserDeptId(ctx),
+			ProductKey: in.ProductKey,
+			DeviceKey:  in.DeviceKey,
+			Version:    int(version) + 1,
+			Content:    string(content),
+			Schema:     string(schema),
+			Sign:       configSign(content),
+			SignMethod: consts.DeviceConfigSignMethod,
+			Desc:       in.Desc,
+			CreatedBy:  uint(service.Context().GetUserId(ctx)),
+			CreatedAt:  gtime.Now(),
+		}).InsertAndGetId()
+		return err
+	})
+	if err != nil {
+		return
+	}
+	err = dao.DevConfig.Ctx(ctx).Where(c.Id, id).Scan(&out)
+	return
+}
+
+func (s *sDevConfig) Detail(ctx context.Context, in *model.DeviceConfigDetailInput) (out *model.DeviceConfigOutput, err error) {
+	if in.Version == 0 {
+		return s.latest(ctx, in.ProductKey, in.DeviceKey)
+	}
+	c := dao.DevConfig.Columns()
+	err = dao.DevConfig.Ctx(ctx).Where(g.Map{
+		c.ProductKey: in.ProductKey,
+		c.DeviceKey:  in.DeviceKey,
+		c.Version:    in.Version,
+	}).Scan(&out)
+	return
+}
+
+func (s *sDevConfig) History(ctx context.Context, in *model.DeviceConfigHistoryInput) (out *model.DeviceConfigHistoryOutput, err error) {
+	out = new(model.DeviceConfigHistoryOutput)
+	c := dao.DevConfig.Columns()
+	m := dao.DevConfig.Ctx(ctx).
+		Where(c.ProductKey, in.ProductKey).
+		Where(c.DeviceKey, in.DeviceKey).
+		OrderDesc(c.Version)
+
+	out.Total, _ = m.Count()
+	out.CurrentPage = in.PageNum
+	err = m.Page(in.PageNum, in.PageSize).Scan(&out.List)
+	return
+}
+
+// Effective 设备生效的配置，设备配置优先于产品配置
+func (s *sDevConfig) Effective(ctx context.Context, productKey string, deviceKey string) (out *model.DeviceConfigOutput, err error) {
+	if deviceKey != "" {
+		if out, err = s.latest(ctx, productKey, deviceKey); err != nil || out != nil {
+			return
+		}
+	}
+	return s.latest(ctx, productKey, "")
+}
+
+func (s *sDevConfig) latest(ctx context.Context, productKey, deviceKey string) (out *model.DeviceConfigOutput, err error) {
+	c := dao.DevConfig.Columns()
+	err = dao.DevConfig.Ctx(ctx).
+		Where(c.ProductKey, productKey).
+		Where(c.DeviceKey, deviceKey).
+		OrderDesc(c.Version).
+		Limit(1).
+		Scan(&out)
+	return
+}
+
+// Push 向设备下发配置，未指定设备时在后台下发给产品的全部在线设备，结果见下发记录，离线设备上线后可主动请求配置
+func (s *sDevConfig) Push(ctx context.Context, in *model.DeviceConfigPushInput) (out *model.DeviceConfigPushOutput, err error) {
+	if in.DeviceKey != "" {
+		if dcache.GetDeviceStatus(ctx, in.DeviceKey) != model.DeviceStatusOn {
+			return nil, gerror.New("设备不在线")
+		}
+		// 单个设备下发时返回失败原因
+		if err = s.push(ctx, in.ProductKey, in.DeviceKey); err != nil {
+			return
+		}
+		return &model.DeviceConfigPushOutput{Total: 1, Success: 1}, nil
+	}
+
+	keys, err := dao.DevDevice.Ctx(ctx).
+		Fields(dao.DevDevice.Columns().Key).
+		Where(dao.DevDevice.Columns().ProductKey, in.ProductKey).
+		Array()
+	if err != nil {
+		return
+	}
+	out = &model.DeviceConfigPushOutput{Total: len(keys)}
+	var online []string
+	for _, deviceKey := range gconv.Strings(keys) {
+		if dcache.GetDeviceStatus(ctx, deviceKey) != model.DeviceStatusOn {
+			out.Offline++
+			continue
+		}
+		online = append(online, deviceKey)
+	}
+	out.Pending = len(online)
+	if len(online) == 0 {
+		return
+	}
+	go s.pushAll(context.WithoutCancel(ctx), in.ProductKey, online)
+	return
+}
+
+// pushAll 依次向设备下发配置，每个设备的下发结果记录在下发记录中
+func (s *sDevConfig) pushAll(ctx context.Context, productKey string, deviceKeys []string) {
+	for _, deviceKey := range deviceKeys {
+		if err := s.push(ctx, productKey, deviceKey); err != nil {
+			g.Log().Debugf(ctx, "push config to %s error: %v", deviceKey, err)
+		}
+	}
+}
+
+func (s *sDevConfig) push(ctx context.Context, productKey, deviceKey string) (err error) {
+	config, err := s.Effective(ctx, productKey, deviceKey)
+	if err != nil {
+		return
+	}
+	if config == nil {
+		return gerror.New("未设置配置")
+	}
+	device, err := dcache.GetDeviceDetailInfo(deviceKey)
+	if err != nil {
+		return
+	}
+	if device == nil {
+		return gerror.New("设备不存在")
+	}
+
+	data := configData(config)
+	record := do.DevConfigPush{
+		ConfigId:   config.Id,
+		ProductKey: productKey,
+		DeviceKey:  deviceKey,
+		Version:    config.Version,
+		Status:     consts.DeviceConfigPushStatusSent,
+		CreatedAt:  gtime.Now(),
+	}
+	requestId, err := dconfig.ConfigPush(ctx, topicModel.TopicDownHandlerData{DeviceDetail: device}, sagooProtocol.ConfigPushRequestData{
+		ConfigId:      data.ConfigId,
+		ConfigSize:    data.ConfigSize,
+		ConfigContent: data.ConfigContent,
+		Sign:          data.Sign,
+		SignMethod:    data.SignMethod,
+		GetType:       data.GetType,
+	})
+	if err != nil {
+		record.Status = consts.DeviceConfigPushStatusFail
+		record.Message = err.Error()
+	}
+	record.RequestId = requestId
+	if _, insertErr := dao.DevConfigPush.Ctx(ctx).Data(record).Insert(); insertErr != nil {
+		g.Log().Errorf(ctx, "insert config push record error: %v", insertErr)
+	}
+	if err != nil {
+		return
+	}
+
+	//北向配置下发消息
+	var content map[string]interface{}
+	_ = json.Unmarshal([]byte(config.Content), &content)
+	north.WriteMessage(ctx, north.ConfigSendMessageTopic, nil, productKey, deviceKey, iotModel.ConfigSendMessage{
+		ConfigId:  data.ConfigId,
+		Version:   config.Version,
+		Content:   content,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	return
+}
+
+func (s *sDevConfig) PushList(ctx context.Context, in *model.DeviceConfigPushListInput) (out *model.DeviceConfigPushListOutput, err error) {
+	out = new(model.DeviceConfigPushListOutput)
+	c := dao.DevConfigPush.Columns()
+	m := dao.DevConfigPush.Ctx(ctx).OrderDesc(c.Id)
+	if in.ProductKey != "" {
+		m = m.Where(c.ProductKey, in.ProductKey)
+	}
+	if in.DeviceKey != "" {
+		m = m.Where(c.DeviceKey, in.DeviceKey)
+	}
+	if in.Status != "" {
+		m = m.Where(c.Status, gconv.Int(in.Status))
+	}
+	if len(in.DateRange) > 0 {
+		m = m.WhereBetween(c.CreatedAt, in.DateRange[0], in.DateRange[1])
+	}
+
+	out.Total, _ = m.Count()
+	out.CurrentPage = in.PageNum
+	err = m.Page(in.PageNum, in.PageSize).Scan(&out.List)
+	return
+}
+
+// Reply 设备响应配置下发，响应码200为成功
+func (s *sDevConfig) Reply(ctx context.Context, in *model.DeviceConfigReplyInput) (err error) {
+	data := do.DevConfigPush{
+		Status:    consts.DeviceConfigPushStatusSuccess,
+		Code:      in.Code,
+		Message:   "",
+		RepliedAt: gtime.Now(),
+	}
+	if in.Code != 200 {
+		data.Status = consts.DeviceConfigPushStatusFail
+		data.Message = gconv.String(in.Data["message"])
+	}
+	c := dao.DevConfigPush.Columns()
+	_, err = dao.DevConfigPush.Ctx(ctx).Data(data).Where(g.Map{
+		c.RequestId: in.RequestId,
+		c.DeviceKey: in.DeviceKey,
+	}).Update()
+	return
+}
+
+// Get 设备主动请求配置，没有配置时返回nil
+func (s *sDevConfig) Get(ctx context.Context, deviceKey string, scope string) (out *sagooProtocol.ConfigGetResponseData, err error) {
+	device, err := dcache.GetDeviceDetailInfo(deviceKey)
+	if err != nil {
+		return
+	}
+	if device == nil || device.DevDevice == nil {
+		return nil, gerror.New("设备不存在")
+	}
+	var config *model.DeviceConfigOutput
+	if scope == consts.DeviceConfigScopeProduct {
+		config, err = s.latest(ctx, device.ProductKey, "")
+	} else {
+		config, err = s.Effective(ctx, device.ProductKey, deviceKey)
+	}
+	if err != nil || config == nil {
+		return
+	}
+	data := configData(config)
+	return &data, nil
+}
+
+// configData 配置对应的下发内容
+func configData(config *model.DeviceConfigOutput) sagooProtocol.ConfigGetResponseData {
+	return sagooProtocol.ConfigGetResponseData{
+		ConfigId:      gconv.String(config.Id),
+		ConfigSize:    len(config.Content),
+		Sign:          config.Sign,
+		SignMethod:    config.SignMethod,
+		GetType:       consts.DeviceConfigGetType,
+		ConfigContent: config.Content,
+	}
+}
+
+// validateConfig 按校验规则校验配置内容，规则为GoFrame的校验规则
+func validateConfig(ctx context.Context, rules map[string]string, content map[string]interface{}) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	if err := g.Validator().Rules(rules).Data(content).Run(ctx); err != nil {
+		return gerror.New(err.FirstError().Error())
+	}
+	return nil
+}
+
+func configSign(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
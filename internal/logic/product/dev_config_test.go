@@ -0,0 +1,44 @@
+package product
+
+import (
+	"context"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/entity"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	ctx := context.Background()
+	rules := map[string]string{
+		"interval": "required|integer|between:1,3600",
+		"mode":     "in:auto,manual",
+	}
+	if err := validateConfig(ctx, rules, map[string]interface{}{"interval": 60, "mode": "auto"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateConfig(ctx, rules, map[string]interface{}{"mode": "auto"}); err == nil {
+		t.Fatal("missing interval should fail")
+	}
+	if err := validateConfig(ctx, rules, map[string]interface{}{"interval": 0}); err == nil {
+		t.Fatal("interval out of range should fail")
+	}
+	if err := validateConfig(ctx, nil, map[string]interface{}{"any": true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigData(t *testing.T) {
+	content := `{"interval":60}`
+	data := configData(&model.DeviceConfigOutput{DevConfig: &entity.DevConfig{
+		Id:         12,
+		Content:    content,
+		Sign:       configSign([]byte(content)),
+		SignMethod: "Sha256",
+	}})
+	if data.ConfigId != "12" || data.ConfigSize != len(content) || data.ConfigContent != content {
+		t.Fatalf("config data got %+v", data)
+	}
+	if data.Sign != "a4759353bbd2718d28a565d27e6db5d40c7013e23182c42115e87da29f904560" {
+		t.Fatalf("config sign got %s", data.Sign)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"sagooiot/internal/model"
 	"sagooiot/internal/service"
 	"sagooiot/pkg/dcache"
+
+	"github.com/gogf/gf/v2/frame/g"
 )
 
 type sDevDataReport struct{}
@@ -30,6 +32,9 @@ func (s *sDevDataReport) Event(ctx context.Context, deviceKey string, data model
 	}
 
 	err = service.AlarmRule().Check(ctx, device.Product.Key, deviceKey, consts.AlarmTriggerTypeEvent, data, subKey...)
+	if sceneErr := service.Scene().Check(ctx, device.Product.Key, deviceKey, consts.SceneTriggerReportEvent, data, subKey...); sceneErr != nil {
+		g.Log().Errorf(ctx, "场景检测失败: %s", sceneErr.Error())
+	}
 
 	return err
 }
@@ -12,6 +12,7 @@ import (
 	"sagooiot/pkg/dcache"
 
 	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
 	"github.com/gogf/gf/v2/util/gconv"
 )
 
@@ -51,6 +52,28 @@ func (s *sDevDevice) Get(ctx context.Context, key string) (out *model.DeviceOutp
 	return
 }
 
+// CheckDataScope 校验设备存在，且在当前用户的数据权限内
+func (s *sDevDevice) CheckDataScope(ctx context.Context, key string) (err error) {
+	user := service.Context().GetLoginUser(ctx)
+	if user == nil {
+		return gerror.New("用户未登录")
+	}
+	device, err := s.Get(ctx, key)
+	if err != nil {
+		return gerror.Newf("设备%s不存在", key)
+	}
+	all, deptIds, err := service.SysRole().GetDataScope(ctx, user.Id, user.DeptId)
+	if err != nil || all {
+		return
+	}
+	for _, id := range deptIds {
+		if int(id) == device.DeptId {
+			return nil
+		}
+	}
+	return gerror.Newf("无权限操作设备%s", key)
+}
+
 // GetAll 获取所有设备
 func (s *sDevDevice) GetAll(ctx context.Context) (out []*entity.DevDevice, err error) {
 	m := dao.DevDevice.Ctx(ctx)
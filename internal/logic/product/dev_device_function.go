@@ -38,6 +38,7 @@ func (s *sDevDeviceFunction) Do(ctx context.Context, in *model.DeviceFunctionInp
 	request := topicModel.TopicDownHandlerData{
 		DeviceDetail: device,
 		PayLoad:      params,
+		Timeout:      in.Timeout,
 	}
 
 	out = &model.DeviceFunctionOutput{}
@@ -3,15 +3,16 @@ package product
 import (
 	"context"
 	"errors"
-	"fmt"
 	"sagooiot/internal/consts"
 	"sagooiot/internal/model"
 	"sagooiot/pkg/dcache"
 	"sagooiot/pkg/tsd"
 	"sagooiot/pkg/tsd/comm"
 	"strings"
+	"time"
 
 	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/database/gdb"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gtime"
 )
@@ -68,25 +69,25 @@ func (s *sDevDevice) GetLatestProperty(ctx context.Context, key string) (list []
 	if err != nil {
 		return
 	}
-	if p.Status == model.DeviceStatusNoEnable {
+	if p.Status == model.DeviceStatusNoEnable || len(p.TSL.Properties) == 0 {
 		return
 	}
 
-	deviceTable := comm.DeviceTableName(p.Key)
-
 	tsdDb := tsd.DB()
 	defer tsdDb.Close()
 
+	var keys []string
 	for _, v := range p.TSL.Properties {
-		ckey := comm.TsdColumnName(v.Key)
+		keys = append(keys, v.Key)
+	}
+	// 获取属性最近有效值
+	rs, err := latestProperty(ctx, tsdDb, p.Key, keys)
+	if err != nil {
+		return
+	}
 
-		// 获取属性最近有效值
-		sql := "select ? from ? where ? is not null order by ts desc limit 1"
-		rs, err := tsdDb.GetTableDataOne(ctx, sql, ckey, deviceTable, ckey)
-		if err != nil {
-			return nil, err
-		}
-		value := rs[strings.ToLower(v.Key)]
+	for _, v := range p.TSL.Properties {
+		value := rs[v.Key]
 		if value.IsEmpty() {
 			continue
 		}
@@ -122,19 +123,8 @@ func (s *sDevDevice) GetProperty(ctx context.Context, in *model.DeviceGetPropert
 	tsdDb := tsd.DB()
 	defer tsdDb.Close()
 
-	sKey := in.PropertyKey
-	in.PropertyKey = strings.ToLower(in.PropertyKey)
-	col := comm.TsdColumnName(in.PropertyKey)
-
-	deviceTable := comm.DeviceTableName(p.Key)
-
-	// 属性上报时间
-	ctime := in.PropertyKey + "_time"
-	ctime = comm.TsdColumnName(ctime)
-
 	// 属性值获取
-	sql := "select ? from ? where ? is not null order by ? desc limit 1"
-	rs, err := tsdDb.GetTableDataOne(ctx, sql, col, deviceTable, col, ctime)
+	rs, err := latestProperty(ctx, tsdDb, p.Key, []string{in.PropertyKey})
 	if err != nil {
 		return
 	}
@@ -142,7 +132,7 @@ func (s *sDevDevice) GetProperty(ctx context.Context, in *model.DeviceGetPropert
 	var name string
 	var valueType string
 	for _, v := range p.TSL.Properties {
-		if strings.ToLower(v.Key) == in.PropertyKey {
+		if strings.EqualFold(v.Key, in.PropertyKey) {
 			name = v.Name
 			valueType = v.ValueType.Type
 			break
@@ -150,14 +140,13 @@ func (s *sDevDevice) GetProperty(ctx context.Context, in *model.DeviceGetPropert
 	}
 
 	out = new(model.DevicePropertiy)
-	out.Key = sKey
+	out.Key = in.PropertyKey
 	out.Name = name
 	out.Type = valueType
 	out.Value = rs[in.PropertyKey]
 
 	// 获取当天属性值列表
-	sql = "select ? from ? where ? >= '?' order by ? desc"
-	ls, _ := tsdDb.GetTableDataAll(ctx, sql, col, deviceTable, ctime, gtime.Now().Format("Y-m-d"), ctime)
+	ls, _ := propertyValues(ctx, tsdDb, p.Key, in.PropertyKey, gtime.Now().StartOfDay().Time, time.Now(), false)
 	out.List = ls.Array(in.PropertyKey)
 
 	return
@@ -193,45 +182,65 @@ func (s *sDevDevice) GetData(ctx context.Context, in *model.DeviceGetDataInput)
 		return
 	}
 
-	deviceTable := comm.DeviceTableName(p.Key)
-
-	in.PropertyKey = strings.ToLower(in.PropertyKey)
-	col := comm.TsdColumnName(in.PropertyKey)
-
-	// 属性上报时间
-	ctime := in.PropertyKey + "_time"
-	ctime = comm.TsdColumnName(ctime)
-
-	where := fmt.Sprintf("%s >= %q", ctime, gtime.Now().Format("Y-m-d"))
+	start, end := gtime.Now().StartOfDay().Time, time.Now()
 	if len(in.DateRange) > 1 {
-		where = fmt.Sprintf("%s >= %q and %s <= %q", ctime, in.DateRange[0], ctime, in.DateRange[1])
-	}
-
-	desc := "asc"
-	if in.IsDesc == 1 {
-		desc = "desc"
+		startTime, err := gtime.StrToTime(in.DateRange[0])
+		if err != nil {
+			return nil, err
+		}
+		endTime, err := gtime.StrToTime(in.DateRange[1])
+		if err != nil {
+			return nil, err
+		}
+		start, end = startTime.Time, endTime.Time
 	}
 
 	tsdDb := tsd.DB()
 	defer tsdDb.Close()
 
-	// TDengine
-	sql := "select ?, ? from ? where ? order by ? ?"
-	ls, _ := tsdDb.GetTableDataAll(
-		ctx,
-		sql,
-		fmt.Sprintf("distinct %s as ts", ctime),
-		col,
-		deviceTable,
-		where,
-		ctime,
-		desc,
-	)
-	for _, v := range ls.List() {
+	ls, _ := propertyValues(ctx, tsdDb, p.Key, in.PropertyKey, start, end, in.IsDesc != 1)
+	for _, v := range ls {
 		list = append(list, model.DevicePropertiyOut{
-			Ts:    gvar.New(v["ts"]).GTime(),
-			Value: gvar.New(v[in.PropertyKey]),
+			Ts:    v["ts"].GTime(),
+			Value: v[in.PropertyKey],
 		})
 	}
 	return
 }
+
+// latestProperty 查询设备属性的最近有效值，last 忽略空值
+func latestProperty(ctx context.Context, tsdDb tsd.Database, deviceKey string, properties []string) (rs gdb.Record, err error) {
+	list, _, err := tsdDb.QueryData(ctx, &comm.Query{
+		DeviceKeys: []string{deviceKey},
+		Properties: properties,
+		Start:      time.Unix(0, 0),
+		End:        time.Now().Add(time.Second),
+		Aggregate:  comm.AggLast,
+		Limit:      1,
+	})
+	if err != nil || list.IsEmpty() {
+		return
+	}
+	return list[0], nil
+}
+
+// propertyValues 查询设备属性在时间范围内的上报值，包含结束时间，不含空值
+func propertyValues(ctx context.Context, tsdDb tsd.Database, deviceKey, property string, start, end time.Time, asc bool) (rs gdb.Result, err error) {
+	list, _, err := tsdDb.QueryData(ctx, &comm.Query{
+		DeviceKeys: []string{deviceKey},
+		Properties: []string{property},
+		Start:      start,
+		End:        end.Add(time.Second),
+		Asc:        asc,
+		Limit:      comm.QueryMaxLimit,
+	})
+	if err != nil {
+		return
+	}
+	for _, rc := range list {
+		if !rc[property].IsNil() {
+			rs = append(rs, rc)
+		}
+	}
+	return
+}
@@ -11,6 +11,7 @@ import (
 	"sagooiot/pkg/iotModel/topicModel"
 
 	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gtime"
 )
 
@@ -28,7 +29,19 @@ func devDeviceProperty() *sDevDeviceProperty {
 func (s *sDevDeviceProperty) Set(ctx context.Context, in *model.DevicePropertyInput) (out *model.DevicePropertyOutput, err error) {
 	device, err := dcache.GetDeviceDetailInfo(in.DeviceKey)
 	if dcache.GetDeviceStatus(ctx, in.DeviceKey) != model.DeviceStatusOn {
-		err = gerror.New("设备不在线")
+		if len(in.Params) == 0 {
+			err = gerror.New("设备不在线")
+			return
+		}
+		// 设备离线时写入设备影子的期望属性，设备上线后下发
+		shadow, shadowErr := service.DevDeviceShadow().Update(ctx, &model.DeviceShadowUpdateInput{
+			DeviceKey: in.DeviceKey,
+			Desired:   in.Params,
+		})
+		if shadowErr != nil {
+			return nil, shadowErr
+		}
+		out = &model.DevicePropertyOutput{Status: consts.DevicePropertySetPending, Data: shadow.State.Delta}
 		return
 	}
 
@@ -41,13 +54,25 @@ func (s *sDevDeviceProperty) Set(ctx context.Context, in *model.DevicePropertyIn
 	request := topicModel.TopicDownHandlerData{
 		DeviceDetail: device,
 		PayLoad:      params,
+		Timeout:      in.Timeout,
 	}
 
-	out = &model.DevicePropertyOutput{}
+	out = &model.DevicePropertyOutput{Status: consts.DevicePropertySetSent}
 	if out.Data, err = dset.PropertySet(ctx, request); err != nil {
 		return
 	}
 
+	// 已直接下发的属性不再保留在设备影子中，避免设备上报后重复下发
+	if len(in.Params) > 0 {
+		keys := make([]string, 0, len(in.Params))
+		for k := range in.Params {
+			keys = append(keys, k)
+		}
+		if shadowErr := service.DevDeviceShadow().Delete(ctx, &model.DeviceShadowDeleteInput{DeviceKey: in.DeviceKey, Keys: keys}); shadowErr != nil {
+			g.Log().Debugf(ctx, "device shadow delete %s error: %v", in.DeviceKey, shadowErr)
+		}
+	}
+
 	// 写日志
 	logData := &model.TdLogAddInput{
 		Ts:      gtime.Now(),
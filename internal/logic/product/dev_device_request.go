@@ -0,0 +1,96 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/do"
+	"sagooiot/internal/service"
+
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+type sDevDeviceRequest struct{}
+
+func init() {
+	service.RegisterDevDeviceRequest(devDeviceRequestNew())
+}
+
+func devDeviceRequestNew() *sDevDeviceRequest {
+	return &sDevDeviceRequest{}
+}
+
+// Add 记录同步请求
+func (s *sDevDeviceRequest) Add(ctx context.Context, in *model.DeviceRequestAddInput) (err error) {
+	request, err := json.Marshal(in.Request)
+	if err != nil {
+		return
+	}
+	_, err = dao.DevDeviceRequest.Ctx(ctx).Data(do.DevDeviceRequest{
+		RequestId:  in.RequestId,
+		ProductKey: in.ProductKey,
+		DeviceKey:  in.DeviceKey,
+		FuncKey:    in.FuncKey,
+		Request:    string(request),
+		Status:     consts.DeviceRequestStatusWait,
+		Timeout:    in.Timeout,
+		CreatedAt:  gtime.Now(),
+	}).Insert()
+	return
+}
+
+// Reply 记录设备响应
+func (s *sDevDeviceRequest) Reply(ctx context.Context, requestId string, reply interface{}) (err error) {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return
+	}
+	_, err = dao.DevDeviceRequest.Ctx(ctx).Data(do.DevDeviceRequest{
+		Reply:     string(data),
+		Status:    consts.DeviceRequestStatusReplied,
+		RepliedAt: gtime.Now(),
+	}).Where(dao.DevDeviceRequest.Columns().RequestId, requestId).Update()
+	return
+}
+
+// Fail 记录请求超时或失败
+func (s *sDevDeviceRequest) Fail(ctx context.Context, requestId string, status int, message string) (err error) {
+	_, err = dao.DevDeviceRequest.Ctx(ctx).Data(do.DevDeviceRequest{
+		Status:  status,
+		Message: message,
+	}).Where(dao.DevDeviceRequest.Columns().RequestId, requestId).Update()
+	return
+}
+
+// List 同步请求记录列表
+func (s *sDevDeviceRequest) List(ctx context.Context, in *model.DeviceRequestListInput) (out *model.DeviceRequestListOutput, err error) {
+	out = new(model.DeviceRequestListOutput)
+	c := dao.DevDeviceRequest.Columns()
+	m := dao.DevDeviceRequest.Ctx(ctx).OrderDesc(c.Id)
+	if in.ProductKey != "" {
+		m = m.Where(c.ProductKey, in.ProductKey)
+	}
+	if in.DeviceKey != "" {
+		m = m.Where(c.DeviceKey, in.DeviceKey)
+	}
+	if in.FuncKey != "" {
+		m = m.Where(c.FuncKey, in.FuncKey)
+	}
+	if in.RequestId != "" {
+		m = m.Where(c.RequestId, in.RequestId)
+	}
+	if in.Status != "" {
+		m = m.Where(c.Status, gconv.Int(in.Status))
+	}
+	if len(in.DateRange) > 0 {
+		m = m.WhereBetween(c.CreatedAt, in.DateRange[0], in.DateRange[1])
+	}
+
+	out.Total, _ = m.Count()
+	out.CurrentPage = in.PageNum
+	err = m.Page(in.PageNum, in.PageSize).Scan(&out.List)
+	return
+}
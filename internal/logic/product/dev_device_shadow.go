@@ -0,0 +1,337 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+	dset "sagooiot/network/core/logic/model/down/property/set"
+	"sagooiot/pkg/cache"
+	"sagooiot/pkg/dcache"
+	"sagooiot/pkg/iotModel"
+	"sagooiot/pkg/iotModel/topicModel"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+type sDevDeviceShadow struct {
+	locks   [64]sync.Mutex // 按设备标识分段加锁，保证影子读改写的一致性
+	syncing sync.Map       // 正在下发差异的设备
+}
+
+func init() {
+	service.RegisterDevDeviceShadow(devDeviceShadowNew())
+}
+
+func devDeviceShadowNew() *sDevDeviceShadow {
+	return &sDevDeviceShadow{}
+}
+
+func (s *sDevDeviceShadow) lock(deviceKey string) func() {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(deviceKey))
+	l := &s.locks[h.Sum32()%uint32(len(s.locks))]
+	l.Lock()
+	return l.Unlock
+}
+
+// shadowCasScript 影子内容与读取时一致时才写入，避免多个实例同时修改时相互覆盖
+const shadowCasScript = `if (redis.call('GET', KEYS[1]) or '') ~= ARGV[1] then return 0 end
+redis.call('SET', KEYS[1], ARGV[2])
+return 1`
+
+// shadowRetry 多个实例同时修改影子时的最多尝试次数
+const shadowRetry = 5
+
+var (
+	shadowRedisOnce sync.Once
+	shadowRedis     bool
+)
+
+func useShadowRedis(ctx context.Context) bool {
+	shadowRedisOnce.Do(func() {
+		shadowRedis = g.Cfg().MustGet(ctx, "cache.adapter").String() == "redis"
+	})
+	return shadowRedis
+}
+
+// Get 获取设备影子，设备没有影子时返回空的影子
+func (s *sDevDeviceShadow) Get(ctx context.Context, deviceKey string) (out *model.DeviceShadow, err error) {
+	out, _, err = s.load(ctx, deviceKey)
+	return
+}
+
+// load 读取设备影子和缓存中的原始内容
+func (s *sDevDeviceShadow) load(ctx context.Context, deviceKey string) (out *model.DeviceShadow, raw string, err error) {
+	v, err := cache.Instance().Get(ctx, consts.DeviceShadowPrefix+deviceKey)
+	if err != nil {
+		return
+	}
+	out = newDeviceShadow(deviceKey)
+	if v != nil && !v.IsEmpty() {
+		raw = v.String()
+		if err = json.Unmarshal([]byte(raw), out); err != nil {
+			return
+		}
+		normalizeShadow(out)
+	}
+	return
+}
+
+// modify 读取影子交给 f 修改，f 返回 true 时保存。进程内按设备加锁，
+// 缓存使用redis时保存前比较影子是否已被其他实例修改，已修改时重新读取重试
+func (s *sDevDeviceShadow) modify(ctx context.Context, deviceKey string, f func(shadow *model.DeviceShadow) (bool, error)) (out *model.DeviceShadow, err error) {
+	defer s.lock(deviceKey)()
+	for i := 0; i < shadowRetry; i++ {
+		var raw string
+		if out, raw, err = s.load(ctx, deviceKey); err != nil {
+			return
+		}
+		var changed bool
+		if changed, err = f(out); err != nil || !changed {
+			return
+		}
+		data, err := json.Marshal(out)
+		if err != nil {
+			return nil, err
+		}
+		key := consts.DeviceShadowPrefix + deviceKey
+		if !useShadowRedis(ctx) {
+			return out, cache.Instance().Set(ctx, key, string(data), 0)
+		}
+		v, err := g.Redis().Do(ctx, "EVAL", shadowCasScript, 1, key, raw, string(data))
+		if err != nil || v.Int() == 1 {
+			return out, err
+		}
+	}
+	return nil, gerror.New("设备影子更新冲突，请稍后重试")
+}
+
+// touch 修改期望或上报属性后更新版本和差异
+func touch(shadow *model.DeviceShadow) {
+	shadow.Version++
+	shadow.Timestamp = time.Now().UnixMilli()
+	shadow.State.Delta = shadowDelta(shadow.State.Desired, shadow.State.Reported)
+}
+
+// Update 更新期望属性，设备在线时立即下发差异
+func (s *sDevDeviceShadow) Update(ctx context.Context, in *model.DeviceShadowUpdateInput) (out *model.DeviceShadow, err error) {
+	device, err := dcache.GetDeviceDetailInfo(in.DeviceKey)
+	if err != nil {
+		return
+	}
+	if device == nil || device.TSL == nil {
+		return nil, gerror.New("设备不存在")
+	}
+	desired := make(map[string]any, len(in.Desired))
+	for k, v := range in.Desired {
+		property := findProperty(device.TSL, k)
+		if property == nil {
+			return nil, gerror.Newf("属性%s不存在", k)
+		}
+		if property.AccessMode == 1 {
+			return nil, gerror.Newf("属性%s只读", k)
+		}
+		if v != nil {
+			v = property.ValueType.ConvertValue(v)
+		}
+		desired[k] = v
+	}
+
+	out, err = s.modify(ctx, in.DeviceKey, func(shadow *model.DeviceShadow) (bool, error) {
+		if in.Version > 0 && in.Version != shadow.Version {
+			return false, gerror.Newf("设备影子版本已更新为%d，请刷新后重试", shadow.Version)
+		}
+		now := time.Now().UnixMilli()
+		for k, v := range desired {
+			if v == nil {
+				delete(shadow.State.Desired, k)
+				delete(shadow.Metadata.Desired, k)
+				continue
+			}
+			shadow.State.Desired[k] = v
+			shadow.Metadata.Desired[k] = now
+		}
+		shadow.DesiredVersion++
+		touch(shadow)
+		return true, nil
+	})
+	if err != nil {
+		return
+	}
+
+	if len(out.State.Delta) > 0 && dcache.GetDeviceStatus(ctx, in.DeviceKey) == model.DeviceStatusOn {
+		go s.syncAsync(ctx, in.DeviceKey)
+	}
+	return
+}
+
+// Delete 删除期望属性或整个设备影子
+func (s *sDevDeviceShadow) Delete(ctx context.Context, in *model.DeviceShadowDeleteInput) (err error) {
+	if len(in.Keys) == 0 {
+		defer s.lock(in.DeviceKey)()
+		_, err = cache.Instance().Remove(ctx, consts.DeviceShadowPrefix+in.DeviceKey)
+		return
+	}
+	_, err = s.modify(ctx, in.DeviceKey, func(shadow *model.DeviceShadow) (bool, error) {
+		if len(shadow.State.Desired) == 0 {
+			return false, nil
+		}
+		for _, k := range in.Keys {
+			delete(shadow.State.Desired, k)
+			delete(shadow.Metadata.Desired, k)
+		}
+		shadow.DesiredVersion++
+		touch(shadow)
+		return true, nil
+	})
+	return
+}
+
+// Report 记录设备上报属性，只有上报了期望属性中的属性时才更新影子，存在未下发的差异时下发
+func (s *sDevDeviceShadow) Report(ctx context.Context, deviceKey string, data iotModel.ReportPropertyData) (err error) {
+	if len(data) == 0 {
+		return
+	}
+	shadow, err := s.modify(ctx, deviceKey, func(shadow *model.DeviceShadow) (bool, error) {
+		// 没有相关的期望属性时不需要比较差异，不写入影子
+		related := false
+		for k := range data {
+			if _, ok := shadow.State.Desired[k]; ok {
+				related = true
+				break
+			}
+		}
+		if !related {
+			return false, nil
+		}
+		now := time.Now().UnixMilli()
+		for k, node := range data {
+			shadow.State.Reported[k] = node.Value
+			shadow.Metadata.Reported[k] = now
+		}
+		touch(shadow)
+		return true, nil
+	})
+	if err != nil {
+		return
+	}
+
+	if len(shadow.State.Delta) > 0 && shadow.DesiredVersion > shadow.SyncedVersion {
+		go s.syncAsync(ctx, deviceKey)
+	}
+	return
+}
+
+// Sync 通过属性设置下发期望属性与上报属性的差异
+func (s *sDevDeviceShadow) Sync(ctx context.Context, deviceKey string) (err error) {
+	if _, ok := s.syncing.LoadOrStore(deviceKey, struct{}{}); ok {
+		return
+	}
+	defer s.syncing.Delete(deviceKey)
+
+	shadow, err := s.Get(ctx, deviceKey)
+	if err != nil || len(shadow.State.Delta) == 0 {
+		return
+	}
+	if dcache.GetDeviceStatus(ctx, deviceKey) != model.DeviceStatusOn {
+		return
+	}
+	device, err := dcache.GetDeviceDetailInfo(deviceKey)
+	if err != nil {
+		return
+	}
+	if device == nil {
+		return gerror.New("设备不存在")
+	}
+
+	payload, err := json.Marshal(shadow.State.Delta)
+	if err != nil {
+		return
+	}
+	if _, err = dset.PropertySet(ctx, topicModel.TopicDownHandlerData{
+		DeviceDetail: device,
+		PayLoad:      payload,
+	}); err != nil {
+		return
+	}
+
+	// 记录已下发的期望属性版本，下发期间期望属性有变化时以下发前的版本为准
+	_, err = s.modify(ctx, deviceKey, func(current *model.DeviceShadow) (bool, error) {
+		if current.SyncedVersion >= shadow.DesiredVersion {
+			return false, nil
+		}
+		current.SyncedVersion = shadow.DesiredVersion
+		return true, nil
+	})
+	return
+}
+
+func (s *sDevDeviceShadow) syncAsync(ctx context.Context, deviceKey string) {
+	ctx = gctx.NeverDone(ctx)
+	if err := s.Sync(ctx, deviceKey); err != nil {
+		g.Log().Debugf(ctx, "device shadow sync %s error: %v", deviceKey, err)
+	}
+}
+
+func newDeviceShadow(deviceKey string) *model.DeviceShadow {
+	shadow := &model.DeviceShadow{DeviceKey: deviceKey}
+	normalizeShadow(shadow)
+	return shadow
+}
+
+func normalizeShadow(shadow *model.DeviceShadow) {
+	if shadow.State.Desired == nil {
+		shadow.State.Desired = make(map[string]any)
+	}
+	if shadow.State.Reported == nil {
+		shadow.State.Reported = make(map[string]any)
+	}
+	if shadow.State.Delta == nil {
+		shadow.State.Delta = make(map[string]any)
+	}
+	if shadow.Metadata.Desired == nil {
+		shadow.Metadata.Desired = make(map[string]int64)
+	}
+	if shadow.Metadata.Reported == nil {
+		shadow.Metadata.Reported = make(map[string]int64)
+	}
+}
+
+func findProperty(tsl *model.TSL, key string) *model.TSLProperty {
+	for i, property := range tsl.Properties {
+		if property.Key == key {
+			return &tsl.Properties[i]
+		}
+	}
+	return nil
+}
+
+// shadowDelta 期望属性中与上报属性不一致的部分
+func shadowDelta(desired, reported map[string]any) map[string]any {
+	delta := make(map[string]any)
+	for k, v := range desired {
+		if r, ok := reported[k]; !ok || !shadowEqual(v, r) {
+			delta[k] = v
+		}
+	}
+	return delta
+}
+
+// shadowEqual 比较属性值，数值按字符串比较以忽略整数和浮点数的差异
+func shadowEqual(a, b any) bool {
+	switch a.(type) {
+	case map[string]any, []any:
+		ja, _ := json.Marshal(a)
+		jb, _ := json.Marshal(b)
+		return string(ja) == string(jb)
+	}
+	return gconv.String(a) == gconv.String(b)
+}
@@ -0,0 +1,41 @@
+package product
+
+import (
+	"testing"
+)
+
+func TestShadowDelta(t *testing.T) {
+	desired := map[string]any{
+		"switch": 1,
+		"temp":   25.5,
+		"mode":   "auto",
+		"range":  map[string]any{"min": 1, "max": 10},
+	}
+	reported := map[string]any{
+		"switch": float64(1),
+		"temp":   20,
+		"range":  map[string]any{"max": 10, "min": 1},
+	}
+	delta := shadowDelta(desired, reported)
+	if len(delta) != 2 {
+		t.Fatalf("delta got %v", delta)
+	}
+	if delta["temp"] != 25.5 || delta["mode"] != "auto" {
+		t.Fatalf("delta got %v", delta)
+	}
+	if len(shadowDelta(nil, reported)) != 0 {
+		t.Fatal("empty desired should have no delta")
+	}
+}
+
+func TestShadowEqual(t *testing.T) {
+	if !shadowEqual(1, "1") || !shadowEqual(int64(3), float64(3)) {
+		t.Fatal("numbers should be equal")
+	}
+	if shadowEqual(true, false) {
+		t.Fatal("different values should not be equal")
+	}
+	if !shadowEqual([]any{1, 2}, []any{1, 2}) || shadowEqual([]any{1, 2}, []any{2, 1}) {
+		t.Fatal("slice compare failed")
+	}
+}
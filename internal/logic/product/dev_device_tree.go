@@ -244,3 +244,35 @@ func (s *sDevDeviceTree) Del(ctx context.Context, infoId int) error {
 	})
 	return err
 }
+
+// GetDeviceKeys 获取节点及下级节点绑定的设备标识
+func (s *sDevDeviceTree) GetDeviceKeys(ctx context.Context, infoId int) (keys []string, err error) {
+	var list []*entity.DevDeviceTree
+	if err = dao.DevDeviceTree.Ctx(ctx).Scan(&list); err != nil {
+		return
+	}
+	children := make(map[int][]int, len(list))
+	for _, v := range list {
+		children[v.ParentInfoId] = append(children[v.ParentInfoId], v.InfoId)
+	}
+	infoIds := []int{infoId}
+	visited := map[int]bool{infoId: true}
+	for i := 0; i < len(infoIds); i++ {
+		for _, id := range children[infoIds[i]] {
+			if !visited[id] {
+				visited[id] = true
+				infoIds = append(infoIds, id)
+			}
+		}
+	}
+
+	rs, err := dao.DevDeviceTreeInfo.Ctx(ctx).
+		Fields(dao.DevDeviceTreeInfo.Columns().DeviceKey).
+		WhereIn(dao.DevDeviceTreeInfo.Columns().Id, infoIds).
+		WhereNot(dao.DevDeviceTreeInfo.Columns().DeviceKey, "").
+		Array()
+	if err != nil {
+		return
+	}
+	return gconv.Strings(rs), nil
+}
@@ -0,0 +1,287 @@
+package product
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/do"
+	"sagooiot/internal/model/entity"
+	"sagooiot/internal/service"
+	"sagooiot/pkg/iotModel/sagooProtocol"
+	"strings"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+type sDevFirmware struct{}
+
+func init() {
+	service.RegisterDevFirmware(devFirmwareNew())
+}
+
+func devFirmwareNew() *sDevFirmware {
+	return &sDevFirmware{}
+}
+
+// Add 添加固件，计算签名后通过上传服务保存固件文件
+func (s *sDevFirmware) Add(ctx context.Context, in *model.FirmwareAddInput, file *ghttp.UploadFile) (err error) {
+	if file == nil {
+		return gerror.New("请上传固件文件")
+	}
+	product, err := service.DevProduct().Detail(ctx, in.ProductKey)
+	if err != nil {
+		return
+	}
+	if product == nil {
+		return gerror.New("产品不存在")
+	}
+	if in.Module == "" {
+		in.Module = consts.FirmwareModuleDefault
+	}
+	if in.SignMethod == "" {
+		in.SignMethod = consts.FirmwareSignMethodMd5
+	}
+	c := dao.DevFirmware.Columns()
+	num, err := dao.DevFirmware.Ctx(ctx).Where(g.Map{
+		c.ProductKey: in.ProductKey,
+		c.Module:     in.Module,
+		c.Version:    in.Version,
+	}).Count()
+	if err != nil {
+		return
+	}
+	if num > 0 {
+		return gerror.Newf("模块%s已存在版本%s的固件", in.Module, in.Version)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return
+	}
+	sign, err := firmwareSign(f, in.SignMethod)
+	_ = f.Close()
+	if err != nil {
+		return
+	}
+
+	res, err := service.Upload().UploadFile(ctx, file, consts.CheckFileTypeFile, in.Source)
+	if err != nil {
+		return
+	}
+	// 只有本地上传的文件记录存储路径，分片下载时直接读取
+	var filePath string
+	if in.Source == consts.SourceLocal {
+		filePath = res.Path
+	}
+
+	_, err = dao.DevFirmware.Ctx(ctx).Data(do.DevFirmware{
+		DeptId:     service.Context().GetUserDeptId(ctx),
+		ProductKey: in.ProductKey,
+		Name:       in.Name,
+		Version:    in.Version,
+		Module:     in.Module,
+		FileName:   file.Filename,
+		FileUrl:    res.FullPath,
+		FilePath:   filePath,
+		Size:       file.Size,
+		Sign:       sign,
+		SignMethod: in.SignMethod,
+		Desc:       in.Desc,
+		CreatedBy:  uint(service.Context().GetUserId(ctx)),
+		CreatedAt:  gtime.Now(),
+	}).Insert()
+	return
+}
+
+func (s *sDevFirmware) Edit(ctx context.Context, in *model.FirmwareEditInput) (err error) {
+	firmware, err := s.Detail(ctx, in.Id)
+	if err != nil {
+		return
+	}
+	if firmware == nil {
+		return gerror.New("固件不存在")
+	}
+	_, err = dao.DevFirmware.Ctx(ctx).Data(do.DevFirmware{
+		Name:      in.Name,
+		Desc:      in.Desc,
+		UpdatedBy: uint(service.Context().GetUserId(ctx)),
+	}).Where(dao.DevFirmware.Columns().Id, in.Id).Update()
+	return
+}
+
+func (s *sDevFirmware) Del(ctx context.Context, id uint64) (err error) {
+	firmware, err := s.Detail(ctx, id)
+	if err != nil {
+		return
+	}
+	if firmware == nil {
+		return gerror.New("固件不存在")
+	}
+	num, err := dao.DevFirmwareTask.Ctx(ctx).
+		Where(dao.DevFirmwareTask.Columns().FirmwareId, id).
+		WhereIn(dao.DevFirmwareTask.Columns().Status, g.Slice{consts.FirmwareTaskStatusWait, consts.FirmwareTaskStatusRunning}).
+		Count()
+	if err != nil {
+		return
+	}
+	if num > 0 {
+		return gerror.New("固件存在未完成的升级任务，不能删除")
+	}
+
+	_, err = dao.DevFirmware.Ctx(ctx).
+		Data(do.DevFirmware{
+			DeletedBy: uint(service.Context().GetUserId(ctx)),
+			DeletedAt: gtime.Now(),
+		}).
+		Where(dao.DevFirmware.Columns().Id, id).
+		Unscoped().
+		Update()
+	return
+}
+
+func (s *sDevFirmware) Detail(ctx context.Context, id uint64) (out *model.FirmwareOutput, err error) {
+	err = dao.DevFirmware.Ctx(ctx).Where(dao.DevFirmware.Columns().Id, id).Scan(&out)
+	return
+}
+
+func (s *sDevFirmware) List(ctx context.Context, in *model.FirmwareListInput) (out *model.FirmwareListOutput, err error) {
+	out = new(model.FirmwareListOutput)
+	c := dao.DevFirmware.Columns()
+	m := dao.DevFirmware.Ctx(ctx).OrderDesc(c.Id)
+	if in.ProductKey != "" {
+		m = m.Where(c.ProductKey, in.ProductKey)
+	}
+	if in.KeyWord != "" {
+		m = m.WhereLike(c.Name, "%"+in.KeyWord+"%")
+	}
+
+	out.Total, _ = m.Count()
+	out.CurrentPage = in.PageNum
+	err = m.Page(in.PageNum, in.PageSize).Scan(&out.List)
+	return
+}
+
+// ReadBlock 读取固件文件分片，本地文件直接读取，其他存储通过Range请求获取。
+// 只能下载设备所属产品的固件，且固件在执行中的升级任务里包含该设备
+func (s *sDevFirmware) ReadBlock(ctx context.Context, productKey, deviceKey string, firmwareId uint64, offset int64, size int64) (out *sagooProtocol.FileDownloadData, err error) {
+	var firmware *entity.DevFirmware
+	if err = dao.DevFirmware.Ctx(ctx).Where(dao.DevFirmware.Columns().Id, firmwareId).Scan(&firmware); err != nil {
+		return
+	}
+	if firmware == nil || firmware.ProductKey != productKey {
+		return nil, gerror.New("固件不存在")
+	}
+	c := dao.DevFirmwareDevice.Columns()
+	tc := dao.DevFirmwareTask.Columns()
+	upgrading, err := dao.DevFirmwareDevice.Ctx(ctx).
+		Where(c.DeviceKey, deviceKey).
+		Where(c.FirmwareId, firmwareId).
+		WhereIn(c.Status, unfinishedDeviceStatus).
+		WhereIn(c.TaskId, dao.DevFirmwareTask.Ctx(ctx).Fields(tc.Id).Where(tc.Status, consts.FirmwareTaskStatusRunning)).
+		Count()
+	if err != nil {
+		return
+	}
+	if upgrading == 0 {
+		return nil, gerror.New("设备没有该固件的升级任务")
+	}
+	if offset < 0 || offset >= firmware.Size {
+		return nil, gerror.Newf("分片偏移量%d超出文件长度%d", offset, firmware.Size)
+	}
+	if size <= 0 || size > consts.FirmwareFileBlockMaxSize {
+		size = consts.FirmwareFileBlockMaxSize
+	}
+	if offset+size > firmware.Size {
+		size = firmware.Size - offset
+	}
+
+	var content []byte
+	if firmware.FilePath != "" {
+		content, err = readLocalBlock(ctx, firmware.FilePath, offset, size)
+	} else {
+		content, err = readRemoteBlock(ctx, firmware.FileUrl, offset, size)
+	}
+	if err != nil {
+		return
+	}
+	out = &sagooProtocol.FileDownloadData{
+		FirmwareId: firmwareId,
+		FileLength: firmware.Size,
+		Offset:     offset,
+		Size:       int64(len(content)),
+		Content:    base64.StdEncoding.EncodeToString(content),
+	}
+	return
+}
+
+func readLocalBlock(ctx context.Context, path string, offset, size int64) ([]byte, error) {
+	root, _ := g.Cfg().Get(ctx, "server.serverRoot")
+	if !root.IsEmpty() {
+		path = strings.TrimRight(root.String(), "/") + "/" + path
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, size)
+	n, err := f.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func readRemoteBlock(ctx context.Context, url string, offset, size int64) ([]byte, error) {
+	res, err := g.Client().Header(map[string]string{
+		"Range": fmt.Sprintf("bytes=%d-%d", offset, offset+size-1),
+	}).Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		return io.ReadAll(io.LimitReader(res.Body, size))
+	case http.StatusOK:
+		// 不支持Range请求时跳过前面的内容
+		if _, err = io.CopyN(io.Discard, res.Body, offset); err != nil {
+			return nil, err
+		}
+		return io.ReadAll(io.LimitReader(res.Body, size))
+	default:
+		return nil, gerror.Newf("下载固件文件失败：%s", res.Status)
+	}
+}
+
+// firmwareSign 按签名方法计算固件文件的签名
+func firmwareSign(r io.Reader, method string) (string, error) {
+	var h hash.Hash
+	switch strings.ToUpper(method) {
+	case consts.FirmwareSignMethodMd5:
+		h = md5.New()
+	case consts.FirmwareSignMethodSha256:
+		h = sha256.New()
+	default:
+		return "", gerror.Newf("未知的签名方法：%s", method)
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,153 @@
+package product
+
+import (
+	"context"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/do"
+	"sagooiot/internal/model/entity"
+	"sagooiot/pkg/dcache"
+	"sagooiot/pkg/iotModel/sagooProtocol"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// unfinishedDeviceStatus 未结束的设备升级状态
+var unfinishedDeviceStatus = g.Slice{
+	consts.FirmwareDeviceStatusWait,
+	consts.FirmwareDeviceStatusPushed,
+	consts.FirmwareDeviceStatusUpgrading,
+}
+
+// progressFailMessage 设备上报的失败进度
+var progressFailMessage = map[int]string{
+	-1: "升级失败",
+	-2: "下载失败",
+	-3: "校验失败",
+	-4: "烧写失败",
+}
+
+// Inform 设备上报版本，更新设备版本号并确认升级结果
+func (s *sDevFirmware) Inform(ctx context.Context, in *model.FirmwareInformInput) (err error) {
+	if in.Version == "" {
+		return
+	}
+	if in.Module == "" {
+		in.Module = consts.FirmwareModuleDefault
+	}
+	// 设备只记录默认模块的版本号
+	if in.Module == consts.FirmwareModuleDefault {
+		_, err = dao.DevDevice.Ctx(ctx).
+			Data(do.DevDevice{Version: in.Version}).
+			Where(dao.DevDevice.Columns().Key, in.DeviceKey).
+			Update()
+		if err != nil {
+			return
+		}
+		if device, _ := dcache.GetDeviceDetailInfo(in.DeviceKey); device != nil && device.DevDevice != nil {
+			device.Version = in.Version
+			_ = dcache.SetDeviceDetailInfo(in.DeviceKey, device)
+		}
+	}
+
+	current, err := s.current(ctx, in.DeviceKey, in.Module)
+	if err != nil || current == nil || current.DestVersion != in.Version {
+		return
+	}
+	_, err = dao.DevFirmwareDevice.Ctx(ctx).Data(do.DevFirmwareDevice{
+		Progress: 100,
+		Status:   consts.FirmwareDeviceStatusSuccess,
+		Message:  "",
+	}).Where(dao.DevFirmwareDevice.Columns().Id, current.Id).Update()
+	if err != nil {
+		return
+	}
+	s.refreshTask(ctx, current.TaskId)
+	return
+}
+
+// Progress 设备上报升级进度
+func (s *sDevFirmware) Progress(ctx context.Context, in *model.FirmwareProgressInput) (err error) {
+	if in.Module == "" {
+		in.Module = consts.FirmwareModuleDefault
+	}
+	current, err := s.current(ctx, in.DeviceKey, in.Module)
+	if err != nil || current == nil {
+		return
+	}
+	status, progress, message := progressStatus(in.Step, in.Desc)
+	_, err = dao.DevFirmwareDevice.Ctx(ctx).Data(do.DevFirmwareDevice{
+		Progress: progress,
+		Status:   status,
+		Message:  message,
+	}).Where(dao.DevFirmwareDevice.Columns().Id, current.Id).Update()
+	if err != nil {
+		return
+	}
+	if status == consts.FirmwareDeviceStatusFail {
+		s.refreshTask(ctx, current.TaskId)
+	}
+	return
+}
+
+// progressStatus 根据设备上报的进度计算升级状态，负数为失败，升级成功以设备上报新版本为准
+func progressStatus(step int, desc string) (status, progress int, message string) {
+	if step < 0 {
+		message = desc
+		if message == "" {
+			message = progressFailMessage[step]
+		}
+		if message == "" {
+			message = progressFailMessage[-1]
+		}
+		return consts.FirmwareDeviceStatusFail, 0, message
+	}
+	if step > 100 {
+		step = 100
+	}
+	return consts.FirmwareDeviceStatusUpgrading, step, desc
+}
+
+// Pending 获取设备待升级的升级包信息，没有时返回nil
+func (s *sDevFirmware) Pending(ctx context.Context, deviceKey string, module string) (out *sagooProtocol.UpgradeCommandRequestData, err error) {
+	if module == "" {
+		module = consts.FirmwareModuleDefault
+	}
+	current, err := s.current(ctx, deviceKey, module)
+	if err != nil || current == nil {
+		return
+	}
+	var firmware *entity.DevFirmware
+	if err = dao.DevFirmware.Ctx(ctx).Where(dao.DevFirmware.Columns().Id, current.FirmwareId).Scan(&firmware); err != nil || firmware == nil {
+		return
+	}
+	if current.Status == consts.FirmwareDeviceStatusWait {
+		c := dao.DevFirmwareDevice.Columns()
+		_, err = dao.DevFirmwareDevice.Ctx(ctx).Data(do.DevFirmwareDevice{
+			Status:   consts.FirmwareDeviceStatusPushed,
+			PushedAt: gtime.Now(),
+		}).Where(c.Id, current.Id).Where(c.Status, consts.FirmwareDeviceStatusWait).Update()
+		if err != nil {
+			return
+		}
+	}
+	data := upgradeData(firmware)
+	return &data, nil
+}
+
+// current 设备模块在执行中任务里未结束的升级记录
+func (s *sDevFirmware) current(ctx context.Context, deviceKey, module string) (out *entity.DevFirmwareDevice, err error) {
+	c := dao.DevFirmwareDevice.Columns()
+	tc := dao.DevFirmwareTask.Columns()
+	err = dao.DevFirmwareDevice.Ctx(ctx).
+		Where(c.DeviceKey, deviceKey).
+		Where(c.Module, module).
+		WhereIn(c.Status, unfinishedDeviceStatus).
+		WhereIn(c.TaskId, dao.DevFirmwareTask.Ctx(ctx).Fields(tc.Id).Where(tc.Status, consts.FirmwareTaskStatusRunning)).
+		OrderDesc(c.Id).
+		Limit(1).
+		Scan(&out)
+	return
+}
@@ -0,0 +1,470 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/do"
+	"sagooiot/internal/model/entity"
+	"sagooiot/internal/service"
+	dota "sagooiot/network/core/logic/model/down/ota"
+	"sagooiot/pkg/dcache"
+	"sagooiot/pkg/iotModel/sagooProtocol"
+	"sagooiot/pkg/iotModel/topicModel"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// rollouts 正在推送中的升级任务，避免同一任务重复推送
+var rollouts sync.Map
+
+const (
+	rolloutLockTTL = 30 // 推送锁的租约时间，单位秒
+	// rolloutRenewScript 推送锁续约
+	rolloutRenewScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then return redis.call('EXPIRE', KEYS[1], ARGV[2]) end
+return 0`
+	// rolloutReleaseScript 推送锁释放
+	rolloutReleaseScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then return redis.call('DEL', KEYS[1]) end
+return 0`
+)
+
+var (
+	rolloutRedisOnce sync.Once
+	rolloutRedis     bool
+)
+
+func useRolloutRedis(ctx context.Context) bool {
+	rolloutRedisOnce.Do(func() {
+		rolloutRedis = g.Cfg().MustGet(ctx, "cache.adapter").String() == "redis"
+	})
+	return rolloutRedis
+}
+
+// lockRollout 获取升级任务的推送锁，不使用redis时只有一个实例，直接获取成功。
+// 获取成功后定时续约，续约失败时取消 ctx 停止推送，返回的 unlock 释放推送锁
+func lockRollout(ctx context.Context, id uint64) (lockCtx context.Context, unlock func(), ok bool) {
+	if !useRolloutRedis(ctx) {
+		return ctx, func() {}, true
+	}
+	key := consts.FirmwareRolloutLockPrefix + gconv.String(id)
+	token := guid.S()
+	v, err := g.Redis().Do(ctx, "SET", key, token, "NX", "EX", rolloutLockTTL)
+	if err != nil || v.String() != "OK" {
+		return ctx, nil, false
+	}
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(rolloutLockTTL * time.Second / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-lockCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			v, err := g.Redis().Do(lockCtx, "EVAL", rolloutRenewScript, 1, key, token, rolloutLockTTL)
+			if err != nil || v.Int() != 1 {
+				g.Log().Errorf(lockCtx, "firmware task %d lock renew failed: %v", id, err)
+				cancel()
+				return
+			}
+		}
+	}()
+	unlock = func() {
+		cancel()
+		_, _ = g.Redis().Do(ctx, "EVAL", rolloutReleaseScript, 1, key, token)
+	}
+	return lockCtx, unlock, true
+}
+
+// AddTask 创建升级任务，按升级范围生成设备升级记录
+func (s *sDevFirmware) AddTask(ctx context.Context, in *model.FirmwareTaskAddInput) (id uint64, err error) {
+	firmware, err := s.Detail(ctx, in.FirmwareId)
+	if err != nil {
+		return
+	}
+	if firmware == nil {
+		return 0, gerror.New("固件不存在")
+	}
+	devices, err := s.targetDevices(ctx, firmware.ProductKey, in)
+	if err != nil {
+		return
+	}
+	if len(devices) == 0 {
+		return 0, gerror.New("升级范围内没有设备")
+	}
+	target, err := json.Marshal(in.FirmwareTaskTarget)
+	if err != nil {
+		return
+	}
+
+	err = dao.DevFirmwareTask.Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		newId, err := dao.DevFirmwareTask.Ctx(ctx).Data(do.DevFirmwareTask{
+			DeptId:        service.Context().GetUserDeptId(ctx),
+			Name:          in.Name,
+			FirmwareId:    firmware.Id,
+			ProductKey:    firmware.ProductKey,
+			TargetType:    in.TargetType,
+			Target:        string(target),
+			BatchSize:     in.BatchSize,
+			BatchInterval: in.BatchInterval,
+			Status:        consts.FirmwareTaskStatusWait,
+			Total:         len(devices),
+			CreatedBy:     uint(service.Context().GetUserId(ctx)),
+			CreatedAt:     gtime.Now(),
+		}).InsertAndGetId()
+		if err != nil {
+			return err
+		}
+		id = uint64(newId)
+
+		list := make([]do.DevFirmwareDevice, 0, len(devices))
+		for _, d := range devices {
+			list = append(list, do.DevFirmwareDevice{
+				TaskId:      id,
+				FirmwareId:  firmware.Id,
+				ProductKey:  firmware.ProductKey,
+				DeviceKey:   d.Key,
+				Module:      firmware.Module,
+				SrcVersion:  d.Version,
+				DestVersion: firmware.Version,
+				Status:      consts.FirmwareDeviceStatusWait,
+				CreatedAt:   gtime.Now(),
+			})
+		}
+		_, err = dao.DevFirmwareDevice.Ctx(ctx).Data(list).Batch(500).Insert()
+		return err
+	})
+	return
+}
+
+// targetDevices 按升级范围获取产品下的设备
+func (s *sDevFirmware) targetDevices(ctx context.Context, productKey string, in *model.FirmwareTaskAddInput) (list []*entity.DevDevice, err error) {
+	c := dao.DevDevice.Columns()
+	m := dao.DevDevice.Ctx(ctx).Fields(c.Key, c.Version).Where(c.ProductKey, productKey)
+	switch in.TargetType {
+	case consts.FirmwareTargetProduct:
+	case consts.FirmwareTargetDevice:
+		if len(in.DeviceKeys) == 0 {
+			return nil, gerror.New("请选择升级的设备")
+		}
+		m = m.WhereIn(c.Key, in.DeviceKeys)
+	case consts.FirmwareTargetTag:
+		if in.TagKey == "" {
+			return nil, gerror.New("请选择设备标签")
+		}
+		tc := dao.DevDeviceTag.Columns()
+		tm := dao.DevDeviceTag.Ctx(ctx).Fields(tc.DeviceKey).Where(tc.Key, in.TagKey)
+		if in.TagValue != "" {
+			tm = tm.Where(tc.Value, in.TagValue)
+		}
+		keys, err := tm.Array()
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) == 0 {
+			return nil, nil
+		}
+		m = m.WhereIn(c.Key, gconv.Strings(keys))
+	default:
+		return nil, gerror.Newf("未知的升级范围：%s", in.TargetType)
+	}
+	err = m.OrderAsc(c.Id).Scan(&list)
+	return
+}
+
+func (s *sDevFirmware) TaskDetail(ctx context.Context, id uint64) (out *model.FirmwareTaskOutput, err error) {
+	if err = dao.DevFirmwareTask.Ctx(ctx).Where(dao.DevFirmwareTask.Columns().Id, id).Scan(&out); err != nil || out == nil {
+		return
+	}
+	err = s.fillTask(ctx, out)
+	return
+}
+
+func (s *sDevFirmware) TaskList(ctx context.Context, in *model.FirmwareTaskListInput) (out *model.FirmwareTaskListOutput, err error) {
+	out = new(model.FirmwareTaskListOutput)
+	c := dao.DevFirmwareTask.Columns()
+	m := dao.DevFirmwareTask.Ctx(ctx).OrderDesc(c.Id)
+	if in.FirmwareId > 0 {
+		m = m.Where(c.FirmwareId, in.FirmwareId)
+	}
+	if in.ProductKey != "" {
+		m = m.Where(c.ProductKey, in.ProductKey)
+	}
+	if in.Status != "" {
+		m = m.Where(c.Status, gconv.Int(in.Status))
+	}
+	if in.KeyWord != "" {
+		m = m.WhereLike(c.Name, "%"+in.KeyWord+"%")
+	}
+
+	out.Total, _ = m.Count()
+	out.CurrentPage = in.PageNum
+	if err = m.Page(in.PageNum, in.PageSize).Scan(&out.List); err != nil {
+		return
+	}
+	for i := range out.List {
+		if err = s.fillTask(ctx, &out.List[i]); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (s *sDevFirmware) fillTask(ctx context.Context, out *model.FirmwareTaskOutput) (err error) {
+	out.TargetTypeName = model.FirmwareTargetType[out.TargetType]
+	if out.Target != "" {
+		if err = json.Unmarshal([]byte(out.Target), &out.TargetInfo); err != nil {
+			return
+		}
+	}
+	err = dao.DevFirmware.Ctx(ctx).Where(dao.DevFirmware.Columns().Id, out.FirmwareId).Scan(&out.Firmware)
+	return
+}
+
+// StartTask 启动升级任务，按批次推送升级包
+func (s *sDevFirmware) StartTask(ctx context.Context, id uint64) (err error) {
+	c := dao.DevFirmwareTask.Columns()
+	res, err := dao.DevFirmwareTask.Ctx(ctx).
+		Data(do.DevFirmwareTask{Status: consts.FirmwareTaskStatusRunning}).
+		Where(c.Id, id).
+		Where(c.Status, consts.FirmwareTaskStatusWait).
+		Update()
+	if err != nil {
+		return
+	}
+	if num, _ := res.RowsAffected(); num == 0 {
+		return gerror.New("升级任务不存在或已启动")
+	}
+	go s.rollout(gctx.NeverDone(ctx), id)
+	return
+}
+
+// CancelTask 取消升级任务，未完成的设备升级记录标记为已取消
+func (s *sDevFirmware) CancelTask(ctx context.Context, id uint64) (err error) {
+	return dao.DevFirmwareTask.Transaction(ctx, func(ctx context.Context, tx gdb.TX) error {
+		c := dao.DevFirmwareTask.Columns()
+		res, err := dao.DevFirmwareTask.Ctx(ctx).
+			Data(do.DevFirmwareTask{Status: consts.FirmwareTaskStatusCancel}).
+			Where(c.Id, id).
+			WhereIn(c.Status, g.Slice{consts.FirmwareTaskStatusWait, consts.FirmwareTaskStatusRunning}).
+			Update()
+		if err != nil {
+			return err
+		}
+		if num, _ := res.RowsAffected(); num == 0 {
+			return gerror.New("升级任务不存在或已结束")
+		}
+		dc := dao.DevFirmwareDevice.Columns()
+		_, err = dao.DevFirmwareDevice.Ctx(ctx).
+			Data(do.DevFirmwareDevice{Status: consts.FirmwareDeviceStatusCancel}).
+			Where(dc.TaskId, id).
+			WhereIn(dc.Status, unfinishedDeviceStatus).
+			Update()
+		return err
+	})
+}
+
+func (s *sDevFirmware) DeviceList(ctx context.Context, in *model.FirmwareDeviceListInput) (out *model.FirmwareDeviceListOutput, err error) {
+	out = new(model.FirmwareDeviceListOutput)
+	c := dao.DevFirmwareDevice.Columns()
+	m := dao.DevFirmwareDevice.Ctx(ctx).OrderAsc(c.Id)
+	if in.TaskId > 0 {
+		m = m.Where(c.TaskId, in.TaskId)
+	}
+	if in.DeviceKey != "" {
+		m = m.Where(c.DeviceKey, in.DeviceKey)
+	}
+	if in.Status != "" {
+		m = m.Where(c.Status, gconv.Int(in.Status))
+	}
+
+	out.Total, _ = m.Count()
+	out.CurrentPage = in.PageNum
+	err = m.Page(in.PageNum, in.PageSize).Scan(&out.List)
+	return
+}
+
+// ResumeTasks 服务启动时恢复执行中的升级任务
+func (s *sDevFirmware) ResumeTasks(ctx context.Context) (err error) {
+	ids, err := dao.DevFirmwareTask.Ctx(ctx).
+		Fields(dao.DevFirmwareTask.Columns().Id).
+		Where(dao.DevFirmwareTask.Columns().Status, consts.FirmwareTaskStatusRunning).
+		Array()
+	if err != nil {
+		return
+	}
+	for _, id := range ids {
+		go s.rollout(gctx.NeverDone(ctx), id.Uint64())
+	}
+	return
+}
+
+// rollout 按批次向待推送的设备推送升级包，离线设备保持待推送，上线后可主动请求升级包
+func (s *sDevFirmware) rollout(ctx context.Context, id uint64) {
+	if _, ok := rollouts.LoadOrStore(id, struct{}{}); ok {
+		return
+	}
+	defer rollouts.Delete(id)
+	// 多个实例时只由获取到推送锁的实例推送
+	ctx, unlock, ok := lockRollout(ctx, id)
+	if !ok {
+		return
+	}
+	defer unlock()
+
+	var cursor uint64
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		var task *entity.DevFirmwareTask
+		if err := dao.DevFirmwareTask.Ctx(ctx).Where(dao.DevFirmwareTask.Columns().Id, id).Scan(&task); err != nil {
+			g.Log().Errorf(ctx, "firmware task %d load error: %v", id, err)
+			return
+		}
+		if task == nil || task.Status != consts.FirmwareTaskStatusRunning {
+			return
+		}
+		var firmware *entity.DevFirmware
+		if err := dao.DevFirmware.Ctx(ctx).Where(dao.DevFirmware.Columns().Id, task.FirmwareId).Scan(&firmware); err != nil || firmware == nil {
+			g.Log().Errorf(ctx, "firmware task %d load firmware %d error: %v", id, task.FirmwareId, err)
+			return
+		}
+
+		c := dao.DevFirmwareDevice.Columns()
+		m := dao.DevFirmwareDevice.Ctx(ctx).
+			Where(c.TaskId, id).
+			Where(c.Status, consts.FirmwareDeviceStatusWait).
+			WhereGT(c.Id, cursor).
+			OrderAsc(c.Id)
+		if task.BatchSize > 0 {
+			m = m.Limit(task.BatchSize)
+		}
+		var list []*entity.DevFirmwareDevice
+		if err := m.Scan(&list); err != nil {
+			g.Log().Errorf(ctx, "firmware task %d load devices error: %v", id, err)
+			return
+		}
+		if len(list) == 0 {
+			s.refreshTask(ctx, id)
+			return
+		}
+		for _, d := range list {
+			cursor = d.Id
+			if err := s.push(ctx, firmware, d); err != nil {
+				g.Log().Debugf(ctx, "firmware task %d push to %s error: %v", id, d.DeviceKey, err)
+			}
+		}
+		if task.BatchSize == 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(task.BatchInterval) * time.Second):
+		}
+	}
+}
+
+// push 向在线设备推送升级包
+func (s *sDevFirmware) push(ctx context.Context, firmware *entity.DevFirmware, d *entity.DevFirmwareDevice) (err error) {
+	if dcache.GetDeviceStatus(ctx, d.DeviceKey) != model.DeviceStatusOn {
+		return
+	}
+	device, err := dcache.GetDeviceDetailInfo(d.DeviceKey)
+	if err != nil {
+		return
+	}
+	if device == nil {
+		return gerror.New("设备不存在")
+	}
+
+	c := dao.DevFirmwareDevice.Columns()
+	if err = dota.UpgradeCommand(ctx, topicModel.TopicDownHandlerData{DeviceDetail: device}, upgradeData(firmware)); err != nil {
+		_, _ = dao.DevFirmwareDevice.Ctx(ctx).Data(do.DevFirmwareDevice{Message: err.Error()}).Where(c.Id, d.Id).Update()
+		return
+	}
+	_, err = dao.DevFirmwareDevice.Ctx(ctx).Data(do.DevFirmwareDevice{
+		Status:   consts.FirmwareDeviceStatusPushed,
+		Message:  "",
+		PushedAt: gtime.Now(),
+	}).Where(c.Id, d.Id).Where(c.Status, consts.FirmwareDeviceStatusWait).Update()
+	return
+}
+
+// refreshTask 统计升级结果，所有设备升级结束后完成任务
+func (s *sDevFirmware) refreshTask(ctx context.Context, id uint64) {
+	c := dao.DevFirmwareDevice.Columns()
+	var counts []struct {
+		Status int
+		Num    int
+	}
+	err := dao.DevFirmwareDevice.Ctx(ctx).
+		Fields(c.Status, "COUNT(1) AS num").
+		Where(c.TaskId, id).
+		Group(c.Status).
+		Scan(&counts)
+	if err != nil {
+		g.Log().Errorf(ctx, "firmware task %d count error: %v", id, err)
+		return
+	}
+	data := do.DevFirmwareTask{Success: 0, Failed: 0}
+	var unfinished int
+	for _, v := range counts {
+		switch v.Status {
+		case consts.FirmwareDeviceStatusSuccess:
+			data.Success = v.Num
+		case consts.FirmwareDeviceStatusFail:
+			data.Failed = v.Num
+		case consts.FirmwareDeviceStatusWait, consts.FirmwareDeviceStatusPushed, consts.FirmwareDeviceStatusUpgrading:
+			unfinished += v.Num
+		}
+	}
+	tc := dao.DevFirmwareTask.Columns()
+	if _, err = dao.DevFirmwareTask.Ctx(ctx).Data(data).Where(tc.Id, id).Update(); err != nil {
+		g.Log().Errorf(ctx, "firmware task %d update error: %v", id, err)
+		return
+	}
+	if unfinished == 0 {
+		_, err = dao.DevFirmwareTask.Ctx(ctx).
+			Data(do.DevFirmwareTask{Status: consts.FirmwareTaskStatusDone}).
+			Where(tc.Id, id).
+			Where(tc.Status, consts.FirmwareTaskStatusRunning).
+			Update()
+		if err != nil {
+			g.Log().Errorf(ctx, "firmware task %d update error: %v", id, err)
+		}
+	}
+}
+
+// upgradeData 固件对应的升级包信息
+func upgradeData(firmware *entity.DevFirmware) sagooProtocol.UpgradeCommandRequestData {
+	data := sagooProtocol.UpgradeCommandRequestData{
+		Size:       int(firmware.Size),
+		Version:    firmware.Version,
+		SignMethod: firmware.SignMethod,
+		Url:        firmware.FileUrl,
+		Sign:       firmware.Sign,
+		Module:     firmware.Module,
+		ExtData: map[string]string{
+			"firmwareId": gconv.String(firmware.Id),
+		},
+	}
+	if u, err := url.Parse(firmware.FileUrl); err == nil {
+		data.DProtocol = u.Scheme
+	}
+	return data
+}
@@ -0,0 +1,65 @@
+package product
+
+import (
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model/entity"
+	"strings"
+	"testing"
+)
+
+func TestFirmwareSign(t *testing.T) {
+	cases := map[string]string{
+		consts.FirmwareSignMethodMd5:    "5eb63bbbe01eeed093cb22bb8f5acdc3",
+		consts.FirmwareSignMethodSha256: "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		"sha256":                        "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+	}
+	for method, want := range cases {
+		got, err := firmwareSign(strings.NewReader("hello world"), method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("%s sign got %s, want %s", method, got, want)
+		}
+	}
+	if _, err := firmwareSign(strings.NewReader("hello world"), "CRC32"); err == nil {
+		t.Fatal("unknown sign method should fail")
+	}
+}
+
+func TestProgressStatus(t *testing.T) {
+	cases := []struct {
+		step     int
+		desc     string
+		status   int
+		progress int
+		message  string
+	}{
+		{30, "下载中", consts.FirmwareDeviceStatusUpgrading, 30, "下载中"},
+		{120, "", consts.FirmwareDeviceStatusUpgrading, 100, ""},
+		{-2, "", consts.FirmwareDeviceStatusFail, 0, "下载失败"},
+		{-3, "签名不一致", consts.FirmwareDeviceStatusFail, 0, "签名不一致"},
+		{-9, "", consts.FirmwareDeviceStatusFail, 0, "升级失败"},
+	}
+	for _, c := range cases {
+		status, progress, message := progressStatus(c.step, c.desc)
+		if status != c.status || progress != c.progress || message != c.message {
+			t.Fatalf("step %d got %d %d %s", c.step, status, progress, message)
+		}
+	}
+}
+
+func TestUpgradeData(t *testing.T) {
+	data := upgradeData(&entity.DevFirmware{
+		Id:         7,
+		Version:    "1.0.1",
+		Module:     consts.FirmwareModuleDefault,
+		FileUrl:    "https://example.com/upload_file/fw.bin",
+		Size:       1024,
+		Sign:       "5eb63bbbe01eeed093cb22bb8f5acdc3",
+		SignMethod: consts.FirmwareSignMethodMd5,
+	})
+	if data.DProtocol != "https" || data.Size != 1024 || data.ExtData["firmwareId"] != "7" {
+		t.Fatalf("upgrade data got %+v", data)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"sagooiot/internal/model/entity"
 	"sagooiot/internal/service"
 	"sagooiot/pkg/cache"
+	"sagooiot/pkg/jsinterpreter"
 	"sagooiot/pkg/tsd/comm"
 	"time"
 
@@ -203,6 +204,7 @@ func (s *sDevProduct) Add(ctx context.Context, in *model.AddProductInput) (err e
 		AccessToken:       in.AccessToken,
 		CertificateId:     in.CertificateId,
 		ScriptInfo:        in.ScriptInfo,
+		Policy:            in.Policy,
 		CreatedBy:         uint(loginUserId),
 		CreatedAt:         gtime.Now(),
 	}).Insert()
@@ -474,8 +476,39 @@ func (s *sDevProduct) UpdateScriptInfo(ctx context.Context, in *model.ScriptInfo
 	devProduct.UpdatedBy = uint(loginUserId)
 	devProduct.UpdatedAt = gtime.Now()
 	_, err = dao.DevProduct.Ctx(ctx).Data(devProduct).Where(dao.DevProduct.Columns().Key, in.Key).Update()
+	if err != nil {
+		return
+	}
 	//从缓存中删除
 	_, err = cache.Instance().Remove(ctx, consts.CacheGfOrmPrefix+consts.GetDetailProductOutput+devProduct.Key)
+	//删除编译后的脚本
+	jsinterpreter.Invalidate(devProduct.Key)
+	return
+}
+
+// DebugScript 脚本调试，使用样例数据执行脚本的解析或编码函数
+func (s *sDevProduct) DebugScript(ctx context.Context, in *model.ScriptDebugInput) (out *model.ScriptDebugOutput, err error) {
+	scriptInfo := in.ScriptInfo
+	if scriptInfo == "" && in.Key != "" {
+		var devProduct *entity.DevProduct
+		if err = dao.DevProduct.Ctx(ctx).Where(dao.DevProduct.Columns().Key, in.Key).Scan(&devProduct); err != nil {
+			return
+		}
+		if devProduct == nil {
+			return nil, gerror.New("产品不存在")
+		}
+		scriptInfo = devProduct.ScriptInfo
+	}
+	if scriptInfo == "" {
+		return nil, gerror.New("脚本不能为空")
+	}
+	res := jsinterpreter.Debug(scriptInfo, in.FuncName, in.Payload)
+	out = &model.ScriptDebugOutput{
+		Output:   res.Output,
+		Logs:     res.Logs,
+		Error:    res.Error,
+		Duration: res.Duration,
+	}
 	return
 }
 
@@ -0,0 +1,333 @@
+package scene
+
+import (
+	"context"
+	"encoding/json"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/do"
+	"sagooiot/internal/model/entity"
+	"sagooiot/internal/service"
+	"sagooiot/pkg/dcache"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+type sScene struct{}
+
+func init() {
+	service.RegisterScene(sceneNew())
+}
+
+func sceneNew() *sScene {
+	return &sScene{}
+}
+
+func (s *sScene) List(ctx context.Context, in *model.SceneListInput) (out *model.SceneListOutput, err error) {
+	out = new(model.SceneListOutput)
+	c := dao.SceneDefinition.Columns()
+	m := dao.SceneDefinition.Ctx(ctx).OrderDesc(c.Id)
+
+	if in.KeyWord != "" {
+		m = m.WhereLike(c.Name, "%"+in.KeyWord+"%")
+	}
+	if in.SceneType != "" {
+		m = m.Where(c.SceneType, in.SceneType)
+	}
+	if in.Status != "" {
+		m = m.Where(c.Status, in.Status)
+	}
+	if len(in.DateRange) > 0 {
+		m = m.WhereBetween(c.CreatedAt, in.DateRange[0], in.DateRange[1])
+	}
+
+	out.Total, _ = m.Count()
+	out.CurrentPage = in.PageNum
+	err = m.Page(in.PageNum, in.PageSize).Scan(&out.List)
+	if err != nil {
+		return
+	}
+	for i := range out.List {
+		if err = s.fill(&out.List[i]); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// fill 填充名称，解析触发条件和执行动作
+func (s *sScene) fill(out *model.SceneOutput) (err error) {
+	out.SceneTypeName = model.SceneType[out.SceneType]
+	out.TriggerTypeName = model.SceneTriggerType[out.TriggerType]
+	if out.TriggerCondition != "" {
+		if err = json.Unmarshal([]byte(out.TriggerCondition), &out.Condition); err != nil {
+			return
+		}
+	}
+	if out.Action != "" {
+		err = json.Unmarshal([]byte(out.Action), &out.PerformAction)
+	}
+	return
+}
+
+// Detail 获取场景详情
+func (s *sScene) Detail(ctx context.Context, id uint64) (out *model.SceneOutput, err error) {
+	err = dao.SceneDefinition.Ctx(ctx).Where(dao.SceneDefinition.Columns().Id, id).Scan(&out)
+	if err != nil || out == nil {
+		return
+	}
+	err = s.fill(out)
+	return
+}
+
+// check 校验场景配置
+func (s *sScene) check(ctx context.Context, in *model.SceneAddInput) (err error) {
+	if in.SceneType == consts.SceneTypeDevice {
+		if _, ok := model.SceneTriggerType[in.TriggerType]; !ok {
+			return gerror.New("未知的触发类型")
+		}
+	}
+	for _, a := range in.Action {
+		if _, ok := model.SceneActionType[a.ActionType]; !ok {
+			return gerror.Newf("未知的动作类型：%s", a.ActionType)
+		}
+		if err = checkAction(a); err != nil {
+			return
+		}
+		if a.ActionType == consts.SceneActionTypeDeviceOutput && a.DeviceOutput.DeviceKey != "" {
+			if err = service.DevDevice().CheckDataScope(ctx, a.DeviceOutput.DeviceKey); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// data 转换为数据表字段
+func (s *sScene) data(in *model.SceneAddInput) (param *do.SceneDefinition, err error) {
+	param = &do.SceneDefinition{
+		Name:           in.Name,
+		SceneType:      in.SceneType,
+		ProductKey:     in.ProductKey,
+		DeviceKey:      in.DeviceKey,
+		TriggerType:    "",
+		EventKey:       "",
+		CronExpression: "",
+		Remark:         in.Remark,
+	}
+	switch in.SceneType {
+	case consts.SceneTypeDevice:
+		param.TriggerType = in.TriggerType
+		if in.TriggerType == consts.SceneTriggerReportEvent {
+			param.EventKey = in.EventKey
+		}
+	case consts.SceneTypeTimer:
+		param.CronExpression = in.CronExpression
+	}
+	if param.TriggerCondition, err = json.Marshal(in.SceneTriggerCondition); err != nil {
+		return
+	}
+	param.Action, err = json.Marshal(in.ScenePerformAction)
+	return
+}
+
+func (s *sScene) Add(ctx context.Context, in *model.SceneAddInput) (err error) {
+	if err = s.check(ctx, in); err != nil {
+		return
+	}
+	param, err := s.data(in)
+	if err != nil {
+		return
+	}
+	param.DeptId = service.Context().GetUserDeptId(ctx)
+	param.Status = consts.SceneStatusOff
+	param.CreatedBy = uint(service.Context().GetUserId(ctx))
+
+	_, err = dao.SceneDefinition.Ctx(ctx).Data(param).Insert()
+	return
+}
+
+func (s *sScene) Edit(ctx context.Context, in *model.SceneEditInput) (err error) {
+	p, err := s.Detail(ctx, in.Id)
+	if err != nil {
+		return
+	}
+	if p == nil {
+		return gerror.New("场景不存在")
+	}
+	if p.Status == consts.SceneStatusOn {
+		return gerror.New("场景已启用，请先禁用，再编辑")
+	}
+	if err = s.check(ctx, &in.SceneAddInput); err != nil {
+		return
+	}
+	param, err := s.data(&in.SceneAddInput)
+	if err != nil {
+		return
+	}
+	param.UpdatedBy = uint(service.Context().GetUserId(ctx))
+
+	_, err = dao.SceneDefinition.Ctx(ctx).Data(param).Where(dao.SceneDefinition.Columns().Id, in.Id).Update()
+	return
+}
+
+// Deploy 启用场景
+func (s *sScene) Deploy(ctx context.Context, id uint64) (err error) {
+	p, err := s.Detail(ctx, id)
+	if err != nil {
+		return
+	}
+	if p == nil || p.Status == consts.SceneStatusOn {
+		return gerror.New("场景不存在，或已启用")
+	}
+
+	if p.SceneType == consts.SceneTypeTimer {
+		if err = s.start(ctx, *p); err != nil {
+			return
+		}
+	}
+
+	_, err = dao.SceneDefinition.Ctx(ctx).
+		Data(g.Map{dao.SceneDefinition.Columns().Status: consts.SceneStatusOn}).
+		Where(dao.SceneDefinition.Columns().Id, id).
+		Update()
+	if err != nil {
+		return
+	}
+
+	if p.SceneType == consts.SceneTypeDevice {
+		err = s.cacheProductScene(ctx, p.ProductKey)
+	}
+	return
+}
+
+// Undeploy 禁用场景
+func (s *sScene) Undeploy(ctx context.Context, id uint64) (err error) {
+	var p *entity.SceneDefinition
+	err = dao.SceneDefinition.Ctx(ctx).Where(dao.SceneDefinition.Columns().Id, id).Scan(&p)
+	if err != nil {
+		return
+	}
+	if p == nil || p.Status == consts.SceneStatusOff {
+		return gerror.New("场景不存在，或已禁用")
+	}
+
+	if p.SceneType == consts.SceneTypeTimer {
+		s.stop(id)
+	}
+
+	_, err = dao.SceneDefinition.Ctx(ctx).
+		Data(g.Map{dao.SceneDefinition.Columns().Status: consts.SceneStatusOff}).
+		Where(dao.SceneDefinition.Columns().Id, id).
+		Update()
+	if err != nil {
+		return
+	}
+
+	if p.SceneType == consts.SceneTypeDevice {
+		err = s.cacheProductScene(ctx, p.ProductKey)
+	}
+	return
+}
+
+func (s *sScene) Del(ctx context.Context, id uint64) (err error) {
+	var p *entity.SceneDefinition
+	err = dao.SceneDefinition.Ctx(ctx).Where(dao.SceneDefinition.Columns().Id, id).Scan(&p)
+	if err != nil {
+		return
+	}
+	if p == nil {
+		return gerror.New("场景不存在")
+	}
+	if p.Status == consts.SceneStatusOn {
+		return gerror.New("场景已启用，请先禁用，再删除")
+	}
+
+	_, err = dao.SceneDefinition.Ctx(ctx).
+		Data(do.SceneDefinition{
+			DeletedBy: uint(service.Context().GetUserId(ctx)),
+			DeletedAt: gtime.Now(),
+		}).
+		Where(dao.SceneDefinition.Columns().Id, id).
+		Where(dao.SceneDefinition.Columns().Status, consts.SceneStatusOff).
+		Unscoped().
+		Update()
+	return
+}
+
+// Run 手动执行场景
+func (s *sScene) Run(ctx context.Context, id uint64) (err error) {
+	p, err := s.Detail(ctx, id)
+	if err != nil {
+		return
+	}
+	if p == nil {
+		return gerror.New("场景不存在")
+	}
+	if p.SceneType != consts.SceneTypeManual {
+		return gerror.New("只有手动触发的场景可以手动执行")
+	}
+	if p.Status != consts.SceneStatusOn {
+		return gerror.New("场景未启用")
+	}
+
+	go s.execute(context.WithoutCancel(ctx), *p, consts.SceneTypeManual, p.ProductKey, p.DeviceKey, nil, nil)
+	return
+}
+
+// LoadScene 缓存设备触发的场景，并启动已启用的定时场景
+func (s *sScene) LoadScene(ctx context.Context) (err error) {
+	var list []model.SceneOutput
+	err = dao.SceneDefinition.Ctx(ctx).
+		Where(dao.SceneDefinition.Columns().Status, consts.SceneStatusOn).
+		WhereIn(dao.SceneDefinition.Columns().SceneType, g.Slice{consts.SceneTypeDevice, consts.SceneTypeTimer}).
+		Scan(&list)
+	if err != nil {
+		return
+	}
+
+	rs := make(map[string][]model.SceneOutput)
+	for _, v := range list {
+		if err = s.fill(&v); err != nil {
+			g.Log().Errorf(ctx, "场景配置解析 - %d：%s", v.Id, err)
+			continue
+		}
+		switch v.SceneType {
+		case consts.SceneTypeDevice:
+			rs[v.ProductKey] = append(rs[v.ProductKey], v)
+		case consts.SceneTypeTimer:
+			if err := s.start(ctx, v); err != nil {
+				g.Log().Errorf(ctx, "场景定时启动 - %d：%s", v.Id, err)
+			}
+		}
+	}
+	for k, v := range rs {
+		if err := dcache.SetDeviceScene(ctx, k, v); err != nil {
+			g.Log().Debug(ctx, "LoadScene Error：", err)
+		}
+	}
+	return nil
+}
+
+// cacheProductScene 缓存单个产品已启用的设备触发场景
+func (s *sScene) cacheProductScene(ctx context.Context, productKey string) (err error) {
+	var list []model.SceneOutput
+	err = dao.SceneDefinition.Ctx(ctx).
+		Where(dao.SceneDefinition.Columns().ProductKey, productKey).
+		Where(dao.SceneDefinition.Columns().SceneType, consts.SceneTypeDevice).
+		Where(dao.SceneDefinition.Columns().Status, consts.SceneStatusOn).
+		OrderDesc(dao.SceneDefinition.Columns().Id).
+		Scan(&list)
+	if err != nil {
+		return
+	}
+	for i := range list {
+		if err = s.fill(&list[i]); err != nil {
+			return
+		}
+	}
+	return dcache.SetDeviceScene(ctx, productKey, list)
+}
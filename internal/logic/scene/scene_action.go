@@ -0,0 +1,265 @@
+package scene
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/do"
+	"sagooiot/internal/service"
+	"sagooiot/pkg/condition"
+	"sagooiot/pkg/dcache"
+	"sagooiot/pkg/iotModel"
+	"sagooiot/pkg/iotModel/sagooProtocol/north"
+	"sagooiot/pkg/utility/utils"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// sceneRun 一次场景执行的上下文
+type sceneRun struct {
+	scene      model.SceneOutput
+	source     string
+	productKey string
+	deviceKey  string
+	param      any
+	data       map[string]any
+}
+
+// checkAction 校验动作配置
+func checkAction(a model.SceneAction) error {
+	switch a.ActionType {
+	case consts.SceneActionTypeDeviceOutput:
+		if a.DeviceOutput == nil {
+			return gerror.New("请设置设备输出")
+		}
+		if a.DeviceOutput.OutputType == consts.SceneDeviceOutputFunction && a.DeviceOutput.FuncKey == "" {
+			return gerror.New("请选择设备功能")
+		}
+		if a.DeviceOutput.OutputType != consts.SceneDeviceOutputFunction && a.DeviceOutput.OutputType != consts.SceneDeviceOutputProperty {
+			return gerror.New("未知的设备输出方式")
+		}
+	case consts.SceneActionTypeSendNotice:
+		if a.SendNotice == nil || a.SendNotice.NoticeTemplate == "" {
+			return gerror.New("请选择通知模板")
+		}
+	case consts.SceneActionTypeCallWebService:
+		if a.WebService == nil || a.WebService.Url == "" {
+			return gerror.New("请输入Web服务地址")
+		}
+	case consts.SceneActionTypeTriggerAlarm:
+		if a.TriggerAlarm == nil {
+			return gerror.New("请选择告警级别")
+		}
+	case consts.SceneActionTypeDelayExecution:
+		if a.Delay == nil || a.Delay.Seconds <= 0 {
+			return gerror.New("请设置延迟时间")
+		}
+	case consts.SceneActionTypeTriggerCustomEvent:
+		if a.CustomEvent == nil || a.CustomEvent.EventKey == "" {
+			return gerror.New("请输入事件标识")
+		}
+	}
+	return nil
+}
+
+// execute 按顺序执行场景动作，并记录执行日志
+func (s *sScene) execute(ctx context.Context, scene model.SceneOutput, source, productKey, deviceKey string, param any, data map[string]any) {
+	run := &sceneRun{
+		scene:      scene,
+		source:     source,
+		productKey: productKey,
+		deviceKey:  deviceKey,
+		param:      param,
+		data:       data,
+	}
+
+	status := consts.SceneLogStatusSuccess
+	results := make([]model.SceneActionResult, 0, len(scene.PerformAction.Action))
+	for _, a := range scene.PerformAction.Action {
+		rs := model.SceneActionResult{ActionType: a.ActionType, Success: true}
+		if err := run.do(ctx, a); err != nil {
+			g.Log().Errorf(ctx, "场景动作执行 - %s - %s：%s", scene.Name, a.ActionType, err)
+			rs.Success = false
+			rs.Message = err.Error()
+			status = consts.SceneLogStatusFail
+		}
+		results = append(results, rs)
+	}
+
+	logData, _ := json.Marshal(param)
+	result, _ := json.Marshal(results)
+	_, err := dao.SceneLog.Ctx(ctx).Data(do.SceneLog{
+		SceneId:     scene.Id,
+		SceneName:   scene.Name,
+		TriggerType: source,
+		ProductKey:  productKey,
+		DeviceKey:   deviceKey,
+		Data:        string(logData),
+		Result:      string(result),
+		Status:      status,
+		CreatedAt:   gtime.Now(),
+	}).Insert()
+	if err != nil {
+		g.Log().Errorf(ctx, "场景执行日志记录 - %s：%s", scene.Name, err)
+	}
+}
+
+// do 执行单个动作
+func (r *sceneRun) do(ctx context.Context, a model.SceneAction) error {
+	if err := checkAction(a); err != nil {
+		return err
+	}
+	switch a.ActionType {
+	case consts.SceneActionTypeDeviceOutput:
+		return r.deviceOutput(ctx, a.DeviceOutput)
+	case consts.SceneActionTypeSendNotice:
+		return r.sendNotice(ctx, a.SendNotice)
+	case consts.SceneActionTypeCallWebService:
+		return r.callWebService(ctx, a.WebService)
+	case consts.SceneActionTypeTriggerAlarm:
+		return r.triggerAlarm(ctx, a.TriggerAlarm)
+	case consts.SceneActionTypeDelayExecution:
+		select {
+		case <-time.After(time.Duration(a.Delay.Seconds) * time.Second):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case consts.SceneActionTypeTriggerCustomEvent:
+		north.WriteMessage(ctx, north.SceneEventMessageTopic, nil, r.productKey, r.deviceKey, iotModel.SceneEventMessage{
+			SceneId:   r.scene.Id,
+			EventKey:  a.CustomEvent.EventKey,
+			Data:      a.CustomEvent.Data,
+			Timestamp: time.Now().UnixMilli(),
+		})
+		return nil
+	}
+	return gerror.Newf("未知的动作类型：%s", a.ActionType)
+}
+
+// deviceOutput 调用设备功能或设置设备属性
+func (r *sceneRun) deviceOutput(ctx context.Context, out *model.SceneDeviceOutput) (err error) {
+	deviceKey := out.DeviceKey
+	if deviceKey == "" {
+		deviceKey = r.deviceKey
+	}
+	if deviceKey == "" {
+		return gerror.New("未设置输出设备")
+	}
+	switch out.OutputType {
+	case consts.SceneDeviceOutputFunction:
+		_, err = service.DevDeviceFunction().Do(ctx, &model.DeviceFunctionInput{
+			DeviceKey: deviceKey,
+			FuncKey:   out.FuncKey,
+			Params:    out.Params,
+		})
+	case consts.SceneDeviceOutputProperty:
+		_, err = service.DevDeviceProperty().Set(ctx, &model.DevicePropertyInput{
+			DeviceKey: deviceKey,
+			Params:    out.Params,
+		})
+	}
+	return
+}
+
+// templateData 模板变量：设备信息和触发数据
+func (r *sceneRun) templateData() map[string]any {
+	var data = make(map[string]any)
+	for k, v := range r.data {
+		data[k] = v
+	}
+	data["SceneName"] = r.scene.Name
+	data["ProductKey"] = r.productKey
+	data["DeviceKey"] = r.deviceKey
+	data["TriggerType"] = r.source
+	data["Time"] = gtime.Now().Format("Y-m-d H:i:s")
+	if r.deviceKey != "" {
+		if device, err := dcache.GetDeviceDetailInfo(r.deviceKey); err == nil && device != nil {
+			data["ProductName"] = device.ProductName
+			data["DeviceName"] = device.Name
+		}
+	}
+	return data
+}
+
+// sendNotice 按通知模板发送通知，并记录通知日志
+func (r *sceneRun) sendNotice(ctx context.Context, in *model.SceneSendNotice) (err error) {
+	tpl, err := service.NoticeTemplate().GetNoticeTemplateById(ctx, in.NoticeTemplate)
+	if err != nil {
+		return
+	}
+	if tpl == nil {
+		return gerror.New("通知模板不存在")
+	}
+	content, err := utils.ReplaceTemplate(tpl.Content, r.templateData())
+	if err != nil {
+		return
+	}
+
+	return service.NoticeTemplate().Send(ctx, tpl, in.Addressee, content)
+}
+
+// callWebService 调用外部Web服务，请求内容为空时发送触发数据
+func (r *sceneRun) callWebService(ctx context.Context, in *model.SceneCallWebService) (err error) {
+	method := strings.ToUpper(in.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	timeout := time.Duration(in.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	var body string
+	if in.Body != "" {
+		if body, err = utils.ReplaceTemplate(in.Body, r.templateData()); err != nil {
+			return
+		}
+	} else {
+		b, _ := json.Marshal(g.Map{
+			"sceneId":    r.scene.Id,
+			"sceneName":  r.scene.Name,
+			"trigger":    r.source,
+			"productKey": r.productKey,
+			"deviceKey":  r.deviceKey,
+			"data":       r.data,
+		})
+		body = string(b)
+	}
+
+	client := g.Client().Timeout(timeout).ContentJson()
+	if len(in.Headers) > 0 {
+		client = client.Header(in.Headers)
+	}
+	res, err := client.DoRequest(ctx, method, in.Url, body)
+	if err != nil {
+		return
+	}
+	defer res.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return gerror.Newf("Web服务响应异常：%s", res.Status)
+	}
+	return
+}
+
+// triggerAlarm 按场景生成一条告警日志
+func (r *sceneRun) triggerAlarm(ctx context.Context, in *model.SceneTriggerAlarm) (err error) {
+	data, _ := json.Marshal(r.param)
+	_, err = service.AlarmLog().Add(ctx, &model.AlarmLogAddInput{
+		Type:       1,
+		RuleName:   r.scene.Name,
+		Level:      in.Level,
+		Data:       string(data),
+		Expression: condition.Expression(r.scene.Condition.TriggerCondition),
+		ProductKey: r.productKey,
+		DeviceKey:  r.deviceKey,
+	})
+	return
+}
@@ -0,0 +1,67 @@
+package scene
+
+import (
+	"context"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/pkg/condition"
+	"sagooiot/pkg/dcache"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+// Check 设备触发场景检测
+func (s *sScene) Check(ctx context.Context, productKey string, deviceKey string, triggerType string, param any, subKey ...string) (err error) {
+	// 网关子设备
+	if len(subKey) > 0 {
+		sub, _ := dcache.GetDeviceDetailInfo(subKey[0])
+		if sub == nil {
+			return
+		}
+		productKey = sub.Product.Key
+		deviceKey = sub.Key
+	}
+
+	scenes := s.matchScene(dcache.GetDeviceScene(ctx, productKey), deviceKey, triggerType)
+	if len(scenes) == 0 {
+		return
+	}
+
+	eventKey, data, err := condition.ParamData(param)
+	if err != nil {
+		return
+	}
+	for _, v := range scenes {
+		if triggerType == consts.SceneTriggerReportEvent && v.EventKey != eventKey {
+			continue
+		}
+		exp := condition.Expression(v.Condition.TriggerCondition)
+		if exp != "" {
+			ok, err := condition.Evaluate(exp, data)
+			if err != nil {
+				return gerror.Wrapf(err, "场景条件 - %s - %s - %s", v.Name, deviceKey, exp)
+			}
+			if !ok {
+				continue
+			}
+		}
+		go s.execute(context.WithoutCancel(ctx), v, triggerType, productKey, deviceKey, param, data)
+	}
+	return
+}
+
+// matchScene 按触发类型和设备过滤场景
+func (s *sScene) matchScene(list []model.SceneOutput, deviceKey string, triggerType string) (res []model.SceneOutput) {
+	for _, v := range list {
+		if v.Status != consts.SceneStatusOn || v.SceneType != consts.SceneTypeDevice {
+			continue
+		}
+		if v.TriggerType != triggerType {
+			continue
+		}
+		if v.DeviceKey == deviceKey || v.DeviceKey == "all" || v.DeviceKey == "" {
+			res = append(res, v)
+		}
+	}
+	return
+}
@@ -0,0 +1,31 @@
+package scene
+
+import (
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/internal/model/entity"
+	"testing"
+)
+
+func TestMatchScene(t *testing.T) {
+	s := sceneNew()
+	list := []model.SceneOutput{
+		{SceneDefinition: sceneEntity(1, consts.SceneTriggerOnLine, "")},
+		{SceneDefinition: sceneEntity(2, consts.SceneTriggerOnLine, "d2")},
+		{SceneDefinition: sceneEntity(3, consts.SceneTriggerOffLine, "d1")},
+	}
+	rs := s.matchScene(list, "d1", consts.SceneTriggerOnLine)
+	if len(rs) != 1 || rs[0].Id != 1 {
+		t.Fatalf("match scene got %v", rs)
+	}
+}
+
+func sceneEntity(id uint64, triggerType, deviceKey string) *entity.SceneDefinition {
+	return &entity.SceneDefinition{
+		Id:          id,
+		SceneType:   consts.SceneTypeDevice,
+		TriggerType: triggerType,
+		DeviceKey:   deviceKey,
+		Status:      consts.SceneStatusOn,
+	}
+}
@@ -0,0 +1,73 @@
+package scene
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/internal/queues"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcron"
+)
+
+// sceneCronTaskTimeout 定时场景任务的超时时间，单位秒
+const sceneCronTaskTimeout = 60
+
+// cronName 定时场景的任务名称
+func cronName(id uint64) string {
+	return fmt.Sprintf("scene-cron-%d", id)
+}
+
+// start 启动定时场景，每个实例都按定时表达式触发，到达时间时加入任务队列，
+// 任务ID由场景和触发时间组成，多个实例同一时间触发只执行一次
+func (s *sScene) start(ctx context.Context, scene model.SceneOutput) error {
+	if scene.CronExpression == "" {
+		return gerror.New("请设置定时表达式")
+	}
+	name := cronName(scene.Id)
+	gcron.Remove(name)
+	_, err := gcron.AddSingleton(ctx, scene.CronExpression, func(ctx context.Context) {
+		if err := pushCron(ctx, scene.Id, time.Now()); err != nil {
+			g.Log().Errorf(ctx, "定时场景排期 - %d：%s", scene.Id, err)
+		}
+	}, name)
+	if err != nil {
+		return err
+	}
+	gcron.Start(name)
+	return nil
+}
+
+// stop 停止定时场景
+func (s *sScene) stop(id uint64) {
+	gcron.Remove(cronName(id))
+}
+
+// pushCron 将定时场景的一次执行加入任务队列
+func pushCron(ctx context.Context, id uint64, at time.Time) error {
+	at = at.Truncate(time.Second)
+	data, err := json.Marshal(model.SceneCronTask{SceneId: id, FireAt: at.Unix()})
+	if err != nil {
+		return err
+	}
+	uid := fmt.Sprintf("sceneCron:%d:%d", id, at.Unix())
+	return queues.SceneCronWorker.PushAt(ctx, consts.QueueSceneCronTopic, uid, data, at, sceneCronTaskTimeout)
+}
+
+// RunCron 执行定时场景，由任务队列调用，场景已禁用时不执行
+func (s *sScene) RunCron(ctx context.Context, id uint64) (err error) {
+	p, err := s.Detail(ctx, id)
+	if err != nil {
+		return
+	}
+	if p == nil || p.SceneType != consts.SceneTypeTimer || p.Status != consts.SceneStatusOn {
+		return
+	}
+	// 动作中可能有延时，不占用任务的执行时间，避免超时后重试导致重复执行
+	go s.execute(context.WithoutCancel(ctx), *p, consts.SceneTypeTimer, p.ProductKey, p.DeviceKey, nil, nil)
+	return
+}
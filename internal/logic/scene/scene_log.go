@@ -0,0 +1,39 @@
+package scene
+
+import (
+	"context"
+	"encoding/json"
+	"sagooiot/internal/dao"
+	"sagooiot/internal/model"
+
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// LogList 场景执行日志
+func (s *sScene) LogList(ctx context.Context, in *model.SceneLogListInput) (out *model.SceneLogListOutput, err error) {
+	out = new(model.SceneLogListOutput)
+	c := dao.SceneLog.Columns()
+	m := dao.SceneLog.Ctx(ctx).OrderDesc(c.Id)
+
+	if in.SceneId > 0 {
+		m = m.Where(c.SceneId, in.SceneId)
+	}
+	if in.Status != "" {
+		m = m.Where(c.Status, gconv.Int(in.Status))
+	}
+	if len(in.DateRange) > 0 {
+		m = m.WhereBetween(c.CreatedAt, in.DateRange[0], in.DateRange[1])
+	}
+
+	out.Total, _ = m.Count()
+	out.CurrentPage = in.PageNum
+	if err = m.Page(in.PageNum, in.PageSize).Scan(&out.List); err != nil {
+		return
+	}
+	for i, v := range out.List {
+		if v.Result != "" {
+			_ = json.Unmarshal([]byte(v.Result), &out.List[i].ActionResult)
+		}
+	}
+	return
+}
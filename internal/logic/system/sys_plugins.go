@@ -16,6 +16,7 @@ import (
 	"sagooiot/internal/model/entity"
 	"sagooiot/internal/service"
 	"sagooiot/pkg/plugins"
+	"sagooiot/pkg/plugins/consts/PluginType"
 	"sagooiot/pkg/utility/utils"
 	"strings"
 )
@@ -376,3 +377,56 @@ func (s *sSysPlugins) GetSysPluginsTypesAll(ctx context.Context, types string) (
 	}
 	return
 }
+
+// GetSysPluginsStatus 获取插件的运行状态，包括重启次数和编解码失败率
+func (s *sSysPlugins) GetSysPluginsStatus(ctx context.Context, types string) (out []*model.SysPluginsStatusOut, err error) {
+	pluginTypes := []string{PluginType.Protocol, PluginType.Notice}
+	if types != "" {
+		if types != PluginType.Protocol && types != PluginType.Notice {
+			err = gerror.New("无效的插件类型")
+			return
+		}
+		pluginTypes = []string{types}
+	}
+
+	var sp []*entity.SysPlugins
+	if err = dao.SysPlugins.Ctx(ctx).Where(dao.SysPlugins.Columns().IsDeleted, 0).Scan(&sp); err != nil {
+		return
+	}
+	titles := make(map[string]string, len(sp))
+	for _, p := range sp {
+		titles[p.Types+"/"+p.Name] = p.Title
+	}
+
+	for _, pluginType := range pluginTypes {
+		list, statusErr := plugins.GetPlugin(pluginType).Status()
+		if statusErr != nil {
+			continue
+		}
+		for _, p := range list {
+			item := &model.SysPluginsStatusOut{
+				Types:           p.Type,
+				Name:            p.ID,
+				Title:           titles[p.Type+"/"+p.ID],
+				Path:            p.Path,
+				Status:          p.Status,
+				Restarts:        p.Restarts,
+				LastError:       p.LastError,
+				DecodeTotal:     p.DecodeTotal,
+				DecodeErrors:    p.DecodeErrors,
+				DecodeErrorRate: p.DecodeErrorRate,
+				EncodeTotal:     p.EncodeTotal,
+				EncodeErrors:    p.EncodeErrors,
+				EncodeErrorRate: p.EncodeErrorRate,
+			}
+			if !p.StartedAt.IsZero() {
+				item.StartedAt = gtime.New(p.StartedAt)
+			}
+			if !p.LastErrorAt.IsZero() {
+				item.LastErrorAt = gtime.New(p.LastErrorAt)
+			}
+			out = append(out, item)
+		}
+	}
+	return
+}
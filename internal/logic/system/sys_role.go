@@ -371,3 +371,54 @@ func (s *sSysRole) GetAuthorizeById(ctx context.Context, id int) (menuIds []stri
 	}
 	return
 }
+
+// GetDataScope 获取用户的数据权限，all为true时拥有全部数据权限，否则只能访问deptIds中的部门数据
+func (s *sSysRole) GetDataScope(ctx context.Context, userId int, deptId int) (all bool, deptIds []int64, err error) {
+	userRoles, err := service.SysUserRole().GetInfoByUserId(ctx, userId)
+	if err != nil || len(userRoles) == 0 {
+		return
+	}
+	var roleIds []int
+	for _, userRole := range userRoles {
+		//超级管理员拥有全部数据权限
+		if userRole.RoleId == 1 {
+			return true, nil, nil
+		}
+		roleIds = append(roleIds, userRole.RoleId)
+	}
+	roles, err := s.GetInfoByIds(ctx, roleIds)
+	if err != nil {
+		return
+	}
+
+	deptMap := make(map[int64]struct{})
+	for _, role := range roles {
+		switch role.DataScope {
+		case 1:
+			return true, nil, nil
+		case 2:
+			var roleDepts []*entity.SysRoleDept
+			if roleDepts, err = service.SysRoleDept().GetInfoByRoleId(ctx, int(role.Id)); err != nil {
+				return
+			}
+			for _, roleDept := range roleDepts {
+				deptMap[roleDept.DeptId] = struct{}{}
+			}
+		case 3:
+			deptMap[int64(deptId)] = struct{}{}
+		case 4:
+			deptMap[int64(deptId)] = struct{}{}
+			var deptList []*entity.SysDept
+			if deptList, err = service.SysDept().GetFromCache(ctx); err != nil {
+				return
+			}
+			for _, dept := range service.SysDept().FindSonByParentId(deptList, int64(deptId)) {
+				deptMap[dept.DeptId] = struct{}{}
+			}
+		}
+	}
+	for id := range deptMap {
+		deptIds = append(deptIds, id)
+	}
+	return
+}
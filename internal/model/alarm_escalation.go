@@ -0,0 +1,60 @@
+package model
+
+import "sagooiot/internal/model/entity"
+
+// AlarmOnCall 值班轮换：从 Start 开始，每 Period 小时按顺序轮换一组值班人员
+type AlarmOnCall struct {
+	Start  string     `json:"start" dc:"轮换开始时间，格式：2006-01-02 15:04"`
+	Period int        `json:"period" dc:"轮换周期，单位小时"`
+	Groups [][]string `json:"groups" dc:"值班组，每组为一组收信人"`
+}
+
+// AlarmEscalationStep 升级步骤：告警产生 After 分钟后仍未处理时执行本步骤的通知
+type AlarmEscalationStep struct {
+	After  int           `json:"after" dc:"告警产生后的分钟数"`
+	Action []AlarmAction `json:"action" dc:"通知动作"`
+	OnCall *AlarmOnCall  `json:"onCall" dc:"值班轮换，当前值班组追加到本步骤的收信人中"`
+}
+
+type AlarmEscalationAddInput struct {
+	Name           string                `json:"name" dc:"策略名称" v:"required#请输入策略名称"`
+	Steps          []AlarmEscalationStep `json:"steps" dc:"升级步骤，按 after 升序执行" v:"required#请添加升级步骤"`
+	RepeatInterval int                   `json:"repeatInterval" dc:"最后一步的重复通知间隔，单位分钟，0=不重复" v:"min:0#重复通知间隔不正确"`
+	RepeatTimes    int                   `json:"repeatTimes" dc:"重复通知次数，0=直到告警处理" v:"min:0#重复通知次数不正确"`
+	QuietStart     string                `json:"quietStart" dc:"免打扰开始时间，HH:MM，免打扰期间的通知推迟到结束时间"`
+	QuietEnd       string                `json:"quietEnd" dc:"免打扰结束时间，HH:MM"`
+	Status         int                   `json:"status" dc:"状态：0=未启用，1=已启用" v:"in:0,1#状态不正确"`
+	Remark         string                `json:"remark" dc:"备注"`
+}
+
+type AlarmEscalationEditInput struct {
+	Id uint64 `json:"id" dc:"升级策略ID" v:"required#升级策略ID不能为空"`
+	AlarmEscalationAddInput
+}
+
+type AlarmEscalationListInput struct {
+	Status int `json:"status" dc:"状态：-1=全部，0=未启用，1=已启用" d:"-1"`
+	PaginationInput
+}
+
+type AlarmEscalationOutput struct {
+	*entity.AlarmEscalation
+	StepList []AlarmEscalationStep `json:"stepList" dc:"升级步骤"`
+}
+
+type AlarmEscalationListOutput struct {
+	List []AlarmEscalationOutput `json:"list" dc:"升级策略列表"`
+	PaginationOutput
+}
+
+// AlarmEscalationTask 告警升级任务，由任务队列在指定时间执行
+type AlarmEscalationTask struct {
+	LogId        uint64 `json:"logId"`
+	EscalationId uint64 `json:"escalationId"`
+	Step         int    `json:"step"`
+	Round        int    `json:"round"`
+	AlarmAt      int64  `json:"alarmAt"`
+	Expression   string `json:"expression"`
+	DeviceKey    string `json:"deviceKey"`
+	Param        any    `json:"param"`
+}
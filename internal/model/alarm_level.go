@@ -11,6 +11,7 @@ type AlarmLevelListOutput struct {
 }
 
 type AlarmLevelEditInput struct {
-	Level uint   `json:"level" dc:"告警级别" v:"required|in:1,2,3,4,5#告警级别不能为空|告警级别不正确"`
-	Name  string `json:"name" dc:"告警名称" v:"required#请输入告警名称"`
+	Level        uint   `json:"level" dc:"告警级别" v:"required|in:1,2,3,4,5#告警级别不能为空|告警级别不正确"`
+	Name         string `json:"name" dc:"告警名称" v:"required#请输入告警名称"`
+	EscalationId uint64 `json:"escalationId" dc:"升级策略ID，0=不升级"`
 }
@@ -8,6 +8,7 @@ const (
 	AlarmLogStatusUnhandle int = iota // 告警日志状态：未处理
 	AlarmLogStatusHandle              // 告警日志状态：已处理
 	AlarmLogStatusIgnore              // 告警日志状态：忽略
+	AlarmLogStatusRecover             // 告警日志状态：已恢复
 )
 
 // 告警日志
@@ -31,6 +32,15 @@ type AlarmLogAddInput struct {
 	DeviceKey  string `json:"deviceKey" dc:"设备标识"`
 }
 
+// AlarmState 告警规则在设备上的告警状态，存在即告警持续中
+type AlarmState struct {
+	LogId       uint64 `json:"logId" dc:"告警日志ID"`
+	RuleId      uint64 `json:"ruleId" dc:"规则id"`
+	DeviceKey   string `json:"deviceKey" dc:"设备标识"`
+	Expression  string `json:"expression" dc:"触发告警的表达式"`
+	TriggeredAt int64  `json:"triggeredAt" dc:"告警时间"`
+}
+
 // 告警处理
 type AlarmLogHandleInput struct {
 	Id      uint64 `json:"id" dc:"告警日志ID" v:"required#告警日志ID不能为空"`
@@ -58,3 +68,8 @@ type AlarmLogListOutput struct {
 	List []AlarmLogOutput `json:"list" dc:"告警日志"`
 	PaginationOutput
 }
+
+// AlarmActionLogListOutput 告警设备控制动作执行记录
+type AlarmActionLogListOutput struct {
+	List []*entity.AlarmActionLog `json:"list" dc:"执行记录"`
+}
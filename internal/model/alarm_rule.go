@@ -26,6 +26,14 @@ type (
 	}
 	AlarmTriggerCondition struct {
 		TriggerCondition []AlarmCondition `json:"triggerCondition" dc:"触发条件" v:"required-unless:triggerType,1,triggerType,2#请添加触发条件"`
+		RecoverCondition []AlarmCondition `json:"recoverCondition" dc:"恢复条件，为空时属性上报不满足触发条件即恢复"`
+		Debounce         AlarmDebounce    `json:"debounce" dc:"防抖设置"`
+	}
+	AlarmDebounce struct {
+		Duration int `json:"duration" dc:"持续时长：条件持续满足N秒后触发，0=不限制"`
+		Count    int `json:"count"    dc:"触发次数：统计窗口内满足N次后触发，0=不限制"`
+		Window   int `json:"window"   dc:"统计窗口，单位秒" v:"required-with:count#请设置统计窗口"`
+		Suppress int `json:"suppress" dc:"抑制时长：触发后T秒内不重复告警，0=不抑制"`
 	}
 )
 
@@ -37,13 +45,29 @@ type (
 )
 
 type AlarmAction struct {
-	SendGateway    string   `json:"sendGateway" dc:"通知发送通道：sms、work_weixin、dingding"`
+	SendGateway    string   `json:"sendGateway" dc:"通知发送通道：mail、webhook、dingding、work_weixin、feishu、sms"`
 	NoticeConfig   string   `json:"noticeConfig" dc:"通知配置"`
 	NoticeTemplate string   `json:"noticeTemplate" dc:"通知模板"`
 	Addressee      []string `json:"addressee" dc:"收信人"`
 }
+
+// AlarmDeviceAction 设备控制动作：调用设备功能或设置设备属性，参数中的字符串值可使用模板变量，
+// 如 {{.pressure}}，渲染结果为数字或布尔值时按对应类型下发
+type AlarmDeviceAction struct {
+	Type       string         `json:"type" dc:"动作类型：function=调用功能，property=设置属性" v:"required|in:function,property#请选择动作类型|未知的动作类型"`
+	DeviceKey  string         `json:"deviceKey" dc:"执行设备标识，为空时为触发告警的设备"`
+	FuncKey    string         `json:"funcKey" dc:"功能标识" v:"required-if:type,function#请选择设备功能"`
+	Params     map[string]any `json:"params" dc:"功能输入参数或属性值"`
+	Timeout    int            `json:"timeout" dc:"等待设备响应的超时时间，单位秒，默认45秒"`
+	Cooldown   int            `json:"cooldown" dc:"冷却时长：执行后N秒内不再执行，0=不限制"`
+	MaxPerHour int            `json:"maxPerHour" dc:"每小时最多执行次数，0=不限制"`
+}
+
 type AlarmPerformAction struct {
-	Action []AlarmAction `json:"action" dc:"执行动作" v:"required#请添加执行动作"`
+	Action        []AlarmAction       `json:"action" dc:"执行动作" v:"required-without:deviceAction#请添加执行动作"`
+	RecoverAction []AlarmAction       `json:"recoverAction" dc:"恢复通知"`
+	EscalationId  uint64              `json:"escalationId" dc:"升级策略ID，为空时使用告警级别的升级策略"`
+	DeviceAction  []AlarmDeviceAction `json:"deviceAction" dc:"设备控制动作"`
 }
 
 type AlarmRuleAddInput struct {
@@ -1,6 +1,10 @@
 package model
 
-import "sagooiot/pkg/iotModel"
+import (
+	"sagooiot/pkg/iotModel"
+
+	"github.com/gogf/gf/v2/database/gdb"
+)
 
 // DeviceOnlineOfflineCount 设备在线离线状态统计
 type DeviceOnlineOfflineCount struct {
@@ -35,3 +39,25 @@ type DeviceDataRes struct {
 	DeviceKey  string                      `json:"deviceKey"`
 	DeviceData iotModel.ReportPropertyData `json:"deviceData"`
 }
+
+// TsdQueryInput 时序数据查询
+type TsdQueryInput struct {
+	ProductKey string   `json:"productKey" dc:"产品标识，查询多个设备时可不填，只填产品时查询产品下所有设备"`
+	DeviceKeys []string `json:"deviceKeys" dc:"设备标识"`
+	Properties []string `json:"properties" v:"required#请选择属性" dc:"属性标识"`
+	StartTime  string   `json:"startTime" v:"datetime#开始时间格式不正确" dc:"开始时间，默认为结束时间前1小时"`
+	EndTime    string   `json:"endTime" v:"datetime#结束时间格式不正确" dc:"结束时间，默认为当前时间"`
+	Aggregate  string   `json:"aggregate" v:"in:avg,min,max,sum,count,first,last,spread,diff#不支持的聚合函数" dc:"聚合函数：avg,min,max,sum,count,first,last,spread,diff，为空时查询原始数据"`
+	Interval   int      `json:"interval" v:"min:0#聚合窗口不能小于0" dc:"聚合窗口，为0时对整个时间范围聚合"`
+	TimeUnit   int      `json:"timeUnit" d:"2" v:"in:1,2,3,4#聚合窗口单位不正确" dc:"聚合窗口时间单位：1=秒，2=分钟，3=小时，4=天"`
+	Fill       string   `json:"fill" v:"in:none,null,prev,linear,value#不支持的填充方式" dc:"空窗口填充方式：none,null,prev,linear,value"`
+	FillValue  float64  `json:"fillValue" dc:"填充方式为value时的填充值"`
+	Order      string   `json:"order" d:"desc" v:"in:asc,desc#排序方式不正确" dc:"按时间排序：asc,desc"`
+	PaginationInput
+}
+
+// TsdQueryOutput 时序数据查询结果
+type TsdQueryOutput struct {
+	List gdb.Result `json:"list" dc:"数据列表，ts为数据时间或窗口开始时间"`
+	PaginationOutput
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"sagooiot/internal/model/entity"
+)
+
+// 设备远程配置，设备配置优先于产品配置
+type DeviceConfigSaveInput struct {
+	ProductKey string                 `json:"productKey" dc:"产品标识" v:"required#请选择产品"`
+	DeviceKey  string                 `json:"deviceKey" dc:"设备标识，为空时保存产品配置"`
+	Content    map[string]interface{} `json:"content" dc:"配置内容" v:"required#请输入配置内容"`
+	Schema     map[string]string      `json:"schema" dc:"配置校验规则，键为配置项，值为校验规则，如required|integer|between:1,100；为空时设备配置使用产品的校验规则"`
+	Desc       string                 `json:"desc" dc:"描述"`
+}
+
+type DeviceConfigOutput struct {
+	*entity.DevConfig
+}
+
+type DeviceConfigDetailInput struct {
+	ProductKey string `json:"productKey" dc:"产品标识" v:"required#请选择产品"`
+	DeviceKey  string `json:"deviceKey" dc:"设备标识，为空时获取产品配置"`
+	Version    int    `json:"version" dc:"配置版本号，为空时获取最新版本"`
+}
+
+type DeviceConfigHistoryInput struct {
+	ProductKey string `json:"productKey" dc:"产品标识" v:"required#请选择产品"`
+	DeviceKey  string `json:"deviceKey" dc:"设备标识，为空时获取产品配置"`
+	PaginationInput
+}
+type DeviceConfigHistoryOutput struct {
+	List []DeviceConfigOutput `json:"list" dc:"配置版本列表"`
+	PaginationOutput
+}
+
+type DeviceConfigPushInput struct {
+	ProductKey string `json:"productKey" dc:"产品标识" v:"required#请选择产品"`
+	DeviceKey  string `json:"deviceKey" dc:"设备标识，为空时下发给产品的全部在线设备"`
+}
+type DeviceConfigPushOutput struct {
+	Total   int `json:"total" dc:"设备总数"`
+	Success int `json:"success" dc:"下发成功数量"`
+	Offline int `json:"offline" dc:"离线设备数量，设备上线后可主动请求配置"`
+	Pending int `json:"pending" dc:"后台下发的在线设备数量，结果见下发记录"`
+}
+
+type DeviceConfigPushListInput struct {
+	ProductKey string `json:"productKey" dc:"产品标识"`
+	DeviceKey  string `json:"deviceKey" dc:"设备标识"`
+	Status     string `json:"status" dc:"下发状态：0=已下发，1=成功，2=失败"`
+	PaginationInput
+}
+type DeviceConfigPushListOutput struct {
+	List []*entity.DevConfigPush `json:"list" dc:"配置下发记录"`
+	PaginationOutput
+}
+
+// 设备下发配置的响应
+type DeviceConfigReplyInput struct {
+	DeviceKey string                 `json:"deviceKey" dc:"设备标识"`
+	RequestId string                 `json:"requestId" dc:"下发请求ID"`
+	Code      int                    `json:"code" dc:"设备响应码"`
+	Data      map[string]interface{} `json:"data" dc:"设备响应数据"`
+}
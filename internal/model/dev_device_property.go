@@ -3,7 +3,9 @@ package model
 type DevicePropertyInput struct {
 	DeviceKey string         `json:"deviceKey" dc:"设备标识" v:"required#设备标识不能为空"`
 	Params    map[string]any `json:"params" dc:"设备属性设置"`
+	Timeout   int            `json:"timeout" dc:"等待设备响应的超时时间，单位秒，默认45秒" v:"min:0#超时时间不能小于0"`
 }
 type DevicePropertyOutput struct {
-	Data map[string]any `json:"data" dc:"设备属性设置输出"`
+	Status string         `json:"status" dc:"设置结果：sent 已下发到设备，pending 设备离线，已写入设备影子等待设备上线后下发"`
+	Data   map[string]any `json:"data" dc:"设备属性设置输出，pending 时为等待下发的属性"`
 }
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"sagooiot/internal/model/entity"
+)
+
+// 设备同步请求记录，服务调用和属性设置的请求与响应
+type DeviceRequestAddInput struct {
+	RequestId  string      `json:"requestId" dc:"请求ID"`
+	ProductKey string      `json:"productKey" dc:"产品标识"`
+	DeviceKey  string      `json:"deviceKey" dc:"设备标识"`
+	FuncKey    string      `json:"funcKey" dc:"功能标识"`
+	Request    interface{} `json:"request" dc:"请求内容"`
+	Timeout    int         `json:"timeout" dc:"超时时间，单位秒"`
+}
+
+type DeviceRequestListInput struct {
+	ProductKey string `json:"productKey" dc:"产品标识"`
+	DeviceKey  string `json:"deviceKey" dc:"设备标识"`
+	FuncKey    string `json:"funcKey" dc:"功能标识"`
+	RequestId  string `json:"requestId" dc:"请求ID"`
+	Status     string `json:"status" dc:"状态：0=等待响应，1=已响应，2=超时，3=失败"`
+	PaginationInput
+}
+type DeviceRequestListOutput struct {
+	List []*entity.DevDeviceRequest `json:"list" dc:"请求记录"`
+	PaginationOutput
+}
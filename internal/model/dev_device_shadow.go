@@ -0,0 +1,34 @@
+package model
+
+// DeviceShadow 设备影子，记录期望属性、设备上报属性以及两者的差异
+type DeviceShadow struct {
+	DeviceKey      string               `json:"deviceKey" dc:"设备标识"`
+	State          DeviceShadowState    `json:"state" dc:"影子状态"`
+	Metadata       DeviceShadowMetadata `json:"metadata" dc:"属性更新时间"`
+	Version        int64                `json:"version" dc:"影子版本号，每次更新加1"`
+	DesiredVersion int64                `json:"desiredVersion" dc:"期望属性版本号，期望属性变化时加1"`
+	SyncedVersion  int64                `json:"syncedVersion" dc:"已下发到设备的期望属性版本号"`
+	Timestamp      int64                `json:"timestamp" dc:"更新时间，单位毫秒"`
+}
+
+type DeviceShadowState struct {
+	Desired  map[string]any `json:"desired" dc:"期望属性"`
+	Reported map[string]any `json:"reported" dc:"设备上报属性"`
+	Delta    map[string]any `json:"delta" dc:"期望属性与上报属性的差异，设备上线或上报时下发"`
+}
+
+type DeviceShadowMetadata struct {
+	Desired  map[string]int64 `json:"desired" dc:"期望属性更新时间，单位毫秒"`
+	Reported map[string]int64 `json:"reported" dc:"上报属性更新时间，单位毫秒"`
+}
+
+type DeviceShadowUpdateInput struct {
+	DeviceKey string         `json:"deviceKey" dc:"设备标识" v:"required#设备标识不能为空"`
+	Desired   map[string]any `json:"desired" dc:"期望属性，值为null时删除该期望属性" v:"required#请输入期望属性"`
+	Version   int64          `json:"version" dc:"影子版本号，不为0时校验版本，避免覆盖其他人的更新"`
+}
+
+type DeviceShadowDeleteInput struct {
+	DeviceKey string   `json:"deviceKey" dc:"设备标识" v:"required#设备标识不能为空"`
+	Keys      []string `json:"keys" dc:"删除的期望属性，为空时删除整个设备影子"`
+}
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model/entity"
+)
+
+var FirmwareTargetType = map[string]string{
+	consts.FirmwareTargetProduct: "产品全部设备",
+	consts.FirmwareTargetDevice:  "指定设备",
+	consts.FirmwareTargetTag:     "设备标签",
+}
+
+// 固件
+type FirmwareAddInput struct {
+	ProductKey string `json:"productKey" dc:"产品标识" v:"required#请选择产品"`
+	Name       string `json:"name" dc:"固件名称" v:"required#请输入固件名称"`
+	Version    string `json:"version" dc:"固件版本号" v:"required#请输入固件版本号"`
+	Module     string `json:"module" dc:"模块名称，默认default"`
+	SignMethod string `json:"signMethod" d:"MD5" dc:"签名方法：MD5、SHA256" v:"in:MD5,SHA256#未知的签名方法"`
+	Source     int    `json:"source" dc:"上传位置：本地-0、腾讯云-1、MinIO-4"`
+	Desc       string `json:"desc" dc:"描述"`
+}
+
+type FirmwareEditInput struct {
+	Id   uint64 `json:"id" dc:"固件ID" v:"required#固件ID不能为空"`
+	Name string `json:"name" dc:"固件名称" v:"required#请输入固件名称"`
+	Desc string `json:"desc" dc:"描述"`
+}
+
+type FirmwareOutput struct {
+	*entity.DevFirmware
+}
+
+type FirmwareListInput struct {
+	ProductKey string `json:"productKey" dc:"产品标识"`
+	PaginationInput
+}
+type FirmwareListOutput struct {
+	List []FirmwareOutput `json:"list" dc:"固件列表"`
+	PaginationOutput
+}
+
+// 升级任务
+type FirmwareTaskTarget struct {
+	DeviceKeys []string `json:"deviceKeys" dc:"设备标识，升级范围为指定设备时必填"`
+	TagKey     string   `json:"tagKey" dc:"标签标识，升级范围为设备标签时必填"`
+	TagValue   string   `json:"tagValue" dc:"标签值"`
+}
+
+type FirmwareTaskAddInput struct {
+	Name          string `json:"name" dc:"任务名称" v:"required#请输入任务名称"`
+	FirmwareId    uint64 `json:"firmwareId" dc:"固件ID" v:"required#请选择固件"`
+	TargetType    string `json:"targetType" dc:"升级范围：product=产品全部设备，device=指定设备，tag=设备标签" v:"required|in:product,device,tag#请选择升级范围|未知的升级范围"`
+	BatchSize     int    `json:"batchSize" dc:"每批推送的设备数量，0=全部" v:"min:0#每批推送的设备数量不能小于0"`
+	BatchInterval int    `json:"batchInterval" dc:"批次间隔，单位秒" v:"min:0#批次间隔不能小于0"`
+	FirmwareTaskTarget
+}
+
+type FirmwareTaskOutput struct {
+	*entity.DevFirmwareTask
+
+	TargetTypeName string              `json:"targetTypeName" dc:"升级范围"`
+	TargetInfo     FirmwareTaskTarget  `json:"targetInfo" dc:"升级目标"`
+	Firmware       *entity.DevFirmware `json:"firmware" dc:"固件"`
+}
+
+type FirmwareTaskListInput struct {
+	FirmwareId uint64 `json:"firmwareId" dc:"固件ID"`
+	ProductKey string `json:"productKey" dc:"产品标识"`
+	Status     string `json:"status" dc:"任务状态：0=待执行，1=执行中，2=已完成，3=已取消"`
+	PaginationInput
+}
+type FirmwareTaskListOutput struct {
+	List []FirmwareTaskOutput `json:"list" dc:"升级任务列表"`
+	PaginationOutput
+}
+
+// 设备升级记录
+type FirmwareDeviceListInput struct {
+	TaskId    uint64 `json:"taskId" dc:"升级任务ID"`
+	DeviceKey string `json:"deviceKey" dc:"设备标识"`
+	Status    string `json:"status" dc:"升级状态：0=待推送，1=已推送，2=升级中，3=升级成功，4=升级失败，5=已取消"`
+	PaginationInput
+}
+type FirmwareDeviceListOutput struct {
+	List []*entity.DevFirmwareDevice `json:"list" dc:"设备升级记录"`
+	PaginationOutput
+}
+
+// 设备上报的版本和升级进度
+type FirmwareInformInput struct {
+	DeviceKey string `json:"deviceKey" dc:"设备标识"`
+	Module    string `json:"module" dc:"模块名称"`
+	Version   string `json:"version" dc:"版本号"`
+}
+
+type FirmwareProgressInput struct {
+	DeviceKey string `json:"deviceKey" dc:"设备标识"`
+	Module    string `json:"module" dc:"模块名称"`
+	Step      int    `json:"step" dc:"升级进度：1-100，-1=升级失败，-2=下载失败，-3=校验失败，-4=烧写失败"`
+	Desc      string `json:"desc" dc:"进度描述"`
+}
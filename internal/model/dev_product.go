@@ -69,6 +69,7 @@ type AddProductInput struct {
 	Desc              string `json:"desc" dc:"描述" v:"max-length:200#描述长度不能超过200个字符"`
 	Icon              string `json:"icon" dc:"图片地址"`
 	ScriptInfo        string `json:"scriptInfo" dc:"脚本信息"`
+	Policy            string `json:"policy" dc:"采集策略"`
 
 	// 认证信息
 	AuthType      int    `json:"authType" dc:"认证方式（1=Basic，2=AccessToken，3=证书）"`
@@ -88,6 +89,7 @@ type EditProductInput struct {
 	Desc              string  `json:"desc" dc:"描述" v:"max-length:200#描述长度不能超过200个字符"`
 	Icon              *string `json:"icon" dc:"图片地址"`
 	ScriptInfo        string  `json:"scriptInfo" dc:"脚本信息"`
+	Policy            string  `json:"policy" dc:"采集策略"`
 
 	// 认证信息
 	AuthType      int    `json:"authType" dc:"认证方式（1=Basic，2=AccessToken，3=证书）"`
@@ -112,6 +114,20 @@ type ScriptInfoInput struct {
 	ScriptInfo string `json:"scriptInfo"        description:"脚本信息"`
 }
 
+type ScriptDebugInput struct {
+	Key        string `json:"key" dc:"产品标识，脚本为空时使用产品已保存的脚本"`
+	ScriptInfo string `json:"scriptInfo" dc:"脚本信息"`
+	FuncName   string `json:"funcName" dc:"执行的函数：parse=上行解析，encode=下行编码" v:"required|in:parse,encode#请选择执行的函数|执行的函数只能是parse或encode"`
+	Payload    string `json:"payload" dc:"样例数据"`
+}
+
+type ScriptDebugOutput struct {
+	Output   string   `json:"output" dc:"执行结果"`
+	Logs     []string `json:"logs" dc:"console输出的日志"`
+	Error    string   `json:"error" dc:"错误信息"`
+	Duration int64    `json:"duration" dc:"执行耗时，单位毫秒"`
+}
+
 type DeviceConnectIntroOutput struct {
 	Name        string `json:"name" dc:"接入方式"`
 	Protocol    string `json:"protocol" dc:"消息协议"`
@@ -10,7 +10,8 @@ import (
 
 // AlarmLevel is the golang structure of table alarm_level for DAO operations like Where/Data.
 type AlarmLevel struct {
-	g.Meta `orm:"table:alarm_level, do:true"`
-	Level  interface{} // 告警级别
-	Name   interface{} // 名称
+	g.Meta       `orm:"table:alarm_level, do:true"`
+	Level        interface{} // 告警级别
+	Name         interface{} // 名称
+	EscalationId interface{} // 升级策略ID
 }
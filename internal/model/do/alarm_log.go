@@ -22,9 +22,11 @@ type AlarmLog struct {
 	Expression interface{} // 触发告警的表达式
 	ProductKey interface{} // 产品标识
 	DeviceKey  interface{} // 设备标识
-	Status     interface{} // 告警状态：0=未处理，1=已处理
+	Status     interface{} // 告警状态：0=未处理，1=已处理，2=忽略，3=已恢复
 	CreatedAt  *gtime.Time // 告警时间
 	UpdatedBy  interface{} // 告警处理人员
 	UpdatedAt  *gtime.Time // 处理时间
 	Content    interface{} // 处理意见
+	RecoverAt  *gtime.Time // 恢复时间
+	Duration   interface{} // 告警持续时长，单位秒
 }
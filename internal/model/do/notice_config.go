@@ -17,5 +17,6 @@ type NoticeConfig struct {
 	Title       interface{} //
 	SendGateway interface{} //
 	Types       interface{} //
+	Config      interface{} // 通道配置，JSON格式
 	CreatedAt   *gtime.Time //
 }
@@ -6,6 +6,7 @@ package entity
 
 // AlarmLevel is the golang structure for table alarm_level.
 type AlarmLevel struct {
-	Level uint   `json:"level" description:"告警级别"`
-	Name  string `json:"name"  description:"名称"`
+	Level        uint   `json:"level"        description:"告警级别"`
+	Name         string `json:"name"         description:"名称"`
+	EscalationId uint64 `json:"escalationId" description:"升级策略ID"`
 }
@@ -20,9 +20,11 @@ type AlarmLog struct {
 	Expression string      `json:"expression" description:"触发告警的表达式"`
 	ProductKey string      `json:"productKey" description:"产品标识"`
 	DeviceKey  string      `json:"deviceKey"  description:"设备标识"`
-	Status     int         `json:"status"     description:"告警状态：0=未处理，1=已处理"`
+	Status     int         `json:"status"     description:"告警状态：0=未处理，1=已处理，2=忽略，3=已恢复"`
 	CreatedAt  *gtime.Time `json:"createdAt"  description:"告警时间"`
 	UpdatedBy  uint        `json:"updatedBy"  description:"告警处理人员"`
 	UpdatedAt  *gtime.Time `json:"updatedAt"  description:"处理时间"`
 	Content    string      `json:"content"    description:"处理意见"`
+	RecoverAt  *gtime.Time `json:"recoverAt"  description:"恢复时间"`
+	Duration   int64       `json:"duration"   description:"告警持续时长，单位秒"`
 }
@@ -15,5 +15,6 @@ type NoticeConfig struct {
 	Title       string      `json:"title"       description:""`
 	SendGateway string      `json:"sendGateway" description:""`
 	Types       int         `json:"types"       description:""`
+	Config      string      `json:"config"      description:"通道配置，JSON格式"`
 	CreatedAt   *gtime.Time `json:"createdAt"   description:""`
 }
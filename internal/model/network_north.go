@@ -0,0 +1,75 @@
+package model
+
+import (
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// NorthMqttConfig mqtt出口配置
+type NorthMqttConfig struct {
+	TopicPrefix string `json:"topicPrefix" dc:"主题前缀，发布的主题为前缀加北向消息主题"`
+}
+
+// NorthWebhookConfig webhook出口配置
+type NorthWebhookConfig struct {
+	Url     string            `json:"url" dc:"推送地址"`
+	Secret  string            `json:"secret" dc:"签名密钥，为空时不签名"`
+	Headers map[string]string `json:"headers" dc:"自定义请求头"`
+	Timeout int               `json:"timeout" dc:"请求超时时间（秒）"`
+}
+
+type GetNetworkNorthSinkListInput struct {
+	Types      string `json:"types" dc:"出口类型"`
+	ProductKey string `json:"productKey" dc:"产品标识"`
+	*PaginationInput
+}
+
+type NetworkNorthSinkOut struct {
+	Id            int         `json:"id"            description:"ID"`
+	Name          string      `json:"name"          description:"名称"`
+	Types         string      `json:"types"         description:"出口类型：mqtt、webhook"`
+	ProductKey    string      `json:"productKey"    description:"产品标识，为空时匹配全部产品"`
+	Topics        string      `json:"topics"        description:"消息主题，多个用逗号分隔，为空时匹配全部主题"`
+	Config        string      `json:"config"        description:"出口配置"`
+	RetryTimes    int         `json:"retryTimes"    description:"重试次数"`
+	RetryInterval int         `json:"retryInterval" description:"首次重试间隔（秒）"`
+	Status        int         `json:"status"        description:"状态：0=停用，1=启用"`
+	CreatedAt     *gtime.Time `json:"createdAt"     description:""`
+	UpdatedAt     *gtime.Time `json:"updatedAt"     description:""`
+	Remark        string      `json:"remark"        description:"备注"`
+}
+
+type NetworkNorthSinkAddInput struct {
+	Name          string `json:"name"          description:"名称"`
+	Types         string `json:"types"         description:"出口类型：mqtt、webhook"`
+	ProductKey    string `json:"productKey"    description:"产品标识，为空时匹配全部产品"`
+	Topics        string `json:"topics"        description:"消息主题，多个用逗号分隔，为空时匹配全部主题"`
+	Config        string `json:"config"        description:"出口配置"`
+	RetryTimes    int    `json:"retryTimes"    description:"重试次数"`
+	RetryInterval int    `json:"retryInterval" description:"首次重试间隔（秒）"`
+	Status        int    `json:"status"        description:"状态：0=停用，1=启用"`
+	Remark        string `json:"remark"        description:"备注"`
+}
+
+type NetworkNorthSinkEditInput struct {
+	Id int `json:"id" description:"ID"`
+	NetworkNorthSinkAddInput
+}
+
+type GetNetworkNorthDeadLetterListInput struct {
+	SinkId    int    `json:"sinkId" dc:"消息出口ID"`
+	Topic     string `json:"topic" dc:"消息主题"`
+	DeviceKey string `json:"deviceKey" dc:"设备标识"`
+	*PaginationInput
+}
+
+type NetworkNorthDeadLetterOut struct {
+	Id         int         `json:"id"         description:"ID"`
+	SinkId     int         `json:"sinkId"     description:"消息出口ID"`
+	Topic      string      `json:"topic"      description:"消息主题"`
+	ProductKey string      `json:"productKey" description:"产品标识"`
+	DeviceKey  string      `json:"deviceKey"  description:"设备标识"`
+	Payload    string      `json:"payload"    description:"消息内容"`
+	Error      string      `json:"error"      description:"失败原因"`
+	Retries    int         `json:"retries"    description:"已重试次数"`
+	CreatedAt  *gtime.Time `json:"createdAt"  description:""`
+}
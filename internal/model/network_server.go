@@ -5,7 +5,7 @@ import "github.com/gogf/gf/v2/os/gtime"
 type NetworkServerOut struct {
 	Id        int         `json:"id"        description:""`
 	Name      string      `json:"name"      description:""`
-	Types     string      `json:"types"     description:"tcp/udp/mqtt_server/http/websocket"`
+	Types     string      `json:"types"     description:"tcp/udp/mqtt_server/http/websocket/coap"`
 	Addr      string      `json:"addr"      description:""`
 	Register  string      `json:"register"  description:"注册包"`
 	Heartbeat string      `json:"heartbeat" description:"心跳包"`
@@ -30,7 +30,7 @@ type NetworkServerOut struct {
 type NetworkServerRes struct {
 	Id        int         `json:"id"        description:""`
 	Name      string      `json:"name"      description:""`
-	Types     string      `json:"types"     description:"tcp/udp/mqtt_server/http/websocket"`
+	Types     string      `json:"types"     description:"tcp/udp/mqtt_server/http/websocket/coap"`
 	Addr      string      `json:"addr"      description:""`
 	Register  string      `json:"register"  description:"注册包"`
 	Heartbeat string      `json:"heartbeat" description:"心跳包"`
@@ -53,7 +53,7 @@ type NetworkServerRes struct {
 }
 type NetworkServerAddInput struct {
 	Name      string      `json:"name"      description:""`
-	Types     string      `json:"types"     description:"tcp/udp/mqtt_server/http/websocket"`
+	Types     string      `json:"types"     description:"tcp/udp/mqtt_server/http/websocket/coap"`
 	Addr      string      `json:"addr"      description:""`
 	Register  string      `json:"register"  description:"注册包"`
 	Heartbeat string      `json:"heartbeat" description:"心跳包"`
@@ -92,5 +92,7 @@ type Stick struct {
 		Len    int    `json:"len" dc:"长度"`
 		Offset int    `json:"offset" dc:"偏移量"`
 		Endian string `json:"endian" dc:"大小端(big|little)"`
+		Adjust int    `json:"adjust" dc:"长度修正值"`
 	} `json:"len,omitempty" dc:"长度字段"`
+	ModbusRtu bool `json:"modbusRtu,omitempty" dc:"按Modbus-RTU的CRC校验分包"`
 }
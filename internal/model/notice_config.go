@@ -15,6 +15,7 @@ type NoticeConfigOutput struct {
 	Title       string `json:"title"          description:""`
 	SendGateway string `json:"sendGateway"          description:""`
 	Types       string `json:"types"          description:""`
+	Config      string `json:"config"      description:"通道配置，JSON格式"`
 	CreatedAt   string `json:"createdAt"          description:""`
 }
 type NoticeConfigAddInput struct {
@@ -22,6 +23,7 @@ type NoticeConfigAddInput struct {
 	Title       string `json:"title"          description:""`
 	SendGateway string `json:"sendGateway"          description:""`
 	Types       string `json:"types"          description:""`
+	Config      string `json:"config"      description:"通道配置，JSON格式"`
 	CreatedAt   string `json:"createdAt"          description:""`
 }
 type NoticeConfigEditInput struct {
@@ -7,7 +7,7 @@ import (
 
 type NoticeLogAddInput struct {
 	TemplateId  string      `json:"templateId" dc:"通知模板ID"`
-	SendGateway string      `json:"sendGateway" dc:"通知发送通道：sms、work_weixin、dingding"`
+	SendGateway string      `json:"sendGateway" dc:"通知发送通道：mail、webhook、dingding、work_weixin、feishu、sms"`
 	Addressee   string      `json:"addressee" dc:"收信人"`
 	Title       string      `json:"title" dc:"通知标题"`
 	Content     string      `json:"content" dc:"通知内容"`
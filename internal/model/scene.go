@@ -0,0 +1,141 @@
+package model
+
+import (
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model/entity"
+)
+
+var SceneType = map[string]string{
+	consts.SceneTypeManual: "手动触发",
+	consts.SceneTypeTimer:  "定时触发",
+	consts.SceneTypeDevice: "设备触发",
+}
+
+var SceneTriggerType = map[string]string{
+	consts.SceneTriggerOnLine:          "设备上线",
+	consts.SceneTriggerOffLine:         "设备离线",
+	consts.SceneTriggerReportAttribute: "属性上报",
+	consts.SceneTriggerReportEvent:     "事件上报",
+}
+
+var SceneActionType = map[string]string{
+	consts.SceneActionTypeDeviceOutput:       "设备输出",
+	consts.SceneActionTypeSendNotice:         "发送通知",
+	consts.SceneActionTypeCallWebService:     "调用Web服务",
+	consts.SceneActionTypeTriggerAlarm:       "触发告警",
+	consts.SceneActionTypeDelayExecution:     "延迟执行",
+	consts.SceneActionTypeTriggerCustomEvent: "触发自定义事件",
+}
+
+// 场景触发条件，复用告警的条件结构，为空时满足触发类型即执行
+type SceneTriggerCondition struct {
+	TriggerCondition []AlarmCondition `json:"triggerCondition" dc:"触发条件"`
+}
+
+// 场景执行动作
+type (
+	SceneDeviceOutput struct {
+		DeviceKey  string         `json:"deviceKey"  dc:"设备标识，为空时使用触发设备"`
+		OutputType string         `json:"outputType" dc:"输出方式：function=调用功能，property=设置属性"`
+		FuncKey    string         `json:"funcKey"    dc:"功能标识"`
+		Params     map[string]any `json:"params"     dc:"功能参数或属性值"`
+	}
+	SceneSendNotice struct {
+		NoticeTemplate string   `json:"noticeTemplate" dc:"通知模板"`
+		Addressee      []string `json:"addressee"      dc:"收信人"`
+	}
+	SceneCallWebService struct {
+		Url     string            `json:"url"     dc:"请求地址"`
+		Method  string            `json:"method"  dc:"请求方法，默认POST"`
+		Headers map[string]string `json:"headers" dc:"请求头"`
+		Body    string            `json:"body"    dc:"请求内容，支持模板变量，为空时发送触发数据"`
+		Timeout int               `json:"timeout" dc:"超时时间，单位秒，默认5秒"`
+	}
+	SceneTriggerAlarm struct {
+		Level uint `json:"level" dc:"告警级别"`
+	}
+	SceneDelayExecution struct {
+		Seconds int `json:"seconds" dc:"延迟时间，单位秒"`
+	}
+	SceneTriggerCustomEvent struct {
+		EventKey string         `json:"eventKey" dc:"事件标识"`
+		Data     map[string]any `json:"data"     dc:"事件数据"`
+	}
+	SceneAction struct {
+		ActionType   string                   `json:"actionType"             dc:"动作类型：deviceOutput、sendNotice、callWebService、triggerAlarm、delayExecution、triggerCustomEvent" v:"required#请选择动作类型"`
+		DeviceOutput *SceneDeviceOutput       `json:"deviceOutput,omitempty" dc:"设备输出"`
+		SendNotice   *SceneSendNotice         `json:"sendNotice,omitempty"   dc:"发送通知"`
+		WebService   *SceneCallWebService     `json:"webService,omitempty"   dc:"调用Web服务"`
+		TriggerAlarm *SceneTriggerAlarm       `json:"triggerAlarm,omitempty" dc:"触发告警"`
+		Delay        *SceneDelayExecution     `json:"delay,omitempty"        dc:"延迟执行"`
+		CustomEvent  *SceneTriggerCustomEvent `json:"customEvent,omitempty"  dc:"触发自定义事件"`
+	}
+	ScenePerformAction struct {
+		Action []SceneAction `json:"action" dc:"执行动作" v:"required#请添加执行动作"`
+	}
+)
+
+// 动作执行结果
+type SceneActionResult struct {
+	ActionType string `json:"actionType" dc:"动作类型"`
+	Success    bool   `json:"success"    dc:"是否成功"`
+	Message    string `json:"message"    dc:"执行信息"`
+}
+
+type SceneAddInput struct {
+	Name           string `json:"name" dc:"场景名称" v:"required#请输入场景名称"`
+	SceneType      string `json:"sceneType" dc:"场景类型：manual=手动触发，timer=定时触发，device=设备触发" v:"required|in:manual,timer,device#请选择场景类型|未知的场景类型"`
+	ProductKey     string `json:"productKey" dc:"产品标识" v:"required-if:sceneType,device#请选择产品"`
+	DeviceKey      string `json:"deviceKey" dc:"设备标识，为空或all时匹配产品下所有设备"`
+	TriggerType    string `json:"triggerType" dc:"触发类型：onLine、offLine、reportAttribute、reportEvent" v:"required-if:sceneType,device#请选择触发类型"`
+	EventKey       string `json:"eventKey" dc:"事件标识" v:"required-if:triggerType,reportEvent#请选择事件"`
+	CronExpression string `json:"cronExpression" dc:"定时表达式" v:"required-if:sceneType,timer#请输入定时表达式"`
+	Remark         string `json:"remark" dc:"备注"`
+	SceneTriggerCondition
+	ScenePerformAction
+}
+
+type SceneEditInput struct {
+	Id uint64 `json:"id" dc:"场景ID" v:"required#场景ID不能为空"`
+	SceneAddInput
+}
+
+type SceneOutput struct {
+	*entity.SceneDefinition
+
+	SceneTypeName   string `json:"sceneTypeName" dc:"场景类型"`
+	TriggerTypeName string `json:"triggerTypeName" dc:"触发类型"`
+
+	Condition     SceneTriggerCondition `json:"condition" dc:"触发条件"`
+	PerformAction ScenePerformAction    `json:"performAction" dc:"执行动作"`
+}
+
+type SceneListInput struct {
+	SceneType string `json:"sceneType" dc:"场景类型"`
+	Status    string `json:"status" dc:"状态"`
+	PaginationInput
+}
+type SceneListOutput struct {
+	List []SceneOutput `json:"list" dc:"场景列表"`
+	PaginationOutput
+}
+
+type SceneLogListInput struct {
+	SceneId uint64 `json:"sceneId" dc:"场景ID"`
+	Status  string `json:"status" dc:"执行结果"`
+	PaginationInput
+}
+type SceneLogOutput struct {
+	*entity.SceneLog
+	ActionResult []SceneActionResult `json:"actionResult" dc:"动作执行结果"`
+}
+type SceneLogListOutput struct {
+	List []SceneLogOutput `json:"list" dc:"场景执行日志"`
+	PaginationOutput
+}
+
+// SceneCronTask 定时场景的一次执行，由任务队列执行
+type SceneCronTask struct {
+	SceneId uint64 `json:"sceneId"`
+	FireAt  int64  `json:"fireAt"`
+}
@@ -139,3 +139,40 @@ type SysPluginsInfoOut struct {
 	Name       string `json:"name"                  description:"名称"`
 	Title      string `json:"title"                 description:"标题"`
 }
+
+// SysPluginsStatusRes 插件运行状态
+type SysPluginsStatusRes struct {
+	Types           string      `json:"types"           description:"插件类型"`
+	Name            string      `json:"name"            description:"名称"`
+	Title           string      `json:"title"           description:"标题"`
+	Path            string      `json:"path"            description:"插件文件路径"`
+	Status          string      `json:"status"          description:"运行状态 stopped:已停用 idle:未启动 running:运行中 crashed:已退出等待重启"`
+	StartedAt       *gtime.Time `json:"startedAt"       description:"进程启动时间"`
+	Restarts        int         `json:"restarts"        description:"重启次数"`
+	LastError       string      `json:"lastError"       description:"最近一次错误"`
+	LastErrorAt     *gtime.Time `json:"lastErrorAt"     description:"最近一次错误时间"`
+	DecodeTotal     int64       `json:"decodeTotal"     description:"解码次数"`
+	DecodeErrors    int64       `json:"decodeErrors"    description:"解码失败次数"`
+	DecodeErrorRate float64     `json:"decodeErrorRate" description:"解码失败率"`
+	EncodeTotal     int64       `json:"encodeTotal"     description:"编码次数"`
+	EncodeErrors    int64       `json:"encodeErrors"    description:"编码失败次数"`
+	EncodeErrorRate float64     `json:"encodeErrorRate" description:"编码失败率"`
+}
+
+type SysPluginsStatusOut struct {
+	Types           string      `json:"types"           description:"插件类型"`
+	Name            string      `json:"name"            description:"名称"`
+	Title           string      `json:"title"           description:"标题"`
+	Path            string      `json:"path"            description:"插件文件路径"`
+	Status          string      `json:"status"          description:"运行状态 stopped:已停用 idle:未启动 running:运行中 crashed:已退出等待重启"`
+	StartedAt       *gtime.Time `json:"startedAt"       description:"进程启动时间"`
+	Restarts        int         `json:"restarts"        description:"重启次数"`
+	LastError       string      `json:"lastError"       description:"最近一次错误"`
+	LastErrorAt     *gtime.Time `json:"lastErrorAt"     description:"最近一次错误时间"`
+	DecodeTotal     int64       `json:"decodeTotal"     description:"解码次数"`
+	DecodeErrors    int64       `json:"decodeErrors"    description:"解码失败次数"`
+	DecodeErrorRate float64     `json:"decodeErrorRate" description:"解码失败率"`
+	EncodeTotal     int64       `json:"encodeTotal"     description:"编码次数"`
+	EncodeErrors    int64       `json:"encodeErrors"    description:"编码失败次数"`
+	EncodeErrorRate float64     `json:"encodeErrorRate" description:"编码失败率"`
+}
@@ -0,0 +1,39 @@
+package queues
+
+import (
+	"context"
+	"encoding/json"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+	"sagooiot/pkg/worker"
+)
+
+var AlarmEscalationWorker = new(worker.Scheduled)
+
+// AlarmEscalationRun 告警升级通知，按升级策略延迟执行
+func AlarmEscalationRun() {
+	AlarmEscalationWorker = worker.RegisterProcess(AlarmEscalation)
+}
+
+// AlarmEscalation 告警升级通知
+var AlarmEscalation = &qAlarmEscalation{}
+
+type qAlarmEscalation struct{}
+
+// GetTopic 主题
+func (q *qAlarmEscalation) GetTopic() string {
+	return consts.QueueAlarmEscalationTopic
+}
+
+// Handle 处理消息
+func (q *qAlarmEscalation) Handle(ctx context.Context, p worker.Payload) (err error) {
+	if p.Payload == nil || q.GetTopic() != p.Group {
+		return nil
+	}
+	var data model.AlarmEscalationTask
+	if err = json.Unmarshal(p.Payload, &data); err != nil {
+		return err
+	}
+	return service.AlarmEscalation().Notify(ctx, &data)
+}
@@ -1,8 +1,9 @@
 package queues
 
 func Run() {
-	ScheduledDeviceAlarmLogRun()
 	ScheduledSysOperLogRun()
 	TaskDeviceDataTsdSaveRun()
 	DeviceInfoUpdateRun()
+	AlarmEscalationRun()
+	SceneCronRun()
 }
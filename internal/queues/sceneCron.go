@@ -0,0 +1,39 @@
+package queues
+
+import (
+	"context"
+	"encoding/json"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+	"sagooiot/pkg/worker"
+)
+
+var SceneCronWorker = new(worker.Scheduled)
+
+// SceneCronRun 定时场景执行，多个实例同时到达定时时间时只执行一次
+func SceneCronRun() {
+	SceneCronWorker = worker.RegisterProcess(SceneCron)
+}
+
+// SceneCron 定时场景执行
+var SceneCron = &qSceneCron{}
+
+type qSceneCron struct{}
+
+// GetTopic 主题
+func (q *qSceneCron) GetTopic() string {
+	return consts.QueueSceneCronTopic
+}
+
+// Handle 处理消息
+func (q *qSceneCron) Handle(ctx context.Context, p worker.Payload) (err error) {
+	if p.Payload == nil || q.GetTopic() != p.Group {
+		return nil
+	}
+	var data model.SceneCronTask
+	if err = json.Unmarshal(p.Payload, &data); err != nil {
+		return err
+	}
+	return service.Scene().RunCron(ctx, data.SceneId)
+}
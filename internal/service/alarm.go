@@ -11,6 +11,19 @@ import (
 )
 
 type (
+	IAlarmEscalation interface {
+		List(ctx context.Context, in *model.AlarmEscalationListInput) (out *model.AlarmEscalationListOutput, err error)
+		// Detail 获取升级策略详情
+		Detail(ctx context.Context, id uint64) (out *model.AlarmEscalationOutput, err error)
+		Add(ctx context.Context, in *model.AlarmEscalationAddInput) (err error)
+		Edit(ctx context.Context, in *model.AlarmEscalationEditInput) (err error)
+		// Del 删除升级策略，同时解除告警级别的关联，已排期的升级任务在执行时自动停止
+		Del(ctx context.Context, id uint64) (err error)
+		// Start 告警产生后按升级策略排期第一步通知，告警规则未设置策略时使用告警级别的策略
+		Start(ctx context.Context, rule model.AlarmRuleOutput, logId uint64, expression string, deviceKey string, param any) (err error)
+		// Notify 执行升级任务：告警仍未处理时通知当前步骤的收信人，并排期下一步或重复通知
+		Notify(ctx context.Context, task *model.AlarmEscalationTask) (err error)
+	}
 	IAlarmLevel interface {
 		Detail(ctx context.Context, level uint) (out model.AlarmLevelOutput, err error)
 		All(ctx context.Context) (out *model.AlarmLevelListOutput, err error)
@@ -21,9 +34,13 @@ type (
 		Add(ctx context.Context, in *model.AlarmLogAddInput) (id uint64, err error)
 		List(ctx context.Context, in *model.AlarmLogListInput) (out *model.AlarmLogListOutput, err error)
 		Handle(ctx context.Context, in *model.AlarmLogHandleInput) (err error)
+		// Recover 告警恢复，记录恢复时间和持续时长，未处理的告警自动关闭
+		Recover(ctx context.Context, id uint64) (out *model.AlarmLogOutput, err error)
 		TotalForLevel(ctx context.Context) (total []model.AlarmLogLevelTotal, err error)
 		// ClearLogByDays 按日期删除日志
 		ClearLogByDays(ctx context.Context, days int) (err error)
+		// ActionLog 获取告警的设备控制动作执行记录
+		ActionLog(ctx context.Context, logId uint64) (out *model.AlarmActionLogListOutput, err error)
 	}
 	IAlarmRule interface {
 		List(ctx context.Context, in *model.AlarmRuleListInput) (out *model.AlarmRuleListOutput, err error)
@@ -42,8 +59,8 @@ type (
 		Operator(ctx context.Context) (out []model.OperatorOutput, err error)
 		TriggerType(ctx context.Context, productKey string) (out []model.TriggerTypeOutput, err error)
 		TriggerParam(ctx context.Context, productKey string, triggerType int, eventKey ...string) (out []model.TriggerParamOutput, err error)
-		// NoticeAction 执行告警通知
-		NoticeAction(ctx context.Context, rule model.AlarmRuleOutput, expression string, deviceKey string, param any)
+		// NoticeAction 执行告警通知，并按升级策略排期后续的升级通知
+		NoticeAction(ctx context.Context, rule model.AlarmRuleOutput, logId uint64, expression string, deviceKey string, param any)
 		// Check 告警检测
 		Check(ctx context.Context, productKey string, deviceKey string, triggerType int, param any, subKey ...string) (err error)
 		// AddCronRule 添加定时触发规则
@@ -54,11 +71,23 @@ type (
 )
 
 var (
-	localAlarmLevel IAlarmLevel
-	localAlarmLog   IAlarmLog
-	localAlarmRule  IAlarmRule
+	localAlarmEscalation IAlarmEscalation
+	localAlarmLevel      IAlarmLevel
+	localAlarmLog        IAlarmLog
+	localAlarmRule       IAlarmRule
 )
 
+func AlarmEscalation() IAlarmEscalation {
+	if localAlarmEscalation == nil {
+		panic("implement not found for interface IAlarmEscalation, forgot register?")
+	}
+	return localAlarmEscalation
+}
+
+func RegisterAlarmEscalation(i IAlarmEscalation) {
+	localAlarmEscalation = i
+}
+
 func AlarmLevel() IAlarmLevel {
 	if localAlarmLevel == nil {
 		panic("implement not found for interface IAlarmLevel, forgot register?")
@@ -44,6 +44,8 @@ type (
 	}
 	IAnalysisDeviceDataTsd interface {
 		GetDeviceData(ctx context.Context, reqData general.SelectReq) (rs []interface{}, err error)
+		// Query 按查询条件查询时序数据，支持聚合、时间窗口和空窗口填充
+		Query(ctx context.Context, in *model.TsdQueryInput) (out *model.TsdQueryOutput, err error)
 	}
 	IAnalysisProduct interface {
 		// GetDeviceCountForProduct 获取产品下的设备数量
@@ -11,6 +11,28 @@ import (
 )
 
 type (
+	INetworkNorth interface {
+		// GetSinkList 获取北向消息出口列表
+		GetSinkList(ctx context.Context, in *model.GetNetworkNorthSinkListInput) (total int, out []*model.NetworkNorthSinkOut, err error)
+		// GetSinkById 获取指定ID的北向消息出口
+		GetSinkById(ctx context.Context, id int) (out *model.NetworkNorthSinkOut, err error)
+		// AddSink 添加北向消息出口
+		AddSink(ctx context.Context, in model.NetworkNorthSinkAddInput) (err error)
+		// EditSink 修改北向消息出口
+		EditSink(ctx context.Context, in model.NetworkNorthSinkEditInput) (err error)
+		// DeleteSink 删除北向消息出口
+		DeleteSink(ctx context.Context, ids []int) (err error)
+		// SetSinkStatus 修改北向消息出口状态
+		SetSinkStatus(ctx context.Context, id int, status int) (err error)
+		// LoadNorthSinks 加载启用的北向消息出口
+		LoadNorthSinks(ctx context.Context) (err error)
+		// GetDeadLetterList 获取投递失败的北向消息列表
+		GetDeadLetterList(ctx context.Context, in *model.GetNetworkNorthDeadLetterListInput) (total int, out []*model.NetworkNorthDeadLetterOut, err error)
+		// RetryDeadLetter 重新投递失败的北向消息，投递成功后删除
+		RetryDeadLetter(ctx context.Context, ids []int) (err error)
+		// DeleteDeadLetter 删除投递失败的北向消息
+		DeleteDeadLetter(ctx context.Context, ids []int) (err error)
+	}
 	INetworkServer interface {
 		// GetServerList 获取列表数据
 		GetServerList(ctx context.Context, in *model.GetNetworkServerListInput) (total int, out []*model.NetworkServerOut, err error)
@@ -48,10 +70,22 @@ type (
 )
 
 var (
+	localNetworkNorth  INetworkNorth
 	localNetworkServer INetworkServer
 	localNetworkTunnel INetworkTunnel
 )
 
+func NetworkNorth() INetworkNorth {
+	if localNetworkNorth == nil {
+		panic("implement not found for interface INetworkNorth, forgot register?")
+	}
+	return localNetworkNorth
+}
+
+func RegisterNetworkNorth(i INetworkNorth) {
+	localNetworkNorth = i
+}
+
 func NetworkServer() INetworkServer {
 	if localNetworkServer == nil {
 		panic("implement not found for interface INetworkServer, forgot register?")
@@ -63,6 +63,8 @@ type (
 		SaveNoticeTemplate(ctx context.Context, in model.NoticeTemplateAddInput) (err error)
 		// DeleteNoticeTemplate 删除数据
 		DeleteNoticeTemplate(ctx context.Context, Ids []string) (err error)
+		// Send 按通知模板发送通知并记录通知日志，通知配置了内置通道时直接发送，否则使用通知插件发送
+		Send(ctx context.Context, tpl *model.NoticeTemplateOutput, addressee []string, content string) (err error)
 	}
 )
 
@@ -28,6 +28,21 @@ type (
 		Edit(ctx context.Context, in *model.EditProductCategoryInput) (err error)
 		Del(ctx context.Context, id uint) (err error)
 	}
+	IDevConfig interface {
+		// Save 保存设备远程配置，每次保存生成新的版本
+		Save(ctx context.Context, in *model.DeviceConfigSaveInput) (out *model.DeviceConfigOutput, err error)
+		Detail(ctx context.Context, in *model.DeviceConfigDetailInput) (out *model.DeviceConfigOutput, err error)
+		History(ctx context.Context, in *model.DeviceConfigHistoryInput) (out *model.DeviceConfigHistoryOutput, err error)
+		// Effective 设备生效的配置，设备配置优先于产品配置
+		Effective(ctx context.Context, productKey string, deviceKey string) (out *model.DeviceConfigOutput, err error)
+		// Push 向设备下发配置，未指定设备时下发给产品的全部在线设备
+		Push(ctx context.Context, in *model.DeviceConfigPushInput) (out *model.DeviceConfigPushOutput, err error)
+		PushList(ctx context.Context, in *model.DeviceConfigPushListInput) (out *model.DeviceConfigPushListOutput, err error)
+		// Reply 设备响应配置下发
+		Reply(ctx context.Context, in *model.DeviceConfigReplyInput) (err error)
+		// Get 设备主动请求配置，没有配置时返回nil
+		Get(ctx context.Context, deviceKey string, scope string) (out *sagooProtocol.ConfigGetResponseData, err error)
+	}
 	IDevDataReport interface {
 		// Event 设备事件上报
 		Event(ctx context.Context, deviceKey string, data model.ReportEventData, subKey ...string) error
@@ -37,6 +52,8 @@ type (
 	IDevDevice interface {
 		// Get 获取设备详情
 		Get(ctx context.Context, key string) (out *model.DeviceOutput, err error)
+		// CheckDataScope 校验设备存在，且在当前用户的数据权限内
+		CheckDataScope(ctx context.Context, key string) (err error)
 		// GetAll 获取所有设备
 		GetAll(ctx context.Context) (out []*entity.DevDevice, err error)
 		Detail(ctx context.Context, key string) (out *model.DeviceOutput, err error)
@@ -109,6 +126,28 @@ type (
 		// Set 设备属性设置
 		Set(ctx context.Context, in *model.DevicePropertyInput) (out *model.DevicePropertyOutput, err error)
 	}
+	IDevDeviceRequest interface {
+		// Add 记录同步请求
+		Add(ctx context.Context, in *model.DeviceRequestAddInput) (err error)
+		// Reply 记录设备响应
+		Reply(ctx context.Context, requestId string, reply interface{}) (err error)
+		// Fail 记录请求超时或失败
+		Fail(ctx context.Context, requestId string, status int, message string) (err error)
+		// List 同步请求记录列表
+		List(ctx context.Context, in *model.DeviceRequestListInput) (out *model.DeviceRequestListOutput, err error)
+	}
+	IDevDeviceShadow interface {
+		// Get 获取设备影子，设备没有影子时返回空的影子
+		Get(ctx context.Context, deviceKey string) (out *model.DeviceShadow, err error)
+		// Update 更新期望属性，设备在线时立即下发差异
+		Update(ctx context.Context, in *model.DeviceShadowUpdateInput) (out *model.DeviceShadow, err error)
+		// Delete 删除期望属性或整个设备影子
+		Delete(ctx context.Context, in *model.DeviceShadowDeleteInput) (err error)
+		// Report 记录设备上报属性，存在未下发的差异时下发
+		Report(ctx context.Context, deviceKey string, data iotModel.ReportPropertyData) (err error)
+		// Sync 通过属性设置下发期望属性与上报属性的差异
+		Sync(ctx context.Context, deviceKey string) (err error)
+	}
 	IDevDeviceTag interface {
 		Add(ctx context.Context, in *model.AddTagDeviceInput) (err error)
 		Edit(ctx context.Context, in *model.EditTagDeviceInput) (err error)
@@ -128,6 +167,35 @@ type (
 		Edit(ctx context.Context, in *model.EditDeviceTreeInfoInput) error
 		// Del 删除设备树基本信息
 		Del(ctx context.Context, infoId int) error
+		// GetDeviceKeys 获取节点及下级节点绑定的设备标识
+		GetDeviceKeys(ctx context.Context, infoId int) (keys []string, err error)
+	}
+	IDevFirmware interface {
+		// Add 添加固件，计算签名后通过上传服务保存固件文件
+		Add(ctx context.Context, in *model.FirmwareAddInput, file *ghttp.UploadFile) (err error)
+		Edit(ctx context.Context, in *model.FirmwareEditInput) (err error)
+		Del(ctx context.Context, id uint64) (err error)
+		Detail(ctx context.Context, id uint64) (out *model.FirmwareOutput, err error)
+		List(ctx context.Context, in *model.FirmwareListInput) (out *model.FirmwareListOutput, err error)
+		// AddTask 创建升级任务，按升级范围生成设备升级记录
+		AddTask(ctx context.Context, in *model.FirmwareTaskAddInput) (id uint64, err error)
+		TaskDetail(ctx context.Context, id uint64) (out *model.FirmwareTaskOutput, err error)
+		TaskList(ctx context.Context, in *model.FirmwareTaskListInput) (out *model.FirmwareTaskListOutput, err error)
+		// StartTask 启动升级任务，按批次推送升级包
+		StartTask(ctx context.Context, id uint64) (err error)
+		// CancelTask 取消升级任务，未完成的设备升级记录标记为已取消
+		CancelTask(ctx context.Context, id uint64) (err error)
+		DeviceList(ctx context.Context, in *model.FirmwareDeviceListInput) (out *model.FirmwareDeviceListOutput, err error)
+		// ResumeTasks 服务启动时恢复执行中的升级任务
+		ResumeTasks(ctx context.Context) (err error)
+		// Inform 设备上报版本，更新设备版本号并确认升级结果
+		Inform(ctx context.Context, in *model.FirmwareInformInput) (err error)
+		// Progress 设备上报升级进度
+		Progress(ctx context.Context, in *model.FirmwareProgressInput) (err error)
+		// Pending 获取设备待升级的升级包信息，没有时返回nil
+		Pending(ctx context.Context, deviceKey string, module string) (out *sagooProtocol.UpgradeCommandRequestData, err error)
+		// ReadBlock 读取固件文件分片
+		ReadBlock(ctx context.Context, productKey, deviceKey string, firmwareId uint64, offset int64, size int64) (out *sagooProtocol.FileDownloadData, err error)
 	}
 	IDevInit interface {
 		// InitProductForTd 产品表结构初始化
@@ -153,6 +221,8 @@ type (
 		ListForSub(ctx context.Context) (list []*model.ProductOutput, err error)
 		// UpdateScriptInfo 脚本更新
 		UpdateScriptInfo(ctx context.Context, in *model.ScriptInfoInput) (err error)
+		// DebugScript 脚本调试，使用样例数据执行脚本的解析或编码函数
+		DebugScript(ctx context.Context, in *model.ScriptDebugInput) (out *model.ScriptDebugOutput, err error)
 		// ConnectIntro 获取设备接入信息
 		ConnectIntro(ctx context.Context, productKey string) (out *model.DeviceConnectIntroOutput, err error)
 	}
@@ -205,13 +275,17 @@ type (
 
 var (
 	localDevCategory       IDevCategory
+	localDevConfig         IDevConfig
 	localDevDataReport     IDevDataReport
 	localDevDevice         IDevDevice
 	localDevDeviceFunction IDevDeviceFunction
 	localDevDeviceLog      IDevDeviceLog
 	localDevDeviceProperty IDevDeviceProperty
+	localDevDeviceRequest  IDevDeviceRequest
+	localDevDeviceShadow   IDevDeviceShadow
 	localDevDeviceTag      IDevDeviceTag
 	localDevDeviceTree     IDevDeviceTree
+	localDevFirmware       IDevFirmware
 	localDevInit           IDevInit
 	localDevProduct        IDevProduct
 	localDevTSLDataType    IDevTSLDataType
@@ -234,6 +308,17 @@ func RegisterDevCategory(i IDevCategory) {
 	localDevCategory = i
 }
 
+func DevConfig() IDevConfig {
+	if localDevConfig == nil {
+		panic("implement not found for interface IDevConfig, forgot register?")
+	}
+	return localDevConfig
+}
+
+func RegisterDevConfig(i IDevConfig) {
+	localDevConfig = i
+}
+
 func DevDataReport() IDevDataReport {
 	if localDevDataReport == nil {
 		panic("implement not found for interface IDevDataReport, forgot register?")
@@ -289,6 +374,28 @@ func RegisterDevDeviceProperty(i IDevDeviceProperty) {
 	localDevDeviceProperty = i
 }
 
+func DevDeviceRequest() IDevDeviceRequest {
+	if localDevDeviceRequest == nil {
+		panic("implement not found for interface IDevDeviceRequest, forgot register?")
+	}
+	return localDevDeviceRequest
+}
+
+func RegisterDevDeviceRequest(i IDevDeviceRequest) {
+	localDevDeviceRequest = i
+}
+
+func DevDeviceShadow() IDevDeviceShadow {
+	if localDevDeviceShadow == nil {
+		panic("implement not found for interface IDevDeviceShadow, forgot register?")
+	}
+	return localDevDeviceShadow
+}
+
+func RegisterDevDeviceShadow(i IDevDeviceShadow) {
+	localDevDeviceShadow = i
+}
+
 func DevDeviceTag() IDevDeviceTag {
 	if localDevDeviceTag == nil {
 		panic("implement not found for interface IDevDeviceTag, forgot register?")
@@ -311,6 +418,17 @@ func RegisterDevDeviceTree(i IDevDeviceTree) {
 	localDevDeviceTree = i
 }
 
+func DevFirmware() IDevFirmware {
+	if localDevFirmware == nil {
+		panic("implement not found for interface IDevFirmware, forgot register?")
+	}
+	return localDevFirmware
+}
+
+func RegisterDevFirmware(i IDevFirmware) {
+	localDevFirmware = i
+}
+
 func DevInit() IDevInit {
 	if localDevInit == nil {
 		panic("implement not found for interface IDevInit, forgot register?")
@@ -330,6 +330,8 @@ type (
 		EditStatus(ctx context.Context, id int, status int) (err error)
 		// GetSysPluginsTypesAll 获取所有插件的通信方式类型
 		GetSysPluginsTypesAll(ctx context.Context, types string) (out []*model.SysPluginsInfoOut, err error)
+		// GetSysPluginsStatus 获取插件的运行状态，包括重启次数和编解码失败率
+		GetSysPluginsStatus(ctx context.Context, types string) (out []*model.SysPluginsStatusOut, err error)
 	}
 	ISystemPluginsConfig interface {
 		// GetPluginsConfigList 获取列表数据
@@ -385,6 +387,8 @@ type (
 		GetInfoByIds(ctx context.Context, id []int) (entity []*entity.SysRole, err error)
 		// DataScope 角色数据授权
 		DataScope(ctx context.Context, id int, dataScope uint, deptIds []int64) (err error)
+		// GetDataScope 获取用户的数据权限，all为true时拥有全部数据权限，否则只能访问deptIds中的部门数据
+		GetDataScope(ctx context.Context, userId int, deptId int) (all bool, deptIds []int64, err error)
 		GetAuthorizeById(ctx context.Context, id int) (menuIds []string, menuButtonIds []string, menuColumnIds []string, menuApiIds []string, err error)
 	}
 	ISysRoleDept interface {
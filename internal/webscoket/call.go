@@ -1,6 +1,7 @@
 package webscoket
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/gogf/gf/v2/encoding/gjson"
@@ -8,6 +9,7 @@ import (
 	"github.com/gogf/gf/v2/os/gctx"
 	"github.com/gogf/gf/v2/text/gstr"
 	"sagooiot/internal/webscoket/common"
+	"sagooiot/internal/webscoket/device"
 	"sagooiot/internal/webscoket/sys"
 )
 
@@ -22,23 +24,31 @@ var logic = map[string]map[string]func(ctx *common.WorkContext) (g.Map, error){
 		"sync":  sys.Sync,
 		"point": sys.Point,
 	},
+	"device": {
+		"subscribe":   device.Subscribe,
+		"unsubscribe": device.Unsubscribe,
+		"list":        device.List,
+	},
 }
 
-func Call(unique interface{}, data *gjson.Json) g.Map {
+// Call 执行客户端的命令，connCtx为连接的上下文，push用于向连接主动推送消息
+func Call(connCtx context.Context, unique interface{}, data *gjson.Json, push func(data g.Map)) g.Map {
 	cmd := data.Get("cmd", "").String()
-	context := &common.WorkContext{
+	work := &common.WorkContext{
+		Ctx: connCtx,
 		Data: common.Medium{
 			Cmd:      cmd,
 			Unique:   unique,
 			Original: data,
 		},
+		Push: push,
 	}
 	var callBack g.Map
 	if gstr.Contains(cmd, ".") {
 		command := gstr.Explode(".", cmd)
 		back := g.Map{}
 		for _, cmd := range command[1:] {
-			call, err := callFunc(command[0], cmd, context)
+			call, err := callFunc(command[0], cmd, work)
 			if err != nil {
 				g.Log().Debug(ctx, command[0]+"."+cmd+" error:"+err.Error())
 				back[command[0]+"."+cmd] = g.Map{
@@ -50,7 +60,7 @@ func Call(unique interface{}, data *gjson.Json) g.Map {
 		}
 		callBack = back
 	} else {
-		callBack = callAllFunc(cmd, context)
+		callBack = callAllFunc(cmd, work)
 	}
 	return callBack
 }
@@ -83,6 +93,11 @@ func callAllFunc(alias string, context *common.WorkContext) g.Map {
 	return back
 }
 
+// Close 连接关闭时清理连接的数据
+func Close(unique string) {
+	device.Remove(unique)
+}
+
 func runRecover(err error) {
 	g.Log().Cat("logic").Error(ctx, err)
 }
@@ -1,12 +1,16 @@
 package common
 
 import (
+	"context"
 	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/frame/g"
 )
 
 // 公共struct
 type WorkContext struct {
+	Ctx  context.Context // 连接的上下文，包含登录用户信息
 	Data Medium
+	Push func(data g.Map) // 向连接主动推送消息
 }
 type Medium struct {
 	Cmd      string
@@ -0,0 +1,355 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/guid"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+	"sagooiot/internal/webscoket/common"
+	"sagooiot/internal/webscoket/realtime"
+	"sagooiot/pkg/dcache"
+)
+
+// 设备实时数据订阅
+// 客户端按设备、产品或设备树节点订阅属性、事件、上下线和告警数据。订阅时校验用户对设备、产品或节点的数据权限，
+// 推送前再按设备所属部门过滤，连接关闭时清理订阅。
+
+const (
+	maxSubscriptions = 100   // 单个连接的最大订阅数
+	eventQueueSize   = 10000 // 待推送的实时数据队列长度
+)
+
+var eventTypes = []string{realtime.TypeProperty, realtime.TypeEvent, realtime.TypeStatus, realtime.TypeAlarm}
+
+// subscription 一个订阅，deviceKey、productKey、nodeId 只指定其中一个
+type subscription struct {
+	Id         string   `json:"id"`
+	Types      []string `json:"types"`
+	DeviceKey  string   `json:"deviceKey,omitempty"`
+	ProductKey string   `json:"productKey,omitempty"`
+	NodeId     int      `json:"nodeId,omitempty"`
+
+	deviceKeys map[string]struct{} // 设备树节点及下级节点绑定的设备，订阅时确定
+}
+
+func (s *subscription) match(e realtime.Event) bool {
+	matched := false
+	for _, t := range s.Types {
+		if t == e.Type {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	switch {
+	case s.DeviceKey != "":
+		return s.DeviceKey == e.DeviceKey
+	case s.ProductKey != "":
+		return s.ProductKey == e.ProductKey
+	default:
+		_, ok := s.deviceKeys[e.DeviceKey]
+		return ok
+	}
+}
+
+// scope 用户的数据权限
+type scope struct {
+	all     bool
+	deptIds map[int]struct{}
+}
+
+func (s *scope) allow(deptId int) bool {
+	if s.all {
+		return true
+	}
+	_, ok := s.deptIds[deptId]
+	return ok
+}
+
+// client 一个连接的订阅
+type client struct {
+	sync.RWMutex
+	push   func(data g.Map)
+	scope  *scope
+	subs   map[string]*subscription
+	closed bool // 连接已关闭，不再接受订阅
+}
+
+// match 返回匹配实时数据的订阅ID
+func (c *client) match(e realtime.Event) (ids []string) {
+	c.RLock()
+	defer c.RUnlock()
+	for id, sub := range c.subs {
+		if sub.match(e) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return
+}
+
+var (
+	clients    = gmap.NewStrAnyMap(true)
+	listenOnce sync.Once
+)
+
+// Subscribe 订阅设备实时数据，id相同时替换原有订阅
+func Subscribe(ctx *common.WorkContext) (g.Map, error) {
+	data := ctx.Data.Original
+	sub := &subscription{
+		Id:         data.Get("id").String(),
+		Types:      data.Get("types").Strings(),
+		DeviceKey:  data.Get("deviceKey").String(),
+		ProductKey: data.Get("productKey").String(),
+		NodeId:     data.Get("nodeId").Int(),
+	}
+	if len(sub.Types) == 0 {
+		sub.Types = eventTypes
+	}
+	for _, t := range sub.Types {
+		if !isEventType(t) {
+			return nil, fmt.Errorf("不支持的订阅类型：%s", t)
+		}
+	}
+	targets := 0
+	for _, ok := range []bool{sub.DeviceKey != "", sub.ProductKey != "", sub.NodeId > 0} {
+		if ok {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return nil, errors.New("deviceKey、productKey、nodeId 需要且只能指定一个")
+	}
+	if sub.Id == "" {
+		sub.Id = guid.S()
+	}
+
+	c, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = authorize(ctx.Ctx, c.scope, sub); err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	// 校验权限期间连接已关闭，订阅已被清理
+	if c.closed {
+		return nil, errors.New("连接已关闭")
+	}
+	_, exists := c.subs[sub.Id]
+	if !exists && len(c.subs) >= maxSubscriptions {
+		return nil, fmt.Errorf("订阅数量不能超过%d个", maxSubscriptions)
+	}
+	c.subs[sub.Id] = sub
+	if !exists {
+		realtime.Subscribed(ctx.Ctx, 1)
+	}
+	startDispatch()
+	return g.Map{"id": sub.Id}, nil
+}
+
+// Unsubscribe 取消订阅，不指定id时取消连接的全部订阅
+func Unsubscribe(ctx *common.WorkContext) (g.Map, error) {
+	id := ctx.Data.Original.Get("id").String()
+	ids := make([]string, 0)
+	v := clients.Get(gconv.String(ctx.Data.Unique))
+	if v == nil {
+		if id != "" {
+			return nil, errors.New("订阅不存在")
+		}
+		return g.Map{"ids": ids}, nil
+	}
+	c := v.(*client)
+	c.Lock()
+	defer c.Unlock()
+	if id == "" {
+		for subId := range c.subs {
+			ids = append(ids, subId)
+		}
+		sort.Strings(ids)
+		c.subs = make(map[string]*subscription)
+		realtime.Subscribed(ctx.Ctx, -len(ids))
+		return g.Map{"ids": ids}, nil
+	}
+	if _, ok := c.subs[id]; !ok {
+		return nil, errors.New("订阅不存在")
+	}
+	delete(c.subs, id)
+	realtime.Subscribed(ctx.Ctx, -1)
+	return g.Map{"ids": append(ids, id)}, nil
+}
+
+// List 连接的订阅列表
+func List(ctx *common.WorkContext) (g.Map, error) {
+	list := make([]*subscription, 0)
+	if v := clients.Get(gconv.String(ctx.Data.Unique)); v != nil {
+		c := v.(*client)
+		c.RLock()
+		for _, sub := range c.subs {
+			list = append(list, sub)
+		}
+		c.RUnlock()
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+	return g.Map{"list": list}, nil
+}
+
+// Remove 连接关闭时清理订阅
+func Remove(unique string) {
+	v := clients.Remove(unique)
+	if v == nil {
+		return
+	}
+	c := v.(*client)
+	c.Lock()
+	defer c.Unlock()
+	realtime.Subscribed(context.Background(), -len(c.subs))
+	c.subs = make(map[string]*subscription)
+	c.closed = true
+}
+
+func isEventType(t string) bool {
+	for _, v := range eventTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// getClient 获取连接的订阅，第一次订阅时加载用户的数据权限
+func getClient(ctx *common.WorkContext) (*client, error) {
+	unique := gconv.String(ctx.Data.Unique)
+	if v := clients.Get(unique); v != nil {
+		return v.(*client), nil
+	}
+	if ctx.Ctx == nil || ctx.Ctx.Err() != nil || ctx.Push == nil {
+		return nil, errors.New("连接已关闭")
+	}
+	user := service.Context().GetLoginUser(ctx.Ctx)
+	if user == nil {
+		return nil, errors.New("用户未登录")
+	}
+	all, deptIds, err := service.SysRole().GetDataScope(ctx.Ctx, user.Id, user.DeptId)
+	if err != nil {
+		return nil, err
+	}
+	s := &scope{all: all, deptIds: make(map[int]struct{}, len(deptIds))}
+	for _, id := range deptIds {
+		s.deptIds[int(id)] = struct{}{}
+	}
+	v := clients.GetOrSetFunc(unique, func() interface{} {
+		return &client{push: ctx.Push, scope: s, subs: make(map[string]*subscription)}
+	})
+	return v.(*client), nil
+}
+
+// authorize 校验订阅对象的数据权限，设备树节点在这里确定订阅的设备
+func authorize(ctx context.Context, s *scope, sub *subscription) error {
+	switch {
+	case sub.DeviceKey != "":
+		device, err := service.DevDevice().Get(ctx, sub.DeviceKey)
+		if err != nil {
+			return err
+		}
+		if !s.allow(device.DeptId) {
+			return errors.New("无权限订阅该设备")
+		}
+	case sub.ProductKey != "":
+		product, err := service.DevProduct().Detail(ctx, sub.ProductKey)
+		if err != nil {
+			return err
+		}
+		if product == nil {
+			return errors.New("产品不存在")
+		}
+		if !s.allow(product.DeptId) {
+			return errors.New("无权限订阅该产品")
+		}
+	default:
+		node, err := service.DevDeviceTree().Detail(ctx, sub.NodeId)
+		if err != nil {
+			return err
+		}
+		if node == nil {
+			return errors.New("设备树节点不存在")
+		}
+		if !s.allow(node.DeptId) {
+			return errors.New("无权限订阅该节点")
+		}
+		keys, err := service.DevDeviceTree().GetDeviceKeys(ctx, sub.NodeId)
+		if err != nil {
+			return err
+		}
+		sub.deviceKeys = make(map[string]struct{}, len(keys))
+		for _, key := range keys {
+			sub.deviceKeys[key] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// startDispatch 有订阅后开始接收实时数据，由一个协程按顺序推送，队列满时丢弃，不阻塞设备数据的处理
+func startDispatch() {
+	listenOnce.Do(func() {
+		ctx := gctx.New()
+		events := make(chan realtime.Event, eventQueueSize)
+		realtime.Listen(ctx, func(e realtime.Event) {
+			select {
+			case events <- e:
+			default:
+				g.Log().Debugf(ctx, "realtime event queue is full, %s of %s dropped", e.Type, e.DeviceKey)
+			}
+		})
+		go func() {
+			for e := range events {
+				dispatch(e)
+			}
+		}()
+	})
+}
+
+func dispatch(e realtime.Event) {
+	var (
+		device *model.DeviceOutput
+		loaded bool
+	)
+	clients.Iterator(func(_ string, v interface{}) bool {
+		c := v.(*client)
+		ids := c.match(e)
+		if len(ids) == 0 {
+			return true
+		}
+		if !loaded {
+			loaded = true
+			device, _ = dcache.GetDeviceDetailInfo(e.DeviceKey)
+		}
+		if device == nil || !c.scope.allow(device.DeptId) {
+			return true
+		}
+		c.push(g.Map{
+			"device.push": g.Map{
+				"ids":        ids,
+				"type":       e.Type,
+				"productKey": e.ProductKey,
+				"deviceKey":  e.DeviceKey,
+				"data":       e.Data,
+				"timestamp":  e.Timestamp,
+			},
+		})
+		return true
+	})
+}
@@ -0,0 +1,39 @@
+package device
+
+import (
+	"sagooiot/internal/webscoket/realtime"
+	"testing"
+)
+
+func TestSubscriptionMatch(t *testing.T) {
+	property := realtime.Event{Type: realtime.TypeProperty, ProductKey: "p1", DeviceKey: "d1"}
+	alarm := realtime.Event{Type: realtime.TypeAlarm, ProductKey: "p1", DeviceKey: "d2"}
+
+	byDevice := &subscription{Types: []string{realtime.TypeProperty}, DeviceKey: "d1"}
+	if !byDevice.match(property) || byDevice.match(alarm) {
+		t.Fatal("device subscription mismatch")
+	}
+	byProduct := &subscription{Types: eventTypes, ProductKey: "p1"}
+	if !byProduct.match(property) || !byProduct.match(alarm) {
+		t.Fatal("product subscription mismatch")
+	}
+	byNode := &subscription{Types: eventTypes, NodeId: 1, deviceKeys: map[string]struct{}{"d2": {}}}
+	if byNode.match(property) || !byNode.match(alarm) {
+		t.Fatal("node subscription mismatch")
+	}
+
+	c := &client{subs: map[string]*subscription{"b": byProduct, "a": byDevice, "c": byNode}}
+	if ids := c.match(property); len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Fatalf("unexpected ids %v", ids)
+	}
+}
+
+func TestScopeAllow(t *testing.T) {
+	if !(&scope{all: true}).allow(10) {
+		t.Fatal("all scope denied")
+	}
+	s := &scope{deptIds: map[int]struct{}{1: {}, 2: {}}}
+	if !s.allow(2) || s.allow(3) {
+		t.Fatal("dept scope mismatch")
+	}
+}
@@ -8,17 +8,18 @@ import (
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/os/gctx"
-	"github.com/gogf/gf/v2/os/gmlock"
 	"github.com/gogf/gf/v2/os/grpool"
 	"github.com/gogf/gf/v2/os/gtimer"
+	"github.com/gogf/gf/v2/util/guid"
 	"github.com/gorilla/websocket"
 	"sagooiot/internal/webscoket"
+	"sync"
 	"time"
 )
 
 type Connection struct {
 	Socket    *ghttp.WebSocket
-	closed    bool
+	closeOnce sync.Once
 	Unique    string
 	writeCh   chan []byte
 	Ctx       context.Context
@@ -26,6 +27,11 @@ type Connection struct {
 	Heartbeat *gtimer.Entry
 }
 
+const (
+	heartbeatTimeout = time.Minute // 超过心跳时间没有收到消息时关闭连接
+	writeBufferSize  = 100         // 待发送消息的缓冲数量
+)
+
 var (
 	WebsocketManager *gmap.Map
 	workPool         *grpool.Pool
@@ -38,21 +44,44 @@ func init() {
 	workPool = grpool.New(100)
 }
 
-// 关闭连接
-func (c *Connection) Close() {
-	if gmlock.TryLock(c.Unique) {
-		if false == c.closed {
-			_ = c.Socket.Close()
-			c.closed = true
-			//必须优先关闭读写协程
-			c.CtxClose()
-			close(c.writeCh)
-			c.Heartbeat.Close()
-			WebsocketManager.Remove(c.Unique)
-			g.Log().Debug(ctx, "remove:"+c.Unique)
-		}
-		gmlock.Unlock(c.Unique)
+// Handler websocket连接入口，需要登录后访问
+func Handler(r *ghttp.Request) {
+	ws, err := r.WebSocket()
+	if err != nil {
+		g.Log().Debug(ctx, "websocket upgrade error:"+err.Error())
+		r.Exit()
+		return
 	}
+	connCtx, cancel := context.WithCancel(r.Context())
+	c := &Connection{
+		Socket:   ws,
+		Unique:   guid.S(),
+		writeCh:  make(chan []byte, writeBufferSize),
+		Ctx:      connCtx,
+		CtxClose: cancel,
+	}
+	c.Heartbeat = gtimer.AddOnce(ctx, heartbeatTimeout, func(ctx context.Context) {
+		c.Close()
+	})
+	WebsocketManager.Set(c.Unique, c)
+	g.Log().Debug(ctx, "add:"+c.Unique)
+
+	go c.write()
+	c.read()
+}
+
+// 关闭连接，写通道不关闭，发送方通过 Ctx 判断连接是否已关闭
+func (c *Connection) Close() {
+	c.closeOnce.Do(func() {
+		//必须优先关闭读写协程
+		c.CtxClose()
+		_ = c.Socket.Close()
+		//清理订阅，停止推送消息
+		webscoket.Close(c.Unique)
+		c.Heartbeat.Close()
+		WebsocketManager.Remove(c.Unique)
+		g.Log().Debug(ctx, "remove:"+c.Unique)
+	})
 }
 
 // 连接读-同步响应
@@ -62,9 +91,6 @@ func (c *Connection) read() {
 		case <-c.Ctx.Done():
 			goto ERR
 		default:
-			if c.closed {
-				goto ERR
-			}
 			_, msg, err := c.Socket.ReadMessage()
 			if err != nil {
 				goto ERR
@@ -80,7 +106,7 @@ func (c *Connection) read() {
 			//client有消息上来重置心跳
 			c.Heartbeat.Reset()
 			err = workPool.AddWithRecover(ctx, func(ctx context.Context) {
-				callBack := webscoket.Call(c.Unique, data)
+				callBack := webscoket.Call(c.Ctx, c.Unique, data, c.push)
 				if len(callBack) > 0 {
 					c.WriteMsg(c.Pack(callBack))
 				}
@@ -123,11 +149,25 @@ func (c *Connection) Pack(data g.Map) []byte {
 	return encode
 }
 func (c *Connection) WriteMsg(msg []byte) {
-	//未关闭连接则发送消息，已关闭连接则后台服务关闭此连接
-	if !c.closed {
-		c.writeCh <- msg
-	} else {
-		c.Close()
+	//未关闭连接则发送消息，已关闭连接时放弃
+	select {
+	case <-c.Ctx.Done():
+	case c.writeCh <- msg:
+	}
+}
+
+// push 主动推送消息，发送缓冲已满时丢弃，不阻塞推送方
+func (c *Connection) push(data g.Map) {
+	select {
+	case <-c.Ctx.Done():
+		return
+	default:
+	}
+	select {
+	case <-c.Ctx.Done():
+	case c.writeCh <- c.Pack(data):
+	default:
+		g.Log().Debug(ctx, c.Unique+":push message dropped")
 	}
 }
 
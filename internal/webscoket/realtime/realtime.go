@@ -0,0 +1,186 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+)
+
+// 设备实时数据
+// 设备数据可能由任意实例收到，使用redis缓存时通过redis发布订阅转给所有实例，再由实例推送给本地的websocket订阅
+
+const (
+	TypeProperty = "property" // 属性上报
+	TypeEvent    = "event"    // 事件上报
+	TypeStatus   = "status"   // 上下线
+	TypeAlarm    = "alarm"    // 告警
+
+	eventChannel = "deviceRealtimeEvent" // 转发实时数据的redis频道
+
+	subscribedKey   = "deviceRealtimeSubscribed" // 有实例存在订阅时设置，由有订阅的实例定期续期
+	subscribedTTL   = 30 * time.Second
+	subscribedCache = time.Second // 本实例没有订阅时，缓存其他实例订阅状态的时长
+)
+
+// Event 设备实时数据
+type Event struct {
+	Type       string      `json:"type"`
+	ProductKey string      `json:"productKey"`
+	DeviceKey  string      `json:"deviceKey"`
+	Data       interface{} `json:"data"`
+	Timestamp  int64       `json:"timestamp"`
+}
+
+var (
+	mu        sync.RWMutex
+	listeners []func(e Event)
+	once      sync.Once
+
+	redisOnce sync.Once
+	useRedis  bool
+
+	subscribers      atomic.Int64 // 本实例的订阅数
+	remoteCheckedAt  atomic.Int64
+	remoteSubscribed atomic.Bool
+)
+
+// Listen 注册实时数据的接收方法
+func Listen(ctx context.Context, f func(e Event)) {
+	mu.Lock()
+	listeners = append(listeners, f)
+	mu.Unlock()
+	if isRedis(ctx) {
+		once.Do(func() {
+			go subscribeLoop(gctx.NeverDone(ctx))
+			go renewLoop(gctx.NeverDone(ctx))
+		})
+	}
+}
+
+// Subscribed 订阅数变化，delta 为新增的订阅数，取消订阅时为负数
+func Subscribed(ctx context.Context, delta int) {
+	n := subscribers.Add(int64(delta))
+	if delta > 0 && n == int64(delta) && isRedis(ctx) {
+		markSubscribed(ctx)
+	}
+}
+
+// Publish 发布设备实时数据，所有实例都没有订阅时不发布
+func Publish(ctx context.Context, typ, productKey, deviceKey string, data interface{}) {
+	if !hasSubscribers(ctx) {
+		return
+	}
+	e := Event{
+		Type:       typ,
+		ProductKey: productKey,
+		DeviceKey:  deviceKey,
+		Data:       data,
+		Timestamp:  time.Now().UnixMilli(),
+	}
+	if !isRedis(ctx) {
+		dispatch(e)
+		return
+	}
+	msg, err := json.Marshal(e)
+	if err != nil {
+		g.Log().Debugf(ctx, "marshal realtime event error: %v", err)
+		return
+	}
+	if _, err = g.Redis().Do(ctx, "PUBLISH", eventChannel, string(msg)); err != nil {
+		g.Log().Debugf(ctx, "publish realtime event error: %v", err)
+	}
+}
+
+func dispatch(e Event) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, f := range listeners {
+		f(e)
+	}
+}
+
+// hasSubscribers 本实例或其他实例是否有订阅，其他实例的订阅状态缓存 subscribedCache 时长
+func hasSubscribers(ctx context.Context) bool {
+	if subscribers.Load() > 0 {
+		return true
+	}
+	if !isRedis(ctx) {
+		return false
+	}
+	now := time.Now().UnixMilli()
+	if now-remoteCheckedAt.Load() < subscribedCache.Milliseconds() {
+		return remoteSubscribed.Load()
+	}
+	remoteCheckedAt.Store(now)
+	v, err := g.Redis().Do(ctx, "EXISTS", subscribedKey)
+	if err != nil {
+		// 无法确认时按有订阅处理
+		remoteSubscribed.Store(true)
+		return true
+	}
+	remoteSubscribed.Store(v.Int() > 0)
+	return v.Int() > 0
+}
+
+// markSubscribed 标记有实例存在订阅
+func markSubscribed(ctx context.Context) {
+	if _, err := g.Redis().Do(ctx, "SET", subscribedKey, 1, "PX", subscribedTTL.Milliseconds()); err != nil {
+		g.Log().Debugf(ctx, "mark realtime subscribed error: %v", err)
+	}
+}
+
+// renewLoop 本实例有订阅时续期订阅标记
+func renewLoop(ctx context.Context) {
+	ticker := time.NewTicker(subscribedTTL / 3)
+	defer ticker.Stop()
+	for range ticker.C {
+		if subscribers.Load() > 0 {
+			markSubscribed(ctx)
+		}
+	}
+}
+
+// isRedis 使用redis缓存时可能有多个实例
+func isRedis(ctx context.Context) bool {
+	redisOnce.Do(func() {
+		useRedis = g.Cfg().MustGet(ctx, "cache.adapter").String() == "redis"
+	})
+	return useRedis
+}
+
+func subscribeLoop(ctx context.Context) {
+	for {
+		if err := subscribe(ctx); err != nil {
+			g.Log().Errorf(ctx, "subscribe realtime event error: %v", err)
+		}
+		time.Sleep(time.Second * 3)
+	}
+}
+
+func subscribe(ctx context.Context) error {
+	conn, err := g.Redis().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+	if _, err = conn.Subscribe(ctx, eventChannel); err != nil {
+		return err
+	}
+	for {
+		msg, err := conn.ReceiveMessage(ctx)
+		if err != nil {
+			return err
+		}
+		var e Event
+		if err = json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+			g.Log().Debugf(ctx, "parse realtime event error: %v, message:%s", err, msg.Payload)
+			continue
+		}
+		dispatch(e)
+	}
+}
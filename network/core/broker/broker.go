@@ -0,0 +1,165 @@
+package broker
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sagooiot/internal/model"
+	"sagooiot/network/core/server/common"
+	networkModel "sagooiot/network/model"
+	"sagooiot/pkg/dcache"
+	"sagooiot/pkg/mqttbroker"
+	"strings"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+)
+
+// 内置MQTT服务
+// 设备使用 deviceKey 作为客户端标识连接，按设备或产品配置的认证方式校验，
+// 只能发布和订阅自己的 /sys/{productKey}/{deviceKey}/... 和 OTA 主题，连接和断开直接驱动设备的在线状态。
+// 平台自身的 mqtt 客户端使用 mqtt.auth 配置的账号连接，不受主题限制。
+
+// systemClient 平台内部客户端的身份标识
+type systemClient struct{}
+
+type hooks struct {
+	userName string
+	password string
+	clientId string
+}
+
+// Start 按 mqtt.broker 配置启动内置MQTT服务，未开启时返回空的停止函数
+func Start(ctx context.Context) (stop func(context.Context) error, err error) {
+	if !g.Cfg().MustGet(ctx, "mqtt.broker.enable").Bool() {
+		return nil, nil
+	}
+	addr := g.Cfg().MustGet(ctx, "mqtt.broker.addr", ":1883").String()
+	var l net.Listener
+	if l, err = net.Listen("tcp", addr); err != nil {
+		return
+	}
+	if certificateId := g.Cfg().MustGet(ctx, "mqtt.broker.certificateId").Int(); certificateId > 0 {
+		var tlsConfig *tls.Config
+		tlsConfig, err = common.TLSConfig(ctx, &networkModel.Server{Name: "mqtt broker", IsTls: 1, CertificateId: certificateId})
+		if err != nil {
+			_ = l.Close()
+			return
+		}
+		l = tls.NewListener(l, tlsConfig)
+	}
+
+	b := mqttbroker.New(mqttbroker.Options{
+		MaxPacketSize: g.Cfg().MustGet(ctx, "mqtt.broker.maxPacketSize").Int(),
+		MaxQueued:     g.Cfg().MustGet(ctx, "mqtt.broker.maxQueued").Int(),
+		MaxInflight:   g.Cfg().MustGet(ctx, "mqtt.broker.maxInflight").Int(),
+		MaxOutbound:   g.Cfg().MustGet(ctx, "mqtt.broker.maxOutbound").Int(),
+		Hooks: &hooks{
+			userName: g.Cfg().MustGet(ctx, "mqtt.auth.userName").String(),
+			password: g.Cfg().MustGet(ctx, "mqtt.auth.userPassWord").String(),
+			clientId: g.Cfg().MustGet(ctx, "mqtt.clientId").String(),
+		},
+	})
+	serveCtx := gctx.NeverDone(ctx)
+	go func() {
+		if err := b.Serve(serveCtx, l); err != nil && !errors.Is(err, mqttbroker.ErrBrokerClosed) {
+			g.Log().Errorf(serveCtx, "内置MQTT服务异常退出: %s", err.Error())
+		}
+	}()
+	g.Log().Infof(ctx, "内置MQTT服务监听地址: %s", addr)
+	return func(context.Context) error {
+		return b.Close()
+	}, nil
+}
+
+func (h *hooks) Authenticate(ctx context.Context, c *mqttbroker.Client, username string, password []byte) error {
+	if h.isSystem(c, username, password) {
+		c.Value = systemClient{}
+		return nil
+	}
+	device, err := common.GetAccessDevice(ctx, "", c.Id)
+	if err != nil {
+		return err
+	}
+	if err = common.DeviceAuth(ctx, device.Key, common.DeviceCredential{
+		User:      username,
+		Passwd:    string(password),
+		Token:     string(password),
+		PeerCerts: c.PeerCerts,
+	}); err != nil {
+		return err
+	}
+	c.Value = device
+	return nil
+}
+
+// isSystem 配置了账号时按账号判断，否则只允许本机使用平台的客户端标识连接
+func (h *hooks) isSystem(c *mqttbroker.Client, username string, password []byte) bool {
+	if h.userName != "" {
+		return subtle.ConstantTimeCompare([]byte(h.userName), []byte(username)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(h.password), password) == 1
+	}
+	if h.clientId == "" || !strings.HasPrefix(c.Id, h.clientId) {
+		return false
+	}
+	addr, ok := c.RemoteAddr.(*net.TCPAddr)
+	return ok && addr.IP.IsLoopback()
+}
+
+func (h *hooks) CanPublish(c *mqttbroker.Client, topic string) bool {
+	return allowed(c, topic, false)
+}
+
+func (h *hooks) CanSubscribe(c *mqttbroker.Client, filter string) bool {
+	return allowed(c, filter, true)
+}
+
+func (h *hooks) OnConnect(ctx context.Context, c *mqttbroker.Client) {
+	if device, ok := c.Value.(*model.DeviceOutput); ok {
+		dcache.Connect(ctx, device, c.KeepAlive)
+	}
+}
+
+func (h *hooks) OnActive(ctx context.Context, c *mqttbroker.Client) {
+	if device, ok := c.Value.(*model.DeviceOutput); ok {
+		dcache.Active(ctx, device)
+	}
+}
+
+func (h *hooks) OnDisconnect(ctx context.Context, c *mqttbroker.Client) {
+	if device, ok := c.Value.(*model.DeviceOutput); ok {
+		dcache.Disconnect(ctx, device)
+	}
+}
+
+// allowed 设备只能访问自己的主题，订阅时主题过滤器的设备部分不能使用通配符
+func allowed(c *mqttbroker.Client, topic string, filter bool) bool {
+	switch v := c.Value.(type) {
+	case systemClient:
+		return true
+	case *model.DeviceOutput:
+		return deviceTopic(v.Product.Key, v.Key, topic, filter)
+	}
+	return false
+}
+
+func deviceTopic(productKey, deviceKey, topic string, filter bool) bool {
+	levels := strings.Split(topic, "/")
+	if len(levels) < 2 || levels[0] != "" {
+		return false
+	}
+	switch levels[1] {
+	case "sys":
+		// /sys/{productKey}/{deviceKey}/...
+		return len(levels) > 4 && levels[2] == productKey && levels[3] == deviceKey
+	case "ota":
+		// /ota/device/{type}/{productKey}/{deviceKey}
+		if len(levels) != 6 || levels[2] != "device" || levels[4] != productKey || levels[5] != deviceKey {
+			return false
+		}
+		return levels[3] != "#" && (filter || levels[3] != "+")
+	}
+	return false
+}
@@ -20,7 +20,7 @@ func StartAction(ctx context.Context, deviceKey string) {
 		g.Log().Errorf(ctx, "deviceKey:%s not found,ignore", deviceKey)
 		return
 	}
-	deviceDetail := GetDevice(uint64(deviceRes.Id))
+	deviceDetail := GetDevice(deviceRes.Key)
 	if deviceDetail != nil {
 		err = deviceDetail.Start(ctx)
 		if err != nil {
@@ -51,8 +51,10 @@ func OffAction(ctx context.Context, tunnelId int) {
 			g.Log().Errorf(ctx, "deviceKey:%s not found,ignore", tunnelInfo.DeviceKey)
 			return
 		}
-		if deviceStopError := GetDevice(uint64(deviceDetail.Id)).Stop(); deviceStopError != nil {
-			g.Log().Errorf(ctx, "Stop device  error:%v ,ignore", deviceStopError)
+		if dev := GetDevice(deviceDetail.Key); dev != nil {
+			if deviceStopError := dev.Stop(); deviceStopError != nil {
+				g.Log().Errorf(ctx, "Stop device  error:%v ,ignore", deviceStopError)
+			}
 		}
 		if deviceDetail.Status == consts.DeviceStatueOnline {
 			if deviceOnlineErr := baseLogic.Offline(ctx, model.DeviceOfflineMessage{
@@ -11,16 +11,16 @@ import (
 
 var allDevices sync.Map
 
-func GetDevice(id uint64) *Device {
-	d, ok := allDevices.Load(id)
+func GetDevice(deviceKey string) *Device {
+	d, ok := allDevices.Load(deviceKey)
 	if ok {
 		return d.(*Device)
 	}
 	return nil
 }
 
-func RemoveDevice(id uint64) error {
-	d, ok := allDevices.LoadAndDelete(id)
+func RemoveDevice(deviceKey string) error {
+	d, ok := allDevices.LoadAndDelete(deviceKey)
 	if ok {
 		dev := d.(*Device)
 		return dev.Stop()
@@ -28,8 +28,11 @@ func RemoveDevice(id uint64) error {
 	return nil //error
 }
 
+// AddDevice 添加设备，已存在的设备先停止采集
 func AddDevice(deviceKey string, device *Device) {
-	allDevices.Store(deviceKey, device)
+	if d, ok := allDevices.Swap(deviceKey, device); ok && d.(*Device) != device {
+		_ = d.(*Device).Stop()
+	}
 }
 
 func LoadDevices(ctx context.Context) error {
@@ -54,7 +57,7 @@ func LoadDevices(ctx context.Context) error {
 			g.Log().Error(ctx, err)
 			return nil
 		}
-		AddDevice(devices[index].ProductKey, dev)
+		AddDevice(devices[index].DeviceKey, dev)
 	}
 	return nil
 }
@@ -71,5 +74,5 @@ func LoadDevice(ctx context.Context, key string) (*Device, error) {
 	}
 	AddDevice(key, dev)
 	err = dev.Start(ctx)
-	return dev, nil
+	return dev, err
 }
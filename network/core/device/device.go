@@ -2,12 +2,34 @@ package device
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"sagooiot/network/core"
+	"sagooiot/network/core/device/modbus"
+	"sagooiot/network/core/logic/baseLogic"
+	tunnelBase "sagooiot/network/core/tunnel/base"
 	"sagooiot/network/events"
 	"sagooiot/network/model"
+	"sagooiot/pkg/dcache"
+	"sagooiot/pkg/iotModel/sagooProtocol"
+	"sagooiot/pkg/iotModel/topicModel"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/util/guid"
 )
 
+// TunnelFinder 查找设备当前连接的通道
+type TunnelFinder func(ctx context.Context, deviceKey string) (tunnelBase.TunnelInstance, error)
+
+var tunnelFinder TunnelFinder
+
+// SetTunnelFinder 设置通道查找方法，由服务模块注册
+func SetTunnelFinder(finder TunnelFinder) {
+	tunnelFinder = finder
+}
+
 // Device 设备
 type Device struct {
 	model.Device
@@ -23,6 +45,11 @@ type Device struct {
 	commandIndex map[string]*model.Command
 
 	running bool
+
+	// client 当前通道上的modbus主站，通道变化时重建
+	clientLock   sync.Mutex
+	client       modbus.Client
+	clientTunnel tunnelBase.TunnelInstance
 }
 
 func NewDevice(ctx context.Context, m *model.Device) (*Device, error) {
@@ -46,8 +73,8 @@ func NewDevice(ctx context.Context, m *model.Device) (*Device, error) {
 	}
 
 	//初始化
-	for _, v := range dev.product.Pollers {
-		dev.pollers = append(dev.pollers, &Poller{Poller: *v, Device: dev})
+	for i, v := range dev.product.Pollers {
+		dev.pollers = append(dev.pollers, &Poller{Poller: *v, Device: dev, index: i})
 	}
 
 	return dev, nil
@@ -85,8 +112,141 @@ func (dev *Device) Running() bool {
 	return dev.running
 }
 
-func (dev *Device) read(size int) error {
-	//todo  等待实现
+// station 从站地址，设备未设置或超出范围时使用产品采集策略中的从站地址
+func (dev *Device) station() byte {
+	if dev.Station > 0 && dev.Station <= 247 {
+		return byte(dev.Station)
+	}
+	if dev.product.Modbus.Station > 0 {
+		return byte(dev.product.Modbus.Station)
+	}
+	return 1
+}
+
+// read 通过设备通道读取采集器的数据，解析数据点后上报属性
+func (dev *Device) read(ctx context.Context, p *Poller) error {
+	code, err := modbus.ParseCode(p.Code)
+	if err != nil {
+		return err
+	}
+	address, err := modbus.ParseAddress(p.Address)
+	if err != nil {
+		return err
+	}
+	if tunnelFinder == nil {
+		return errors.New("未设置通道查找方法")
+	}
+	tnl, err := tunnelFinder(ctx, dev.DeviceKey)
+	if err != nil {
+		return err
+	}
+	if tnl == nil || !tnl.Online() {
+		return fmt.Errorf("设备通道不在线,deviceKey:%s", dev.DeviceKey)
+	}
+
+	client, err := dev.modbusClient(tnl)
+	if err != nil {
+		return err
+	}
+	buf, err := client.Read(dev.station(), code, address, uint16(p.Length))
+	if errors.Is(err, tunnelBase.ErrAskNotSupported) {
+		return fmt.Errorf("设备通道不支持请求响应，无法轮询采集,deviceKey:%s", dev.DeviceKey)
+	}
+	if err != nil {
+		return err
+	}
+
+	values := decodePoints(dev.product.Points, code, address, buf)
+	if len(values) == 0 {
+		return nil
+	}
+	if err = dev.report(ctx, values); err != nil {
+		return err
+	}
+	dev.onData(values)
+	return nil
+}
+
+// modbusClient 获取通道上的modbus主站，通道未变化时复用
+func (dev *Device) modbusClient(tnl tunnelBase.TunnelInstance) (modbus.Client, error) {
+	dev.clientLock.Lock()
+	defer dev.clientLock.Unlock()
+	if dev.client != nil && dev.clientTunnel == tnl {
+		return dev.client, nil
+	}
+	client, err := modbus.NewClient(dev.product.Modbus.Mode, tnl, time.Duration(dev.product.Modbus.Timeout)*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	dev.client, dev.clientTunnel = client, tnl
+	return client, nil
+}
+
+// decodePoints 按数据点解析读取结果，只处理功能码相同且地址在读取范围内的数据点
+func decodePoints(points []*model.Point, code byte, address uint16, buf []byte) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, point := range points {
+		pc, err := modbus.ParseCode(point.Code)
+		if err != nil || pc != code {
+			continue
+		}
+		pa, err := modbus.ParseAddress(point.Address)
+		if err != nil || pa < address {
+			continue
+		}
+		offset := int(pa - address)
+		if modbus.IsBit(code) {
+			if offset >= len(buf) {
+				continue
+			}
+			values[point.Name] = buf[offset] > 0
+			continue
+		}
+		offset *= 2
+		if offset >= len(buf) {
+			continue
+		}
+		val, err := point.Type.Decode(buf[offset:], point.LittleEndian, point.Precision)
+		if err != nil {
+			continue
+		}
+		values[point.Name] = val
+	}
+	return values
+}
+
+// report 按物模型属性上报的流程处理采集数据
+func (dev *Device) report(ctx context.Context, values map[string]interface{}) error {
+	deviceDetail, err := dcache.GetDeviceDetailInfo(dev.DeviceKey)
+	if err != nil {
+		return err
+	}
+	if deviceDetail == nil {
+		return fmt.Errorf("设备不存在,deviceKey:%s", dev.DeviceKey)
+	}
+	handleF := tunnelBase.GetModelHandle(tunnelBase.UpProperty)
+	if handleF.Handle == nil {
+		return errors.New("未注册属性上报处理方法")
+	}
+	payload, err := json.Marshal(sagooProtocol.ReportPropertyReq{
+		Id:      guid.S(),
+		Version: "1.0",
+		Params:  values,
+		Method:  "thing.event.property.post",
+	})
+	if err != nil {
+		return err
+	}
+	if err = handleF.Handle(ctx, topicModel.TopicHandlerData{
+		Topic:        handleF.GetTopicWithInfo(dev.ProductKey, dev.DeviceKey, "property"),
+		ProductKey:   dev.ProductKey,
+		DeviceKey:    dev.DeviceKey,
+		PayLoad:      payload,
+		DeviceDetail: deviceDetail,
+	}); err != nil {
+		return err
+	}
+	baseLogic.InertTdLog(ctx, handleF.LogType, dev.DeviceKey, string(payload))
 	return nil
 }
 
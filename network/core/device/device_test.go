@@ -0,0 +1,52 @@
+package device
+
+import (
+	"sagooiot/network/core/device/modbus"
+	tunnelBase "sagooiot/network/core/tunnel/base"
+	"sagooiot/network/model"
+	"testing"
+)
+
+func TestDecodePoints(t *testing.T) {
+	points := []*model.Point{
+		{Name: "temp", Type: model.TypeSHORT, Precision: 1, Code: "3", Address: "10"},
+		{Name: "count", Type: model.TypeDWORD, Code: "3", Address: "11"},
+		{Name: "le", Type: model.TypeWORD, LittleEndian: true, Code: "3", Address: "13"},
+		{Name: "out", Type: model.TypeWORD, Code: "3", Address: "14"},
+		{Name: "input", Type: model.TypeWORD, Code: "4", Address: "10"},
+		{Name: "switch", Type: model.TypeBIT, Code: "1", Address: "2"},
+	}
+
+	values := decodePoints(points, modbus.FuncReadHoldingRegisters, 10, []byte{0xff, 0x38, 0, 1, 0, 2, 0x34, 0x12})
+	if len(values) != 3 {
+		t.Fatalf("decode got %v", values)
+	}
+	if values["temp"] != -20.0 || values["count"] != uint32(0x10002) || values["le"] != uint16(0x1234) {
+		t.Fatalf("decode got %v", values)
+	}
+
+	values = decodePoints(points, modbus.FuncReadCoils, 0, []byte{0, 0, 1})
+	if len(values) != 1 || values["switch"] != true {
+		t.Fatalf("decode bit got %v", values)
+	}
+}
+
+// askTunnel 测试用的通道
+type askTunnel struct {
+	tunnelBase.TunnelInstance
+}
+
+func TestModbusClient(t *testing.T) {
+	dev := &Device{product: &model.Product{}}
+	t1, t2 := &askTunnel{}, &askTunnel{}
+	c1, err := dev.modbusClient(t1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := dev.modbusClient(t1); c != c1 {
+		t.Fatal("client should be reused on the same tunnel")
+	}
+	if c, _ := dev.modbusClient(t2); c == c1 {
+		t.Fatal("client should be rebuilt when the tunnel changes")
+	}
+}
@@ -0,0 +1,35 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"sagooiot/network/core/tunnel/framer"
+	"time"
+)
+
+// RtuClient Modbus RTU over TCP，帧格式：站号 + PDU + CRC
+type RtuClient struct {
+	asker   Asker
+	timeout time.Duration
+}
+
+func (c *RtuClient) Read(station byte, code byte, address, quantity uint16) ([]byte, error) {
+	p, err := pdu(code, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	req := append([]byte{station}, p...)
+	req = binary.LittleEndian.AppendUint16(req, framer.Crc16(req))
+
+	res, err := c.asker.Ask(req, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	n := len(res)
+	if n < 5 || res[0] != station {
+		return nil, ErrInvalidResponse
+	}
+	if framer.Crc16(res[:n-2]) != binary.LittleEndian.Uint16(res[n-2:]) {
+		return nil, ErrInvalidResponse
+	}
+	return parsePdu(code, quantity, res[1:n-2])
+}
@@ -0,0 +1,48 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+)
+
+// mbapSize MBAP报文头长度：事务标识 + 协议标识 + 长度 + 单元标识
+const mbapSize = 7
+
+// TcpClient Modbus TCP，帧格式：MBAP报文头 + PDU
+type TcpClient struct {
+	asker   Asker
+	timeout time.Duration
+
+	transaction atomic.Uint32
+}
+
+func (c *TcpClient) Read(station byte, code byte, address, quantity uint16) ([]byte, error) {
+	p, err := pdu(code, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	tid := uint16(c.transaction.Add(1))
+	req := make([]byte, mbapSize, mbapSize+len(p))
+	binary.BigEndian.PutUint16(req[0:], tid)
+	binary.BigEndian.PutUint16(req[2:], 0)
+	binary.BigEndian.PutUint16(req[4:], uint16(len(p)+1))
+	req[6] = station
+	req = append(req, p...)
+
+	res, err := c.asker.Ask(req, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) < mbapSize+2 {
+		return nil, ErrInvalidResponse
+	}
+	if binary.BigEndian.Uint16(res[0:]) != tid || binary.BigEndian.Uint16(res[2:]) != 0 || res[6] != station {
+		return nil, ErrInvalidResponse
+	}
+	length := int(binary.BigEndian.Uint16(res[4:]))
+	if length < 2 || len(res) < 6+length {
+		return nil, ErrInvalidResponse
+	}
+	return parsePdu(code, quantity, res[mbapSize:6+length])
+}
@@ -0,0 +1,141 @@
+package modbus
+
+import (
+	"errors"
+	"fmt"
+	"sagooiot/network/codebin"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 读功能码
+const (
+	FuncReadCoils            byte = 1
+	FuncReadDiscreteInputs   byte = 2
+	FuncReadHoldingRegisters byte = 3
+	FuncReadInputRegisters   byte = 4
+)
+
+// 传输方式
+const (
+	ModeRtu = "rtu" // Modbus RTU over TCP
+	ModeTcp = "tcp" // Modbus TCP
+)
+
+const (
+	// maxBitCount 单次读取线圈、离散输入的最大数量
+	maxBitCount = 2000
+	// maxRegisterCount 单次读取寄存器的最大数量
+	maxRegisterCount = 125
+	// DefaultTimeout 默认的响应超时时间
+	DefaultTimeout = 3 * time.Second
+)
+
+var (
+	ErrInvalidResponse = errors.New("modbus: invalid response")
+	ErrInvalidQuantity = errors.New("modbus: invalid quantity")
+)
+
+// Exception 从站返回的异常响应
+type Exception struct {
+	Code      byte
+	Exception byte
+}
+
+func (e *Exception) Error() string {
+	return fmt.Sprintf("modbus: exception %d, function %d", e.Exception, e.Code)
+}
+
+// Asker 发送指令并等待响应，通道的 Ask 方法满足该接口
+type Asker interface {
+	Ask(cmd []byte, timeout time.Duration) ([]byte, error)
+}
+
+// Client Modbus主站
+type Client interface {
+	// Read 读取数据，线圈和离散输入按位展开为每个点一个字节，寄存器返回原始字节
+	Read(station byte, code byte, address, quantity uint16) ([]byte, error)
+}
+
+// NewClient 按传输方式创建主站
+func NewClient(mode string, asker Asker, timeout time.Duration) (Client, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	switch strings.ToLower(mode) {
+	case ModeRtu, "":
+		return &RtuClient{asker: asker, timeout: timeout}, nil
+	case ModeTcp:
+		return &TcpClient{asker: asker, timeout: timeout}, nil
+	}
+	return nil, fmt.Errorf("modbus: unknown mode %s", mode)
+}
+
+// ParseCode 解析功能码，支持数字和名称
+func ParseCode(code string) (byte, error) {
+	switch strings.ToLower(strings.TrimSpace(code)) {
+	case "1", "01", "0x01", "coil", "coils":
+		return FuncReadCoils, nil
+	case "2", "02", "0x02", "discrete", "discrete_input", "discrete_inputs":
+		return FuncReadDiscreteInputs, nil
+	case "3", "03", "0x03", "holding", "holding_register", "holding_registers":
+		return FuncReadHoldingRegisters, nil
+	case "4", "04", "0x04", "input", "input_register", "input_registers":
+		return FuncReadInputRegisters, nil
+	}
+	return 0, fmt.Errorf("modbus: unknown function code %s", code)
+}
+
+// ParseAddress 解析地址，支持十进制和0x开头的十六进制
+func ParseAddress(address string) (uint16, error) {
+	v, err := strconv.ParseUint(strings.TrimSpace(address), 0, 16)
+	if err != nil {
+		return 0, fmt.Errorf("modbus: invalid address %s", address)
+	}
+	return uint16(v), nil
+}
+
+// IsBit 是否按位读取的功能码
+func IsBit(code byte) bool {
+	return code == FuncReadCoils || code == FuncReadDiscreteInputs
+}
+
+// pdu 读请求的协议数据单元
+func pdu(code byte, address, quantity uint16) ([]byte, error) {
+	limit := uint16(maxRegisterCount)
+	if IsBit(code) {
+		limit = maxBitCount
+	}
+	if quantity == 0 || quantity > limit {
+		return nil, ErrInvalidQuantity
+	}
+	return []byte{code, byte(address >> 8), byte(address), byte(quantity >> 8), byte(quantity)}, nil
+}
+
+// parsePdu 解析响应的协议数据单元，返回数据部分
+func parsePdu(code byte, quantity uint16, res []byte) ([]byte, error) {
+	if len(res) < 2 {
+		return nil, ErrInvalidResponse
+	}
+	if res[0] == code|0x80 {
+		return nil, &Exception{Code: code, Exception: res[1]}
+	}
+	if res[0] != code {
+		return nil, ErrInvalidResponse
+	}
+	size := int(quantity) * 2
+	if IsBit(code) {
+		size = (int(quantity) + 7) / 8
+	}
+	if int(res[1]) != size || len(res) < 2+size {
+		return nil, ErrInvalidResponse
+	}
+	data := res[2 : 2+size]
+	if IsBit(code) {
+		return codebin.ExpandBool(data, int(quantity)), nil
+	}
+	out := make([]byte, size)
+	copy(out, data)
+	return out, nil
+}
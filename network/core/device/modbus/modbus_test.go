@@ -0,0 +1,187 @@
+package modbus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sagooiot/network/core/tunnel/framer"
+	"testing"
+	"time"
+)
+
+// slave 进程内的Modbus从站，寄存器值等于地址，线圈和离散输入按地址奇偶交替
+type slave struct {
+	mode    string
+	station byte
+	ln      net.Listener
+}
+
+func newSlave(t *testing.T, mode string, station byte) *slave {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &slave{mode: mode, station: station, ln: ln}
+	t.Cleanup(func() { _ = ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *slave) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *slave) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var req []byte
+		if s.mode == ModeTcp {
+			req = make([]byte, mbapSize+5)
+		} else {
+			req = make([]byte, 8)
+		}
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		var res []byte
+		if s.mode == ModeTcp {
+			p := s.respond(req[mbapSize:])
+			res = make([]byte, mbapSize, mbapSize+len(p))
+			copy(res, req[:4])
+			binary.BigEndian.PutUint16(res[4:], uint16(len(p)+1))
+			res[6] = s.station
+			res = append(res, p...)
+		} else {
+			if framer.Crc16(req[:6]) != binary.LittleEndian.Uint16(req[6:]) {
+				return
+			}
+			res = append([]byte{s.station}, s.respond(req[1:6])...)
+			res = binary.LittleEndian.AppendUint16(res, framer.Crc16(res))
+		}
+		if _, err := conn.Write(res); err != nil {
+			return
+		}
+	}
+}
+
+// respond 根据请求PDU生成响应PDU，地址1000以上返回非法地址异常
+func (s *slave) respond(req []byte) []byte {
+	code := req[0]
+	address := binary.BigEndian.Uint16(req[1:])
+	quantity := binary.BigEndian.Uint16(req[3:])
+	if address >= 1000 {
+		return []byte{code | 0x80, 2}
+	}
+	if IsBit(code) {
+		data := make([]byte, (quantity+7)/8)
+		for i := uint16(0); i < quantity; i++ {
+			if (address+i)%2 == 1 {
+				data[i/8] |= 1 << (i % 8)
+			}
+		}
+		return append([]byte{code, byte(len(data))}, data...)
+	}
+	data := make([]byte, 0, quantity*2)
+	for i := uint16(0); i < quantity; i++ {
+		v := address + i
+		if code == FuncReadInputRegisters {
+			v += 100
+		}
+		data = binary.BigEndian.AppendUint16(data, v)
+	}
+	return append([]byte{code, byte(len(data))}, data...)
+}
+
+// asker 模拟通道的 Ask 方法
+type asker struct {
+	conn net.Conn
+}
+
+func (a *asker) Ask(cmd []byte, timeout time.Duration) ([]byte, error) {
+	if _, err := a.conn.Write(cmd); err != nil {
+		return nil, err
+	}
+	_ = a.conn.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, 512)
+	n, err := a.conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func dial(t *testing.T, s *slave) *asker {
+	t.Helper()
+	conn, err := net.Dial("tcp", s.ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return &asker{conn: conn}
+}
+
+func TestRead(t *testing.T) {
+	for _, mode := range []string{ModeRtu, ModeTcp} {
+		s := newSlave(t, mode, 3)
+		client, err := NewClient(mode, dial(t, s), time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cases := []struct {
+			code     byte
+			address  uint16
+			quantity uint16
+			want     []byte
+		}{
+			{FuncReadCoils, 0, 10, []byte{0, 1, 0, 1, 0, 1, 0, 1, 0, 1}},
+			{FuncReadDiscreteInputs, 5, 3, []byte{1, 0, 1}},
+			{FuncReadHoldingRegisters, 10, 2, []byte{0, 10, 0, 11}},
+			{FuncReadInputRegisters, 1, 1, []byte{0, 101}},
+		}
+		for _, c := range cases {
+			got, err := client.Read(3, c.code, c.address, c.quantity)
+			if err != nil {
+				t.Fatalf("%s read %d failed: %v", mode, c.code, err)
+			}
+			if !bytes.Equal(got, c.want) {
+				t.Fatalf("%s read %d got %v, want %v", mode, c.code, got, c.want)
+			}
+		}
+
+		_, err = client.Read(3, FuncReadHoldingRegisters, 1000, 1)
+		var e *Exception
+		if !errors.As(err, &e) || e.Exception != 2 {
+			t.Fatalf("%s exception got %v", mode, err)
+		}
+
+		if _, err = client.Read(4, FuncReadHoldingRegisters, 0, 1); !errors.Is(err, ErrInvalidResponse) {
+			t.Fatalf("%s station mismatch got %v", mode, err)
+		}
+		if _, err = client.Read(3, FuncReadHoldingRegisters, 0, 0); !errors.Is(err, ErrInvalidQuantity) {
+			t.Fatalf("%s invalid quantity got %v", mode, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for s, want := range map[string]byte{"3": 3, "0x01": 1, "coils": 1, "input_registers": 4} {
+		if code, err := ParseCode(s); err != nil || code != want {
+			t.Fatalf("parse code %s got %d %v", s, code, err)
+		}
+	}
+	if _, err := ParseCode("5"); err == nil {
+		t.Fatal("unknown code should fail")
+	}
+	if address, err := ParseAddress("0x10"); err != nil || address != 16 {
+		t.Fatalf("parse address got %d %v", address, err)
+	}
+}
@@ -2,63 +2,62 @@ package device
 
 import (
 	"context"
+	"fmt"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gcron"
 	"sagooiot/network/model"
-	//"sagooiot/network/pkg/cron"
+	"sync/atomic"
 )
 
-//TODO 采集器的定时任务需要与系统的定时任务采用同样的库
-
 // Poller 采集器
 type Poller struct {
 	model.Poller
 	Device *Device
 
-	reading bool
-	//job     *cron.Job
-	job *gcron.Entry
+	// index 采集器在产品采集策略中的序号，用于生成定时任务名称
+	index   int
+	reading atomic.Bool
+	job     *gcron.Entry
+}
+
+// name 定时任务名称
+func (p *Poller) name() string {
+	return fmt.Sprintf("poller-%s-%d", p.Device.DeviceKey, p.index)
 }
 
 // Start 启动
 func (p *Poller) Start(ctx context.Context) (err error) {
-	if p.job != nil {
-		//p.job.Cancel()
-		p.job.Close()
-		//return errors.New("已经启动")
+	p.Stop()
+	if p.Disabled || p.Interval <= 0 {
+		return nil
 	}
-	//p.job, err = gcron.Add(context.Background(), p.Interval, func() {
-	//	p.Execute(ctx)
-	//})
-	//
-	//p.job, err = cron.Interval(p.Interval, func() {
-	//	p.Execute(ctx)
-	//})
+	p.job, err = gcron.AddSingleton(ctx, fmt.Sprintf("@every %ds", p.Interval), func(ctx context.Context) {
+		p.Execute(ctx)
+	}, p.name())
 	return
 }
 
 // Execute 执行
 func (p *Poller) Execute(ctx context.Context) {
-	if p.reading {
+	if !p.reading.CompareAndSwap(false, true) {
 		return
 	}
 	go p.read(ctx)
 }
 
 func (p *Poller) read(ctx context.Context) {
-	p.reading = true
-	err := p.Device.read(p.Length)
-	p.reading = false
+	err := p.Device.read(ctx, p)
+	p.reading.Store(false)
 
 	if err != nil {
-		//log error
-		g.Log().Error(ctx, err)
+		g.Log().Errorf(ctx, "采集失败,deviceKey:%s,poller:%s-%s:%v", p.Device.DeviceKey, p.Code, p.Address, err)
 	}
 }
 
 // Stop 结束
 func (p *Poller) Stop() {
 	if p.job != nil {
-		//p.job.Cancel()
+		gcron.Remove(p.job.Name)
+		p.job = nil
 	}
 }
@@ -2,15 +2,34 @@ package baseLogic
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+)
+
+// 设备同步请求
+// 请求在发起的实例本地等待响应，同时在redis中登记请求信息。设备响应可能由任意实例收到，
+// 收到响应的实例从redis中领取请求后，通过redis发布订阅把响应转给发起请求的实例。
+
+const (
+	DefaultRequestTimeout = 45                   // 默认的同步请求超时时间，单位秒
+	requestKeyPrefix      = "deviceRequest:"     // redis中登记的请求信息KEY前缀
+	replyChannel          = "deviceRequestReply" // 转发设备响应的redis频道
 )
 
-type AsyncMap struct {
+// claimScript 原子地读取并删除请求信息，保证一个请求只被响应一次
+const claimScript = `local v = redis.call('GET', KEYS[1])
+if v then redis.call('DEL', KEYS[1]) end
+return v`
+
+type asyncMap struct {
 	sync.RWMutex
-	Info map[string]*FInfo
+	info map[string]*FInfo
 }
 
 type FInfo struct {
@@ -19,45 +38,223 @@ type FInfo struct {
 	Response chan interface{}
 }
 
-var AsyncMapInfo = &AsyncMap{Info: make(map[string]*FInfo)}
+var asyncMapInfo = &asyncMap{info: make(map[string]*FInfo)}
 
-func SyncRequest(ctx context.Context, id, funcKey string, params interface{}, timeout int) (interface{}, error) {
-	if timeout == 0 {
-		timeout = 45
-	}
+// requestInfo redis中登记的请求信息
+type requestInfo struct {
+	FuncKey string      `json:"funcKey"`
+	Request interface{} `json:"request"`
+}
 
-	responseChan := make(chan interface{}, 1)
-	AsyncMapInfo.Lock()
-	AsyncMapInfo.Info[id] = &FInfo{
+// replyMessage 通过redis转发的设备响应
+type replyMessage struct {
+	Id       string      `json:"id"`
+	Response interface{} `json:"response"`
+}
+
+// PendingRequest 等待设备响应的请求
+type PendingRequest struct {
+	Id      string
+	FuncKey string
+	Timeout int
+	resp    chan interface{}
+}
+
+// Register 登记同步请求，需要在下发请求之前调用，避免设备响应先于登记到达
+func Register(ctx context.Context, id, funcKey string, params interface{}, timeout int) *PendingRequest {
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	p := &PendingRequest{Id: id, FuncKey: funcKey, Timeout: timeout, resp: make(chan interface{}, 1)}
+	asyncMapInfo.Lock()
+	asyncMapInfo.info[id] = &FInfo{
 		FuncKey:  funcKey,
 		Request:  params,
-		Response: responseChan,
+		Response: p.resp,
 	}
-	AsyncMapInfo.Unlock()
+	asyncMapInfo.Unlock()
 
-	defer func() {
-		AsyncMapInfo.Lock()
-		delete(AsyncMapInfo.Info, id)
-		AsyncMapInfo.Unlock()
-		close(responseChan)
-	}()
+	if err := store.save(ctx, id, requestInfo{FuncKey: funcKey, Request: params}, timeout); err != nil {
+		g.Log().Debugf(ctx, "register request %s error: %v", id, err)
+	}
+	return p
+}
 
+// Wait 等待设备响应，超时返回错误
+func (p *PendingRequest) Wait(ctx context.Context) (interface{}, error) {
+	defer p.Cancel(ctx)
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
-	case <-time.After(time.Second * time.Duration(timeout)):
-		return nil, fmt.Errorf("invoke service %s timed out", funcKey)
-	case response := <-responseChan:
+	case <-time.After(time.Second * time.Duration(p.Timeout)):
+		return nil, fmt.Errorf("invoke service %s timed out", p.FuncKey)
+	case response := <-p.resp:
 		return response, nil
 	}
 }
 
-func GetCallInfoById(ctx context.Context, id string) (funcKey string, params interface{}, response chan interface{}, err error) {
-	AsyncMapInfo.RLock()
-	defer AsyncMapInfo.RUnlock()
-	if info, ok := AsyncMapInfo.Info[id]; !ok {
-		return "", nil, nil, errors.New("cannot get call info by id " + id)
-	} else {
-		return info.FuncKey, info.Request, info.Response, nil
+// Cancel 取消登记的请求，请求下发失败时调用
+func (p *PendingRequest) Cancel(ctx context.Context) {
+	asyncMapInfo.Lock()
+	if _, ok := asyncMapInfo.info[p.Id]; !ok {
+		asyncMapInfo.Unlock()
+		return
+	}
+	delete(asyncMapInfo.info, p.Id)
+	close(p.resp)
+	asyncMapInfo.Unlock()
+
+	if _, err := store.claim(ctx, p.Id); err != nil {
+		g.Log().Debugf(ctx, "remove request %s error: %v", p.Id, err)
+	}
+}
+
+// SyncRequest 登记请求并等待设备响应
+func SyncRequest(ctx context.Context, id, funcKey string, params interface{}, timeout int) (interface{}, error) {
+	return Register(ctx, id, funcKey, params, timeout).Wait(ctx)
+}
+
+// GetCallInfoById 获取请求信息，请求可能由其他实例发起
+func GetCallInfoById(ctx context.Context, id string) (funcKey string, params interface{}, err error) {
+	asyncMapInfo.RLock()
+	info, ok := asyncMapInfo.info[id]
+	asyncMapInfo.RUnlock()
+	if ok {
+		return info.FuncKey, info.Request, nil
+	}
+
+	req, err := store.load(ctx, id)
+	if err != nil {
+		return
+	}
+	if req == nil {
+		return "", nil, errors.New("cannot get call info by id " + id)
+	}
+	return req.FuncKey, req.Request, nil
+}
+
+// Reply 设备响应请求，发起请求的实例不是当前实例时通过redis转发
+func Reply(ctx context.Context, id string, response interface{}) (err error) {
+	claimed, err := store.claim(ctx, id)
+	if deliver(id, response) {
+		return nil
+	}
+	if err != nil {
+		return
+	}
+	if !claimed {
+		return errors.New("cannot get call info by id " + id)
+	}
+	return store.publish(ctx, replyMessage{Id: id, Response: response})
+}
+
+// deliver 把响应交给本实例等待中的请求
+func deliver(id string, response interface{}) bool {
+	asyncMapInfo.RLock()
+	defer asyncMapInfo.RUnlock()
+	info, ok := asyncMapInfo.info[id]
+	if !ok {
+		return false
+	}
+	select {
+	case info.Response <- response:
+	default:
+		// 已有响应，忽略重复的响应
+	}
+	return true
+}
+
+var subscribeOnce sync.Once
+
+// StartReplySubscriber 订阅其他实例转发的设备响应
+func StartReplySubscriber(ctx context.Context) error {
+	subscribeOnce.Do(func() {
+		go func() {
+			ctx := gctx.NeverDone(ctx)
+			for {
+				if err := store.subscribe(ctx, func(reply replyMessage) {
+					deliver(reply.Id, reply.Response)
+				}); err != nil {
+					g.Log().Errorf(ctx, "subscribe device reply error: %v", err)
+				}
+				time.Sleep(time.Second * 3)
+			}
+		}()
+	})
+	return nil
+}
+
+// requestStore 多个实例共享的请求信息
+type requestStore interface {
+	save(ctx context.Context, id string, info requestInfo, timeout int) error
+	load(ctx context.Context, id string) (*requestInfo, error)
+	// claim 领取并删除请求信息，请求不存在时返回false
+	claim(ctx context.Context, id string) (bool, error)
+	publish(ctx context.Context, reply replyMessage) error
+	subscribe(ctx context.Context, f func(reply replyMessage)) error
+}
+
+var store requestStore = redisStore{}
+
+type redisStore struct{}
+
+func (redisStore) save(ctx context.Context, id string, info requestInfo, timeout int) (err error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return
+	}
+	_, err = g.Redis().Do(ctx, "SET", requestKeyPrefix+id, string(data), "EX", timeout)
+	return
+}
+
+func (redisStore) load(ctx context.Context, id string) (*requestInfo, error) {
+	v, err := g.Redis().Do(ctx, "GET", requestKeyPrefix+id)
+	if err != nil || v.IsNil() {
+		return nil, err
+	}
+	var info requestInfo
+	if err = json.Unmarshal(v.Bytes(), &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (redisStore) claim(ctx context.Context, id string) (bool, error) {
+	v, err := g.Redis().Do(ctx, "EVAL", claimScript, 1, requestKeyPrefix+id)
+	if err != nil {
+		return false, err
+	}
+	return !v.IsNil(), nil
+}
+
+func (redisStore) publish(ctx context.Context, reply replyMessage) (err error) {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return
+	}
+	_, err = g.Redis().Do(ctx, "PUBLISH", replyChannel, string(data))
+	return
+}
+
+func (redisStore) subscribe(ctx context.Context, f func(reply replyMessage)) error {
+	conn, err := g.Redis().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+	if _, err = conn.Subscribe(ctx, replyChannel); err != nil {
+		return err
+	}
+	for {
+		msg, err := conn.ReceiveMessage(ctx)
+		if err != nil {
+			return err
+		}
+		var reply replyMessage
+		if err = json.Unmarshal([]byte(msg.Payload), &reply); err != nil {
+			g.Log().Debugf(ctx, "parse device reply error: %v, message:%s", err, msg.Payload)
+			continue
+		}
+		f(reply)
 	}
 }
@@ -3,12 +3,65 @@ package baseLogic
 import (
 	"context"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
 
+// memoryStore 测试用的请求信息存储，代替redis
+type memoryStore struct {
+	sync.Mutex
+	requests  map[string]requestInfo
+	published []replyMessage
+}
+
+func (m *memoryStore) save(_ context.Context, id string, info requestInfo, _ int) error {
+	m.Lock()
+	defer m.Unlock()
+	m.requests[id] = info
+	return nil
+}
+
+func (m *memoryStore) load(_ context.Context, id string) (*requestInfo, error) {
+	m.Lock()
+	defer m.Unlock()
+	if info, ok := m.requests[id]; ok {
+		return &info, nil
+	}
+	return nil, nil
+}
+
+func (m *memoryStore) claim(_ context.Context, id string) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	_, ok := m.requests[id]
+	delete(m.requests, id)
+	return ok, nil
+}
+
+func (m *memoryStore) publish(_ context.Context, reply replyMessage) error {
+	m.Lock()
+	defer m.Unlock()
+	m.published = append(m.published, reply)
+	return nil
+}
+
+func (m *memoryStore) subscribe(ctx context.Context, _ func(reply replyMessage)) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func useMemoryStore(t *testing.T) *memoryStore {
+	m := &memoryStore{requests: make(map[string]requestInfo)}
+	old := store
+	store = m
+	t.Cleanup(func() { store = old })
+	return m
+}
+
 // TestSyncRequest 测试 SyncRequest 函数.
 func TestSyncRequest(t *testing.T) {
+	useMemoryStore(t)
 	ctx := context.Background()
 	id := "testID"
 	funcKey := "testFunc"
@@ -17,12 +70,10 @@ func TestSyncRequest(t *testing.T) {
 
 	// 启动一个 goroutine 模拟异步响应.
 	go func() {
-		time.Sleep(2 * time.Second) // 模拟处理时间.
-		asyncMapInfo.Lock()
-		if info, ok := asyncMapInfo.info[id]; ok {
-			info.Response <- "testResponse"
+		time.Sleep(time.Second) // 模拟处理时间.
+		if err := Reply(ctx, id, "testResponse"); err != nil {
+			t.Errorf("Reply() error = %v", err)
 		}
-		asyncMapInfo.Unlock()
 	}()
 
 	got, err := SyncRequest(ctx, id, funcKey, params, timeout)
@@ -35,22 +86,33 @@ func TestSyncRequest(t *testing.T) {
 	}
 }
 
+// TestSyncRequestTimeout 测试请求超时.
+func TestSyncRequestTimeout(t *testing.T) {
+	m := useMemoryStore(t)
+	ctx := context.Background()
+	if _, err := SyncRequest(ctx, "timeoutID", "testFunc", nil, 1); err == nil {
+		t.Error("SyncRequest() should time out")
+	}
+	if _, ok := m.requests["timeoutID"]; ok {
+		t.Error("request should be removed after timeout")
+	}
+	if err := Reply(ctx, "timeoutID", "late"); err == nil {
+		t.Error("Reply() after timeout should fail")
+	}
+}
+
 // TestGetCallInfoById 测试 GetCallInfoById 函数.
 func TestGetCallInfoById(t *testing.T) {
+	useMemoryStore(t)
 	ctx := context.Background()
 	id := "testID"
 	funcKey := "testFunc"
 	params := "testParams"
 
-	asyncMapInfo.Lock()
-	asyncMapInfo.info[id] = &FInfo{
-		FuncKey:  funcKey,
-		Request:  params,
-		Response: make(chan interface{}),
-	}
-	asyncMapInfo.Unlock()
+	p := Register(ctx, id, funcKey, params, 10)
+	defer p.Cancel(ctx)
 
-	gotFuncKey, gotParams, _, err := GetCallInfoById(ctx, id)
+	gotFuncKey, gotParams, err := GetCallInfoById(ctx, id)
 	if err != nil {
 		t.Errorf("GetCallInfoById() error = %v", err)
 		return
@@ -62,3 +124,25 @@ func TestGetCallInfoById(t *testing.T) {
 		t.Errorf("GetCallInfoById() gotParams = %v, want %v", gotParams, params)
 	}
 }
+
+// TestReplyForward 测试其他实例发起的请求，响应通过发布转发.
+func TestReplyForward(t *testing.T) {
+	m := useMemoryStore(t)
+	ctx := context.Background()
+	_ = m.save(ctx, "remoteID", requestInfo{FuncKey: "remoteFunc"}, 10)
+
+	funcKey, _, err := GetCallInfoById(ctx, "remoteID")
+	if err != nil || funcKey != "remoteFunc" {
+		t.Fatalf("GetCallInfoById() got = %v, %v", funcKey, err)
+	}
+	if err = Reply(ctx, "remoteID", "remoteResponse"); err != nil {
+		t.Fatalf("Reply() error = %v", err)
+	}
+	if len(m.published) != 1 || m.published[0].Response != "remoteResponse" {
+		t.Fatalf("published = %+v", m.published)
+	}
+	// 同一个请求只响应一次
+	if err = Reply(ctx, "remoteID", "remoteResponse"); err == nil {
+		t.Error("duplicate Reply() should fail")
+	}
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"context"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+	"sagooiot/network/core/logic/baseLogic"
+	"sagooiot/pkg/iotModel/topicModel"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// RegisterRequest 登记同步请求并记录请求内容，需要在下发之前调用。
+func RegisterRequest(ctx context.Context, request topicModel.TopicDownHandlerData, id, funcKey string, payload interface{}) *baseLogic.PendingRequest {
+	p := baseLogic.Register(ctx, id, funcKey, payload, request.Timeout)
+	if err := service.DevDeviceRequest().Add(ctx, &model.DeviceRequestAddInput{
+		RequestId:  id,
+		ProductKey: getProductKey(request.DeviceDetail),
+		DeviceKey:  getDeviceKey(request.DeviceDetail),
+		FuncKey:    funcKey,
+		Request:    payload,
+		Timeout:    p.Timeout,
+	}); err != nil {
+		g.Log().Debugf(ctx, "add device request %s error: %v", id, err)
+	}
+	return p
+}
+
+// CancelRequest 请求下发失败，取消登记并记录失败原因。
+func CancelRequest(ctx context.Context, p *baseLogic.PendingRequest, cause error) {
+	p.Cancel(ctx)
+	if err := service.DevDeviceRequest().Fail(ctx, p.Id, consts.DeviceRequestStatusFail, cause.Error()); err != nil {
+		g.Log().Debugf(ctx, "update device request %s error: %v", p.Id, err)
+	}
+}
+
+// WaitReply 等待设备响应并记录响应结果。
+func WaitReply(ctx context.Context, p *baseLogic.PendingRequest) (response interface{}, err error) {
+	response, err = p.Wait(ctx)
+	var recordErr error
+	if err == nil {
+		recordErr = service.DevDeviceRequest().Reply(ctx, p.Id, response)
+	} else if ctx.Err() == nil {
+		recordErr = service.DevDeviceRequest().Fail(ctx, p.Id, consts.DeviceRequestStatusTimeout, err.Error())
+	} else {
+		recordErr = service.DevDeviceRequest().Fail(context.WithoutCancel(ctx), p.Id, consts.DeviceRequestStatusFail, err.Error())
+	}
+	if recordErr != nil {
+		g.Log().Debugf(ctx, "update device request %s error: %v", p.Id, recordErr)
+	}
+	return
+}
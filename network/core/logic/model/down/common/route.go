@@ -10,6 +10,7 @@ import (
 	"sagooiot/pkg/dcache"
 	"sagooiot/pkg/iotModel/sagooProtocol"
 	"sagooiot/pkg/iotModel/topicModel"
+	"sagooiot/pkg/jsinterpreter"
 )
 
 type DownstreamRouteLog struct {
@@ -101,6 +102,19 @@ func BuildTunnelPayload(params map[string]interface{}, identity *sagooProtocol.I
 	return json.Marshal(payload)
 }
 
+// EncodePayload 使用目标设备产品脚本的 encode 函数编码下发数据，没有脚本时原样返回。
+func EncodePayload(target topicModel.TopicDownHandlerData, payload []byte) ([]byte, error) {
+	product := target.DeviceDetail.Product
+	if product == nil || product.ScriptInfo == "" {
+		return payload, nil
+	}
+	data, err := jsinterpreter.Encode(product.Key, product.ScriptInfo, payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode payload error: %w", err)
+	}
+	return data, nil
+}
+
 // BuildDownstreamRouteLog 构建下发日志内容，记录真实目标与实际通道。
 func BuildDownstreamRouteLog(request, target topicModel.TopicDownHandlerData, identity *sagooProtocol.Identity, payload interface{}) DownstreamRouteLog {
 	viaGateway := identity != nil
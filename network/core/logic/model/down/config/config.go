@@ -0,0 +1,68 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/mqtt"
+	"sagooiot/network/core/logic/baseLogic"
+	dcommon "sagooiot/network/core/logic/model/down/common"
+	dservice "sagooiot/network/core/logic/model/down/service"
+	"sagooiot/pkg/iotModel/sagooProtocol"
+	"sagooiot/pkg/iotModel/topicModel"
+	"strings"
+
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// ConfigPush 向设备下发配置，返回下发请求ID，设备响应走 config/push/reply
+func ConfigPush(ctx context.Context, request topicModel.TopicDownHandlerData, data sagooProtocol.ConfigPushRequestData) (string, error) {
+	r := sagooProtocol.ConfigPushRequest{
+		Id:      guid.S(),
+		Version: "1.0.0",
+		Params:  data,
+		Method:  "thing.config.push",
+	}
+	targetRequest, identity, err := dcommon.ResolveDownstreamTarget(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	requestData, err := dcommon.BuildGatewayRequestPayload(r.Id, r.Version, r.Method, gconv.Map(r.Params), identity)
+	if err != nil {
+		return "", err
+	}
+	if err = write(ctx, sagooProtocol.ConfigPushRegisterSubRequestTopic, targetRequest, requestData); err != nil {
+		return "", err
+	}
+	baseLogic.InertTdLog(ctx, consts.MsgTypeConfigPush, request.DeviceDetail.Key, dcommon.BuildDownstreamRouteLog(request, targetRequest, identity, r))
+	return r.Id, nil
+}
+
+// ConfigGetReply 回复设备请求的配置
+func ConfigGetReply(ctx context.Context, request topicModel.TopicDownHandlerData, reply sagooProtocol.ConfigGetResponse) error {
+	targetRequest, identity, err := dcommon.ResolveDownstreamTarget(ctx, request)
+	if err != nil {
+		return err
+	}
+	payload := gconv.Map(reply)
+	if identity != nil {
+		payload["identity"] = identity
+	}
+	requestData, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return write(ctx, sagooProtocol.ConfigGetResponseTopic, targetRequest, requestData)
+}
+
+func write(ctx context.Context, topic string, targetRequest topicModel.TopicDownHandlerData, requestData []byte) error {
+	transportProtocol := targetRequest.DeviceDetail.Product.TransportProtocol
+	if transportProtocol == "mqtt_server" {
+		return mqtt.Publish(fmt.Sprintf(strings.ReplaceAll(topic, "+", "%s"), targetRequest.DeviceDetail.Product.Key, targetRequest.DeviceDetail.Key), requestData)
+	} else if transportProtocol == "udp" || transportProtocol == "tcp" || transportProtocol == "http" || transportProtocol == "websocket" || transportProtocol == "coap" {
+		return dservice.WriteTunnel(ctx, "config", targetRequest, requestData)
+	}
+	return fmt.Errorf("transport protocol %s not support", transportProtocol)
+}
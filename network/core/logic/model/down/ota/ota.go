@@ -0,0 +1,86 @@
+package ota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/mqtt"
+	"sagooiot/network/core/logic/baseLogic"
+	dcommon "sagooiot/network/core/logic/model/down/common"
+	dservice "sagooiot/network/core/logic/model/down/service"
+	"sagooiot/pkg/iotModel/sagooProtocol"
+	"sagooiot/pkg/iotModel/topicModel"
+	"strings"
+	"time"
+)
+
+// envelope 下发报文，子设备场景在根节点增加 identity，便于网关识别目标子设备
+type envelope struct {
+	Payload  interface{}
+	Identity *sagooProtocol.Identity
+}
+
+func (e envelope) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(e.Payload)
+	if err != nil || e.Identity == nil {
+		return data, err
+	}
+	payload := make(map[string]interface{})
+	if err = json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+	payload["identity"] = e.Identity
+	return json.Marshal(payload)
+}
+
+// UpgradeCommand 向设备推送升级包信息
+func UpgradeCommand(ctx context.Context, request topicModel.TopicDownHandlerData, data sagooProtocol.UpgradeCommandRequestData) error {
+	r := sagooProtocol.UpgradeCommandRequest{
+		Code:    "1000",
+		Data:    data,
+		Id:      int(time.Now().UnixMilli() % 1e9),
+		Message: "success",
+	}
+	return write(ctx, consts.MsgTypeUpgradeCommand, sagooProtocol.OtaUpgradeCommandTopic, request, r)
+}
+
+// FirmwareGetReply 回复设备请求的升级包信息
+func FirmwareGetReply(ctx context.Context, request topicModel.TopicDownHandlerData, reply sagooProtocol.FirmwareGetReply) error {
+	return write(ctx, consts.MsgTypeFirmwareGet, sagooProtocol.OtaDeviceRequestUpgradePackageResponseTopic, request, reply)
+}
+
+// FileDownloadReply 回复设备请求的文件分片，分片内容较大不写日志
+func FileDownloadReply(ctx context.Context, request topicModel.TopicDownHandlerData, reply sagooProtocol.FileDownloadReply) error {
+	return write(ctx, "", sagooProtocol.OtaDeviceRequestDownloadFileResponseTopic, request, reply)
+}
+
+func write(ctx context.Context, logType, topic string, request topicModel.TopicDownHandlerData, payload interface{}) error {
+	targetRequest, identity, err := dcommon.ResolveDownstreamTarget(ctx, request)
+	if err != nil {
+		return err
+	}
+	requestData, err := json.Marshal(envelope{Payload: payload, Identity: identity})
+	if err != nil {
+		return err
+	}
+
+	transportProtocol := targetRequest.DeviceDetail.Product.TransportProtocol
+	switch transportProtocol {
+	case "mqtt_server":
+		if err = mqtt.Publish(fmt.Sprintf(strings.ReplaceAll(topic, "+", "%s"), targetRequest.DeviceDetail.Product.Key, targetRequest.DeviceDetail.Key), requestData); err != nil {
+			return err
+		}
+	case "udp", "tcp", "http", "websocket", "coap":
+		if err = dservice.WriteTunnel(ctx, "ota", targetRequest, requestData); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("transport protocol %s not support", transportProtocol)
+	}
+	if logType == "" {
+		return nil
+	}
+	baseLogic.InertTdLog(ctx, logType, request.DeviceDetail.Key, dcommon.BuildDownstreamRouteLog(request, targetRequest, identity, payload))
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gogf/gf/v2/util/gconv"
 	"github.com/gogf/gf/v2/util/guid"
 )
 
@@ -58,21 +59,11 @@ func PropertySet(ctx context.Context, request topicModel.TopicDownHandlerData) (
 		return nil, err
 	}
 
-	transportProtocol := targetRequest.DeviceDetail.Product.TransportProtocol
-	if transportProtocol == "mqtt_server" {
-		if err = mqtt.Publish(fmt.Sprintf(strings.ReplaceAll(sagooProtocol.PropertySetRegisterSubRequestTopic, "+", "%s"), targetRequest.DeviceDetail.Product.Key, targetRequest.DeviceDetail.Key), requestData); err != nil {
-			return nil, err
-		}
-	} else if transportProtocol == "udp" || transportProtocol == "tcp" {
-		reqData, payloadErr := dcommon.BuildTunnelPayload(requestDataMap, identity)
-		if payloadErr != nil {
-			return nil, payloadErr
-		}
-		if err = dservice.WriteTunnel(ctx, "property", targetRequest, reqData); err != nil {
-			return nil, err
-		}
-	} else {
-		return nil, fmt.Errorf("transport protocol %s not support", transportProtocol)
+	// 下发之前登记请求，设备响应可能由其他实例收到
+	pending := dcommon.RegisterRequest(ctx, request, r.Id, "SetProperty", r)
+	if err = send(ctx, targetRequest, identity, requestDataMap, requestData); err != nil {
+		dcommon.CancelRequest(ctx, pending, err)
+		return nil, err
 	}
 	//北向属性设置消息
 	north.WriteMessage(ctx, north.PropertySetMessageTopic, nil, request.DeviceDetail.Product.Key, request.DeviceDetail.Key, iotModel.PropertySetMessage{
@@ -80,13 +71,13 @@ func PropertySet(ctx context.Context, request topicModel.TopicDownHandlerData) (
 		Timestamp:  time.Now().UnixMilli(),
 	})
 	baseLogic.InertTdLog(ctx, consts.MsgTypePropertySet, request.DeviceDetail.Key, dcommon.BuildDownstreamRouteLog(request, targetRequest, identity, r))
-	response, err := baseLogic.SyncRequest(ctx, r.Id, "SetProperty", r, 0)
+	response, err := dcommon.WaitReply(ctx, pending)
 	if err != nil {
 		return nil, err
 	} else if res, covertOk := response.(map[string]interface{}); !covertOk {
 		return nil, fmt.Errorf("set property  failed,response: %+v", response)
 	} else {
-		code, _ := res["code"].(int)
+		code := gconv.Int(res["code"])
 		//北向属性设置回复消息
 		north.WriteMessage(ctx, north.PropertySetReplyMessageTopic, nil, request.DeviceDetail.Product.Key, request.DeviceDetail.Key, iotModel.PropertySetReplyMessage{
 			Code:      code,
@@ -96,3 +87,26 @@ func PropertySet(ctx context.Context, request topicModel.TopicDownHandlerData) (
 		return res, nil
 	}
 }
+
+// send 按产品的传输协议下发属性设置请求，下发前经过产品脚本编码
+func send(ctx context.Context, target topicModel.TopicDownHandlerData, identity *sagooProtocol.Identity, params map[string]interface{}, requestData []byte) (err error) {
+	transportProtocol := target.DeviceDetail.Product.TransportProtocol
+	payload := requestData
+	if transportProtocol == "udp" || transportProtocol == "tcp" {
+		if payload, err = dcommon.BuildTunnelPayload(params, identity); err != nil {
+			return err
+		}
+	}
+	if payload, err = dcommon.EncodePayload(target, payload); err != nil {
+		return err
+	}
+	if transportProtocol == "mqtt_server" {
+		return mqtt.Publish(fmt.Sprintf(strings.ReplaceAll(sagooProtocol.PropertySetRegisterSubRequestTopic, "+", "%s"), target.DeviceDetail.Product.Key, target.DeviceDetail.Key), payload)
+	} else if transportProtocol == "udp" || transportProtocol == "tcp" {
+		return dservice.WriteTunnel(ctx, "property", target, payload)
+	} else if transportProtocol == "http" || transportProtocol == "websocket" || transportProtocol == "coap" {
+		// http设备进入通道的下发队列，设备下次请求时带回；websocket设备直接推送完整的请求报文；coap设备有观察关系时推送，否则进入下发队列
+		return dservice.WriteTunnel(ctx, "property", target, payload)
+	}
+	return fmt.Errorf("transport protocol %s not support", transportProtocol)
+}
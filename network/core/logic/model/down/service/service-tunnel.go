@@ -42,5 +42,9 @@ func WriteTunnel(ctx context.Context, funcKey string, request topicModel.TopicDo
 		}
 		paramsBytes, _ = json.Marshal(pluginData.Data)
 	}
-	return s.Instance.GetTunnel(base.GetTunnelIdByDeviceKey(ctx, request.DeviceDetail.Key)).Write(paramsBytes)
+	tnl := s.Instance.GetTunnel(base.GetTunnelIdByDeviceKey(ctx, request.DeviceDetail.Key))
+	if tnl == nil {
+		return fmt.Errorf("tunnel not found,serverId:%d,deviceKey:%s", t.ServerId, request.DeviceDetail.Key)
+	}
+	return tnl.Write(paramsBytes)
 }
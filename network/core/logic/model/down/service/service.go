@@ -16,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gogf/gf/v2/util/gconv"
 	"github.com/gogf/gf/v2/util/guid"
 )
 
@@ -69,24 +70,11 @@ func ServiceCall(ctx context.Context, funcKey string, request topicModel.TopicDo
 	time.Sleep(time.Second * 1)
 	g.Log().Debug(ctx, "service call request: %s", string(requestData))
 
-	// 产品定义的传输协议支持 tcp/udp/mqtt_server/http/websocket 后面定义为变量
-	if targetRequest.DeviceDetail.Product.TransportProtocol == "mqtt_server" {
-		if err := mqtt.Publish(fmt.Sprintf(strings.ReplaceAll(sagooProtocol.ServiceCallRegisterSubRequestTopic, "+", "%s"), targetRequest.DeviceDetail.Product.Key, targetRequest.DeviceDetail.Key, funcKey), requestData); err != nil {
-			return nil, err
-		}
-	} else if targetRequest.DeviceDetail.Product.TransportProtocol == "udp" || targetRequest.DeviceDetail.Product.TransportProtocol == "tcp" {
-		// 如果是udp或者tcp，查询出通道然后通过通道下发，需要注意的是，仅仅支持服务端建立的通道。
-		//todo 暂时不支持多节点部署，支持多节点部署的话，需要一个有效的查找连接的方法
-
-		reqData, err := dcommon.BuildTunnelPayload(requestDataMap, identity)
-		if err != nil {
-			return nil, err
-		}
-		if err := WriteTunnel(ctx, funcKey, targetRequest, reqData); err != nil {
-			return nil, err
-		}
-	} else {
-		return nil, fmt.Errorf("transport protocol %s not support", targetRequest.DeviceDetail.Product.TransportProtocol)
+	// 下发之前登记请求，设备响应可能由其他实例收到
+	pending := dcommon.RegisterRequest(ctx, request, r.Id, funcKey, r)
+	if err = send(ctx, funcKey, targetRequest, identity, requestDataMap, requestData); err != nil {
+		dcommon.CancelRequest(ctx, pending, err)
+		return nil, err
 	}
 	//北向服务调用请求消息
 	north.WriteMessage(ctx, north.ServiceCallMessageTopic, nil, request.DeviceDetail.Product.Key, request.DeviceDetail.Key, iotModel.ServiceCallMessage{
@@ -94,13 +82,13 @@ func ServiceCall(ctx context.Context, funcKey string, request topicModel.TopicDo
 		Params:    requestDataMap,
 		Timestamp: time.Now().UnixMilli(),
 	})
-	response, err := baseLogic.SyncRequest(ctx, r.Id, funcKey, r, 0)
+	response, err := dcommon.WaitReply(ctx, pending)
 	if err != nil {
 		return nil, err
 	} else if res, covertOk := response.(map[string]interface{}); !covertOk {
 		return nil, fmt.Errorf("invoke service %s failed,response: %+v", funcKey, response)
 	} else {
-		code, _ := res["code"].(int)
+		code := gconv.Int(res["code"])
 		//北向服务调用响应请求消息
 		north.WriteMessage(ctx, north.ServiceReplyMessageTopic, nil, request.DeviceDetail.Product.Key, request.DeviceDetail.Key, iotModel.ServiceCallReplyMessage{
 			ServiceId: funcKey,
@@ -112,3 +100,29 @@ func ServiceCall(ctx context.Context, funcKey string, request topicModel.TopicDo
 	}
 
 }
+
+// send 按产品的传输协议下发服务调用请求，下发前经过产品脚本编码
+func send(ctx context.Context, funcKey string, target topicModel.TopicDownHandlerData, identity *sagooProtocol.Identity, params map[string]interface{}, requestData []byte) (err error) {
+	// 产品定义的传输协议支持 tcp/udp/mqtt_server/http/websocket/coap 后面定义为变量
+	transportProtocol := target.DeviceDetail.Product.TransportProtocol
+	payload := requestData
+	if transportProtocol == "udp" || transportProtocol == "tcp" {
+		if payload, err = dcommon.BuildTunnelPayload(params, identity); err != nil {
+			return err
+		}
+	}
+	if payload, err = dcommon.EncodePayload(target, payload); err != nil {
+		return err
+	}
+	if transportProtocol == "mqtt_server" {
+		return mqtt.Publish(fmt.Sprintf(strings.ReplaceAll(sagooProtocol.ServiceCallRegisterSubRequestTopic, "+", "%s"), target.DeviceDetail.Product.Key, target.DeviceDetail.Key, funcKey), payload)
+	} else if transportProtocol == "udp" || transportProtocol == "tcp" {
+		// 如果是udp或者tcp，查询出通道然后通过通道下发，需要注意的是，仅仅支持服务端建立的通道。
+		//todo 暂时不支持多节点部署，支持多节点部署的话，需要一个有效的查找连接的方法
+		return WriteTunnel(ctx, funcKey, target, payload)
+	} else if transportProtocol == "http" || transportProtocol == "websocket" || transportProtocol == "coap" {
+		// http设备进入通道的下发队列，设备下次请求时带回；websocket设备直接推送完整的请求报文；coap设备有观察关系时推送，否则进入下发队列
+		return WriteTunnel(ctx, funcKey, target, payload)
+	}
+	return fmt.Errorf("transport protocol %s not support", transportProtocol)
+}
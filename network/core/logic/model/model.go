@@ -2,7 +2,9 @@ package model
 
 import (
 	"context"
+	"sagooiot/network/core/logic/model/up/config"
 	"sagooiot/network/core/logic/model/up/event"
+	"sagooiot/network/core/logic/model/up/ota"
 	"sagooiot/network/core/logic/model/up/property/batch"
 	"sagooiot/network/core/logic/model/up/property/reporter"
 	"sagooiot/network/core/logic/model/up/property/set"
@@ -16,6 +18,8 @@ func InitCoreLogic(ctx context.Context) error {
 		reporter.Init,
 		set.Init,
 		service.Init,
+		ota.Init,
+		config.Init,
 	} {
 		if err := v(); err != nil {
 			return err
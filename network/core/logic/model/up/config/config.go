@@ -0,0 +1,104 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+	"sagooiot/network/core"
+	dconfig "sagooiot/network/core/logic/model/down/config"
+	"sagooiot/network/core/tunnel/base"
+	"sagooiot/pkg/iotModel"
+	"sagooiot/pkg/iotModel/sagooProtocol"
+	"sagooiot/pkg/iotModel/sagooProtocol/north"
+	"sagooiot/pkg/iotModel/topicModel"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+func Init() (err error) {
+	//  /sys/${productKey}/${deviceKey}/thing/config/push/reply
+	if err = core.RegisterSubTopicHandler(sagooProtocol.ConfigPushRegisterSubResponseTopic, consts.MsgTypeConfigPushReply, ConfigPushReply); err != nil {
+		return err
+	}
+	base.RegisterModelType(base.UpSetConfig, base.ModelType{
+		LogType:          consts.MsgTypeConfigPushReply,
+		GetTopicWithInfo: GetPushReplyTopic,
+		Handle:           ConfigPushReply,
+	})
+	//  /sys/${productKey}/${deviceKey}/thing/config/get
+	if err = core.RegisterSubTopicHandler(sagooProtocol.ConfigGetRequestTopic, consts.MsgTypeConfigGet, ConfigGet); err != nil {
+		return err
+	}
+	base.RegisterModelType(base.UpConfigGet, base.ModelType{
+		LogType:          consts.MsgTypeConfigGet,
+		GetTopicWithInfo: GetConfigGetTopic,
+		Handle:           ConfigGet,
+	})
+	return nil
+}
+
+// ConfigPushReply 设备响应配置下发
+func ConfigPushReply(ctx context.Context, data topicModel.TopicHandlerData) error {
+	var res sagooProtocol.ConfigPushResponse
+	if err := json.Unmarshal(data.PayLoad, &res); err != nil {
+		g.Log().Errorf(ctx, "parse data error: %v, topic:%s, message:%s, message ignored", err, data.Topic, string(data.PayLoad))
+		return err
+	}
+	if err := service.DevConfig().Reply(ctx, &model.DeviceConfigReplyInput{
+		DeviceKey: data.DeviceKey,
+		RequestId: res.Id,
+		Code:      res.Code,
+		Data:      res.Data,
+	}); err != nil {
+		return err
+	}
+	//北向配置下发响应消息
+	north.WriteMessage(ctx, north.ConfigSendMessageReplyTopic, nil, data.ProductKey, data.DeviceKey, iotModel.ConfigSetReplyMessage{
+		Code:      res.Code,
+		Data:      res.Data,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	return nil
+}
+
+// ConfigGet 设备主动请求配置，平台回复设备生效的配置
+func ConfigGet(ctx context.Context, data topicModel.TopicHandlerData) error {
+	var req sagooProtocol.ConfigGetRequest
+	if err := json.Unmarshal(data.PayLoad, &req); err != nil {
+		g.Log().Errorf(ctx, "parse data error: %v, topic:%s, message:%s, message ignored", err, data.Topic, string(data.PayLoad))
+		return err
+	}
+	//北向配置获取消息
+	north.WriteMessage(ctx, north.ConfigGetMessageTopic, nil, data.ProductKey, data.DeviceKey, iotModel.ConfigGetMessage{
+		ConfigScope: req.Params.ConfigScope,
+		GetType:     req.Params.GetType,
+	})
+
+	version := req.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+	reply := sagooProtocol.ConfigGetResponse{Id: req.Id, Version: version, Code: 200}
+	config, err := service.DevConfig().Get(ctx, data.DeviceKey, req.Params.ConfigScope)
+	if err != nil {
+		reply.Code = 500
+	} else if config == nil {
+		reply.Code = 404
+	} else {
+		reply.Data = *config
+	}
+	return dconfig.ConfigGetReply(ctx, topicModel.TopicDownHandlerData{DeviceDetail: data.DeviceDetail}, reply)
+}
+
+func GetPushReplyTopic(productKey, deviceKey, identity string) string {
+	return fmt.Sprintf(strings.ReplaceAll(sagooProtocol.ConfigPushRegisterSubResponseTopic, "+", "%s"), productKey, deviceKey)
+}
+
+func GetConfigGetTopic(productKey, deviceKey, identity string) string {
+	return fmt.Sprintf(strings.ReplaceAll(sagooProtocol.ConfigGetRequestTopic, "+", "%s"), productKey, deviceKey)
+}
@@ -10,6 +10,7 @@ import (
 	"sagooiot/internal/model"
 	"sagooiot/internal/mqtt"
 	"sagooiot/internal/service"
+	"sagooiot/internal/webscoket/realtime"
 	"sagooiot/network/core"
 	"sagooiot/network/core/logic/model/up/property/reporter"
 	"sagooiot/network/core/tunnel/base"
@@ -83,6 +84,10 @@ func ReportEvent(ctx context.Context, data topicModel.TopicHandlerData) error {
 		Events:    reportEventData.Param.Value,
 		Timestamp: time.Now().UnixMilli(),
 	})
+	realtime.Publish(ctx, realtime.TypeEvent, data.DeviceDetail.Product.Key, data.DeviceDetail.Key, g.Map{
+		"eventId": eventKey,
+		"events":  reportEventData.Param.Value,
+	})
 	if alarmCheckErr := service.AlarmRule().Check(ctx, data.DeviceKey, data.DeviceKey, consts.AlarmTriggerTypeProperty, reportEventData); alarmCheckErr != nil {
 		g.Log().Errorf(ctx, "alarm check error: %v, topic:%s, message:%s, message ignored", alarmCheckErr, data.Topic, string(data.PayLoad))
 		return alarmCheckErr
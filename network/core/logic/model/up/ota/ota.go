@@ -0,0 +1,128 @@
+package ota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+	"sagooiot/network/core"
+	dota "sagooiot/network/core/logic/model/down/ota"
+	"sagooiot/network/core/tunnel/base"
+	"sagooiot/pkg/iotModel/sagooProtocol"
+	"sagooiot/pkg/iotModel/topicModel"
+	"strings"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+func Init() (err error) {
+	//  /ota/device/inform/${productKey}/${deviceKey}
+	if err = core.RegisterSubTopicHandler(sagooProtocol.OtaDeviceInformVersionInfoTopic, consts.MsgTypeDeviceInForm, Inform); err != nil {
+		return err
+	}
+	base.RegisterModelType(base.UpInform, base.ModelType{
+		LogType:          consts.MsgTypeDeviceInForm,
+		GetTopicWithInfo: GetInformTopic,
+		Handle:           Inform,
+	})
+	//  /ota/device/progress/${productKey}/${deviceKey}
+	if err = core.RegisterSubTopicHandler(sagooProtocol.OtaUpgradeProgressTopic, consts.MsgTypeDeviceUpgradeProcess, Progress); err != nil {
+		return err
+	}
+	base.RegisterModelType(base.UpProcess, base.ModelType{
+		LogType:          consts.MsgTypeDeviceUpgradeProcess,
+		GetTopicWithInfo: GetProgressTopic,
+		Handle:           Progress,
+	})
+	//  /sys/${productKey}/${deviceKey}/thing/ota/firmware/get
+	if err = core.RegisterSubTopicHandler(sagooProtocol.OtaDeviceRequestUpgradePackageRequestTopic, consts.MsgTypeFirmwareGet, FirmwareGet); err != nil {
+		return err
+	}
+	//  /sys/${productKey}/${deviceKey}/thing/file/download
+	if err = core.RegisterSubTopicHandler(sagooProtocol.OtaDeviceRequestDownloadFileRequestTopic, consts.MsgTypeFileDownload, FileDownload); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Inform 设备上报版本信息
+func Inform(ctx context.Context, data topicModel.TopicHandlerData) error {
+	var req sagooProtocol.InformRequest
+	if err := json.Unmarshal(data.PayLoad, &req); err != nil {
+		g.Log().Errorf(ctx, "parse data error: %v, topic:%s, message:%s, message ignored", err, data.Topic, string(data.PayLoad))
+		return err
+	}
+	return service.DevFirmware().Inform(ctx, &model.FirmwareInformInput{
+		DeviceKey: data.DeviceKey,
+		Module:    req.Params.Module,
+		Version:   req.Params.Version,
+	})
+}
+
+// Progress 设备上报升级进度
+func Progress(ctx context.Context, data topicModel.TopicHandlerData) error {
+	var req sagooProtocol.ProProgress
+	if err := json.Unmarshal(data.PayLoad, &req); err != nil {
+		g.Log().Errorf(ctx, "parse data error: %v, topic:%s, message:%s, message ignored", err, data.Topic, string(data.PayLoad))
+		return err
+	}
+	return service.DevFirmware().Progress(ctx, &model.FirmwareProgressInput{
+		DeviceKey: data.DeviceKey,
+		Module:    req.Params.Module,
+		Step:      gconv.Int(req.Params.Step),
+		Desc:      req.Params.Desc,
+	})
+}
+
+// FirmwareGet 设备请求升级包信息
+func FirmwareGet(ctx context.Context, data topicModel.TopicHandlerData) error {
+	var req sagooProtocol.FirmwareGetRequest
+	if err := json.Unmarshal(data.PayLoad, &req); err != nil {
+		g.Log().Errorf(ctx, "parse data error: %v, topic:%s, message:%s, message ignored", err, data.Topic, string(data.PayLoad))
+		return err
+	}
+	reply := sagooProtocol.FirmwareGetReply{Id: req.Id, Code: 200, Message: "success"}
+	pending, err := service.DevFirmware().Pending(ctx, data.DeviceKey, req.Params.Module)
+	if err != nil {
+		reply.Code, reply.Message = 500, err.Error()
+	} else if pending == nil {
+		reply.Code, reply.Message = 404, "no upgrade package"
+	}
+	reply.Data = pending
+	return dota.FirmwareGetReply(ctx, topicModel.TopicDownHandlerData{DeviceDetail: data.DeviceDetail}, reply)
+}
+
+// FileDownload 设备分片下载固件文件
+func FileDownload(ctx context.Context, data topicModel.TopicHandlerData) error {
+	var req sagooProtocol.FileDownloadRequest
+	if err := json.Unmarshal(data.PayLoad, &req); err != nil {
+		g.Log().Errorf(ctx, "parse data error: %v, topic:%s, message:%s, message ignored", err, data.Topic, string(data.PayLoad))
+		return err
+	}
+	reply := sagooProtocol.FileDownloadReply{Id: req.Id, Code: 200, Message: "success"}
+	// 使用设备实际所属的产品，不信任主题中的产品标识
+	productKey := ""
+	if data.DeviceDetail != nil && data.DeviceDetail.Product != nil {
+		productKey = data.DeviceDetail.Product.Key
+	}
+	block, err := service.DevFirmware().ReadBlock(ctx, productKey, data.DeviceKey, req.Params.FirmwareId, req.Params.Offset, req.Params.Size)
+	if err != nil {
+		reply.Code, reply.Message = 500, err.Error()
+		reply.Data.FirmwareId = req.Params.FirmwareId
+		reply.Data.Offset = req.Params.Offset
+	} else {
+		reply.Data = *block
+	}
+	return dota.FileDownloadReply(ctx, topicModel.TopicDownHandlerData{DeviceDetail: data.DeviceDetail}, reply)
+}
+
+func GetInformTopic(productKey, deviceKey, identity string) string {
+	return fmt.Sprintf(strings.ReplaceAll(sagooProtocol.OtaDeviceInformVersionInfoTopic, "+", "%s"), productKey, deviceKey)
+}
+
+func GetProgressTopic(productKey, deviceKey, identity string) string {
+	return fmt.Sprintf(strings.ReplaceAll(sagooProtocol.OtaUpgradeProgressTopic, "+", "%s"), productKey, deviceKey)
+}
@@ -10,6 +10,7 @@ import (
 	"sagooiot/internal/model"
 	"sagooiot/internal/mqtt"
 	"sagooiot/internal/service"
+	"sagooiot/internal/webscoket/realtime"
 	"sagooiot/network/core"
 	"sagooiot/network/core/logic/baseLogic"
 	"sagooiot/network/core/tunnel/base"
@@ -97,11 +98,18 @@ func handleProperties(ctx context.Context, data topicModel.TopicHandlerData, sub
 		north.WriteMessage(ctx, north.PropertyReportMessageTopic, nil, subDevice.ProductKey, subDevice.Key, iotModel.PropertyReportMessage{
 			Properties: reportDataInfo,
 		})
+		realtime.Publish(ctx, realtime.TypeProperty, subDevice.ProductKey, subDevice.Key, reportDataInfo)
 
 		// 检查报警规则
 		if err := service.AlarmRule().Check(ctx, subDevice.ProductKey, subDevice.Key, consts.AlarmTriggerTypeProperty, reportDataInfo); err != nil {
 			return logError(ctx, "handleProperties alarm check error", err, data)
 		}
+		if err := service.Scene().Check(ctx, subDevice.ProductKey, subDevice.Key, consts.SceneTriggerReportAttribute, reportDataInfo); err != nil {
+			return logError(ctx, "handleProperties scene check error", err, data)
+		}
+		if err := service.DevDeviceShadow().Report(ctx, subDevice.Key, reportDataInfo); err != nil {
+			return logError(ctx, "handleProperties device shadow error", err, data)
+		}
 	}
 
 	return nil
@@ -127,6 +135,10 @@ func handleEvents(ctx context.Context, data topicModel.TopicHandlerData, subDevi
 			Events:    eventData.Value,
 			Timestamp: time.Now().UnixMilli(),
 		})
+		realtime.Publish(ctx, realtime.TypeEvent, subDevice.ProductKey, subDevice.Key, g.Map{
+			"eventId": eventName,
+			"events":  eventData.Value,
+		})
 
 	}
 	return nil
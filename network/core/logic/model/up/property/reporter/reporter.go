@@ -8,6 +8,8 @@ import (
 	"sagooiot/internal/consts"
 	"sagooiot/internal/mqtt"
 	"sagooiot/internal/service"
+	"sagooiot/internal/webscoket/realtime"
+	"sagooiot/network/core/tunnel/base"
 	"sagooiot/pkg/iotModel"
 	"sagooiot/pkg/iotModel/sagooProtocol"
 	"sagooiot/pkg/iotModel/sagooProtocol/north"
@@ -16,6 +18,12 @@ import (
 )
 
 func Init() (err error) {
+	// 属性上报的topic由事件的通配topic统一订阅，这里只注册通道使用的处理方法
+	base.RegisterModelType(base.UpProperty, base.ModelType{
+		LogType:          consts.MsgTypePropertyReport,
+		GetTopicWithInfo: GetTopicWithInfo,
+		Handle:           ReportProperty,
+	})
 	return nil
 }
 
@@ -42,6 +50,7 @@ func ReportProperty(ctx context.Context, data topicModel.TopicHandlerData) error
 	north.WriteMessage(ctx, north.PropertyReportMessageTopic, nil, data.ProductKey, data.DeviceDetail.Key, iotModel.PropertyReportMessage{
 		Properties: reportDataInfo,
 	})
+	realtime.Publish(ctx, realtime.TypeProperty, data.ProductKey, data.DeviceDetail.Key, reportDataInfo)
 
 	//告警处理
 	err = service.AlarmRule().Check(ctx, data.ProductKey, data.DeviceKey, consts.AlarmTriggerTypeProperty, reportDataInfo)
@@ -49,6 +58,16 @@ func ReportProperty(ctx context.Context, data topicModel.TopicHandlerData) error
 		g.Log().Errorf(ctx, "告警检测失败: %s", err.Error())
 	}
 
+	//场景联动
+	if err = service.Scene().Check(ctx, data.ProductKey, data.DeviceKey, consts.SceneTriggerReportAttribute, reportDataInfo); err != nil {
+		g.Log().Errorf(ctx, "场景检测失败: %s", err.Error())
+	}
+
+	//设备影子
+	if err = service.DevDeviceShadow().Report(ctx, data.DeviceKey, reportDataInfo); err != nil {
+		g.Log().Errorf(ctx, "设备影子更新失败: %s", err.Error())
+	}
+
 	//记录结束时间
 	//end := time.Now()
 	//计算运行时间
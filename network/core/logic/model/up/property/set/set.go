@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
 	"sagooiot/internal/consts"
 	"sagooiot/network/core"
 	"sagooiot/network/core/logic/baseLogic"
@@ -44,25 +45,21 @@ func PropertySet(ctx context.Context, data topicModel.TopicHandlerData) error {
 		// 忽略Id不存在的消息
 		return errors.New("ignore")
 	}
-	funcKey, _, responseChan, err := baseLogic.GetCallInfoById(ctx, setPropertyRes.Id)
-	if err != nil {
+	if _, _, err := baseLogic.GetCallInfoById(ctx, setPropertyRes.Id); err != nil {
 		g.Log().Debugf(ctx, "get call info for service(id:%s) call error:%s", setPropertyRes.Id, err.Error())
 		return err
 	}
 	var responseData = make(map[string]interface{})
-	for _, function := range data.DeviceDetail.TSL.Functions {
-		if function.Key == funcKey {
-			for _, node := range function.Outputs {
-				for key, value := range setPropertyRes.Data {
-					if key == node.Key {
-						responseData[key] = node.ValueType.ConvertValue(value)
-					}
-				}
-			}
+	for _, property := range data.DeviceDetail.TSL.Properties {
+		if value, ok := setPropertyRes.Data[property.Key]; ok {
+			responseData[property.Key] = property.ValueType.ConvertValue(value)
 		}
 	}
-	responseChan <- responseData
-	return nil
+	response := gconv.Map(setPropertyRes)
+	if len(responseData) > 0 {
+		response["data"] = responseData
+	}
+	return baseLogic.Reply(ctx, setPropertyRes.Id, response)
 }
 
 func GetTopicWithInfo(deviceKey, productKey, identity string) string {
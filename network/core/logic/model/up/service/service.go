@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/util/gconv"
 	"sagooiot/internal/consts"
 	"sagooiot/network/core"
 	"sagooiot/network/core/logic/baseLogic"
@@ -44,7 +45,7 @@ func ServiceCallOutput(ctx context.Context, data topicModel.TopicHandlerData) er
 		// 忽略Id不存在的消息
 		return errors.New("ignore")
 	}
-	funcKey, _, responseChan, err := baseLogic.GetCallInfoById(ctx, serviceCallResult.Id)
+	funcKey, _, err := baseLogic.GetCallInfoById(ctx, serviceCallResult.Id)
 	if err != nil {
 		g.Log().Errorf(ctx, "get call info for service(id:%s) call error:%s", serviceCallResult.Id, err.Error())
 		return err
@@ -61,8 +62,11 @@ func ServiceCallOutput(ctx context.Context, data topicModel.TopicHandlerData) er
 			}
 		}
 	}
-	responseChan <- responseData
-	return nil
+	response := gconv.Map(serviceCallResult)
+	if len(responseData) > 0 {
+		response["data"] = responseData
+	}
+	return baseLogic.Reply(ctx, serviceCallResult.Id, response)
 }
 
 func GetTopicWithInfo(productKey, deviceKey, identity string) string {
@@ -6,6 +6,9 @@ import (
 	logicModel "sagooiot/internal/model"
 	"sagooiot/network/model"
 	"strconv"
+	"strings"
+
+	"github.com/gogf/gf/v2/util/gconv"
 )
 
 // Server Server todo 需要处理下心跳和注册包，需要考虑到客户端可能不支持心跳或者注册包
@@ -40,21 +43,30 @@ func Server(ctx context.Context, res *logicModel.NetworkServerOut) model.Server
 	return s
 }
 
+// StationTag 设置设备从站地址的标签标识
+const StationTag = "station"
+
 func Device(res logicModel.DeviceOutput) model.Device {
-	return model.Device{
+	d := model.Device{
 		DeviceKey:  res.Key,
 		TunnelId:   uint64(res.TunnelId),
 		ProductKey: res.ProductKey,
 		Name:       res.Name,
-		//todo 这里的station等待后面处理
-		Station:  res.Status,
-		Disabled: false,
-		Created:  res.CreatedAt.Time,
+		Disabled:   false,
+		Created:    res.CreatedAt.Time,
 	}
+	// 从站地址取设备的 station 标签，未设置时为0，使用产品采集策略中的从站地址
+	for _, tag := range res.Tags {
+		if tag.Key == StationTag {
+			d.Station = gconv.Int(strings.TrimSpace(tag.Value))
+			break
+		}
+	}
+	return d
 }
 
 func Product(res logicModel.DetailProductOutput) model.Product {
-	return model.Product{
+	p := model.Product{
 		Id:           strconv.Itoa(int(res.Id)),
 		Name:         res.Name,
 		Manufacturer: res.CategoryName,
@@ -69,6 +81,15 @@ func Product(res logicModel.DetailProductOutput) model.Product {
 		Commands: nil,
 		Created:  res.CreatedAt.Time,
 	}
+	// 采集策略：Modbus参数、采集器和数据点
+	if res.Policy != "" {
+		var policy model.Policy
+		StrToPointInterfaceWithoutError(context.Background(), res.Policy, &policy)
+		p.Modbus = policy.Modbus
+		p.Pollers = policy.Pollers
+		p.Points = policy.Points
+	}
+	return p
 }
 
 func Tunnel(ctx context.Context, res logicModel.NetworkTunnelOut) model.Tunnel {
@@ -7,14 +7,12 @@ import (
 	"fmt"
 	MQTT "github.com/eclipse/paho.mqtt.golang"
 	"github.com/gogf/gf/v2/frame/g"
-	"github.com/gogf/gf/v2/util/gconv"
 	"sagooiot/internal/consts"
 	"sagooiot/internal/model"
 	"sagooiot/internal/mqtt"
 	"sagooiot/network/core/logic/baseLogic"
 	"sagooiot/pkg/dcache"
 	"sagooiot/pkg/gpool"
-	"sagooiot/pkg/iotModel/sagooProtocol"
 	"sagooiot/pkg/iotModel/topicModel"
 	"sagooiot/pkg/jsinterpreter"
 	"sagooiot/pkg/plugins"
@@ -82,16 +80,6 @@ func (s *SubMap) HandleMessage(ctx context.Context, handleF handleFunc) func(con
 				return nil
 			}
 
-			// 处理设备应答
-			if strings.HasSuffix(topicInfo[6], "reply") {
-				var msg sagooProtocol.ServiceCallOutputRes
-				json.Unmarshal([]byte(res), &msg)
-
-				if info, ok := baseLogic.AsyncMapInfo.Info[msg.Id]; ok {
-					info.Response <- gconv.Map(msg)
-				}
-			}
-
 			// 获取设备详情，拿出来消息协议，然后按照产品定义的消息协议解析消息
 			deviceInfo, err := dcache.GetDeviceDetailInfo(deviceKey)
 			if err != nil {
@@ -132,7 +120,7 @@ func (s *SubMap) HandleMessage(ctx context.Context, handleF handleFunc) func(con
 			// 如果有js脚本，根据js脚本处理解析后的数据，处理后的数据数据格式为默认的消息协议格式
 			if deviceInfo.Product.ScriptInfo != "" {
 				var runScriptErr error
-				res, runScriptErr = jsinterpreter.RunScript(res, deviceInfo.Product.ScriptInfo)
+				res, runScriptErr = jsinterpreter.Parse(deviceInfo.Product.Key, deviceInfo.Product.ScriptInfo, res)
 				if runScriptErr != nil {
 					return errors.New(fmt.Sprintf("runScriptErr error: %v, topic:%s, message:%s, message ignored", runScriptErr, message.Topic(), string(message.Payload())))
 				}
@@ -0,0 +1,261 @@
+package coap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gogf/gf/v2/frame/g"
+	"net"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/network/core/logic/baseLogic"
+	"sagooiot/network/core/server/common"
+	"sagooiot/network/core/tunnel"
+	tunelBase "sagooiot/network/core/tunnel/base"
+	"sagooiot/pkg/dcache"
+	"sagooiot/pkg/iotModel/topicModel"
+	"strings"
+)
+
+// routeDownlink 获取和观察下行指令的路径
+const routeDownlink = "downlink"
+
+// reply 上报请求的响应，downlink中带回平台待下发的指令
+type reply struct {
+	Id       string            `json:"id"`
+	Code     int               `json:"code"`
+	Message  string            `json:"message"`
+	Version  string            `json:"version"`
+	Data     struct{}          `json:"data"`
+	Downlink []json.RawMessage `json:"downlink,omitempty"`
+}
+
+// route 上报路径与设备的 /sys/{productKey}/{deviceKey}/thing/... 和 /ota/device/... topic 保持一致
+func route(path string) (productKey, deviceKey, modelFuncName string, ok bool) {
+	levels := strings.Split(strings.Trim(path, "/"), "/")
+	if len(levels) == 5 && levels[0] == "ota" && levels[1] == "device" {
+		switch levels[2] {
+		case "inform":
+			return levels[3], levels[4], tunelBase.UpInform, true
+		case "progress":
+			return levels[3], levels[4], tunelBase.UpProcess, true
+		}
+		return
+	}
+	if len(levels) < 5 || levels[0] != "sys" || levels[3] != "thing" {
+		return
+	}
+	productKey, deviceKey = levels[1], levels[2]
+	switch rest := strings.Join(levels[4:], "/"); {
+	case rest == "event/property/post":
+		modelFuncName = tunelBase.UpProperty
+	case rest == "event/property/pack/post":
+		modelFuncName = tunelBase.UpBatch
+	case len(levels) == 7 && levels[4] == "event" && levels[6] == "post":
+		modelFuncName = tunelBase.UpEvent
+	case rest == "service/property/set_reply":
+		modelFuncName = tunelBase.UpSetProperty
+	case len(levels) == 6 && levels[4] == "service" && strings.HasSuffix(levels[5], "_reply"):
+		modelFuncName = tunelBase.UpServiceOutput
+	case rest == "config/push/reply":
+		modelFuncName = tunelBase.UpSetConfig
+	case rest == "config/get":
+		modelFuncName = tunelBase.UpConfigGet
+	case rest == routeDownlink:
+		modelFuncName = routeDownlink
+	default:
+		return "", "", "", false
+	}
+	return productKey, deviceKey, modelFuncName, true
+}
+
+// credential 认证信息优先从自定义选项中读取 AccessToken，也可以通过 Uri-Query 传递
+func credential(req *message) common.DeviceCredential {
+	cred := common.DeviceCredential{
+		User:   req.query("username"),
+		Passwd: req.query("password"),
+		Token:  req.query("access_token"),
+	}
+	if token, ok := req.option(optionAccessToken); ok {
+		cred.Token = string(token)
+	}
+	return cred
+}
+
+func (server *ServerCoAP) handle(ctx context.Context, remote *net.UDPAddr, req *message) *message {
+	if number, ok := req.unknownCritical(); ok {
+		return errorMessage(codeBadOption, fmt.Errorf("unsupported critical option %d", number))
+	}
+	path := req.path()
+	productKey, deviceKey, modelFuncName, ok := route(path)
+	if !ok {
+		return errorMessage(codeNotFound, errors.New("path not found"))
+	}
+	if (modelFuncName == routeDownlink && req.Code != codeGET) || (modelFuncName != routeDownlink && req.Code != codePOST) {
+		return errorMessage(codeMethodNotAllowed, errors.New("method not allowed"))
+	}
+	tnl, device, code, err := server.access(ctx, remote, req, productKey, deviceKey)
+	if err != nil {
+		g.Log().Debugf(ctx, "coap access error: %v, path:%s remote_addr:%s", err, path, remote.String())
+		return errorMessage(code, err)
+	}
+
+	b2, hasBlock2, err := req.block(optionBlock2)
+	if err != nil {
+		return errorMessage(codeBadOption, err)
+	}
+	if hasBlock2 && b2.Num > 0 {
+		// 分块获取响应的后续块
+		res := &message{Code: codeContent}
+		if req.Code == codePOST {
+			res.Code = codeChanged
+		}
+		res.setUintOption(optionContentFormat, contentFormatApplicationJson)
+		if data, found := server.cachedBlock(remote, req.Token, path); !found || !server.setBlock2(res, remote, req.Token, path, data, b2) {
+			return errorMessage(codeBadRequest, errors.New("block not found"))
+		}
+		return res
+	}
+	szx := defaultSzx
+	if hasBlock2 && b2.Szx < szx {
+		szx = b2.Szx
+	}
+
+	var res *message
+	if modelFuncName == routeDownlink {
+		res = server.handleDownlink(remote, req, tnl, path, szx)
+	} else {
+		res = server.handleUp(ctx, remote, req, tnl, device, modelFuncName, path)
+	}
+	if res.Code>>5 == 2 && res.Code != codeContinue {
+		payload := res.Payload
+		res.Payload = nil
+		server.setBlock2(res, remote, req.Token, path, payload, block{Szx: szx})
+	}
+	return res
+}
+
+// access 校验设备身份并刷新设备的通道
+func (server *ServerCoAP) access(ctx context.Context, remote *net.UDPAddr, req *message, productKey, deviceKey string) (*ServerCoapTunnel, *model.DeviceOutput, byte, error) {
+	device, err := common.GetAccessDevice(ctx, productKey, deviceKey)
+	if err != nil {
+		return nil, nil, codeNotFound, err
+	}
+	if err = common.DeviceAuth(ctx, deviceKey, credential(req)); err != nil {
+		return nil, nil, codeUnauthorized, err
+	}
+	dcache.UpdateStatus(ctx, device) //更新设备状态
+
+	tnl, err := server.getOrCreateTunnel(ctx, deviceKey, remote.String())
+	if err != nil {
+		return nil, nil, codeInternalServerError, err
+	}
+	return tnl, device, 0, nil
+}
+
+// handleUp 处理上报，大的请求体由设备分块上传，收齐后再处理
+func (server *ServerCoAP) handleUp(ctx context.Context, remote *net.UDPAddr, req *message, tnl *ServerCoapTunnel, device *model.DeviceOutput, modelFuncName, path string) *message {
+	body := req.Payload
+	b1, hasBlock1, err := req.block(optionBlock1)
+	if err != nil {
+		return errorMessage(codeBadOption, err)
+	}
+	if hasBlock1 {
+		data, done, code := server.upload(remote, path, b1, req.Payload)
+		if code != 0 {
+			res := errorMessage(code, errors.New("block upload failed"))
+			if code == codeRequestEntityTooLarge {
+				res.setUintOption(optionSize1, maxBodySize)
+			}
+			return res
+		}
+		if !done {
+			res := &message{Code: codeContinue}
+			res.setUintOption(optionBlock1, block{Num: b1.Num, More: true, Szx: b1.Szx}.encode())
+			return res
+		}
+		body = data
+	}
+
+	res, err := tunnel.DecodeData(ctx, &model.DetailProductOutput{DevProduct: device.Product}, device.Key, body)
+	if err != nil {
+		return errorMessage(codeBadRequest, err)
+	}
+	handleF := tunelBase.GetModelHandle(modelFuncName)
+	if handleF.Handle == nil {
+		return errorMessage(codeNotImplemented, errors.New("handler not registered: "+modelFuncName))
+	}
+	if err = handleF.Handle(ctx, topicModel.TopicHandlerData{
+		Topic:        path,
+		ProductKey:   device.Product.Key,
+		DeviceKey:    device.Key,
+		PayLoad:      []byte(res),
+		DeviceDetail: device,
+	}); err != nil && err.Error() != "ignore" {
+		g.Log().Infof(ctx, "handleF error: %v, topic:%s, message:%s", err, path, string(body))
+		return errorMessage(codeBadRequest, err)
+	}
+	// 记录原始日志,网关批量的放在网关内部处理
+	if handleF.LogType != consts.MsgTypeGatewayBatch {
+		baseLogic.InertTdLog(ctx, handleF.LogType, device.Key, string(body))
+	}
+
+	var request struct {
+		Id      string `json:"id"`
+		Version string `json:"version"`
+	}
+	_ = json.Unmarshal([]byte(res), &request)
+	if request.Version == "" {
+		request.Version = "1.0"
+	}
+	var list []json.RawMessage
+	if queued := tnl.conn.drain(); len(queued) > 0 {
+		_ = json.Unmarshal(downlink(queued), &list)
+	}
+	out := jsonMessage(codeChanged, reply{
+		Id:       request.Id,
+		Code:     200,
+		Message:  "success",
+		Version:  request.Version,
+		Downlink: list,
+	})
+	if hasBlock1 {
+		out.setUintOption(optionBlock1, block{Num: b1.Num, Szx: b1.Szx}.encode())
+	}
+	return out
+}
+
+// handleDownlink 获取待下发的指令，Observe 为0时登记观察关系，之后的指令通过通知推送，为1时取消观察
+func (server *ServerCoAP) handleDownlink(remote *net.UDPAddr, req *message, tnl *ServerCoapTunnel, path string, szx byte) *message {
+	res := &message{Code: codeContent}
+	res.setUintOption(optionContentFormat, contentFormatApplicationJson)
+	if v, ok := req.uintOption(optionObserve); ok {
+		switch v {
+		case 0:
+			o := &observer{addr: remote, token: req.Token, path: path, szx: szx}
+			tnl.conn.observe(o)
+			res.setUintOption(optionObserve, o.nextSeq())
+		case 1:
+			tnl.conn.cancel(req.Token)
+		}
+	}
+	res.Payload = downlink(tnl.conn.drain())
+	return res
+}
+
+func jsonMessage(code byte, data interface{}) *message {
+	m := &message{Code: code}
+	m.setUintOption(optionContentFormat, contentFormatApplicationJson)
+	m.Payload, _ = json.Marshal(data)
+	return m
+}
+
+// errorMessage 错误响应，code 为 CoAP 响应码对应的三位数字，如 4.04 为 404
+func errorMessage(code byte, err error) *message {
+	return jsonMessage(code, reply{
+		Code:    int(code>>5)*100 + int(code&0x1f),
+		Message: err.Error(),
+		Version: "1.0",
+	})
+}
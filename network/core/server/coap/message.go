@@ -0,0 +1,302 @@
+package coap
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// CoAP 报文 (RFC 7252)，只实现平台接入需要的部分
+
+// 报文类型
+const (
+	typeCON byte = 0
+	typeNON byte = 1
+	typeACK byte = 2
+	typeRST byte = 3
+)
+
+// 请求方法和响应码，高3位为类别，低5位为详情
+const (
+	codeEmpty                   byte = 0
+	codeGET                     byte = 1
+	codePOST                    byte = 2
+	codePUT                     byte = 3
+	codeChanged                 byte = 2<<5 | 4
+	codeContent                 byte = 2<<5 | 5
+	codeContinue                byte = 2<<5 | 31
+	codeBadRequest              byte = 4<<5 | 0
+	codeUnauthorized            byte = 4<<5 | 1
+	codeBadOption               byte = 4<<5 | 2
+	codeNotFound                byte = 4<<5 | 4
+	codeMethodNotAllowed        byte = 4<<5 | 5
+	codeRequestEntityIncomplete byte = 4<<5 | 8
+	codeRequestEntityTooLarge   byte = 4<<5 | 13
+	codeInternalServerError     byte = 5<<5 | 0
+	codeNotImplemented          byte = 5<<5 | 1
+)
+
+// contentFormatApplicationJson 内容格式 application/json
+const contentFormatApplicationJson = 50
+
+// 选项编号
+const (
+	optionObserve       uint16 = 6
+	optionUriPath       uint16 = 11
+	optionContentFormat uint16 = 12
+	optionUriQuery      uint16 = 15
+	optionBlock2        uint16 = 23
+	optionBlock1        uint16 = 27
+	optionSize2         uint16 = 28
+	optionSize1         uint16 = 60
+	// optionAccessToken 设备认证使用的自定义选项，取值为设备或产品的 AccessToken
+	optionAccessToken uint16 = 2088
+)
+
+var errMalformed = errors.New("malformed coap message")
+
+type option struct {
+	Number uint16
+	Value  []byte
+}
+
+type message struct {
+	Type      byte
+	Code      byte
+	MessageId uint16
+	Token     []byte
+	Options   []option
+	Payload   []byte
+}
+
+// isRequest 请求的类别为0，空报文除外
+func (m *message) isRequest() bool {
+	return m.Code != codeEmpty && m.Code>>5 == 0
+}
+
+func (m *message) option(number uint16) ([]byte, bool) {
+	for _, o := range m.Options {
+		if o.Number == number {
+			return o.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (m *message) uintOption(number uint16) (uint32, bool) {
+	v, ok := m.option(number)
+	if !ok || len(v) > 4 {
+		return 0, false
+	}
+	return decodeUint(v), true
+}
+
+func (m *message) stringOptions(number uint16) (out []string) {
+	for _, o := range m.Options {
+		if o.Number == number {
+			out = append(out, string(o.Value))
+		}
+	}
+	return
+}
+
+// path 由 Uri-Path 选项拼接的请求路径
+func (m *message) path() string {
+	return "/" + strings.Join(m.stringOptions(optionUriPath), "/")
+}
+
+// query 读取 Uri-Query 选项中的参数
+func (m *message) query(name string) string {
+	for _, q := range m.stringOptions(optionUriQuery) {
+		if k, v, ok := strings.Cut(q, "="); ok && k == name {
+			return v
+		}
+	}
+	return ""
+}
+
+func (m *message) setOption(number uint16, value []byte) {
+	m.removeOption(number)
+	m.Options = append(m.Options, option{Number: number, Value: value})
+}
+
+func (m *message) setUintOption(number uint16, v uint32) {
+	m.setOption(number, encodeUint(v))
+}
+
+func (m *message) removeOption(number uint16) {
+	out := m.Options[:0]
+	for _, o := range m.Options {
+		if o.Number != number {
+			out = append(out, o)
+		}
+	}
+	m.Options = out
+}
+
+// unknownCritical 返回不认识的关键选项，编号为奇数的选项是关键选项
+func (m *message) unknownCritical() (uint16, bool) {
+	for _, o := range m.Options {
+		if o.Number&1 == 0 {
+			continue
+		}
+		switch o.Number {
+		case optionUriPath, optionUriQuery, optionBlock1, optionBlock2, 3, 7:
+			// Uri-Host 和 Uri-Port 忽略即可
+		default:
+			return o.Number, true
+		}
+	}
+	return 0, false
+}
+
+func parseMessage(data []byte) (*message, error) {
+	if len(data) < 4 || data[0]>>6 != 1 {
+		return nil, errMalformed
+	}
+	m := &message{
+		Type:      data[0] >> 4 & 0x03,
+		Code:      data[1],
+		MessageId: binary.BigEndian.Uint16(data[2:4]),
+	}
+	tkl := int(data[0] & 0x0f)
+	if tkl > 8 || len(data) < 4+tkl {
+		return nil, errMalformed
+	}
+	m.Token = append([]byte(nil), data[4:4+tkl]...)
+	data = data[4+tkl:]
+	var number uint16
+	for len(data) > 0 {
+		if data[0] == 0xff {
+			if len(data) == 1 {
+				return nil, errMalformed
+			}
+			m.Payload = append([]byte(nil), data[1:]...)
+			break
+		}
+		delta, length := int(data[0]>>4), int(data[0]&0x0f)
+		data = data[1:]
+		var err error
+		if delta, data, err = extendedValue(delta, data); err != nil {
+			return nil, err
+		}
+		if length, data, err = extendedValue(length, data); err != nil {
+			return nil, err
+		}
+		if len(data) < length || int(number)+delta > 0xffff {
+			return nil, errMalformed
+		}
+		number += uint16(delta)
+		m.Options = append(m.Options, option{Number: number, Value: append([]byte(nil), data[:length]...)})
+		data = data[length:]
+	}
+	return m, nil
+}
+
+// extendedValue 选项增量和长度为13、14时后面跟随扩展字节，15为保留值
+func extendedValue(v int, data []byte) (int, []byte, error) {
+	switch v {
+	case 13:
+		if len(data) < 1 {
+			return 0, nil, errMalformed
+		}
+		return int(data[0]) + 13, data[1:], nil
+	case 14:
+		if len(data) < 2 {
+			return 0, nil, errMalformed
+		}
+		return int(binary.BigEndian.Uint16(data)) + 269, data[2:], nil
+	case 15:
+		return 0, nil, errMalformed
+	}
+	return v, data, nil
+}
+
+func (m *message) marshal() []byte {
+	out := make([]byte, 0, 4+len(m.Token)+len(m.Payload)+32)
+	out = append(out, 1<<6|m.Type<<4|byte(len(m.Token)), m.Code, byte(m.MessageId>>8), byte(m.MessageId))
+	out = append(out, m.Token...)
+	options := append([]option(nil), m.Options...)
+	sort.SliceStable(options, func(i, j int) bool { return options[i].Number < options[j].Number })
+	var last uint16
+	for _, o := range options {
+		delta, length := int(o.Number-last), len(o.Value)
+		last = o.Number
+		dn, dext := splitExtended(delta)
+		ln, lext := splitExtended(length)
+		out = append(out, dn<<4|ln)
+		out = append(out, dext...)
+		out = append(out, lext...)
+		out = append(out, o.Value...)
+	}
+	if len(m.Payload) > 0 {
+		out = append(out, 0xff)
+		out = append(out, m.Payload...)
+	}
+	return out
+}
+
+func splitExtended(v int) (byte, []byte) {
+	switch {
+	case v < 13:
+		return byte(v), nil
+	case v < 269:
+		return 13, []byte{byte(v - 13)}
+	default:
+		return 14, []byte{byte((v - 269) >> 8), byte(v - 269)}
+	}
+}
+
+// encodeUint 选项中的整数使用最少的字节表示，0为空值
+func encodeUint(v uint32) []byte {
+	switch {
+	case v == 0:
+		return nil
+	case v < 1<<8:
+		return []byte{byte(v)}
+	case v < 1<<16:
+		return []byte{byte(v >> 8), byte(v)}
+	case v < 1<<24:
+		return []byte{byte(v >> 16), byte(v >> 8), byte(v)}
+	}
+	return []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+func decodeUint(b []byte) (v uint32) {
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return
+}
+
+// block 分块传输选项 (RFC 7959)，块大小为 2^(szx+4)
+type block struct {
+	Num  uint32
+	More bool
+	Szx  byte
+}
+
+func (b block) size() int {
+	return 1 << (b.Szx + 4)
+}
+
+func (b block) encode() uint32 {
+	v := b.Num<<4 | uint32(b.Szx)
+	if b.More {
+		v |= 0x08
+	}
+	return v
+}
+
+func (m *message) block(number uint16) (b block, ok bool, err error) {
+	v, ok := m.uintOption(number)
+	if !ok {
+		return
+	}
+	b = block{Num: v >> 4, More: v&0x08 != 0, Szx: byte(v & 0x07)}
+	if b.Szx == 7 {
+		err = errMalformed
+	}
+	return
+}
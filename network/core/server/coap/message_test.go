@@ -0,0 +1,136 @@
+package coap
+
+import (
+	"bytes"
+	"net"
+	tunelBase "sagooiot/network/core/tunnel/base"
+	"strings"
+	"testing"
+)
+
+func TestMessageMarshal(t *testing.T) {
+	m := &message{Type: typeCON, Code: codePOST, MessageId: 0x1234, Token: []byte{1, 2, 3}, Payload: []byte(`{"id":"1"}`)}
+	for _, level := range []string{"sys", "pk", "dk", "thing", "event", "property", "post"} {
+		m.Options = append(m.Options, option{Number: optionUriPath, Value: []byte(level)})
+	}
+	m.setOption(optionAccessToken, []byte(strings.Repeat("t", 300)))
+	m.setUintOption(optionBlock1, block{Num: 2, More: true, Szx: 6}.encode())
+
+	got, err := parseMessage(m.marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != typeCON || got.Code != codePOST || got.MessageId != 0x1234 || !bytes.Equal(got.Token, m.Token) || !bytes.Equal(got.Payload, m.Payload) {
+		t.Fatalf("parse got %+v", got)
+	}
+	if got.path() != "/sys/pk/dk/thing/event/property/post" {
+		t.Fatalf("path got %s", got.path())
+	}
+	if token, _ := got.option(optionAccessToken); len(token) != 300 {
+		t.Fatalf("token length got %d", len(token))
+	}
+	b, ok, err := got.block(optionBlock1)
+	if err != nil || !ok || b.Num != 2 || !b.More || b.size() != 1024 {
+		t.Fatalf("block got %+v %v %v", b, ok, err)
+	}
+	if _, err = parseMessage([]byte{0x40, 0x01, 0x00}); err == nil {
+		t.Fatal("short message should fail")
+	}
+	if _, err = parseMessage([]byte{0x40, 0x01, 0x00, 0x01, 0xff}); err == nil {
+		t.Fatal("payload marker without payload should fail")
+	}
+}
+
+func TestRoute(t *testing.T) {
+	cases := map[string]string{
+		"/sys/pk/dk/thing/event/property/post":        tunelBase.UpProperty,
+		"/sys/pk/dk/thing/event/property/pack/post":   tunelBase.UpBatch,
+		"/sys/pk/dk/thing/event/alarm/post":           tunelBase.UpEvent,
+		"/sys/pk/dk/thing/service/property/set_reply": tunelBase.UpSetProperty,
+		"/sys/pk/dk/thing/service/reboot_reply":       tunelBase.UpServiceOutput,
+		"/sys/pk/dk/thing/config/get":                 tunelBase.UpConfigGet,
+		"/ota/device/progress/pk/dk":                  tunelBase.UpProcess,
+		"/sys/pk/dk/thing/downlink":                   routeDownlink,
+	}
+	for path, want := range cases {
+		productKey, deviceKey, got, ok := route(path)
+		if !ok || got != want || productKey != "pk" || deviceKey != "dk" {
+			t.Fatalf("route %s got %s %s %s %v", path, productKey, deviceKey, got, ok)
+		}
+	}
+	for _, path := range []string{"/sys/pk/dk/thing/service/reboot", "/sys/pk/dk", "/other/pk/dk/thing/downlink"} {
+		if _, _, _, ok := route(path); ok {
+			t.Fatalf("route %s should not match", path)
+		}
+	}
+}
+
+func TestBlockwise(t *testing.T) {
+	server := NewServerCoAP(nil)
+	remote := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5683}
+	path := "/sys/pk/dk/thing/event/property/post"
+	token := []byte{0x0a, 0x0b}
+
+	// 分块上传，块大小16
+	payload := []byte(strings.Repeat("0123456789abcdef", 2) + "end")
+	for i := 0; i < 2; i++ {
+		if _, done, code := server.upload(remote, path, block{Num: uint32(i), More: true, Szx: 0}, payload[i*16:(i+1)*16]); done || code != 0 {
+			t.Fatalf("block %d done:%v code:%d", i, done, code)
+		}
+	}
+	data, done, code := server.upload(remote, path, block{Num: 2, Szx: 0}, payload[32:])
+	if !done || code != 0 || !bytes.Equal(data, payload) {
+		t.Fatalf("upload got %s %v %d", data, done, code)
+	}
+	if _, _, code = server.upload(remote, path, block{Num: 1, More: true, Szx: 0}, payload[16:32]); code != codeRequestEntityIncomplete {
+		t.Fatalf("out of order block code %d", code)
+	}
+
+	// 分块下载，后续块从缓存中读取
+	res := &message{}
+	if !server.setBlock2(res, remote, token, path, payload, block{Szx: 0}) || !bytes.Equal(res.Payload, payload[:16]) {
+		t.Fatalf("first block got %s", res.Payload)
+	}
+	if b, _, _ := res.block(optionBlock2); !b.More {
+		t.Fatal("first block should have more")
+	}
+	cached, ok := server.cachedBlock(remote, token, path)
+	if !ok {
+		t.Fatal("response should be cached")
+	}
+	if _, ok = server.cachedBlock(remote, []byte{0x0c}, path); ok {
+		t.Fatal("response of another token should not be found")
+	}
+	res = &message{}
+	if !server.setBlock2(res, remote, token, path, cached, block{Num: 2, Szx: 0}) || !bytes.Equal(res.Payload, payload[32:]) {
+		t.Fatalf("last block got %s", res.Payload)
+	}
+	if b, _, _ := res.block(optionBlock2); b.More {
+		t.Fatal("last block should not have more")
+	}
+	if server.setBlock2(&message{}, remote, token, path, cached, block{Num: 3, Szx: 0}) {
+		t.Fatal("block out of range should fail")
+	}
+}
+
+func TestCoapConnObserve(t *testing.T) {
+	notified := make(chan []byte, 1)
+	conn := newCoapConn(func(o *observer, payload []byte) error {
+		notified <- payload
+		return nil
+	})
+	// 没有观察关系时进入队列
+	_, _ = conn.Write([]byte(`{"id":"1"}`))
+	if list := conn.drain(); len(list) != 1 {
+		t.Fatalf("drain got %d", len(list))
+	}
+	conn.observe(&observer{token: []byte{1}})
+	_, _ = conn.Write([]byte(`{"id":"2"}`))
+	if got := <-notified; string(got) != `[{"id":"2"}]` {
+		t.Fatalf("notify got %s", got)
+	}
+	conn.cancel([]byte{1})
+	if conn.current() != nil {
+		t.Fatal("observer should be canceled")
+	}
+}
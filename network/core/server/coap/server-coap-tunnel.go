@@ -0,0 +1,233 @@
+package coap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"io"
+	"net"
+	"sagooiot/internal/consts"
+	"sagooiot/network/core/server/base"
+	"sagooiot/network/core/tunnel"
+	"sagooiot/network/core/tunnel/action"
+	tunnelBase "sagooiot/network/core/tunnel/base"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// downlinkQueueSize 每个设备最多缓存的下行指令数量
+const downlinkQueueSize = 64
+
+var errDownlinkQueueFull = errors.New("coap downlink queue is full")
+
+// observer 设备对下行资源的观察关系，下行指令通过通知推送
+type observer struct {
+	addr  *net.UDPAddr
+	token []byte
+	path  string
+	szx   byte
+	seq   atomic.Uint32
+}
+
+// nextSeq Observe 序号只使用低24位
+func (o *observer) nextSeq() uint32 {
+	return o.seq.Add(1) & 0xffffff
+}
+
+// coapConn 设备有观察关系时直接推送下行指令，否则写入队列，等设备下次请求时带回
+type coapConn struct {
+	queue  chan []byte
+	closed chan struct{}
+	once   sync.Once
+
+	lock     sync.Mutex
+	observer *observer
+	notify   func(o *observer, payload []byte) error
+}
+
+func newCoapConn(notify func(o *observer, payload []byte) error) *coapConn {
+	return &coapConn{
+		queue:  make(chan []byte, downlinkQueueSize),
+		closed: make(chan struct{}),
+		notify: notify,
+	}
+}
+
+// Read 下行队列不支持读取，阻塞到连接关闭
+func (c *coapConn) Read(p []byte) (int, error) {
+	<-c.closed
+	return 0, io.EOF
+}
+
+func (c *coapConn) Write(p []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+	buf := make([]byte, len(p))
+	copy(buf, p)
+	if o := c.current(); o != nil {
+		// 通知发送失败时取消观察关系，指令放回队列
+		go func() {
+			if err := c.notify(o, downlink([][]byte{buf})); err != nil {
+				c.cancel(o.token)
+				_ = c.enqueue(buf)
+			}
+		}()
+		return len(p), nil
+	}
+	if err := c.enqueue(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *coapConn) enqueue(buf []byte) error {
+	select {
+	case c.queue <- buf:
+		return nil
+	default:
+		return errDownlinkQueueFull
+	}
+}
+
+func (c *coapConn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+// drain 取出所有待下发的指令
+func (c *coapConn) drain() (list [][]byte) {
+	for {
+		select {
+		case buf := <-c.queue:
+			list = append(list, buf)
+		default:
+			return
+		}
+	}
+}
+
+// observe 登记观察关系，同一设备只保留最新的一个
+func (c *coapConn) observe(o *observer) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.observer = o
+}
+
+// cancel 取消令牌对应的观察关系
+func (c *coapConn) cancel(token []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.observer != nil && bytes.Equal(c.observer.token, token) {
+		c.observer = nil
+	}
+}
+
+func (c *coapConn) current() *observer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.observer
+}
+
+// ServerCoapTunnel CoAP设备对应的虚拟通道
+type ServerCoapTunnel struct {
+	serverId   int
+	deviceKey  string
+	conn       *coapConn
+	lastActive atomic.Int64
+	*tunnel.TunnelBase
+}
+
+func newServerCoapTunnel(ctx context.Context, serverId int, deviceKey, localAddr, remoteAddr string, notify func(o *observer, payload []byte) error) (*ServerCoapTunnel, error) {
+	baseServerTunnelInfo := base.ServerTunnel{
+		ServerId:   serverId,
+		DeviceKey:  deviceKey,
+		Type:       "coap",
+		Status:     consts.TunnelIsOnLine,
+		LocalAddr:  localAddr,
+		RemoteAddr: remoteAddr,
+		Remark:     "",
+	}
+	var err error
+	baseServerTunnelInfo.TunnelId, err = base.AddOrEditServerTunnel(ctx, baseServerTunnelInfo)
+	if err != nil {
+		return nil, err
+	}
+	g.Log().Debug(ctx, "newServerCoapTunnel", serverId, deviceKey, localAddr, remoteAddr)
+	tnl := &ServerCoapTunnel{serverId: serverId, deviceKey: deviceKey}
+	// 设备确认了通知也视为活跃
+	tnl.conn = newCoapConn(func(o *observer, payload []byte) error {
+		err := notify(o, payload)
+		if err == nil {
+			tnl.touch()
+		}
+		return err
+	})
+	tunnelBase := tunnel.TunnelBase{
+		TunnelId: baseServerTunnelInfo.TunnelId,
+		Link:     tnl.conn,
+		ServerId: serverId,
+	}
+	tunnelBase.SetRunning(true)
+	tunnelBase.SetOnline(true)
+	tnl.TunnelBase = &tunnelBase
+	tnl.touch()
+	return tnl, nil
+}
+
+func (l *ServerCoapTunnel) Open(ctx context.Context) error {
+	return errors.New("ServerCoapTunnel cannot open")
+}
+
+// Ask CoAP 设备通过请求上报数据，下发的数据在设备的下一次请求中返回，不能同步等待设备响应
+func (l *ServerCoapTunnel) Ask(cmd []byte, timeout time.Duration) ([]byte, error) {
+	return nil, tunnelBase.ErrAskNotSupported
+}
+
+// touch 刷新最后活跃时间
+func (l *ServerCoapTunnel) touch() {
+	l.lastActive.Store(time.Now().UnixNano())
+}
+
+func (l *ServerCoapTunnel) idle(timeout time.Duration) bool {
+	return time.Since(time.Unix(0, l.lastActive.Load())) > timeout
+}
+
+// downlink 把指令组装为json数组，非json数据按字符串返回
+func downlink(list [][]byte) []byte {
+	out := make([]json.RawMessage, 0, len(list))
+	for _, buf := range list {
+		if json.Valid(buf) {
+			out = append(out, buf)
+			continue
+		}
+		str, _ := json.Marshal(string(buf))
+		out = append(out, str)
+	}
+	data, _ := json.Marshal(out)
+	return data
+}
+
+// keepalive 通道上线后阻塞到通道关闭，然后做下线处理
+func (l *ServerCoapTunnel) keepalive(ctx context.Context) {
+	if err := action.TunnelOnlineAction(ctx, l.serverId, l.TunnelId, l.deviceKey); err != nil {
+		g.Log().Errorf(ctx, "tunnel online error: %v", err)
+		_ = l.conn.Close()
+		return
+	}
+	<-l.conn.closed
+	if l.Running() {
+		l.OnClose()
+	}
+	l.SetRunning(false)
+	l.SetOnline(false)
+
+	action.TunnelOfflineAction(ctx, l.serverId, l.TunnelId, l.deviceKey)
+}
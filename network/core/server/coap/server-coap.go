@@ -0,0 +1,456 @@
+package coap
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"net"
+	serverBase "sagooiot/network/core/server/base"
+	"sagooiot/network/core/server/common"
+	"sagooiot/network/core/tunnel/base"
+	"sagooiot/network/model"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultIdleTimeout 没有配置心跳超时时间时，设备多久没有请求视为离线
+	defaultIdleTimeout = 5 * time.Minute
+	// exchangeLifetime 请求去重的保留时间，重传的请求直接返回缓存的响应
+	exchangeLifetime = 247 * time.Second
+	// blockLifetime 分块传输的中间数据保留时间
+	blockLifetime = 2 * time.Minute
+	// ackTimeout 可靠通知的首次重传时间，之后每次翻倍
+	ackTimeout = 2 * time.Second
+	// maxRetransmit 可靠通知的最大重传次数
+	maxRetransmit = 4
+	// maxBodySize 分块上传的最大长度
+	maxBodySize = 1 << 20
+	// defaultSzx 默认块大小1024
+	defaultSzx byte = 6
+	// maxDatagramSize 单个数据报文的最大长度
+	maxDatagramSize = 64 * 1024
+	// maxWorkers 同时处理的请求数，超过时丢弃请求，由设备重传
+	maxWorkers = 256
+)
+
+var (
+	errNotifyReset   = errors.New("coap notification reset by device")
+	errNotifyTimeout = errors.New("coap notification not acknowledged")
+)
+
+type ServerCoAP struct {
+	server *model.Server
+
+	lock     sync.RWMutex
+	children map[string]*ServerCoapTunnel
+
+	conn      *net.UDPConn
+	stop      chan struct{}
+	messageId atomic.Uint32
+
+	exchangeLock sync.Mutex
+	exchanges    map[string]*exchange // 收到的请求，用于重传去重
+
+	blockLock sync.Mutex
+	uploads   map[string]*blockBuffer // 分块上传中的请求体
+	responses map[string]*blockBuffer // 分块下载的完整响应
+
+	pendingLock sync.Mutex
+	pending     map[uint16]chan byte // 等待设备确认的通知
+
+	workers chan struct{} // 处理请求的并发数限制
+
+	running atomic.Bool
+}
+
+// exchange 请求的处理结果，处理中时 response 为空
+type exchange struct {
+	response []byte
+	created  time.Time
+}
+
+type blockBuffer struct {
+	data    []byte
+	updated time.Time
+}
+
+func NewServerCoAP(server *model.Server) *ServerCoAP {
+	svr := &ServerCoAP{
+		server:    server,
+		children:  make(map[string]*ServerCoapTunnel),
+		exchanges: make(map[string]*exchange),
+		uploads:   make(map[string]*blockBuffer),
+		responses: make(map[string]*blockBuffer),
+		pending:   make(map[uint16]chan byte),
+		workers:   make(chan struct{}, maxWorkers),
+	}
+	svr.messageId.Store(uint32(time.Now().UnixNano()))
+	return svr
+}
+
+func (server *ServerCoAP) Open(ctx context.Context) error {
+	if server.running.Load() {
+		return errors.New("server is opened")
+	}
+	if server.server.IsTls == 1 {
+		return errors.New("coap server does not support dtls")
+	}
+	common.ServerOpenAction(server.server.Id)
+
+	addr, err := net.ResolveUDPAddr("udp4", common.ResolvePort(server.server.Addr))
+	if err != nil {
+		return err
+	}
+	server.conn, err = net.ListenUDP("udp4", addr)
+	if err != nil {
+		return err
+	}
+	server.stop = make(chan struct{})
+
+	server.running.Store(true)
+	go server.sweep(ctx, server.stop)
+	go func() {
+		server.receive(ctx)
+		server.running.Store(false)
+	}()
+	return nil
+}
+
+// receive 读取数据报文，请求交给处理协程，避免阻塞接收
+func (server *ServerCoAP) receive(ctx context.Context) {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, remote, err := server.conn.ReadFromUDP(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				g.Log().Errorf(ctx, "coap read error: %s", err.Error())
+			}
+			return
+		}
+		m, err := parseMessage(buf[:n])
+		if err != nil {
+			g.Log().Debugf(ctx, "coap parse error: %v, remote_addr:%s", err, remote.String())
+			continue
+		}
+		switch {
+		case m.Type == typeACK || m.Type == typeRST:
+			server.acknowledge(m)
+		case m.Code == codeEmpty:
+			// CoAP ping 使用复位报文响应
+			if m.Type == typeCON {
+				server.send(remote, &message{Type: typeRST, MessageId: m.MessageId})
+			}
+		case m.isRequest():
+			server.dispatch(ctx, remote, m)
+		}
+	}
+}
+
+// dispatch 重传的请求返回缓存的响应，处理中的请求忽略，同时处理的请求达到上限时丢弃新请求
+func (server *ServerCoAP) dispatch(ctx context.Context, remote *net.UDPAddr, m *message) {
+	key := remote.String() + "#" + strconv.Itoa(int(m.MessageId))
+	server.exchangeLock.Lock()
+	if ex, ok := server.exchanges[key]; ok {
+		response := ex.response
+		server.exchangeLock.Unlock()
+		if response != nil {
+			_, _ = server.conn.WriteToUDP(response, remote)
+		}
+		return
+	}
+	select {
+	case server.workers <- struct{}{}:
+	default:
+		server.exchangeLock.Unlock()
+		g.Log().Debugf(ctx, "coap too many requests, dropped, remote_addr:%s", remote.String())
+		return
+	}
+	ex := &exchange{created: time.Now()}
+	server.exchanges[key] = ex
+	server.exchangeLock.Unlock()
+
+	go func() {
+		defer func() { <-server.workers }()
+		res := server.handle(ctx, remote, m)
+		res.Token = m.Token
+		if m.Type == typeCON {
+			res.Type, res.MessageId = typeACK, m.MessageId
+		} else {
+			res.Type, res.MessageId = typeNON, server.nextMessageId()
+		}
+		data := res.marshal()
+		server.exchangeLock.Lock()
+		ex.response = data
+		server.exchangeLock.Unlock()
+		_, _ = server.conn.WriteToUDP(data, remote)
+	}()
+}
+
+func (server *ServerCoAP) nextMessageId() uint16 {
+	return uint16(server.messageId.Add(1))
+}
+
+func (server *ServerCoAP) send(remote *net.UDPAddr, m *message) {
+	_, _ = server.conn.WriteToUDP(m.marshal(), remote)
+}
+
+// acknowledge 设备确认或拒绝了可靠通知
+func (server *ServerCoAP) acknowledge(m *message) {
+	server.pendingLock.Lock()
+	ch, ok := server.pending[m.MessageId]
+	server.pendingLock.Unlock()
+	if ok {
+		select {
+		case ch <- m.Type:
+		default:
+		}
+	}
+}
+
+// notify 以可靠报文向观察者推送下行指令，超过块大小时先发送第一块，设备再分块获取剩余部分
+func (server *ServerCoAP) notify(o *observer, payload []byte) error {
+	m := &message{Type: typeCON, Code: codeContent, Token: o.token}
+	m.setUintOption(optionObserve, o.nextSeq())
+	m.setUintOption(optionContentFormat, contentFormatApplicationJson)
+	_ = server.setBlock2(m, o.addr, o.token, o.path, payload, block{Szx: o.szx})
+
+	// 重传使用相同的报文标识，设备据此去重
+	m.MessageId = server.nextMessageId()
+	ch := make(chan byte, 1)
+	server.pendingLock.Lock()
+	server.pending[m.MessageId] = ch
+	server.pendingLock.Unlock()
+	defer func() {
+		server.pendingLock.Lock()
+		delete(server.pending, m.MessageId)
+		server.pendingLock.Unlock()
+	}()
+	data := m.marshal()
+	for i := 0; i <= maxRetransmit; i++ {
+		_, _ = server.conn.WriteToUDP(data, o.addr)
+		timer := time.NewTimer(ackTimeout << i)
+		select {
+		case typ := <-ch:
+			timer.Stop()
+			if typ == typeRST {
+				return errNotifyReset
+			}
+			return nil
+		case <-timer.C:
+		}
+	}
+	return errNotifyTimeout
+}
+
+// blockKey 分块下载的缓存键，同一设备同一路径上的不同响应按令牌区分，设备获取后续块时需使用相同的令牌
+func blockKey(remote *net.UDPAddr, token []byte, path string) string {
+	return remote.String() + "#" + hex.EncodeToString(token) + path
+}
+
+// setBlock2 设置响应内容，超过块大小时只放入请求的块，完整内容缓存用于后续分块获取，块序号超出范围时返回false
+func (server *ServerCoAP) setBlock2(m *message, remote *net.UDPAddr, token []byte, path string, payload []byte, b block) bool {
+	size := b.size()
+	if b.Num == 0 && len(payload) <= size {
+		m.Payload = payload
+		return true
+	}
+	key := blockKey(remote, token, path)
+	if b.Num == 0 {
+		server.blockLock.Lock()
+		server.responses[key] = &blockBuffer{data: payload, updated: time.Now()}
+		server.blockLock.Unlock()
+	}
+	start := int(b.Num) * size
+	if start >= len(payload) {
+		return false
+	}
+	end := start + size
+	if end > len(payload) {
+		end = len(payload)
+	}
+	b.More = end < len(payload)
+	m.setUintOption(optionBlock2, b.encode())
+	m.setUintOption(optionSize2, uint32(len(payload)))
+	m.Payload = payload[start:end]
+	return true
+}
+
+// cachedBlock 获取分块下载的后续块
+func (server *ServerCoAP) cachedBlock(remote *net.UDPAddr, token []byte, path string) ([]byte, bool) {
+	server.blockLock.Lock()
+	defer server.blockLock.Unlock()
+	buf, ok := server.responses[blockKey(remote, token, path)]
+	if !ok {
+		return nil, false
+	}
+	buf.updated = time.Now()
+	return buf.data, true
+}
+
+// upload 处理分块上传，返回完整的请求体或需要返回的错误码
+func (server *ServerCoAP) upload(remote *net.UDPAddr, path string, b block, payload []byte) (data []byte, done bool, code byte) {
+	key := remote.String() + path
+	server.blockLock.Lock()
+	defer server.blockLock.Unlock()
+	buf, ok := server.uploads[key]
+	if b.Num == 0 {
+		buf = &blockBuffer{}
+		server.uploads[key] = buf
+	} else if !ok || len(buf.data) != int(b.Num)*b.size() {
+		// 块序号不连续，需要设备从第一块重新上传
+		delete(server.uploads, key)
+		return nil, false, codeRequestEntityIncomplete
+	}
+	if len(buf.data)+len(payload) > maxBodySize {
+		delete(server.uploads, key)
+		return nil, false, codeRequestEntityTooLarge
+	}
+	if b.More && len(payload) != b.size() {
+		delete(server.uploads, key)
+		return nil, false, codeBadRequest
+	}
+	buf.data = append(buf.data, payload...)
+	buf.updated = time.Now()
+	if b.More {
+		return nil, false, 0
+	}
+	delete(server.uploads, key)
+	return buf.data, true, 0
+}
+
+// idleTimeout 设备请求的空闲过期时间，优先使用心跳超时时间
+func (server *ServerCoAP) idleTimeout() time.Duration {
+	if server.server.Heartbeat.Timeout > 0 {
+		return time.Duration(server.server.Heartbeat.Timeout) * time.Second
+	}
+	return defaultIdleTimeout
+}
+
+// sweep 定时关闭长时间没有请求的通道，清理过期的去重和分块数据
+func (server *ServerCoAP) sweep(ctx context.Context, stop chan struct{}) {
+	timeout := server.idleTimeout()
+	interval := timeout / 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		server.lock.RLock()
+		var expired []*ServerCoapTunnel
+		for _, tnl := range server.children {
+			if tnl.idle(timeout) {
+				expired = append(expired, tnl)
+			}
+		}
+		server.lock.RUnlock()
+		for _, tnl := range expired {
+			g.Log().Debugf(ctx, "coap tunnel idle timeout, deviceKey:%s", tnl.deviceKey)
+			_ = tnl.Close()
+		}
+
+		now := time.Now()
+		server.exchangeLock.Lock()
+		for key, ex := range server.exchanges {
+			if now.Sub(ex.created) > exchangeLifetime {
+				delete(server.exchanges, key)
+			}
+		}
+		server.exchangeLock.Unlock()
+		server.blockLock.Lock()
+		for _, buffers := range []map[string]*blockBuffer{server.uploads, server.responses} {
+			for key, buf := range buffers {
+				if now.Sub(buf.updated) > blockLifetime {
+					delete(buffers, key)
+				}
+			}
+		}
+		server.blockLock.Unlock()
+	}
+}
+
+// getOrCreateTunnel 设备每次请求都会刷新通道，首次请求时创建通道并上线
+func (server *ServerCoAP) getOrCreateTunnel(ctx context.Context, deviceKey, remoteAddr string) (*ServerCoapTunnel, error) {
+	tunnelId := serverBase.GetTunnelIdByDeviceKey(ctx, deviceKey)
+	server.lock.RLock()
+	tnl, ok := server.children[tunnelId]
+	server.lock.RUnlock()
+	if ok && tnl.Running() {
+		tnl.touch()
+		return tnl, nil
+	}
+
+	server.lock.Lock()
+	if tnl, ok = server.children[tunnelId]; ok && tnl.Running() {
+		server.lock.Unlock()
+		tnl.touch()
+		return tnl, nil
+	}
+	tnl, err := newServerCoapTunnel(ctx, server.server.Id, deviceKey, server.conn.LocalAddr().String(), remoteAddr, server.notify)
+	if err != nil {
+		server.lock.Unlock()
+		return nil, err
+	}
+	server.children[tnl.TunnelId] = tnl
+	server.lock.Unlock()
+
+	go func() {
+		tnl.keepalive(ctx)
+		server.removeTunnel(tnl)
+	}()
+	common.ServerTunnelAction(ctx, server.server.Id, deviceKey)
+	return tnl, nil
+}
+
+func (server *ServerCoAP) removeTunnel(tnl *ServerCoapTunnel) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if server.children[tnl.TunnelId] == tnl {
+		delete(server.children, tnl.TunnelId)
+	}
+}
+
+func (server *ServerCoAP) Close() (err error) {
+	common.ServerCloseAction(server.server.Id)
+	if server.stop != nil {
+		close(server.stop)
+		server.stop = nil
+	}
+	server.lock.RLock()
+	for _, l := range server.children {
+		_ = l.Close()
+	}
+	server.lock.RUnlock()
+	if server.conn == nil {
+		return nil
+	}
+	return server.conn.Close()
+}
+
+func (server *ServerCoAP) GetTunnel(id string) base.TunnelInstance {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+	if tnl, ok := server.children[id]; ok {
+		return tnl
+	}
+	return nil
+}
+
+func (server *ServerCoAP) RemoveTunnel(id string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	delete(server.children, id)
+}
+
+func (server *ServerCoAP) Running() bool {
+	return server.running.Load()
+}
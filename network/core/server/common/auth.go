@@ -0,0 +1,96 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+	"strings"
+)
+
+// DeviceCredential 设备接入时携带的认证信息
+type DeviceCredential struct {
+	User      string
+	Passwd    string
+	Token     string
+	PeerCerts []*x509.Certificate
+}
+
+var ErrDeviceAuthFailed = errors.New("device auth failed")
+
+// DeviceAuth 校验设备接入凭证，设备未配置认证信息时使用产品的认证信息
+func DeviceAuth(ctx context.Context, deviceKey string, cred DeviceCredential) error {
+	authInfo, err := service.DevDevice().AuthInfo(ctx, &model.AuthInfoInput{DeviceKey: deviceKey})
+	if err != nil {
+		return err
+	}
+	return CheckCredential(authInfo, cred)
+}
+
+// CheckCredential 按认证方式比对凭证，未配置认证方式时不做校验
+func CheckCredential(authInfo *model.AuthInfoOutput, cred DeviceCredential) error {
+	if authInfo == nil {
+		return ErrDeviceAuthFailed
+	}
+	switch authInfo.AuthType {
+	case consts.DeviceAuthTypeBasic:
+		if authInfo.AuthUser == "" || !secureEqual(authInfo.AuthUser, cred.User) || !secureEqual(authInfo.AuthPasswd, cred.Passwd) {
+			return ErrDeviceAuthFailed
+		}
+	case consts.DeviceAuthTypeAccessToken:
+		if authInfo.AccessToken == "" || !secureEqual(authInfo.AccessToken, cred.Token) {
+			return ErrDeviceAuthFailed
+		}
+	case consts.DeviceAuthTypeCertificate:
+		if authInfo.Certificate == nil || len(cred.PeerCerts) == 0 {
+			return ErrDeviceAuthFailed
+		}
+		if !certificateMatch(authInfo.Certificate.FileContent, cred.PeerCerts[0]) &&
+			!certificateMatch(authInfo.Certificate.PublicKeyContent, cred.PeerCerts[0]) {
+			return ErrDeviceAuthFailed
+		}
+	}
+	return nil
+}
+
+// HttpCredential 从http请求中提取认证信息，支持Basic认证、Bearer/X-Access-Token/access_token三种AccessToken携带方式以及TLS客户端证书
+func HttpCredential(r *http.Request) DeviceCredential {
+	var cred DeviceCredential
+	cred.User, cred.Passwd, _ = r.BasicAuth()
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		cred.Token = strings.TrimPrefix(auth, "Bearer ")
+	} else if token := r.Header.Get("X-Access-Token"); token != "" {
+		cred.Token = token
+	} else {
+		cred.Token = r.URL.Query().Get("access_token")
+	}
+	if r.TLS != nil {
+		cred.PeerCerts = r.TLS.PeerCertificates
+	}
+	return cred
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// certificateMatch 设备证书与平台登记的证书一致时认证通过
+func certificateMatch(content string, peer *x509.Certificate) bool {
+	rest := []byte(content)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return false
+		}
+		if block.Type == "CERTIFICATE" && bytes.Equal(block.Bytes, peer.Raw) {
+			return true
+		}
+	}
+}
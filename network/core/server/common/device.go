@@ -0,0 +1,30 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sagooiot/internal/model"
+	"sagooiot/internal/service"
+	"sagooiot/pkg/dcache"
+)
+
+// GetAccessDevice 获取接入设备详情，并校验设备所属产品和启用状态
+func GetAccessDevice(ctx context.Context, productKey, deviceKey string) (*model.DeviceOutput, error) {
+	device, err := dcache.GetDeviceDetailInfo(deviceKey)
+	if err != nil || device == nil {
+		device, err = service.DevDevice().Get(ctx, deviceKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if device == nil || device.Product == nil {
+		return nil, fmt.Errorf("deviceKey:%s not found", deviceKey)
+	}
+	if productKey != "" && device.Product.Key != productKey {
+		return nil, fmt.Errorf("deviceKey:%s not belong to productKey:%s", deviceKey, productKey)
+	}
+	if device.Status == model.DeviceStatusNoEnable {
+		return nil, fmt.Errorf("deviceKey:%s is not enabled", deviceKey)
+	}
+	return device, nil
+}
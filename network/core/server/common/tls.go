@@ -0,0 +1,39 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sagooiot/internal/service"
+	"sagooiot/network/model"
+)
+
+// TLSConfig 根据服务配置的证书生成TLS配置，未开启TLS时返回nil
+func TLSConfig(ctx context.Context, server *model.Server) (*tls.Config, error) {
+	if server.IsTls != 1 {
+		return nil, nil
+	}
+	if server.CertificateId == 0 {
+		return nil, fmt.Errorf("server %s enabled tls without certificate", server.Name)
+	}
+	cert, err := service.SysCertificate().GetInfoById(ctx, server.CertificateId)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, fmt.Errorf("certificate %d not found", server.CertificateId)
+	}
+	certContent := cert.FileContent
+	if certContent == "" {
+		certContent = cert.PublicKeyContent
+	}
+	pair, err := tls.X509KeyPair([]byte(certContent), []byte(cert.PrivateKeyContent))
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		// 请求但不强制客户端证书，证书认证的设备在接入时再校验
+		ClientAuth: tls.RequestClientCert,
+	}, nil
+}
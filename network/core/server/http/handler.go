@@ -0,0 +1,179 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"io"
+	nethttp "net/http"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/network/core/logic/baseLogic"
+	"sagooiot/network/core/server/common"
+	"sagooiot/network/core/tunnel"
+	tunelBase "sagooiot/network/core/tunnel/base"
+	"sagooiot/pkg/dcache"
+	"sagooiot/pkg/iotModel/topicModel"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reply 上报请求的响应，downlink中带回平台待下发的指令
+type reply struct {
+	Id       string            `json:"id"`
+	Code     int               `json:"code"`
+	Message  string            `json:"message"`
+	Version  string            `json:"version"`
+	Data     struct{}          `json:"data"`
+	Downlink []json.RawMessage `json:"downlink,omitempty"`
+}
+
+// pollReply 长轮询的响应
+type pollReply struct {
+	Code     int               `json:"code"`
+	Message  string            `json:"message"`
+	Downlink []json.RawMessage `json:"downlink"`
+}
+
+// routes 上报路径与设备的 /sys/{productKey}/{deviceKey}/thing/... 和 /ota/device/... topic 保持一致
+func (server *ServerHTTP) routes(ctx context.Context) nethttp.Handler {
+	mux := nethttp.NewServeMux()
+	mux.HandleFunc("POST /sys/{productKey}/{deviceKey}/thing/event/property/post", server.handleUp(ctx, tunelBase.UpProperty))
+	mux.HandleFunc("POST /sys/{productKey}/{deviceKey}/thing/event/property/pack/post", server.handleUp(ctx, tunelBase.UpBatch))
+	mux.HandleFunc("POST /sys/{productKey}/{deviceKey}/thing/event/{identifier}/post", server.handleUp(ctx, tunelBase.UpEvent))
+	mux.HandleFunc("POST /sys/{productKey}/{deviceKey}/thing/service/property/set_reply", server.handleUp(ctx, tunelBase.UpSetProperty))
+	mux.HandleFunc("POST /sys/{productKey}/{deviceKey}/thing/service/{identifier}", server.handleUp(ctx, tunelBase.UpServiceOutput))
+	mux.HandleFunc("POST /sys/{productKey}/{deviceKey}/thing/config/push/reply", server.handleUp(ctx, tunelBase.UpSetConfig))
+	mux.HandleFunc("POST /sys/{productKey}/{deviceKey}/thing/config/get", server.handleUp(ctx, tunelBase.UpConfigGet))
+	mux.HandleFunc("POST /ota/device/inform/{productKey}/{deviceKey}", server.handleUp(ctx, tunelBase.UpInform))
+	mux.HandleFunc("POST /ota/device/progress/{productKey}/{deviceKey}", server.handleUp(ctx, tunelBase.UpProcess))
+	mux.HandleFunc("GET /sys/{productKey}/{deviceKey}/thing/downlink", server.handlePoll(ctx))
+	return mux
+}
+
+// access 校验设备身份并刷新设备的通道
+func (server *ServerHTTP) access(ctx context.Context, r *nethttp.Request) (*ServerHttpTunnel, *model.DeviceOutput, int, error) {
+	productKey, deviceKey := r.PathValue("productKey"), r.PathValue("deviceKey")
+	device, err := common.GetAccessDevice(ctx, productKey, deviceKey)
+	if err != nil {
+		return nil, nil, nethttp.StatusNotFound, err
+	}
+	if err = common.DeviceAuth(ctx, deviceKey, common.HttpCredential(r)); err != nil {
+		return nil, nil, nethttp.StatusUnauthorized, err
+	}
+	dcache.UpdateStatus(ctx, device) //更新设备状态
+
+	tnl, err := server.getOrCreateTunnel(ctx, deviceKey, r.RemoteAddr)
+	if err != nil {
+		return nil, nil, nethttp.StatusInternalServerError, err
+	}
+	return tnl, device, nethttp.StatusOK, nil
+}
+
+func (server *ServerHTTP) handleUp(ctx context.Context, modelFuncName string) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		tnl, device, status, err := server.access(ctx, r)
+		if err != nil {
+			g.Log().Debugf(ctx, "http access error: %v, path:%s remote_addr:%s", err, r.URL.Path, r.RemoteAddr)
+			writeError(w, status, err)
+			return
+		}
+		if modelFuncName == tunelBase.UpServiceOutput && !strings.HasSuffix(r.PathValue("identifier"), "_reply") {
+			writeError(w, nethttp.StatusNotFound, errors.New("service reply path must end with _reply"))
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+		if err != nil {
+			writeError(w, nethttp.StatusBadRequest, err)
+			return
+		}
+
+		res, err := tunnel.DecodeData(ctx, &model.DetailProductOutput{DevProduct: device.Product}, device.Key, body)
+		if err != nil {
+			writeError(w, nethttp.StatusBadRequest, err)
+			return
+		}
+		handleF := tunelBase.GetModelHandle(modelFuncName)
+		if handleF.Handle == nil {
+			writeError(w, nethttp.StatusNotImplemented, errors.New("handler not registered: "+modelFuncName))
+			return
+		}
+		if err = handleF.Handle(ctx, topicModel.TopicHandlerData{
+			Topic:        r.URL.Path,
+			ProductKey:   device.Product.Key,
+			DeviceKey:    device.Key,
+			PayLoad:      []byte(res),
+			DeviceDetail: device,
+		}); err != nil && err.Error() != "ignore" {
+			g.Log().Infof(ctx, "handleF error: %v, topic:%s, message:%s", err, r.URL.Path, string(body))
+			writeError(w, nethttp.StatusBadRequest, err)
+			return
+		}
+		// 记录原始日志,网关批量的放在网关内部处理
+		if handleF.LogType != consts.MsgTypeGatewayBatch {
+			baseLogic.InertTdLog(ctx, handleF.LogType, device.Key, string(body))
+		}
+
+		var request struct {
+			Id      string `json:"id"`
+			Version string `json:"version"`
+		}
+		_ = json.Unmarshal([]byte(res), &request)
+		if request.Version == "" {
+			request.Version = "1.0"
+		}
+		writeJson(w, nethttp.StatusOK, reply{
+			Id:       request.Id,
+			Code:     nethttp.StatusOK,
+			Message:  "success",
+			Version:  request.Version,
+			Downlink: downlink(tnl.conn.drain()),
+		})
+	}
+}
+
+// handlePoll 长轮询获取下行指令，wait参数为等待秒数
+func (server *ServerHTTP) handlePoll(ctx context.Context) nethttp.HandlerFunc {
+	return func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		tnl, _, status, err := server.access(ctx, r)
+		if err != nil {
+			g.Log().Debugf(ctx, "http access error: %v, path:%s remote_addr:%s", err, r.URL.Path, r.RemoteAddr)
+			writeError(w, status, err)
+			return
+		}
+		wait := defaultPollWait
+		if seconds, convErr := strconv.Atoi(r.URL.Query().Get("wait")); convErr == nil && seconds >= 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+		if wait > maxPollWait {
+			wait = maxPollWait
+		}
+		list := tnl.conn.poll(r.Context(), wait)
+		tnl.touch()
+		out := downlink(list)
+		if out == nil {
+			out = []json.RawMessage{}
+		}
+		writeJson(w, nethttp.StatusOK, pollReply{
+			Code:     nethttp.StatusOK,
+			Message:  "success",
+			Downlink: out,
+		})
+	}
+}
+
+func writeError(w nethttp.ResponseWriter, status int, err error) {
+	writeJson(w, status, reply{
+		Code:    status,
+		Message: err.Error(),
+		Version: "1.0",
+	})
+}
+
+func writeJson(w nethttp.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
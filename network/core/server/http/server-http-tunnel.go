@@ -0,0 +1,181 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"io"
+	"sagooiot/internal/consts"
+	"sagooiot/network/core/server/base"
+	"sagooiot/network/core/tunnel"
+	"sagooiot/network/core/tunnel/action"
+	tunnelBase "sagooiot/network/core/tunnel/base"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// downlinkQueueSize 每个设备最多缓存的下行指令数量
+const downlinkQueueSize = 64
+
+var errDownlinkQueueFull = errors.New("http downlink queue is full")
+
+// httpConn http设备没有长连接，下行数据写入队列，等设备下次请求时带回
+type httpConn struct {
+	queue  chan []byte
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newHttpConn() *httpConn {
+	return &httpConn{
+		queue:  make(chan []byte, downlinkQueueSize),
+		closed: make(chan struct{}),
+	}
+}
+
+// Read 下行队列不支持读取，阻塞到连接关闭
+func (c *httpConn) Read(p []byte) (int, error) {
+	<-c.closed
+	return 0, io.EOF
+}
+
+func (c *httpConn) Write(p []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+	buf := make([]byte, len(p))
+	copy(buf, p)
+	select {
+	case c.queue <- buf:
+		return len(p), nil
+	default:
+		return 0, errDownlinkQueueFull
+	}
+}
+
+func (c *httpConn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+// drain 取出所有待下发的指令
+func (c *httpConn) drain() (list [][]byte) {
+	for {
+		select {
+		case buf := <-c.queue:
+			list = append(list, buf)
+		default:
+			return
+		}
+	}
+}
+
+// poll 等待下行指令，最多等待wait时长，收到第一条后把队列里的指令一起取出
+func (c *httpConn) poll(ctx context.Context, wait time.Duration) [][]byte {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case buf := <-c.queue:
+		return append([][]byte{buf}, c.drain()...)
+	case <-timer.C:
+	case <-ctx.Done():
+	case <-c.closed:
+	}
+	return nil
+}
+
+// ServerHttpTunnel http设备对应的虚拟通道
+type ServerHttpTunnel struct {
+	serverId   int
+	deviceKey  string
+	conn       *httpConn
+	lastActive atomic.Int64
+	*tunnel.TunnelBase
+}
+
+func newServerHttpTunnel(ctx context.Context, serverId int, deviceKey, localAddr, remoteAddr string) (*ServerHttpTunnel, error) {
+	baseServerTunnelInfo := base.ServerTunnel{
+		ServerId:   serverId,
+		DeviceKey:  deviceKey,
+		Type:       "http",
+		Status:     consts.TunnelIsOnLine,
+		LocalAddr:  localAddr,
+		RemoteAddr: remoteAddr,
+		Remark:     "",
+	}
+	var err error
+	baseServerTunnelInfo.TunnelId, err = base.AddOrEditServerTunnel(ctx, baseServerTunnelInfo)
+	if err != nil {
+		return nil, err
+	}
+	g.Log().Debug(ctx, "newServerHttpTunnel", serverId, deviceKey, localAddr, remoteAddr)
+	conn := newHttpConn()
+	tunnelBase := tunnel.TunnelBase{
+		TunnelId: baseServerTunnelInfo.TunnelId,
+		Link:     conn,
+		ServerId: serverId,
+	}
+	tunnelBase.SetRunning(true)
+	tunnelBase.SetOnline(true)
+	tnl := &ServerHttpTunnel{serverId: serverId, deviceKey: deviceKey, conn: conn, TunnelBase: &tunnelBase}
+	tnl.touch()
+	return tnl, nil
+}
+
+func (l *ServerHttpTunnel) Open(ctx context.Context) error {
+	return errors.New("ServerHttpTunnel cannot open")
+}
+
+// Ask HTTP 设备通过请求上报数据，下发的数据在设备的下一次请求中返回，不能同步等待设备响应
+func (l *ServerHttpTunnel) Ask(cmd []byte, timeout time.Duration) ([]byte, error) {
+	return nil, tunnelBase.ErrAskNotSupported
+}
+
+// touch 刷新最后活跃时间
+func (l *ServerHttpTunnel) touch() {
+	l.lastActive.Store(time.Now().UnixNano())
+}
+
+func (l *ServerHttpTunnel) idle(timeout time.Duration) bool {
+	return time.Since(time.Unix(0, l.lastActive.Load())) > timeout
+}
+
+// downlink 把队列中的指令转为json，非json数据按字符串返回
+func downlink(list [][]byte) []json.RawMessage {
+	if len(list) == 0 {
+		return nil
+	}
+	out := make([]json.RawMessage, 0, len(list))
+	for _, buf := range list {
+		if json.Valid(buf) {
+			out = append(out, buf)
+			continue
+		}
+		str, _ := json.Marshal(string(buf))
+		out = append(out, str)
+	}
+	return out
+}
+
+// keepalive 通道上线后阻塞到通道关闭，然后做下线处理
+func (l *ServerHttpTunnel) keepalive(ctx context.Context) {
+	if err := action.TunnelOnlineAction(ctx, l.serverId, l.TunnelId, l.deviceKey); err != nil {
+		g.Log().Errorf(ctx, "tunnel online error: %v", err)
+		_ = l.conn.Close()
+		return
+	}
+	<-l.conn.closed
+	if l.Running() {
+		l.OnClose()
+	}
+	l.SetRunning(false)
+	l.SetOnline(false)
+
+	action.TunnelOfflineAction(ctx, l.serverId, l.TunnelId, l.deviceKey)
+}
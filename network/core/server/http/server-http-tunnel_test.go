@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHttpConnDrain(t *testing.T) {
+	conn := newHttpConn()
+	for _, msg := range []string{`{"id":"1"}`, `{"id":"2"}`} {
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	list := conn.drain()
+	if len(list) != 2 {
+		t.Fatalf("drain got %d messages, want 2", len(list))
+	}
+	if len(conn.drain()) != 0 {
+		t.Fatalf("queue should be empty after drain")
+	}
+}
+
+func TestHttpConnQueueFull(t *testing.T) {
+	conn := newHttpConn()
+	for i := 0; i < downlinkQueueSize; i++ {
+		if _, err := conn.Write([]byte("{}")); err != nil {
+			t.Fatalf("write %d failed: %v", i, err)
+		}
+	}
+	if _, err := conn.Write([]byte("{}")); err != errDownlinkQueueFull {
+		t.Fatalf("write got err %v, want %v", err, errDownlinkQueueFull)
+	}
+}
+
+func TestHttpConnPoll(t *testing.T) {
+	conn := newHttpConn()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = conn.Write([]byte(`{"id":"1"}`))
+	}()
+	list := conn.poll(context.Background(), time.Second)
+	if len(list) != 1 || string(list[0]) != `{"id":"1"}` {
+		t.Fatalf("poll got %q", list)
+	}
+
+	start := time.Now()
+	if list = conn.poll(context.Background(), 30*time.Millisecond); list != nil {
+		t.Fatalf("poll on empty queue got %q", list)
+	}
+	if time.Since(start) < 30*time.Millisecond {
+		t.Fatalf("poll returned before wait elapsed")
+	}
+
+	_ = conn.Close()
+	if _, err := conn.Write([]byte("{}")); err == nil {
+		t.Fatalf("write after close should fail")
+	}
+}
+
+func TestDownlink(t *testing.T) {
+	out := downlink([][]byte{[]byte(`{"id":"1"}`), []byte("raw")})
+	if len(out) != 2 {
+		t.Fatalf("downlink got %d messages, want 2", len(out))
+	}
+	if string(out[0]) != `{"id":"1"}` {
+		t.Fatalf("json message got %s", out[0])
+	}
+	if string(out[1]) != `"raw"` {
+		t.Fatalf("raw message got %s", out[1])
+	}
+	if downlink(nil) != nil {
+		t.Fatalf("empty downlink should be nil")
+	}
+}
@@ -0,0 +1,193 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"net"
+	nethttp "net/http"
+	serverBase "sagooiot/network/core/server/base"
+	"sagooiot/network/core/server/common"
+	"sagooiot/network/core/tunnel/base"
+	"sagooiot/network/model"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultIdleTimeout 没有配置心跳超时时间时，设备多久没有请求视为离线
+	defaultIdleTimeout = 5 * time.Minute
+	// defaultPollWait 长轮询默认等待时间
+	defaultPollWait = 30 * time.Second
+	// maxPollWait 长轮询最大等待时间
+	maxPollWait = 60 * time.Second
+	// maxBodySize 上报数据的最大长度
+	maxBodySize = 4 << 20
+)
+
+type ServerHTTP struct {
+	server *model.Server
+
+	lock     sync.RWMutex
+	children map[string]*ServerHttpTunnel
+
+	listener   net.Listener
+	httpServer *nethttp.Server
+	stop       chan struct{}
+
+	running bool
+}
+
+func NewServerHTTP(server *model.Server) *ServerHTTP {
+	svr := &ServerHTTP{
+		server:   server,
+		children: make(map[string]*ServerHttpTunnel),
+	}
+	return svr
+}
+
+func (server *ServerHTTP) Open(ctx context.Context) error {
+	if server.running {
+		return errors.New("server is opened")
+	}
+	common.ServerOpenAction(server.server.Id)
+
+	tlsConfig, err := common.TLSConfig(ctx, server.server)
+	if err != nil {
+		return err
+	}
+	server.listener, err = net.Listen("tcp4", common.ResolvePort(server.server.Addr))
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		server.listener = tls.NewListener(server.listener, tlsConfig)
+	}
+	server.httpServer = &nethttp.Server{
+		Handler:           server.routes(ctx),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	server.stop = make(chan struct{})
+
+	server.running = true
+	go server.sweep(ctx, server.stop)
+	go func() {
+		if serveErr := server.httpServer.Serve(server.listener); serveErr != nil && !errors.Is(serveErr, nethttp.ErrServerClosed) {
+			g.Log().Errorf(ctx, "serve http error: %s", serveErr.Error())
+		}
+		server.running = false
+	}()
+	return nil
+}
+
+// idleTimeout 设备请求的空闲过期时间，优先使用心跳超时时间
+func (server *ServerHTTP) idleTimeout() time.Duration {
+	if server.server.Heartbeat.Timeout > 0 {
+		return time.Duration(server.server.Heartbeat.Timeout) * time.Second
+	}
+	return defaultIdleTimeout
+}
+
+// sweep 定时关闭长时间没有请求的通道
+func (server *ServerHTTP) sweep(ctx context.Context, stop chan struct{}) {
+	timeout := server.idleTimeout()
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		server.lock.RLock()
+		var expired []*ServerHttpTunnel
+		for _, tnl := range server.children {
+			if tnl.idle(timeout) {
+				expired = append(expired, tnl)
+			}
+		}
+		server.lock.RUnlock()
+		for _, tnl := range expired {
+			g.Log().Debugf(ctx, "http tunnel idle timeout, deviceKey:%s", tnl.deviceKey)
+			_ = tnl.Close()
+		}
+	}
+}
+
+// getOrCreateTunnel 设备每次请求都会刷新通道，首次请求时创建通道并上线
+func (server *ServerHTTP) getOrCreateTunnel(ctx context.Context, deviceKey, remoteAddr string) (*ServerHttpTunnel, error) {
+	tunnelId := serverBase.GetTunnelIdByDeviceKey(ctx, deviceKey)
+	server.lock.RLock()
+	tnl, ok := server.children[tunnelId]
+	server.lock.RUnlock()
+	if ok && tnl.Running() {
+		tnl.touch()
+		return tnl, nil
+	}
+
+	server.lock.Lock()
+	if tnl, ok = server.children[tunnelId]; ok && tnl.Running() {
+		server.lock.Unlock()
+		tnl.touch()
+		return tnl, nil
+	}
+	tnl, err := newServerHttpTunnel(ctx, server.server.Id, deviceKey, server.listener.Addr().String(), remoteAddr)
+	if err != nil {
+		server.lock.Unlock()
+		return nil, err
+	}
+	server.children[tnl.TunnelId] = tnl
+	server.lock.Unlock()
+
+	go func() {
+		tnl.keepalive(ctx)
+		server.removeTunnel(tnl)
+	}()
+	common.ServerTunnelAction(ctx, server.server.Id, deviceKey)
+	return tnl, nil
+}
+
+func (server *ServerHTTP) removeTunnel(tnl *ServerHttpTunnel) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if server.children[tnl.TunnelId] == tnl {
+		delete(server.children, tnl.TunnelId)
+	}
+}
+
+func (server *ServerHTTP) Close() (err error) {
+	common.ServerCloseAction(server.server.Id)
+	if server.stop != nil {
+		close(server.stop)
+		server.stop = nil
+	}
+	server.lock.RLock()
+	for _, l := range server.children {
+		_ = l.Close()
+	}
+	server.lock.RUnlock()
+	if server.httpServer == nil {
+		return nil
+	}
+	return server.httpServer.Close()
+}
+
+func (server *ServerHTTP) GetTunnel(id string) base.TunnelInstance {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+	if tnl, ok := server.children[id]; ok {
+		return tnl
+	}
+	return nil
+}
+
+func (server *ServerHTTP) RemoveTunnel(id string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	delete(server.children, id)
+}
+
+func (server *ServerHTTP) Running() bool {
+	return server.running
+}
@@ -7,14 +7,38 @@ import (
 	"sagooiot/internal/consts"
 	interModel "sagooiot/internal/model"
 	"sagooiot/internal/service"
+	"sagooiot/network/core/device"
 	"sagooiot/network/core/mapper"
+	"sagooiot/network/core/server/base"
 	"sagooiot/network/core/tunnel"
+	tunnelBase "sagooiot/network/core/tunnel/base"
 	"sagooiot/network/model"
 	"sync"
 )
 
 var allServers sync.Map
 
+func init() {
+	device.SetTunnelFinder(GetDeviceTunnel)
+}
+
+// GetDeviceTunnel 获取设备当前连接的服务通道
+func GetDeviceTunnel(ctx context.Context, deviceKey string) (tunnelBase.TunnelInstance, error) {
+	t, err := base.GetServerTunnel(ctx, deviceKey)
+	if err != nil {
+		return nil, err
+	}
+	s := GetServer(t.ServerId)
+	if s == nil {
+		return nil, fmt.Errorf("server not found,serverId:%d,deviceKey:%s", t.ServerId, deviceKey)
+	}
+	tnl := s.Instance.GetTunnel(base.GetTunnelIdByDeviceKey(ctx, deviceKey))
+	if tnl == nil {
+		return nil, fmt.Errorf("tunnel not found,serverId:%d,deviceKey:%s", t.ServerId, deviceKey)
+	}
+	return tnl, nil
+}
+
 func startServer(ctx context.Context, server *model.Server) error {
 	svr, err := NewServer(server)
 	if err != nil {
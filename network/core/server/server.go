@@ -3,7 +3,11 @@ package server
 import (
 	"fmt"
 	"sagooiot/network/core/server/base"
+	"sagooiot/network/core/server/coap"
+	"sagooiot/network/core/server/http"
 	"sagooiot/network/core/server/tcp"
+	"sagooiot/network/core/server/udp"
+	"sagooiot/network/core/server/websocket"
 	"sagooiot/network/model"
 )
 
@@ -16,10 +20,16 @@ func NewServer(server *model.Server) (base.ServerInstance, error) {
 		svr = tcp.NewServerTCP(server)
 		break
 	case "udp":
+		svr = udp.NewServerUDP(server)
 		break
 	case "http":
+		svr = http.NewServerHTTP(server)
 		break
 	case "websocket":
+		svr = websocket.NewServerWebsocket(server)
+		break
+	case "coap":
+		svr = coap.NewServerCoAP(server)
 		break
 	default:
 		return nil, fmt.Errorf("Unsupport type %s ", server.Type)
@@ -1,6 +1,7 @@
 package tcp
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"github.com/gogf/gf/v2/frame/g"
@@ -9,6 +10,7 @@ import (
 	"sagooiot/network/core/server/base"
 	"sagooiot/network/core/tunnel"
 	"sagooiot/network/core/tunnel/action"
+	"sagooiot/network/core/tunnel/framer"
 )
 
 // ServerTcpTunnel 网络连接
@@ -18,7 +20,7 @@ type ServerTcpTunnel struct {
 	*tunnel.TunnelBase
 }
 
-func newServerTcpTunnel(ctx context.Context, serverId int, deviceKey string, conn net.Conn) (*ServerTcpTunnel, error) {
+func newServerTcpTunnel(ctx context.Context, serverId int, deviceKey string, conn net.Conn, f framer.Framer) (*ServerTcpTunnel, error) {
 	baseServerTunnelInfo := base.ServerTunnel{
 		ServerId:   serverId,
 		DeviceKey:  deviceKey,
@@ -39,6 +41,7 @@ func newServerTcpTunnel(ctx context.Context, serverId int, deviceKey string, con
 		Link:     conn,
 		ServerId: serverId,
 	}
+	tunnelBase.SetFramer(f)
 	tunnelBase.SetRunning(true)
 	tunnelBase.SetOnline(true)
 	return &ServerTcpTunnel{serverId: serverId, deviceKey: deviceKey, TunnelBase: &tunnelBase}, nil
@@ -54,26 +57,33 @@ func (l *ServerTcpTunnel) receive(ctx context.Context) {
 		g.Log().Errorf(ctx, "tunnel online error: %v", err)
 		return
 	}
-	buf := make([]byte, 1024)
-	for {
-		n, err := l.Link.Read(buf)
-		if err != nil {
-			l.OnClose()
-			break
-		}
-		if n == 0 {
+	// 按粘包处理方式把数据流切分为完整的帧
+	scanner := bufio.NewScanner(l.Link)
+	scanner.Buffer(make([]byte, 1024), framer.MaxFrameSize)
+	scanner.Split(l.Framer().Split)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
 			continue
 		}
+		data := make([]byte, len(scanner.Bytes()))
+		copy(data, scanner.Bytes())
 		if l.GetPipe() != nil {
-			_, err = l.GetPipe().Write(buf[:n])
+			_, err := l.GetPipe().Write(data)
 			if err != nil {
 				l.SetPipe(nil)
 			} else {
 				continue
 			}
 		}
-		go l.TunnelBase.ReadData(ctx, l.deviceKey, buf[:n])
+		if l.Reply(data) {
+			continue
+		}
+		go l.TunnelBase.ReadData(ctx, l.deviceKey, data)
+	}
+	if err := scanner.Err(); err != nil {
+		g.Log().Debugf(ctx, "tcp tunnel read error: %v, deviceKey:%s", err, l.deviceKey)
 	}
+	l.OnClose()
 	l.SetRunning(false)
 	l.SetOnline(false)
 
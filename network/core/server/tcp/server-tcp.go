@@ -7,6 +7,7 @@ import (
 	"net"
 	"sagooiot/network/core/server/common"
 	"sagooiot/network/core/tunnel/base"
+	"sagooiot/network/core/tunnel/framer"
 	"sagooiot/network/model"
 )
 
@@ -34,6 +35,10 @@ func (server *ServerTCP) Open(ctx context.Context) error {
 	}
 	common.ServerOpenAction(server.server.Id)
 
+	f, err := framer.New(server.server.Stick)
+	if err != nil {
+		return err
+	}
 	addr, err := net.ResolveTCPAddr("tcp4", common.ResolvePort(server.server.Addr))
 	if err != nil {
 		return err
@@ -66,7 +71,7 @@ func (server *ServerTCP) Open(ctx context.Context) error {
 				_ = c.Close()
 				continue
 			}
-			tnl, tnlErr := newServerTcpTunnel(ctx, server.server.Id, deviceKey, c)
+			tnl, tnlErr := newServerTcpTunnel(ctx, server.server.Id, deviceKey, c, f)
 			if tnlErr != nil {
 				g.Log().Errorf(ctx, "new tcp tunnel error: %v,local_addr:%s remote_addr:%s", tnlErr, c.LocalAddr().String(), c.RemoteAddr().String())
 				continue
@@ -94,7 +99,10 @@ func (server *ServerTCP) Close() (err error) {
 }
 
 func (server *ServerTCP) GetTunnel(id string) base.TunnelInstance {
-	return server.children[id]
+	if tnl, ok := server.children[id]; ok {
+		return tnl
+	}
+	return nil
 }
 
 func (server *ServerTCP) RemoveTunnel(id string) {
@@ -0,0 +1,101 @@
+package udp
+
+import (
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"sagooiot/internal/consts"
+	"sagooiot/network/core/server/base"
+	"sagooiot/network/core/tunnel"
+	"sagooiot/network/core/tunnel/action"
+	"sync/atomic"
+)
+
+// maxDatagramSize UDP报文的最大长度
+const maxDatagramSize = 65535
+
+// ServerUdpTunnel 远端地址对应的虚拟通道
+type ServerUdpTunnel struct {
+	serverId  int
+	deviceKey string
+	conn      *udpConn
+	// replaced 已被同设备的新通道替换，关闭时不再下线设备
+	replaced atomic.Bool
+	*tunnel.TunnelBase
+}
+
+func newServerUdpTunnel(ctx context.Context, serverId int, deviceKey string, conn *udpConn) (*ServerUdpTunnel, error) {
+	baseServerTunnelInfo := base.ServerTunnel{
+		ServerId:   serverId,
+		DeviceKey:  deviceKey,
+		Type:       "udp",
+		Status:     consts.TunnelIsOnLine,
+		LocalAddr:  conn.LocalAddr().String(),
+		RemoteAddr: conn.RemoteAddr().String(),
+		Remark:     "",
+	}
+	var err error
+	baseServerTunnelInfo.TunnelId, err = base.AddOrEditServerTunnel(ctx, baseServerTunnelInfo)
+	if err != nil {
+		return nil, err
+	}
+	g.Log().Debug(ctx, "newServerUdpTunnel", serverId, deviceKey, conn.LocalAddr().String(), conn.RemoteAddr().String())
+	tunnelBase := tunnel.TunnelBase{
+		TunnelId: baseServerTunnelInfo.TunnelId,
+		Link:     conn,
+		ServerId: serverId,
+	}
+	tunnelBase.SetRunning(true)
+	tunnelBase.SetOnline(true)
+	return &ServerUdpTunnel{serverId: serverId, deviceKey: deviceKey, conn: conn, TunnelBase: &tunnelBase}, nil
+}
+
+func (l *ServerUdpTunnel) Open(ctx context.Context) error {
+	return errors.New("ServerUdpTunnel cannot open")
+}
+
+func (l *ServerUdpTunnel) receive(ctx context.Context) {
+
+	if err := action.TunnelOnlineAction(ctx, l.serverId, l.TunnelId, l.deviceKey); err != nil {
+		g.Log().Errorf(ctx, "tunnel online error: %v", err)
+		_ = l.conn.Close()
+		return
+	}
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, err := l.Link.Read(buf)
+		if err != nil {
+			if errors.Is(err, errIdleTimeout) {
+				g.Log().Debugf(ctx, "udp tunnel idle timeout, deviceKey:%s remote_addr:%s", l.deviceKey, l.conn.RemoteAddr().String())
+			}
+			l.OnClose()
+			break
+		}
+		if n == 0 {
+			continue
+		}
+		if l.GetPipe() != nil {
+			_, err = l.GetPipe().Write(buf[:n])
+			if err != nil {
+				l.SetPipe(nil)
+			} else {
+				continue
+			}
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		if l.Reply(data) {
+			continue
+		}
+		go l.TunnelBase.ReadData(ctx, l.deviceKey, data)
+	}
+	_ = l.conn.Close()
+	l.SetRunning(false)
+	l.SetOnline(false)
+
+	//设备已经通过新通道上线
+	if l.replaced.Load() {
+		return
+	}
+	action.TunnelOfflineAction(ctx, l.serverId, l.TunnelId, l.deviceKey)
+}
@@ -0,0 +1,176 @@
+package udp
+
+import (
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"net"
+	"sagooiot/network/core/server/common"
+	"sagooiot/network/core/tunnel/base"
+	"sagooiot/network/model"
+	"sync"
+	"time"
+)
+
+// defaultIdleTimeout 没有配置心跳超时时间时，虚拟通道的默认空闲过期时间
+const defaultIdleTimeout = 5 * time.Minute
+
+type ServerUDP struct {
+	server *model.Server
+
+	lock sync.RWMutex
+	// 通道id -> 通道
+	children map[string]*ServerUdpTunnel
+	// 远端地址 -> 通道
+	remotes map[string]*ServerUdpTunnel
+
+	conn *net.UDPConn
+
+	running bool
+}
+
+func NewServerUDP(server *model.Server) *ServerUDP {
+	svr := &ServerUDP{
+		server:   server,
+		children: make(map[string]*ServerUdpTunnel),
+		remotes:  make(map[string]*ServerUdpTunnel),
+	}
+	return svr
+}
+
+func (server *ServerUDP) Open(ctx context.Context) error {
+	if server.running {
+		return errors.New("server is opened")
+	}
+	common.ServerOpenAction(server.server.Id)
+
+	addr, err := net.ResolveUDPAddr("udp4", common.ResolvePort(server.server.Addr))
+	if err != nil {
+		return err
+	}
+	server.conn, err = net.ListenUDP("udp4", addr)
+	if err != nil {
+		return err
+	}
+
+	server.running = true
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, remote, readErr := server.conn.ReadFromUDP(buf)
+			if readErr != nil {
+				g.Log().Errorf(ctx, "read udp error: %s", readErr.Error())
+				break
+			}
+			if n == 0 {
+				continue
+			}
+			server.dispatch(ctx, remote, buf[:n])
+		}
+		server.running = false
+	}()
+	return nil
+}
+
+// dispatch 按远端地址分发报文，未注册的地址需要先通过注册包校验
+func (server *ServerUDP) dispatch(ctx context.Context, remote *net.UDPAddr, data []byte) {
+	server.lock.RLock()
+	tnl := server.remotes[remote.String()]
+	server.lock.RUnlock()
+
+	if tnl != nil {
+		//心跳包只用来刷新空闲时间，不进入消息处理
+		if server.server.Heartbeat.Enable && server.server.Heartbeat.Check(data) {
+			data = nil
+		}
+		if tnl.conn.push(data) {
+			return
+		}
+		g.Log().Debugf(ctx, "udp tunnel busy or closed, deviceKey:%s remote_addr:%s, message ignored", tnl.deviceKey, remote.String())
+		return
+	}
+
+	deviceKey, checkIsOk := server.server.Register.Check(data)
+	if !checkIsOk {
+		g.Log().Errorf(ctx, "register check not right,check_data:%s ,local_addr:%s remote_addr:%s", string(data), server.conn.LocalAddr().String(), remote.String())
+		return
+	}
+	conn := newUdpConn(server.conn, remote, server.idleTimeout())
+	tnl, tnlErr := newServerUdpTunnel(ctx, server.server.Id, deviceKey, conn)
+	if tnlErr != nil {
+		g.Log().Errorf(ctx, "new udp tunnel error: %v,local_addr:%s remote_addr:%s", tnlErr, server.conn.LocalAddr().String(), remote.String())
+		return
+	}
+
+	//同一设备只保留一个通道，设备地址变化（如NAT重新映射）时关闭旧通道
+	server.lock.Lock()
+	if old, ok := server.children[tnl.TunnelId]; ok {
+		delete(server.remotes, old.conn.RemoteAddr().String())
+		old.replaced.Store(true)
+		_ = old.conn.Close()
+	}
+	server.children[tnl.TunnelId] = tnl
+	server.remotes[remote.String()] = tnl
+	server.lock.Unlock()
+
+	go func() {
+		tnl.receive(ctx)
+		server.removeTunnel(tnl)
+	}()
+	common.ServerTunnelAction(ctx, server.server.Id, deviceKey)
+}
+
+// idleTimeout 虚拟通道空闲过期时间，优先使用心跳超时时间
+func (server *ServerUDP) idleTimeout() time.Duration {
+	if server.server.Heartbeat.Timeout > 0 {
+		return time.Duration(server.server.Heartbeat.Timeout) * time.Second
+	}
+	return defaultIdleTimeout
+}
+
+// removeTunnel 移除通道，通道已经被同设备的新通道替换时只清理地址索引
+func (server *ServerUDP) removeTunnel(tnl *ServerUdpTunnel) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if server.remotes[tnl.conn.RemoteAddr().String()] == tnl {
+		delete(server.remotes, tnl.conn.RemoteAddr().String())
+	}
+	if server.children[tnl.TunnelId] == tnl {
+		delete(server.children, tnl.TunnelId)
+	}
+}
+
+func (server *ServerUDP) Close() (err error) {
+	common.ServerCloseAction(server.server.Id)
+	server.lock.RLock()
+	for _, l := range server.children {
+		_ = l.Close()
+	}
+	server.lock.RUnlock()
+	if server.conn == nil {
+		return nil
+	}
+	return server.conn.Close()
+}
+
+func (server *ServerUDP) GetTunnel(id string) base.TunnelInstance {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+	if tnl, ok := server.children[id]; ok {
+		return tnl
+	}
+	return nil
+}
+
+func (server *ServerUDP) RemoveTunnel(id string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if tnl, ok := server.children[id]; ok {
+		delete(server.remotes, tnl.conn.RemoteAddr().String())
+		delete(server.children, id)
+	}
+}
+
+func (server *ServerUDP) Running() bool {
+	return server.running
+}
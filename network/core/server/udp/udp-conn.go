@@ -0,0 +1,93 @@
+package udp
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+var errIdleTimeout = errors.New("udp tunnel idle timeout")
+
+// udpConn 虚拟连接，把同一远端地址的数据报文包装成 io.ReadWriteCloser，
+// 这样UDP通道就可以和TCP通道一样复用 TunnelBase 的读写逻辑
+type udpConn struct {
+	conn    *net.UDPConn
+	remote  *net.UDPAddr
+	timeout time.Duration
+
+	packets chan []byte
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func newUdpConn(conn *net.UDPConn, remote *net.UDPAddr, timeout time.Duration) *udpConn {
+	return &udpConn{
+		conn:    conn,
+		remote:  remote,
+		timeout: timeout,
+		packets: make(chan []byte, 64),
+		closed:  make(chan struct{}),
+	}
+}
+
+// push 服务端收到该远端地址的报文后投递到虚拟连接，连接已关闭或缓冲已满时返回false
+func (c *udpConn) push(data []byte) bool {
+	select {
+	case <-c.closed:
+		return false
+	default:
+	}
+	buf := make([]byte, len(data))
+	copy(buf, data)
+	select {
+	case c.packets <- buf:
+		return true
+	default:
+		return false
+	}
+}
+
+// Read 读取一个数据报文，超过空闲时间没有收到报文则返回超时错误
+func (c *udpConn) Read(p []byte) (int, error) {
+	var idle <-chan time.Time
+	if c.timeout > 0 {
+		timer := time.NewTimer(c.timeout)
+		defer timer.Stop()
+		idle = timer.C
+	}
+	select {
+	case buf := <-c.packets:
+		return copy(p, buf), nil
+	case <-idle:
+		return 0, errIdleTimeout
+	case <-c.closed:
+		return 0, io.EOF
+	}
+}
+
+func (c *udpConn) Write(p []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+	return c.conn.WriteToUDP(p, c.remote)
+}
+
+// Close 关闭虚拟连接，不会关闭底层的监听socket
+func (c *udpConn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+func (c *udpConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *udpConn) RemoteAddr() net.Addr {
+	return c.remote
+}
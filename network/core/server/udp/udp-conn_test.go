@@ -0,0 +1,82 @@
+package udp
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestConn(t *testing.T) (*net.UDPConn, *net.UDPConn) {
+	server, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen udp failed: %v", err)
+	}
+	client, err := net.DialUDP("udp4", nil, server.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		_ = server.Close()
+		t.Fatalf("dial udp failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+	return server, client
+}
+
+func TestUdpConnReadWrite(t *testing.T) {
+	server, client := newTestConn(t)
+	conn := newUdpConn(server, client.LocalAddr().(*net.UDPAddr), time.Second)
+
+	if !conn.push([]byte("hello")) {
+		t.Fatalf("push failed")
+	}
+	buf := make([]byte, maxDatagramSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(buf[:n]) != "hello" {
+		t.Fatalf("read got %q, want %q", string(buf[:n]), "hello")
+	}
+
+	if _, err = conn.Write([]byte("reply")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	_ = client.SetReadDeadline(time.Now().Add(time.Second))
+	n, err = client.Read(buf)
+	if err != nil {
+		t.Fatalf("client read failed: %v", err)
+	}
+	if string(buf[:n]) != "reply" {
+		t.Fatalf("client got %q, want %q", string(buf[:n]), "reply")
+	}
+}
+
+func TestUdpConnIdleTimeout(t *testing.T) {
+	server, client := newTestConn(t)
+	conn := newUdpConn(server, client.LocalAddr().(*net.UDPAddr), 50*time.Millisecond)
+
+	_, err := conn.Read(make([]byte, 16))
+	if !errors.Is(err, errIdleTimeout) {
+		t.Fatalf("read got err %v, want %v", err, errIdleTimeout)
+	}
+}
+
+func TestUdpConnClose(t *testing.T) {
+	server, client := newTestConn(t)
+	conn := newUdpConn(server, client.LocalAddr().(*net.UDPAddr), time.Second)
+
+	_ = conn.Close()
+	_ = conn.Close()
+	if conn.push([]byte("late")) {
+		t.Fatalf("push after close should fail")
+	}
+	if _, err := conn.Read(make([]byte, 16)); err != io.EOF {
+		t.Fatalf("read got err %v, want EOF", err)
+	}
+	if _, err := conn.Write([]byte("late")); err == nil {
+		t.Fatalf("write after close should fail")
+	}
+}
@@ -0,0 +1,122 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"sagooiot/internal/consts"
+	"sagooiot/network/core/server/base"
+	"sagooiot/network/core/tunnel"
+	"sagooiot/network/core/tunnel/action"
+	"sync/atomic"
+	"time"
+)
+
+// ServerWebsocketTunnel websocket连接对应的通道
+type ServerWebsocketTunnel struct {
+	serverId  int
+	deviceKey string
+	conn      *wsConn
+	// replaced 已被同设备的新连接替换，关闭时不再下线设备
+	replaced atomic.Bool
+	*tunnel.TunnelBase
+}
+
+func newServerWebsocketTunnel(ctx context.Context, serverId int, deviceKey string, conn *wsConn) (*ServerWebsocketTunnel, error) {
+	baseServerTunnelInfo := base.ServerTunnel{
+		ServerId:   serverId,
+		DeviceKey:  deviceKey,
+		Type:       "websocket",
+		Status:     consts.TunnelIsOnLine,
+		LocalAddr:  conn.conn.LocalAddr().String(),
+		RemoteAddr: conn.conn.RemoteAddr().String(),
+		Remark:     "",
+	}
+	var err error
+	baseServerTunnelInfo.TunnelId, err = base.AddOrEditServerTunnel(ctx, baseServerTunnelInfo)
+	if err != nil {
+		return nil, err
+	}
+	g.Log().Debug(ctx, "newServerWebsocketTunnel", serverId, deviceKey, baseServerTunnelInfo.LocalAddr, baseServerTunnelInfo.RemoteAddr)
+	tunnelBase := tunnel.TunnelBase{
+		TunnelId: baseServerTunnelInfo.TunnelId,
+		Link:     conn,
+		ServerId: serverId,
+	}
+	tunnelBase.SetRunning(true)
+	tunnelBase.SetOnline(true)
+	return &ServerWebsocketTunnel{serverId: serverId, deviceKey: deviceKey, conn: conn, TunnelBase: &tunnelBase}, nil
+}
+
+func (l *ServerWebsocketTunnel) Open(ctx context.Context) error {
+	return errors.New("ServerWebsocketTunnel cannot open")
+}
+
+// keepalive 定时发送ping，收到pong或数据帧时延长读超时
+func (l *ServerWebsocketTunnel) keepalive(timeout time.Duration, done chan struct{}) {
+	_ = l.conn.conn.SetReadDeadline(time.Now().Add(timeout))
+	l.conn.conn.SetPongHandler(func(string) error {
+		return l.conn.conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := l.conn.ping(); err != nil {
+				_ = l.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (l *ServerWebsocketTunnel) receive(ctx context.Context, timeout time.Duration) {
+
+	if err := action.TunnelOnlineAction(ctx, l.serverId, l.TunnelId, l.deviceKey); err != nil {
+		g.Log().Errorf(ctx, "tunnel online error: %v", err)
+		_ = l.conn.Close()
+		return
+	}
+	done := make(chan struct{})
+	go l.keepalive(timeout, done)
+
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, err := l.Link.Read(buf)
+		if err != nil {
+			l.OnClose()
+			break
+		}
+		_ = l.conn.conn.SetReadDeadline(time.Now().Add(timeout))
+		if n == 0 {
+			continue
+		}
+		if l.GetPipe() != nil {
+			_, err = l.GetPipe().Write(buf[:n])
+			if err != nil {
+				l.SetPipe(nil)
+			} else {
+				continue
+			}
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		if l.Reply(data) {
+			continue
+		}
+		go l.TunnelBase.ReadData(ctx, l.deviceKey, data)
+	}
+	close(done)
+	_ = l.conn.Close()
+	l.SetRunning(false)
+	l.SetOnline(false)
+
+	//设备已经通过新连接上线
+	if l.replaced.Load() {
+		return
+	}
+	action.TunnelOfflineAction(ctx, l.serverId, l.TunnelId, l.deviceKey)
+}
@@ -0,0 +1,207 @@
+package websocket
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	ws "github.com/gorilla/websocket"
+	"net"
+	"net/http"
+	"net/url"
+	"sagooiot/network/core/server/common"
+	"sagooiot/network/core/tunnel/base"
+	"sagooiot/network/model"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultIdleTimeout 没有配置心跳超时时间时，连接多久没有数据视为断开
+	defaultIdleTimeout = 60 * time.Second
+	// maxMessageSize 单帧数据的最大长度
+	maxMessageSize = 1 << 20
+	// optionAllowedOrigins 服务配置项，允许浏览器跨域连接的来源，多个以逗号分隔，* 表示允许所有来源
+	optionAllowedOrigins = "allowedOrigins"
+)
+
+type ServerWebsocket struct {
+	server *model.Server
+
+	lock     sync.RWMutex
+	children map[string]*ServerWebsocketTunnel
+
+	listener   net.Listener
+	httpServer *http.Server
+	upgrader   ws.Upgrader
+
+	running bool
+}
+
+func NewServerWebsocket(server *model.Server) *ServerWebsocket {
+	svr := &ServerWebsocket{
+		server:   server,
+		children: make(map[string]*ServerWebsocketTunnel),
+	}
+	svr.upgrader = ws.Upgrader{
+		ReadBufferSize:  4096,
+		WriteBufferSize: 4096,
+		CheckOrigin:     svr.checkOrigin,
+	}
+	return svr
+}
+
+func (server *ServerWebsocket) Open(ctx context.Context) error {
+	if server.running {
+		return errors.New("server is opened")
+	}
+	common.ServerOpenAction(server.server.Id)
+
+	tlsConfig, err := common.TLSConfig(ctx, server.server)
+	if err != nil {
+		return err
+	}
+	server.listener, err = net.Listen("tcp4", common.ResolvePort(server.server.Addr))
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		server.listener = tls.NewListener(server.listener, tlsConfig)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sys/{productKey}/{deviceKey}", server.handleConnect(ctx))
+	server.httpServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	server.running = true
+	go func() {
+		if serveErr := server.httpServer.Serve(server.listener); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			g.Log().Errorf(ctx, "serve websocket error: %s", serveErr.Error())
+		}
+		server.running = false
+	}()
+	return nil
+}
+
+// checkOrigin 校验浏览器发起连接的来源，默认只允许同源；设备直连时没有 Origin 头，不做限制
+func (server *ServerWebsocket) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range strings.Split(server.server.Options[optionAllowedOrigins], ",") {
+		allowed = strings.TrimSuffix(strings.TrimSpace(allowed), "/")
+		if allowed == "*" || (allowed != "" && strings.EqualFold(allowed, origin)) {
+			return true
+		}
+	}
+	return false
+}
+
+// idleTimeout 连接的读超时时间，优先使用心跳超时时间
+func (server *ServerWebsocket) idleTimeout() time.Duration {
+	if server.server.Heartbeat.Timeout > 0 {
+		return time.Duration(server.server.Heartbeat.Timeout) * time.Second
+	}
+	return defaultIdleTimeout
+}
+
+// handleConnect 握手时校验设备身份，通过后升级为websocket连接
+func (server *ServerWebsocket) handleConnect(ctx context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productKey, deviceKey := r.PathValue("productKey"), r.PathValue("deviceKey")
+		if _, err := common.GetAccessDevice(ctx, productKey, deviceKey); err != nil {
+			g.Log().Debugf(ctx, "websocket access error: %v, path:%s remote_addr:%s", err, r.URL.Path, r.RemoteAddr)
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err := common.DeviceAuth(ctx, deviceKey, common.HttpCredential(r)); err != nil {
+			g.Log().Debugf(ctx, "websocket auth error: %v, path:%s remote_addr:%s", err, r.URL.Path, r.RemoteAddr)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		c, err := server.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			g.Log().Debugf(ctx, "websocket upgrade error: %v, remote_addr:%s", err, r.RemoteAddr)
+			return
+		}
+		c.SetReadLimit(maxMessageSize)
+
+		tnl, err := newServerWebsocketTunnel(ctx, server.server.Id, deviceKey, newWsConn(c))
+		if err != nil {
+			g.Log().Errorf(ctx, "new websocket tunnel error: %v", err)
+			_ = c.Close()
+			return
+		}
+
+		// 同一设备重复连接时，关闭旧的连接
+		server.lock.Lock()
+		old := server.children[tnl.TunnelId]
+		if old != nil {
+			old.replaced.Store(true)
+		}
+		server.children[tnl.TunnelId] = tnl
+		server.lock.Unlock()
+		// 只关闭旧连接，通道关闭事件会作用到同一通道id上的新连接
+		if old != nil {
+			_ = old.conn.Close()
+		}
+		common.ServerTunnelAction(ctx, server.server.Id, deviceKey)
+
+		go func() {
+			tnl.receive(ctx, server.idleTimeout())
+			server.removeTunnel(tnl)
+		}()
+	}
+}
+
+func (server *ServerWebsocket) removeTunnel(tnl *ServerWebsocketTunnel) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if server.children[tnl.TunnelId] == tnl {
+		delete(server.children, tnl.TunnelId)
+	}
+}
+
+func (server *ServerWebsocket) Close() (err error) {
+	common.ServerCloseAction(server.server.Id)
+	server.lock.RLock()
+	for _, l := range server.children {
+		_ = l.Close()
+	}
+	server.lock.RUnlock()
+	if server.httpServer == nil {
+		return nil
+	}
+	return server.httpServer.Close()
+}
+
+func (server *ServerWebsocket) GetTunnel(id string) base.TunnelInstance {
+	server.lock.RLock()
+	defer server.lock.RUnlock()
+	if tnl, ok := server.children[id]; ok {
+		return tnl
+	}
+	return nil
+}
+
+func (server *ServerWebsocket) RemoveTunnel(id string) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	delete(server.children, id)
+}
+
+func (server *ServerWebsocket) Running() bool {
+	return server.running
+}
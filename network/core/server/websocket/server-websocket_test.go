@@ -0,0 +1,34 @@
+package websocket
+
+import (
+	"net/http/httptest"
+	"sagooiot/network/model"
+	"testing"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	cases := []struct {
+		allowed string
+		origin  string
+		want    bool
+	}{
+		{"", "", true},
+		{"", "http://iot.example.com", true},
+		{"", "http://evil.example.com", false},
+		{"", "://bad", false},
+		{"https://app.example.com, http://localhost:8080/", "https://app.example.com", true},
+		{"https://app.example.com, http://localhost:8080/", "http://localhost:8080", true},
+		{"https://app.example.com", "http://app.example.com", false},
+		{"*", "http://evil.example.com", true},
+	}
+	for i, c := range cases {
+		svr := NewServerWebsocket(&model.Server{Options: map[string]string{optionAllowedOrigins: c.allowed}})
+		r := httptest.NewRequest("GET", "http://iot.example.com/sys/p1/d1", nil)
+		if c.origin != "" {
+			r.Header.Set("Origin", c.origin)
+		}
+		if got := svr.upgrader.CheckOrigin(r); got != c.want {
+			t.Errorf("case %d: got %v, want %v", i, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package websocket
+
+import (
+	ws "github.com/gorilla/websocket"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// writeWait 写入一帧数据的超时时间
+const writeWait = 10 * time.Second
+
+// wsConn 把websocket连接包装成 io.ReadWriteCloser，每一帧对应一次读写
+type wsConn struct {
+	conn      *ws.Conn
+	writeLock sync.Mutex
+}
+
+func newWsConn(conn *ws.Conn) *wsConn {
+	return &wsConn{conn: conn}
+}
+
+// Read 读取下一帧数据，帧的长度超过p时截断
+func (c *wsConn) Read(p []byte) (int, error) {
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		return 0, err
+	}
+	return copy(p, data), nil
+}
+
+// Write 写入一帧数据，utf8文本按文本帧发送，其他数据按二进制帧发送
+func (c *wsConn) Write(p []byte) (int, error) {
+	messageType := ws.BinaryMessage
+	if utf8.Valid(p) {
+		messageType = ws.TextMessage
+	}
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := c.conn.WriteMessage(messageType, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// ping 发送心跳帧
+func (c *wsConn) ping() error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.conn.WriteControl(ws.PingMessage, nil, time.Now().Add(writeWait))
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
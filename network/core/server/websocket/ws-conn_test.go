@@ -0,0 +1,61 @@
+package websocket
+
+import (
+	ws "github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWsConnReadWrite(t *testing.T) {
+	upgrader := ws.Upgrader{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn := newWsConn(c)
+		defer conn.Close()
+		buf := make([]byte, 1024)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			if _, err = conn.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+	}))
+	defer svr.Close()
+
+	client, _, err := ws.DefaultDialer.Dial("ws"+strings.TrimPrefix(svr.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer client.Close()
+
+	cases := []struct {
+		data        []byte
+		messageType int
+	}{
+		{[]byte(`{"id":"1"}`), ws.TextMessage},
+		{[]byte{0x01, 0x03, 0xff, 0xfe}, ws.BinaryMessage},
+	}
+	for _, c := range cases {
+		if err = client.WriteMessage(ws.BinaryMessage, c.data); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		messageType, data, err := client.ReadMessage()
+		if err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+		if messageType != c.messageType {
+			t.Fatalf("message type got %d, want %d", messageType, c.messageType)
+		}
+		if string(data) != string(c.data) {
+			t.Fatalf("message got %q, want %q", data, c.data)
+		}
+	}
+}
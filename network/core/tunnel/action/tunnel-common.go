@@ -40,7 +40,7 @@ func TunnelOnlineAction(ctx context.Context, serverId int, tunnelId string, devi
 		err = errors.New("device not found")
 		return
 	}
-	deviceDetail := device.GetDevice(uint64(deviceRes.Id))
+	deviceDetail := device.GetDevice(deviceRes.Key)
 	if deviceDetail != nil {
 		err = deviceDetail.Start(ctx)
 		if err != nil {
@@ -69,8 +69,10 @@ func TunnelOfflineAction(ctx context.Context, serverId int, tunnelId, deviceKey
 			g.Log().Errorf(ctx, "deviceKey:%s not found,ignore", deviceKey)
 			return
 		}
-		if deviceStopError := device.GetDevice(uint64(deviceDetail.Id)).Stop(); deviceStopError != nil {
-			g.Log().Errorf(ctx, "Stop device  error:%v ,ignore", deviceStopError)
+		if dev := device.GetDevice(deviceDetail.Key); dev != nil {
+			if deviceStopError := dev.Stop(); deviceStopError != nil {
+				g.Log().Errorf(ctx, "Stop device  error:%v ,ignore", deviceStopError)
+			}
 		}
 		if deviceDetail.Status == consts.DeviceStatueOnline {
 			if offlineErr := baseLogic.Offline(ctx, model.DeviceOfflineMessage{
@@ -2,12 +2,16 @@ package base
 
 import (
 	"context"
+	"errors"
 	"io"
 	"sagooiot/pkg/iotModel/topicModel"
 	"sync"
 	"time"
 )
 
+// ErrAskNotSupported 通道不能同步等待设备响应，如 HTTP、CoAP 等由设备发起请求的通道
+var ErrAskNotSupported = errors.New("tunnel does not support ask")
+
 // Tunnel 通道
 type TunnelInstance interface {
 	Write(data []byte) error
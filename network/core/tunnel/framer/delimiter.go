@@ -0,0 +1,41 @@
+package framer
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Delimiter 按分隔符分包，帧内容不包含分隔符
+type Delimiter struct {
+	delimit []byte
+}
+
+func NewDelimiter(delimit string) (*Delimiter, error) {
+	if delimit == "" {
+		return nil, errors.New("delimiter is empty")
+	}
+	return &Delimiter{delimit: []byte(delimit)}, nil
+}
+
+func (f *Delimiter) Split(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.Index(data, f.delimit); i >= 0 {
+		return i + len(f.delimit), data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	if len(data) > MaxFrameSize {
+		return 0, nil, ErrFrameTooLong
+	}
+	return 0, nil, nil
+}
+
+// Encode 末尾没有分隔符时补上分隔符
+func (f *Delimiter) Encode(data []byte) ([]byte, error) {
+	if bytes.HasSuffix(data, f.delimit) {
+		return data, nil
+	}
+	res := make([]byte, 0, len(data)+len(f.delimit))
+	res = append(res, data...)
+	return append(res, f.delimit...), nil
+}
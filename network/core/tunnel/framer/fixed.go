@@ -0,0 +1,32 @@
+package framer
+
+import "fmt"
+
+// FixedLength 按固定长度分包
+type FixedLength struct {
+	length int
+}
+
+func NewFixedLength(length int) (*FixedLength, error) {
+	if length <= 0 || length > MaxFrameSize {
+		return nil, fmt.Errorf("fixed length %d out of range", length)
+	}
+	return &FixedLength{length: length}, nil
+}
+
+func (f *FixedLength) Split(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) >= f.length {
+		return f.length, data[:f.length], nil
+	}
+	return 0, nil, nil
+}
+
+// Encode 不足固定长度时在末尾补0
+func (f *FixedLength) Encode(data []byte) ([]byte, error) {
+	if len(data) > f.length {
+		return nil, fmt.Errorf("data length %d exceeds fixed length %d", len(data), f.length)
+	}
+	res := make([]byte, f.length)
+	copy(res, data)
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package framer
+
+import (
+	"encoding/json"
+	"errors"
+	"sagooiot/internal/model"
+	"strconv"
+	"strings"
+)
+
+// MaxFrameSize 单帧数据的最大长度
+const MaxFrameSize = 1 << 20
+
+var (
+	ErrFrameTooLong  = errors.New("frame too long")
+	ErrInvalidLength = errors.New("invalid frame length")
+)
+
+// Framer 粘包处理，把字节流切分为完整的帧，下发时按同样的方式封装
+type Framer interface {
+	// Split 符合 bufio.SplitFunc，从已读取的数据中切分出一帧
+	Split(data []byte, atEOF bool) (advance int, token []byte, err error)
+	// Encode 按分包方式封装下发的数据
+	Encode(data []byte) ([]byte, error)
+}
+
+// New 根据服务的粘包处理配置创建分包方式，没有配置时按每次读取的数据作为一帧
+func New(stick string) (Framer, error) {
+	if strings.TrimSpace(stick) == "" {
+		return Raw{}, nil
+	}
+	var conf model.Stick
+	if err := json.Unmarshal([]byte(stick), &conf); err != nil {
+		return nil, err
+	}
+	return FromStick(conf)
+}
+
+// FromStick 按 Modbus-RTU、分隔符、固定长度、长度字段的顺序选择分包方式
+func FromStick(conf model.Stick) (Framer, error) {
+	switch {
+	case conf.ModbusRtu:
+		return ModbusRtu{}, nil
+	case conf.Delimit != "":
+		return NewDelimiter(unescape(conf.Delimit))
+	case conf.FixedLen > 0:
+		return NewFixedLength(conf.FixedLen)
+	case conf.Len.Len > 0:
+		return NewLengthField(conf.Len.Offset, conf.Len.Len, conf.Len.Endian, conf.Len.Adjust)
+	}
+	return Raw{}, nil
+}
+
+// unescape 支持在配置中填写 \r\n、\x03 这样的转义字符
+func unescape(s string) string {
+	if res, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return res
+	}
+	return s
+}
+
+// Raw 不做分包，每次读取到的数据即为一帧
+type Raw struct{}
+
+func (Raw) Split(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+	return len(data), data, nil
+}
+
+func (Raw) Encode(data []byte) ([]byte, error) {
+	return data, nil
+}
@@ -0,0 +1,177 @@
+package framer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// scan 用分包方式读取全部帧，oneByte为true时每次只读一个字节，模拟拆包
+func scan(t *testing.T, f Framer, data []byte, oneByte bool) []string {
+	t.Helper()
+	var r io.Reader = bytes.NewReader(data)
+	if oneByte {
+		r = iotest.OneByteReader(r)
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Split(f.Split)
+	var frames []string
+	for scanner.Scan() {
+		frames = append(frames, string(scanner.Bytes()))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	return frames
+}
+
+func assertFrames(t *testing.T, f Framer, data []byte, want ...string) {
+	t.Helper()
+	for _, oneByte := range []bool{false, true} {
+		got := scan(t, f, data, oneByte)
+		if len(got) != len(want) {
+			t.Fatalf("oneByte=%v got %d frames %q, want %q", oneByte, len(got), got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("oneByte=%v frame %d got %q, want %q", oneByte, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		stick string
+		want  Framer
+	}{
+		{"", Raw{}},
+		{`{}`, Raw{}},
+		{`{"delimit":"\\r\\n"}`, &Delimiter{delimit: []byte("\r\n")}},
+		{`{"fixedLen":8}`, &FixedLength{length: 8}},
+		{`{"len":{"len":2,"offset":1,"endian":"little"}}`, &LengthField{}},
+		{`{"modbusRtu":true,"delimit":"\n"}`, ModbusRtu{}},
+	}
+	for _, c := range cases {
+		f, err := New(c.stick)
+		if err != nil {
+			t.Fatalf("New(%s) failed: %v", c.stick, err)
+		}
+		switch want := c.want.(type) {
+		case *Delimiter:
+			if d, ok := f.(*Delimiter); !ok || !bytes.Equal(d.delimit, want.delimit) {
+				t.Fatalf("New(%s) got %#v", c.stick, f)
+			}
+		case *FixedLength:
+			if d, ok := f.(*FixedLength); !ok || d.length != want.length {
+				t.Fatalf("New(%s) got %#v", c.stick, f)
+			}
+		case *LengthField:
+			if _, ok := f.(*LengthField); !ok {
+				t.Fatalf("New(%s) got %#v", c.stick, f)
+			}
+		default:
+			if f != c.want {
+				t.Fatalf("New(%s) got %#v", c.stick, f)
+			}
+		}
+	}
+	if _, err := New(`{"len":{"len":3}}`); err == nil {
+		t.Fatalf("length field size 3 should fail")
+	}
+}
+
+func TestDelimiter(t *testing.T) {
+	f, _ := NewDelimiter("\r\n")
+	assertFrames(t, f, []byte("{\"id\":\"1\"}\r\n{\"id\":\"2\"}\r\n{\"id\":\"3\"}"), `{"id":"1"}`, `{"id":"2"}`, `{"id":"3"}`)
+
+	res, _ := f.Encode([]byte("abc"))
+	if string(res) != "abc\r\n" {
+		t.Fatalf("encode got %q", res)
+	}
+	res, _ = f.Encode([]byte("abc\r\n"))
+	if string(res) != "abc\r\n" {
+		t.Fatalf("encode with delimiter got %q", res)
+	}
+}
+
+func TestFixedLength(t *testing.T) {
+	f, _ := NewFixedLength(4)
+	assertFrames(t, f, []byte("aaaabbbbcccc"), "aaaa", "bbbb", "cccc")
+
+	res, _ := f.Encode([]byte("ab"))
+	if !bytes.Equal(res, []byte{'a', 'b', 0, 0}) {
+		t.Fatalf("encode got %q", res)
+	}
+	if _, err := f.Encode([]byte("abcde")); err == nil {
+		t.Fatalf("encode longer data should fail")
+	}
+}
+
+func TestLengthField(t *testing.T) {
+	// 帧头 0x68 + 2字节大端长度，长度包含末尾的1字节校验
+	f, _ := NewLengthField(1, 2, "big", 0)
+	frame1 := []byte{0x68, 0x00, 0x03, 'a', 'b', 0x16}
+	frame2 := []byte{0x68, 0x00, 0x01, 0x16}
+	assertFrames(t, f, append(append([]byte{}, frame1...), frame2...), string(frame1), string(frame2))
+
+	// 下发时在帧头之后插入长度字段
+	res, err := f.Encode([]byte{0x68, 'a', 'b', 0x16})
+	if err != nil || !bytes.Equal(res, frame1) {
+		t.Fatalf("encode got %x, %v, want %x", res, err, frame1)
+	}
+	// 数据本身像一帧时同样补上长度字段
+	res, err = f.Encode(frame1)
+	if err != nil || !bytes.Equal(res, []byte{0x68, 0x00, 0x05, 0x00, 0x03, 'a', 'b', 0x16}) {
+		t.Fatalf("encode frame-like data got %x, %v", res, err)
+	}
+	if _, err = f.Encode(nil); err == nil {
+		t.Fatalf("encode data shorter than offset should fail")
+	}
+
+	// 长度字段在帧首，小端，长度不包含末尾2字节
+	f, _ = NewLengthField(0, 2, "little", 2)
+	res, err = f.Encode([]byte("hello"))
+	if err != nil || !bytes.Equal(res, []byte{0x03, 0x00, 'h', 'e', 'l', 'l', 'o'}) {
+		t.Fatalf("encode got %x, %v", res, err)
+	}
+	assertFrames(t, f, append(append([]byte{}, res...), res...), string(res), string(res))
+
+	f, _ = NewLengthField(0, 1, "big", -2)
+	if _, _, err = f.Split([]byte{0x00, 0x01}, false); err != ErrInvalidLength {
+		t.Fatalf("split got err %v, want %v", err, ErrInvalidLength)
+	}
+}
+
+func TestModbusRtu(t *testing.T) {
+	req, _ := hex.DecodeString("010300000002c40b")
+	resp, _ := hex.DecodeString("0103040001000a2bf4")
+	if Crc16(req[:6]) != 0x0bc4 {
+		t.Fatalf("crc got %04x", Crc16(req[:6]))
+	}
+	f := ModbusRtu{}
+	write, _ := f.Encode([]byte{0x01, 0x06, 0x00, 0x01, 0x00, 0x03})
+	exception, _ := f.Encode([]byte{0x01, 0x83, 0x02})
+	// 数据区中出现CRC正确的前缀时，按字节数取完整的一帧
+	data := []byte{0x01, 0x03, 0x06}
+	data = binary.LittleEndian.AppendUint16(data, Crc16(data))
+	data = append(data, 0x12, 0x34, 0x56, 0x78)
+	read, _ := f.Encode(data)
+	stream := append(append(append(append([]byte{}, resp...), write...), exception...), read...)
+	assertFrames(t, f, stream, string(resp), string(write), string(exception), string(read))
+	// 开头的干扰数据被丢弃
+	assertFrames(t, f, append([]byte{0xff}, resp...), string(resp))
+
+	res, _ := f.Encode(req[:6])
+	if !bytes.Equal(res, req) {
+		t.Fatalf("encode got %x, want %x", res, req)
+	}
+	res, _ = f.Encode(req)
+	if !bytes.Equal(res, req) {
+		t.Fatalf("encode with crc got %x, want %x", res, req)
+	}
+}
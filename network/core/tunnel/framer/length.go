@@ -0,0 +1,88 @@
+package framer
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// LengthField 按帧头中的长度字段分包
+// 帧长度 = offset + size + 长度字段的值 + adjust
+type LengthField struct {
+	offset int
+	size   int
+	adjust int
+	order  binary.ByteOrder
+}
+
+func NewLengthField(offset, size int, endian string, adjust int) (*LengthField, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("length field offset %d out of range", offset)
+	}
+	switch size {
+	case 1, 2, 4:
+	default:
+		return nil, fmt.Errorf("length field size %d not support", size)
+	}
+	f := &LengthField{offset: offset, size: size, adjust: adjust, order: binary.BigEndian}
+	if endian == "little" {
+		f.order = binary.LittleEndian
+	}
+	return f, nil
+}
+
+func (f *LengthField) header() int {
+	return f.offset + f.size
+}
+
+func (f *LengthField) readLength(b []byte) int {
+	switch f.size {
+	case 1:
+		return int(b[0])
+	case 2:
+		return int(f.order.Uint16(b))
+	default:
+		return int(f.order.Uint32(b))
+	}
+}
+
+func (f *LengthField) Split(data []byte, atEOF bool) (int, []byte, error) {
+	header := f.header()
+	if len(data) < header {
+		return 0, nil, nil
+	}
+	frameLen := header + f.readLength(data[f.offset:header]) + f.adjust
+	if frameLen < header {
+		return 0, nil, ErrInvalidLength
+	}
+	if frameLen > MaxFrameSize {
+		return 0, nil, ErrFrameTooLong
+	}
+	if len(data) < frameLen {
+		return 0, nil, nil
+	}
+	return frameLen, data[:frameLen], nil
+}
+
+// Encode 在 offset 处插入长度字段，data 的前 offset 个字节为长度字段之前的帧头
+func (f *LengthField) Encode(data []byte) ([]byte, error) {
+	if len(data) < f.offset {
+		return nil, fmt.Errorf("data length %d is shorter than length field offset %d", len(data), f.offset)
+	}
+	value := len(data) - f.offset - f.adjust
+	if value < 0 || (f.size < 4 && value >= 1<<(8*f.size)) {
+		return nil, ErrInvalidLength
+	}
+	field := make([]byte, f.size)
+	switch f.size {
+	case 1:
+		field[0] = byte(value)
+	case 2:
+		f.order.PutUint16(field, uint16(value))
+	default:
+		f.order.PutUint32(field, uint32(value))
+	}
+	res := make([]byte, 0, len(data)+f.size)
+	res = append(res, data[:f.offset]...)
+	res = append(res, field...)
+	return append(res, data[f.offset:]...), nil
+}
@@ -0,0 +1,113 @@
+package framer
+
+import "encoding/binary"
+
+const (
+	// modbusRtuMinSize 地址 + 功能码 + CRC
+	modbusRtuMinSize = 4
+	// modbusRtuMaxSize Modbus-RTU 帧的最大长度
+	modbusRtuMaxSize = 256
+)
+
+// ModbusRtu 按从站响应的功能码计算帧长度分包，并校验CRC；未知的功能码找到CRC正确的最短数据作为一帧
+type ModbusRtu struct{}
+
+func (ModbusRtu) Split(data []byte, atEOF bool) (int, []byte, error) {
+	n := modbusRtuLength(data)
+	switch {
+	case n < 0:
+		return splitByCrc(data, atEOF)
+	case n > modbusRtuMaxSize:
+		// 长度不合法，丢弃一个字节重新同步
+		return 1, nil, nil
+	case n > 0 && len(data) >= n:
+		if checkCrc16(data[:n]) {
+			return n, data[:n], nil
+		}
+		// 校验失败，丢弃一个字节重新同步
+		return 1, nil, nil
+	}
+	if atEOF {
+		return len(data), nil, nil
+	}
+	return 0, nil, nil
+}
+
+// splitByCrc 找到CRC正确的最短数据作为一帧
+func splitByCrc(data []byte, atEOF bool) (int, []byte, error) {
+	for n := modbusRtuMinSize; n <= len(data) && n <= modbusRtuMaxSize; n++ {
+		if checkCrc16(data[:n]) {
+			return n, data[:n], nil
+		}
+	}
+	if len(data) >= modbusRtuMaxSize {
+		// 没有校验通过的帧，丢弃一个字节重新同步
+		return 1, nil, nil
+	}
+	if atEOF {
+		return len(data), nil, nil
+	}
+	return 0, nil, nil
+}
+
+// modbusRtuLength 根据功能码计算从站响应帧的长度，数据不足以确定长度时返回0，未知的功能码返回-1
+func modbusRtuLength(data []byte) int {
+	if len(data) < 2 {
+		return 0
+	}
+	fc := data[1]
+	switch {
+	case fc&0x80 != 0, fc == 0x07:
+		// 异常响应、读异常状态：地址 + 功能码 + 1字节 + CRC
+		return 5
+	case fc == 0x05, fc == 0x06, fc == 0x08, fc == 0x0B, fc == 0x0F, fc == 0x10:
+		// 写单个、写多个和诊断：地址 + 功能码 + 4字节 + CRC
+		return 8
+	case fc == 0x16:
+		return 10
+	case fc >= 0x01 && fc <= 0x04, fc == 0x0C, fc == 0x11, fc == 0x14, fc == 0x15, fc == 0x17:
+		// 读数据：地址 + 功能码 + 字节数 + 数据 + CRC
+		if len(data) < 3 {
+			return 0
+		}
+		return 5 + int(data[2])
+	case fc == 0x18:
+		// 读FIFO队列：地址 + 功能码 + 2字节字节数 + 数据 + CRC
+		if len(data) < 4 {
+			return 0
+		}
+		return 6 + int(binary.BigEndian.Uint16(data[2:4]))
+	}
+	return -1
+}
+
+// Encode 末尾没有正确的CRC时补上CRC
+func (ModbusRtu) Encode(data []byte) ([]byte, error) {
+	if len(data) >= modbusRtuMinSize && checkCrc16(data) {
+		return data, nil
+	}
+	res := make([]byte, len(data), len(data)+2)
+	copy(res, data)
+	return binary.LittleEndian.AppendUint16(res, Crc16(data)), nil
+}
+
+func checkCrc16(frame []byte) bool {
+	n := len(frame) - 2
+	return Crc16(frame[:n]) == binary.LittleEndian.Uint16(frame[n:])
+}
+
+// Crc16 Modbus CRC16
+func Crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gogf/gf/v2/frame/g"
 	"io"
 	"sagooiot/internal/consts"
@@ -12,6 +13,7 @@ import (
 	"sagooiot/network/core/logic/baseLogic"
 	"sagooiot/network/core/tunnel/action"
 	tunelBase "sagooiot/network/core/tunnel/base"
+	"sagooiot/network/core/tunnel/framer"
 	networkModel "sagooiot/network/model"
 	"sagooiot/pkg/iotModel/topicModel"
 	"sagooiot/pkg/jsinterpreter"
@@ -36,6 +38,9 @@ type TunnelBase struct {
 
 	pipe io.ReadWriteCloser
 	data chan []byte
+
+	framer  framer.Framer
+	askLock sync.Mutex
 }
 
 func (l *TunnelBase) Running() bool {
@@ -62,6 +67,19 @@ func (l *TunnelBase) SetPipe(pipe io.ReadWriteCloser) {
 	l.pipe = pipe
 }
 
+// SetFramer 设置粘包处理方式
+func (l *TunnelBase) SetFramer(f framer.Framer) {
+	l.framer = f
+}
+
+// Framer 粘包处理方式，没有设置时不做分包
+func (l *TunnelBase) Framer() framer.Framer {
+	if l.framer == nil {
+		return framer.Raw{}
+	}
+	return l.framer
+}
+
 // Close 关闭
 func (l *TunnelBase) Close() error {
 	if l.retryTimer != nil {
@@ -91,9 +109,11 @@ func (l *TunnelBase) Write(data []byte) error {
 	if l.pipe != nil {
 		return nil //透传模式下，直接抛弃
 	}
-	//TODO 需要加入黏包的分割字符，不然客户端会阻塞
-	//data = append(data, []byte("\n")...)
-	_, err := l.Link.Write(data)
+	data, err := l.Framer().Encode(data)
+	if err != nil {
+		return err
+	}
+	_, err = l.Link.Write(data)
 	return err
 }
 
@@ -138,15 +158,49 @@ func (l *TunnelBase) Ask(cmd []byte, timeout time.Duration) ([]byte, error) {
 		return nil, errors.New("tunnel closed")
 	}
 
+	cmd, err := l.Framer().Encode(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	//堵塞
 	l.Lock.Lock()
 	defer l.Lock.Unlock() //自动解锁
 
-	_, err := l.Link.Write(cmd)
-	if err != nil {
+	ch := make(chan []byte, 1)
+	l.askLock.Lock()
+	l.data = ch
+	l.askLock.Unlock()
+	defer func() {
+		l.askLock.Lock()
+		if l.data == ch {
+			l.data = nil
+		}
+		l.askLock.Unlock()
+	}()
+
+	if _, err = l.Link.Write(cmd); err != nil {
 		return nil, err
 	}
-	return l.Wait(timeout)
+	select {
+	case <-time.After(timeout):
+		return nil, errors.New("超时")
+	case buf := <-ch:
+		return buf, nil
+	}
+}
+
+// Reply 有等待响应的Ask时，把收到的一帧数据交给Ask，返回是否已交付
+func (l *TunnelBase) Reply(frame []byte) bool {
+	l.askLock.Lock()
+	ch := l.data
+	l.data = nil
+	l.askLock.Unlock()
+	if ch == nil {
+		return false
+	}
+	ch <- frame
+	return true
 }
 
 func (l *TunnelBase) Pipe(pipe io.ReadWriteCloser) {
@@ -183,34 +237,9 @@ func (l *TunnelBase) Pipe(pipe io.ReadWriteCloser) {
 }
 
 func (l *TunnelBase) router(ctx context.Context, productDetail *model.DetailProductOutput, deviceDetail *model.DeviceOutput, data []byte) {
-	res := string(data)
-	if productDetail.MessageProtocol != consts.DefaultProtocol && productDetail.MessageProtocol != "" {
-		if plugins.GetProtocolPlugin() == nil {
-			return
-		}
-		var err error
-		// 通过消息协议插件解析数据
-		pluginData, err := plugins.GetProtocolPlugin().GetProtocolDecodeData(productDetail.MessageProtocol, data)
-		g.Log().Debug(context.TODO(), "GetProtocolDecodeData", pluginData)
-		if err != nil {
-			g.Log().Debugf(ctx, "get plugin error: %v, deviceKey:%s, data:%s, message ignored", err, deviceDetail.Key, string(data))
-			return
-		}
-		if pluginData.Code != 0 || pluginData.Data == nil {
-			g.Log().Debugf(ctx, "plugin parse error: code:%d message:%s, deviceKey:%s, data:%s, message ignored", pluginData.Code, pluginData.Message, deviceDetail.Key, string(data))
-			return
-		}
-		pluginDataByte, _ := json.Marshal(pluginData.Data)
-		res = string(pluginDataByte)
-	}
-	// 如果有js脚本，根据js脚本处理解析后的数据，处理后的数据数据格式为默认的消息协议格式
-	if productDetail.ScriptInfo != "" {
-		var runScriptErr error
-		res, runScriptErr = jsinterpreter.RunScript(res, productDetail.ScriptInfo)
-		if runScriptErr != nil {
-			g.Log().Errorf(ctx, "runScriptErr error: %v, deviceKey:%s, data:%s, message ignored", runScriptErr, deviceDetail.Key, string(data))
-			return
-		}
+	res, err := DecodeData(ctx, productDetail, deviceDetail.Key, data)
+	if err != nil {
+		return
 	}
 
 	var dataInfo = map[string]interface{}{}
@@ -248,3 +277,36 @@ func (l *TunnelBase) router(ctx context.Context, productDetail *model.DetailProd
 		baseLogic.InertTdLog(ctx, handleF.LogType, deviceDetail.Key, string(data))
 	}
 }
+
+// DecodeData 通过产品的消息协议插件和js脚本，把设备原始数据解析为默认的消息协议格式
+func DecodeData(ctx context.Context, productDetail *model.DetailProductOutput, deviceKey string, data []byte) (string, error) {
+	res := string(data)
+	if productDetail.MessageProtocol != consts.DefaultProtocol && productDetail.MessageProtocol != "" {
+		if plugins.GetProtocolPlugin() == nil {
+			return "", errors.New("protocol plugin not found")
+		}
+		// 通过消息协议插件解析数据
+		pluginData, err := plugins.GetProtocolPlugin().GetProtocolDecodeData(productDetail.MessageProtocol, data)
+		g.Log().Debug(context.TODO(), "GetProtocolDecodeData", pluginData)
+		if err != nil {
+			g.Log().Debugf(ctx, "get plugin error: %v, deviceKey:%s, data:%s, message ignored", err, deviceKey, string(data))
+			return "", err
+		}
+		if pluginData.Code != 0 || pluginData.Data == nil {
+			g.Log().Debugf(ctx, "plugin parse error: code:%d message:%s, deviceKey:%s, data:%s, message ignored", pluginData.Code, pluginData.Message, deviceKey, string(data))
+			return "", fmt.Errorf("plugin parse error: code:%d message:%s", pluginData.Code, pluginData.Message)
+		}
+		pluginDataByte, _ := json.Marshal(pluginData.Data)
+		res = string(pluginDataByte)
+	}
+	// 如果有js脚本，根据js脚本处理解析后的数据，处理后的数据数据格式为默认的消息协议格式
+	if productDetail.ScriptInfo != "" {
+		var runScriptErr error
+		res, runScriptErr = jsinterpreter.Parse(productDetail.Key, productDetail.ScriptInfo, res)
+		if runScriptErr != nil {
+			g.Log().Errorf(ctx, "runScriptErr error: %v, deviceKey:%s, data:%s, message ignored", runScriptErr, deviceKey, string(data))
+			return "", runScriptErr
+		}
+	}
+	return res, nil
+}
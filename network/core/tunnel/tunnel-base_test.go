@@ -0,0 +1,43 @@
+package tunnel
+
+import (
+	"bytes"
+	"net"
+	"sagooiot/network/core/tunnel/framer"
+	"testing"
+	"time"
+)
+
+func TestTunnelBaseAsk(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	f, _ := framer.NewDelimiter("\n")
+	l := &TunnelBase{Link: server}
+	l.SetFramer(f)
+	l.SetRunning(true)
+
+	go func() {
+		buf := make([]byte, 64)
+		n, err := client.Read(buf)
+		if err != nil || string(buf[:n]) != "ping\n" {
+			return
+		}
+		// 模拟接收循环分包后交给Ask
+		for !l.Reply([]byte("pong")) {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	res, err := l.Ask([]byte("ping"), time.Second)
+	if err != nil {
+		t.Fatalf("ask failed: %v", err)
+	}
+	if !bytes.Equal(res, []byte("pong")) {
+		t.Fatalf("ask got %q, want pong", res)
+	}
+	if l.Reply([]byte("late")) {
+		t.Fatalf("reply without waiting ask should not be delivered")
+	}
+}
@@ -13,9 +13,11 @@ type Product struct {
 	Protocol     Protocol `json:"protocol"`
 	//Tunnel       string `json:"tunnel"` // serial tcp udp ???
 
-	Tags     []string   `json:"tags,omitempty"`
-	Pollers  []*Poller  `json:"pollers"`
-	Commands []*Command `json:"commands"`
+	Tags     []string      `json:"tags,omitempty"`
+	Modbus   ModbusOptions `json:"modbus"`
+	Pollers  []*Poller     `json:"pollers"`
+	Points   []*Point      `json:"points"`
+	Commands []*Command    `json:"commands"`
 
 	Created time.Time `json:"created" xorm:"created"`
 }
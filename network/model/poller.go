@@ -8,3 +8,17 @@ type Poller struct {
 	Address  string `json:"address"`
 	Length   int    `json:"length"`
 }
+
+// ModbusOptions Modbus采集参数
+type ModbusOptions struct {
+	Mode    string `json:"mode"`    // 传输方式：rtu=Modbus RTU over TCP，tcp=Modbus TCP
+	Station int    `json:"station"` // 从站地址
+	Timeout int    `json:"timeout"` // 响应超时时间，单位毫秒
+}
+
+// Policy 采集策略，保存在产品的采集策略中
+type Policy struct {
+	Modbus  ModbusOptions `json:"modbus"`
+	Pollers []*Poller     `json:"pollers"`
+	Points  []*Point      `json:"points"`
+}
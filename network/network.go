@@ -4,6 +4,7 @@ import (
 	"context"
 	"sagooiot/network/core"
 	"sagooiot/network/core/device"
+	"sagooiot/network/core/logic/baseLogic"
 	"sagooiot/network/core/server"
 	"sagooiot/network/core/tunnel"
 )
@@ -11,6 +12,8 @@ import (
 var reloadNetWorkFunc = []func(ctx context.Context) error{
 	// 开启主题订阅
 	core.StartSubscriber,
+	// 订阅其他实例转发的设备响应
+	baseLogic.StartReplySubscriber,
 	device.LoadDevices,
 	tunnel.LoadTunnels,
 	server.LoadServers,
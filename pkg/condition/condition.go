@@ -0,0 +1,174 @@
+// Package condition 告警规则和场景联动共用的条件表达式：上报数据转换为计算参数，条件组生成并计算 govaluate 表达式
+package condition
+
+import (
+	"fmt"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/pkg/iotModel"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Knetic/govaluate"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/text/gstr"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// ParamData 将上报数据转换为条件计算的参数，eventKey 事件标识
+func ParamData(param any) (eventKey string, data map[string]any, err error) {
+	data = make(map[string]any)
+	switch pd := param.(type) {
+	case iotModel.ReportPropertyData:
+		for k, v := range pd {
+			data[k] = value(v.Value)
+			data[k+"_time"] = v.CreateTime
+		}
+	case model.ReportPropertyData:
+		for k, v := range pd {
+			data[k] = value(v.Value)
+			data[k+"_time"] = v.CreateTime
+		}
+	case iotModel.ReportEventData:
+		for k, v := range pd.Param.Value {
+			data[k] = value(v)
+		}
+		data["CreateTime"] = pd.Param.CreateTime
+		eventKey = pd.Key
+	case model.ReportEventData:
+		for k, v := range pd.Param.Value {
+			data[k] = value(v)
+		}
+		data["CreateTime"] = pd.Param.CreateTime
+		eventKey = pd.Key
+	case iotModel.ReportStatusData:
+		data["Status"] = pd.Status
+		data["CreateTime"] = pd.CreateTime
+	case model.ReportStatusData:
+		data["Status"] = pd.Status
+		data["CreateTime"] = pd.CreateTime
+	default:
+		return "", nil, gerror.New("数据格式错误")
+	}
+	if t := gconv.Int64(data["CreateTime"]); t == 0 {
+		data["CreateTime"] = time.Now().Unix()
+	}
+	return
+}
+
+// value 数值和时间转换为可比较的数字
+func value(v any) any {
+	vv := gconv.String(v)
+	if gstr.IsNumeric(vv) {
+		return gconv.Float64(v)
+	}
+	if gt, err := gtime.StrToTime(vv); err == nil {
+		return gt.Unix()
+	}
+	return v
+}
+
+// Expression 生成条件表达式，条件组之间、条件之间按 andOr 连接
+func Expression(groups []model.AlarmCondition) string {
+	var exp string
+	for _, group := range groups {
+		var gexp string
+		for _, v := range group.Filters {
+			fexp := filterExpression(v)
+			if fexp == "" {
+				continue
+			}
+			if gexp != "" {
+				gexp += andOr(v.AndOr)
+			}
+			gexp += fexp
+		}
+		if gexp == "" {
+			continue
+		}
+		if exp != "" {
+			exp += andOr(group.AndOr)
+		}
+		exp += "(" + gexp + ")"
+	}
+	return exp
+}
+
+func andOr(v int) string {
+	if v == 2 {
+		return " || "
+	}
+	return " && "
+}
+
+// filterExpression 单个条件的表达式
+func filterExpression(v model.AlarmFilters) string {
+	if len(v.Value) == 0 || v.Key == "" {
+		return ""
+	}
+	if (v.Operator == consts.OperatorBet || v.Operator == consts.OperatorNbet) && len(v.Value) < 2 {
+		return ""
+	}
+
+	key := "[" + v.Key + "]"
+	// 条件参数是上报时间时，与上报数据的时间戳比较
+	if v.Key == "sysReportTime" {
+		key = "[CreateTime]"
+	}
+	value := make([]string, len(v.Value))
+	for i, val := range v.Value {
+		value[i] = literal(val)
+	}
+
+	switch v.Operator {
+	case consts.OperatorEq:
+		return fmt.Sprintf("(%s == %s)", key, value[0])
+	case consts.OperatorNe:
+		return fmt.Sprintf("(%s != %s)", key, value[0])
+	case consts.OperatorGt:
+		return fmt.Sprintf("(%s > %s)", key, value[0])
+	case consts.OperatorGte:
+		return fmt.Sprintf("(%s >= %s)", key, value[0])
+	case consts.OperatorLt:
+		return fmt.Sprintf("(%s < %s)", key, value[0])
+	case consts.OperatorLte:
+		return fmt.Sprintf("(%s <= %s)", key, value[0])
+	case consts.OperatorBet:
+		return fmt.Sprintf("(%s >= %s && %s <= %s)", key, value[0], key, value[1])
+	case consts.OperatorNbet:
+		return fmt.Sprintf("(%s < %s || %s > %s)", key, value[0], key, value[1])
+	}
+	return ""
+}
+
+// literal 条件值转换为表达式常量，与 value 的转换规则保持一致
+func literal(v string) string {
+	if gstr.IsNumeric(v) {
+		return v
+	}
+	if gt, err := gtime.StrToTime(v); err == nil {
+		return strconv.FormatInt(gt.Unix(), 10)
+	}
+	return "'" + strings.ReplaceAll(v, "'", "\\'") + "'"
+}
+
+// Evaluate 计算条件表达式，缺少参数时视为不满足
+func Evaluate(exp string, data map[string]any) (bool, error) {
+	gov, err := govaluate.NewEvaluableExpression(exp)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range gov.Vars() {
+		if _, ok := data[v]; !ok {
+			return false, nil
+		}
+	}
+	rs, err := gov.Evaluate(data)
+	if err != nil {
+		return false, err
+	}
+	y, ok := rs.(bool)
+	return ok && y, nil
+}
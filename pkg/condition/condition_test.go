@@ -0,0 +1,67 @@
+package condition
+
+import (
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"testing"
+)
+
+func TestExpression(t *testing.T) {
+	groups := []model.AlarmCondition{
+		{
+			Filters: []model.AlarmFilters{
+				{Key: "temp", Operator: consts.OperatorGt, Value: []string{"30"}},
+				{Key: "mode", Operator: consts.OperatorEq, Value: []string{"auto"}, AndOr: 1},
+			},
+		},
+		{
+			Filters: []model.AlarmFilters{
+				{Key: "hum", Operator: consts.OperatorNbet, Value: []string{"20", "80"}},
+			},
+			AndOr: 2,
+		},
+	}
+	exp := Expression(groups)
+	want := "(([temp] > 30) && ([mode] == 'auto')) || (([hum] < 20 || [hum] > 80))"
+	if exp != want {
+		t.Fatalf("expression got %s, want %s", exp, want)
+	}
+
+	cases := []struct {
+		data map[string]any
+		want bool
+	}{
+		{map[string]any{"temp": 31.0, "mode": "auto", "hum": 50.0}, true},
+		{map[string]any{"temp": 31.0, "mode": "manual", "hum": 50.0}, false},
+		{map[string]any{"temp": 10.0, "mode": "manual", "hum": 90.0}, true},
+		{map[string]any{"temp": 31.0, "mode": "auto"}, false},
+	}
+	for _, c := range cases {
+		ok, err := Evaluate(exp, c.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != c.want {
+			t.Fatalf("evaluate %v got %v, want %v", c.data, ok, c.want)
+		}
+	}
+}
+
+func TestParamData(t *testing.T) {
+	eventKey, data, err := ParamData(model.ReportEventData{
+		Key:   "overheat",
+		Param: model.ReportEventParam{Value: map[string]any{"temp": "42.5"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eventKey != "overheat" || data["temp"] != 42.5 {
+		t.Fatalf("param data got %s %v", eventKey, data)
+	}
+	if data["CreateTime"] == int64(0) {
+		t.Fatal("create time should default to now")
+	}
+	if _, _, err = ParamData("invalid"); err == nil {
+		t.Fatal("invalid param should fail")
+	}
+}
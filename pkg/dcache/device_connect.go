@@ -0,0 +1,83 @@
+package dcache
+
+import (
+	"context"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/pkg/cache"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// 保持长连接的设备，在线状态由连接建立、心跳和断开驱动，不依赖上报超时。
+// 连接期间同样记录离线截止时间并在收到报文时刷新，平台重启或连接丢失未触发断开时由离线检测处理下线
+var connected sync.Map
+
+// connection 长连接设备的离线超时和最近一次刷新截止时间的时间
+type connection struct {
+	timeout time.Duration
+	renewed atomic.Int64
+}
+
+func isConnected(deviceKey string) bool {
+	_, ok := connected.Load(deviceKey)
+	return ok
+}
+
+// Connect 设备建立连接，设置为在线，keepAlive 为连接的保持时间，为0时使用设备的超时时间
+func Connect(ctx context.Context, device *model.DeviceOutput, keepAlive time.Duration) {
+	timeout := keepAlive * 3 / 2
+	if timeout <= 0 {
+		timeout = time.Duration(onlineTimeout(ctx, device)) * time.Second
+	}
+	conn := &connection{timeout: timeout}
+	connected.Store(device.Key, conn)
+	wasOnline := GetDeviceStatus(ctx, device.Key) == consts.DeviceStatueOnline
+	conn.renew(ctx, device.Key)
+	if !wasOnline {
+		if err := online(ctx, device); err != nil {
+			g.Log().Debug(ctx, device.Key, "设备上线处理失败")
+		}
+	}
+}
+
+// Active 收到长连接设备的报文，刷新在线缓存和离线截止时间
+func Active(ctx context.Context, device *model.DeviceOutput) {
+	v, ok := connected.Load(device.Key)
+	if !ok {
+		return
+	}
+	conn := v.(*connection)
+	// 每个超时周期最多刷新三次，避免每个报文都写缓存
+	if time.Now().UnixMilli()-conn.renewed.Load() < conn.timeout.Milliseconds()/3 {
+		return
+	}
+	conn.renew(ctx, device.Key)
+}
+
+// renew 刷新在线缓存和离线截止时间
+func (c *connection) renew(ctx context.Context, deviceKey string) {
+	now := time.Now()
+	c.renewed.Store(now.UnixMilli())
+	setOnlineStatus(ctx, deviceKey, c.timeout+offlineGrace)
+	if err := getOfflineDeadlines(ctx).set(ctx, deviceKey, now.Add(c.timeout).UnixMilli()); err != nil {
+		g.Log().Debug(ctx, deviceKey, "设置设备离线截止时间失败")
+	}
+}
+
+// Disconnect 设备断开连接，设置为离线
+func Disconnect(ctx context.Context, device *model.DeviceOutput) {
+	connected.Delete(device.Key)
+	if _, err := cache.Instance().Remove(ctx, consts.DeviceStatusPrefix+device.Key); err != nil {
+		g.Log().Debug(ctx, device.Key, "删除设备在线缓存失败")
+	}
+	if err := getOfflineDeadlines(ctx).remove(ctx, device.Key); err != nil {
+		g.Log().Debug(ctx, device.Key, "删除设备离线截止时间失败")
+	}
+	if err := offline(ctx, device); err != nil {
+		g.Log().Debug(ctx, device.Key, "设备下线处理失败")
+	}
+}
@@ -0,0 +1,300 @@
+package dcache
+
+import (
+	"container/heap"
+	"context"
+	"sagooiot/internal/consts"
+	"sagooiot/pkg/cache"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/grpool"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// 设备离线检测
+// 设备上线和上报数据时记录离线截止时间，由单个扫描协程定时取出已到期的设备做离线处理。
+// 缓存使用redis时截止时间保存在有序集合中，多个平台实例通过选主保证同一时间只有一个实例扫描。
+
+const (
+	offlineScanInterval = time.Second     // 离线扫描间隔
+	offlineScanBatch    = 500             // 每次取出的到期设备数量
+	offlineGrace        = 5 * time.Second // 设备状态缓存比离线截止时间多保留的时间，由扫描协程处理离线
+	offlineLeaderTTL    = 10              // 扫描主节点的租约时间，单位秒
+	offlineWorkers      = 50              // 并发处理离线的协程数
+)
+
+const (
+	// offlineDueScript 原子地取出并删除已到期的设备，避免多个实例重复处理
+	offlineDueScript = `local keys = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+if #keys > 0 then redis.call('ZREM', KEYS[1], unpack(keys)) end
+return keys`
+	// offlineRenewScript 主节点续约
+	offlineRenewScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then return redis.call('EXPIRE', KEYS[1], ARGV[2]) end
+return 0`
+	// offlineReleaseScript 主节点释放
+	offlineReleaseScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then return redis.call('DEL', KEYS[1]) end
+return 0`
+)
+
+// offlineDeadlines 设备离线截止时间，单位毫秒
+type offlineDeadlines interface {
+	set(ctx context.Context, deviceKey string, deadline int64) error
+	exists(ctx context.Context, deviceKey string) (bool, error)
+	due(ctx context.Context, now int64, limit int) ([]string, error)
+	remove(ctx context.Context, deviceKey string) error
+}
+
+var (
+	deadlines     offlineDeadlines
+	deadlinesOnce sync.Once
+	isRedisCache  bool
+)
+
+// getOfflineDeadlines 根据缓存驱动选择截止时间的存储方式
+func getOfflineDeadlines(ctx context.Context) offlineDeadlines {
+	deadlinesOnce.Do(func() {
+		isRedisCache = g.Cfg().MustGet(ctx, "cache.adapter").String() == "redis"
+		if isRedisCache {
+			deadlines = &redisDeadlines{key: consts.DeviceOfflineDeadlineKey}
+		} else {
+			deadlines = newLocalDeadlines()
+		}
+	})
+	return deadlines
+}
+
+// StartOfflineCheck 启动设备离线检测，返回停止函数
+func StartOfflineCheck(ctx context.Context) (stop func(context.Context) error) {
+	ctx, cancel := context.WithCancel(gctx.NeverDone(ctx))
+	c := &offlineChecker{
+		deadlines: getOfflineDeadlines(ctx),
+		leader:    !isRedisCache,
+		token:     guid.S(),
+		pool:      grpool.New(offlineWorkers),
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.run(ctx)
+	}()
+	return func(ctx context.Context) error {
+		cancel()
+		<-done
+		return c.release(ctx)
+	}
+}
+
+type offlineChecker struct {
+	deadlines offlineDeadlines
+	leader    bool
+	token     string
+	pool      *grpool.Pool
+}
+
+func (c *offlineChecker) run(ctx context.Context) {
+	ticker := time.NewTicker(offlineScanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !c.elect(ctx) {
+			continue
+		}
+		c.scan(ctx)
+	}
+}
+
+// elect 竞选或续约扫描主节点，不使用redis时只有一个实例，始终为主节点
+func (c *offlineChecker) elect(ctx context.Context) bool {
+	if !isRedisCache {
+		return true
+	}
+	if c.leader {
+		v, err := g.Redis().Do(ctx, "EVAL", offlineRenewScript, 1, consts.DeviceOfflineLeaderKey, c.token, offlineLeaderTTL)
+		c.leader = err == nil && v.Int() == 1
+		if c.leader {
+			return true
+		}
+	}
+	v, err := g.Redis().Do(ctx, "SET", consts.DeviceOfflineLeaderKey, c.token, "NX", "EX", offlineLeaderTTL)
+	c.leader = err == nil && v.String() == "OK"
+	if c.leader {
+		g.Log().Infof(ctx, "设备离线检测主节点: %s", c.token)
+	}
+	return c.leader
+}
+
+func (c *offlineChecker) release(ctx context.Context) (err error) {
+	if !isRedisCache || !c.leader {
+		return
+	}
+	c.leader = false
+	_, err = g.Redis().Do(ctx, "EVAL", offlineReleaseScript, 1, consts.DeviceOfflineLeaderKey, c.token)
+	return
+}
+
+// scan 取出所有已到期的设备做离线处理
+func (c *offlineChecker) scan(ctx context.Context) {
+	now := time.Now().UnixMilli()
+	for {
+		keys, err := c.deadlines.due(ctx, now, offlineScanBatch)
+		if err != nil {
+			g.Log().Errorf(ctx, "设备离线扫描失败: %s", err.Error())
+			return
+		}
+		var wg sync.WaitGroup
+		for _, key := range keys {
+			deviceKey := key
+			wg.Add(1)
+			if err = c.pool.Add(ctx, func(ctx context.Context) {
+				defer wg.Done()
+				c.offline(ctx, deviceKey)
+			}); err != nil {
+				wg.Done()
+			}
+		}
+		wg.Wait()
+		if len(keys) < offlineScanBatch {
+			return
+		}
+	}
+}
+
+func (c *offlineChecker) offline(ctx context.Context, deviceKey string) {
+	// 取出后设备又上报了数据，已记录了新的截止时间
+	if ok, err := c.deadlines.exists(ctx, deviceKey); err != nil || ok {
+		return
+	}
+	if _, err := cache.Instance().Remove(ctx, consts.DeviceStatusPrefix+deviceKey); err != nil {
+		g.Log().Debug(ctx, deviceKey, "删除设备在线缓存失败")
+	}
+	device, err := GetDeviceDetailInfo(deviceKey)
+	if err != nil || device == nil {
+		pushDeviceStatus(deviceKey, 1)
+		return
+	}
+	if err = offline(ctx, device); err != nil {
+		g.Log().Debug(ctx, deviceKey, "设备下线处理失败")
+	}
+}
+
+// redisDeadlines 截止时间保存在redis有序集合中，多个实例共享
+type redisDeadlines struct {
+	key string
+}
+
+func (r *redisDeadlines) set(ctx context.Context, deviceKey string, deadline int64) (err error) {
+	_, err = g.Redis().Do(ctx, "ZADD", r.key, deadline, deviceKey)
+	return
+}
+
+func (r *redisDeadlines) exists(ctx context.Context, deviceKey string) (bool, error) {
+	v, err := g.Redis().Do(ctx, "ZSCORE", r.key, deviceKey)
+	if err != nil {
+		return false, err
+	}
+	return !v.IsNil(), nil
+}
+
+func (r *redisDeadlines) due(ctx context.Context, now int64, limit int) ([]string, error) {
+	v, err := g.Redis().Do(ctx, "EVAL", offlineDueScript, 1, r.key, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return v.Strings(), nil
+}
+
+func (r *redisDeadlines) remove(ctx context.Context, deviceKey string) (err error) {
+	_, err = g.Redis().Do(ctx, "ZREM", r.key, deviceKey)
+	return
+}
+
+// localDeadlines 截止时间保存在本地最小堆中，用于单实例的内存或文件缓存
+type localDeadlines struct {
+	mu    sync.Mutex
+	items map[string]*deadlineItem
+	queue deadlineHeap
+}
+
+func newLocalDeadlines() *localDeadlines {
+	return &localDeadlines{items: make(map[string]*deadlineItem)}
+}
+
+func (l *localDeadlines) set(_ context.Context, deviceKey string, deadline int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if item, ok := l.items[deviceKey]; ok {
+		item.deadline = deadline
+		heap.Fix(&l.queue, item.index)
+		return nil
+	}
+	item := &deadlineItem{key: deviceKey, deadline: deadline}
+	l.items[deviceKey] = item
+	heap.Push(&l.queue, item)
+	return nil
+}
+
+func (l *localDeadlines) exists(_ context.Context, deviceKey string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.items[deviceKey]
+	return ok, nil
+}
+
+func (l *localDeadlines) due(_ context.Context, now int64, limit int) (keys []string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for len(l.queue) > 0 && len(keys) < limit && l.queue[0].deadline <= now {
+		item := heap.Pop(&l.queue).(*deadlineItem)
+		delete(l.items, item.key)
+		keys = append(keys, item.key)
+	}
+	return
+}
+
+func (l *localDeadlines) remove(_ context.Context, deviceKey string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if item, ok := l.items[deviceKey]; ok {
+		heap.Remove(&l.queue, item.index)
+		delete(l.items, deviceKey)
+	}
+	return nil
+}
+
+type deadlineItem struct {
+	key      string
+	deadline int64
+	index    int
+}
+
+type deadlineHeap []*deadlineItem
+
+func (h deadlineHeap) Len() int           { return len(h) }
+func (h deadlineHeap) Less(i, j int) bool { return h[i].deadline < h[j].deadline }
+func (h deadlineHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *deadlineHeap) Push(x any) {
+	item := x.(*deadlineItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *deadlineHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,49 @@
+package dcache
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLocalDeadlines(t *testing.T) {
+	ctx := context.Background()
+	l := newLocalDeadlines()
+	_ = l.set(ctx, "d1", 300)
+	_ = l.set(ctx, "d2", 100)
+	_ = l.set(ctx, "d3", 200)
+	// 设备再次上报，截止时间后延
+	_ = l.set(ctx, "d2", 400)
+
+	keys, _ := l.due(ctx, 250, 10)
+	if len(keys) != 1 || keys[0] != "d3" {
+		t.Fatalf("due got %v", keys)
+	}
+	if ok, _ := l.exists(ctx, "d3"); ok {
+		t.Fatal("due device should be removed")
+	}
+	keys, _ = l.due(ctx, 500, 1)
+	if len(keys) != 1 || keys[0] != "d1" {
+		t.Fatalf("due got %v", keys)
+	}
+	keys, _ = l.due(ctx, 500, 10)
+	if len(keys) != 1 || keys[0] != "d2" {
+		t.Fatalf("due got %v", keys)
+	}
+	if ok, _ := l.exists(ctx, "d2"); ok {
+		t.Fatal("due device should be removed")
+	}
+}
+
+func TestLocalDeadlinesRemove(t *testing.T) {
+	ctx := context.Background()
+	l := newLocalDeadlines()
+	_ = l.set(ctx, "d1", 100)
+	_ = l.set(ctx, "d2", 200)
+	// 设备断开连接时已做离线处理，不再等待超时
+	_ = l.remove(ctx, "d1")
+	_ = l.remove(ctx, "d3")
+	keys, _ := l.due(ctx, 300, 10)
+	if len(keys) != 1 || keys[0] != "d2" {
+		t.Fatalf("due got %v", keys)
+	}
+}
@@ -1 +1,31 @@
 package dcache
+
+import (
+	"context"
+	"github.com/gogf/gf/v2/util/gconv"
+	"sagooiot/internal/consts"
+	"sagooiot/internal/model"
+	"sagooiot/pkg/cache"
+)
+
+// GetDeviceScene 基于产品key获取设备触发的场景
+func GetDeviceScene(ctx context.Context, productKey string) (out []model.SceneOutput) {
+	data, err := cache.Instance().Get(ctx, consts.DeviceScenePrefix+productKey)
+	if err != nil || data.Val() == nil {
+		return
+	}
+	if err = gconv.Scan(data, &out); err != nil {
+		return
+	}
+	return
+}
+
+// SetDeviceScene 基于产品key设置设备触发的场景，没有场景时清除缓存
+func SetDeviceScene(ctx context.Context, productKey string, data []model.SceneOutput) (err error) {
+	if len(data) == 0 {
+		_, err = cache.Instance().Remove(ctx, consts.DeviceScenePrefix+productKey)
+		return
+	}
+	err = cache.Instance().Set(ctx, consts.DeviceScenePrefix+productKey, data, 0)
+	return
+}
@@ -11,6 +11,7 @@ import (
 	"sagooiot/internal/model/entity"
 	"sagooiot/internal/queues"
 	"sagooiot/internal/service"
+	"sagooiot/internal/webscoket/realtime"
 	"sagooiot/pkg/cache"
 	"sagooiot/pkg/iotModel"
 	"time"
@@ -18,58 +19,67 @@ import (
 
 // UpdateStatus 更新设备状态
 func UpdateStatus(ctx context.Context, device *model.DeviceOutput) {
-	timeout := 0
-	if device.OnlineTimeout > 0 {
-		timeout = device.OnlineTimeout
+	// 通过内置MQTT服务连接的设备，在线状态由连接、心跳和断开决定
+	if isConnected(device.Key) {
+		return
 	}
-	if timeout == 0 {
-		//设备超时时间
-		defaultTimeout, err := service.ConfigData().GetConfigByKey(ctx, consts.DeviceDefaultTimeoutTime)
-		if err != nil || defaultTimeout == nil {
-			defaultTimeout = &entity.SysConfig{
-				ConfigValue: "30",
-			}
+	timeout := onlineTimeout(ctx, device)
+	deviceStatus := GetDeviceStatus(ctx, device.Key)
+	deadlines := getOfflineDeadlines(ctx)
+	if deviceStatus != consts.DeviceStatueOnline {
+		// 在线缓存已过期但还未做离线处理，按在线处理，避免重复记录上线
+		if pending, _ := deadlines.exists(ctx, device.Key); pending {
+			deviceStatus = consts.DeviceStatueOnline
 		}
-		timeout = gconv.Int(defaultTimeout.ConfigValue)
 	}
-	deviceStatus := GetDeviceStatus(ctx, device.Key)
 
+	//设备在线缓存比离线截止时间多保留一段时间，由离线检测处理下线
+	expire := time.Duration(timeout)*time.Second + offlineGrace
 	if deviceStatus == consts.DeviceStatueOnline {
 		//正常数据上报，更新设备在线的缓存时间
-		_, err := cache.Instance().UpdateExpire(ctx, consts.DeviceStatusPrefix+device.Key, time.Duration(timeout+1)*time.Second)
-		if err != nil {
-			g.Log().Debug(ctx, device.Key, "更新设备在线的缓存时间失败")
+		oldExpire, err := cache.Instance().UpdateExpire(ctx, consts.DeviceStatusPrefix+device.Key, expire)
+		if err != nil || oldExpire < 0 {
+			setOnlineStatus(ctx, device.Key, expire)
 		}
-
 	} else {
-		var deviceStatusLog = new(iotModel.DeviceStatusLog)
-		deviceStatusLog.Status = 2
-		deviceStatusLog.Timestamp = time.Now()
-		deviceStatusLog.DeviceKey = device.Key
 		//设备首次上线，设置设备在线的缓存时间
-		if err := cache.Instance().Set(ctx, consts.DeviceStatusPrefix+device.Key, deviceStatusLog, time.Duration(timeout)*time.Second); err != nil {
-			g.Log().Debug(ctx, device.Key, "设置设备在线的缓存时间失败")
-		}
-
-		//添加延时下线消息判断
-		go func(deviceKey string, timeOutSecond time.Duration) {
+		setOnlineStatus(ctx, device.Key, expire)
+		go func() {
 			if err := online(ctx, device); err != nil {
 				g.Log().Debug(ctx, device.Key, "设备上线处理失败")
 			}
-			for {
-				ds := GetDeviceStatus(ctx, deviceKey)
-				if ds == consts.DeviceStatueOffline { //离线
-					break
-				}
-				time.Sleep(timeOutSecond * time.Second)
-			}
+		}()
+	}
 
-			//设备下线
-			err := offline(ctx, device)
-			if err != nil {
-				return
-			}
-		}(device.Key, time.Duration(timeout)*time.Second)
+	//记录设备离线截止时间
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second).UnixMilli()
+	if err := deadlines.set(ctx, device.Key, deadline); err != nil {
+		g.Log().Debug(ctx, device.Key, "设置设备离线截止时间失败")
+	}
+}
+
+// onlineTimeout 设备超时时间，单位秒，设备未设置时使用系统默认值
+func onlineTimeout(ctx context.Context, device *model.DeviceOutput) int {
+	if device.OnlineTimeout > 0 {
+		return device.OnlineTimeout
+	}
+	defaultTimeout, err := service.ConfigData().GetConfigByKey(ctx, consts.DeviceDefaultTimeoutTime)
+	if err != nil || defaultTimeout == nil {
+		defaultTimeout = &entity.SysConfig{
+			ConfigValue: "30",
+		}
+	}
+	return gconv.Int(defaultTimeout.ConfigValue)
+}
+
+// setOnlineStatus 设置设备在线缓存
+func setOnlineStatus(ctx context.Context, deviceKey string, expire time.Duration) {
+	var deviceStatusLog = new(iotModel.DeviceStatusLog)
+	deviceStatusLog.Status = 2
+	deviceStatusLog.Timestamp = time.Now()
+	deviceStatusLog.DeviceKey = deviceKey
+	if err := cache.Instance().Set(ctx, consts.DeviceStatusPrefix+deviceKey, deviceStatusLog, expire); err != nil {
+		g.Log().Debug(ctx, deviceKey, "设置设备在线的缓存时间失败")
 	}
 }
 
@@ -94,6 +104,7 @@ func online(ctx context.Context, device *model.DeviceOutput) (err error) {
 		Desc:      "",
 	})
 	pushDeviceStatus(device.Key, 2)
+	realtime.Publish(ctx, realtime.TypeStatus, device.ProductKey, device.Key, g.Map{"status": "online"})
 
 	//告警处理
 	go func() {
@@ -108,6 +119,13 @@ func online(ctx context.Context, device *model.DeviceOutput) (err error) {
 				g.Log().Errorf(ctx, "告警检测失败: %s", err.Error())
 			}
 		}
+		if sceneErr := service.Scene().Check(ctx, device.Product.Key, device.Key, consts.SceneTriggerOnLine, data); sceneErr != nil {
+			g.Log().Errorf(ctx, "场景检测失败: %s", sceneErr.Error())
+		}
+		// 下发设备离线期间设置的期望属性
+		if shadowErr := service.DevDeviceShadow().Sync(ctx, device.Key); shadowErr != nil {
+			g.Log().Errorf(ctx, "设备影子下发失败: %s", shadowErr.Error())
+		}
 	}()
 
 	return
@@ -120,6 +138,7 @@ func offline(ctx context.Context, device *model.DeviceOutput) (err error) {
 		Desc:      "",
 	})
 	pushDeviceStatus(device.Key, 1)
+	realtime.Publish(ctx, realtime.TypeStatus, device.ProductKey, device.Key, g.Map{"status": "offline"})
 
 	// 离线告警提醒
 	data := iotModel.ReportStatusData{
@@ -128,6 +147,9 @@ func offline(ctx context.Context, device *model.DeviceOutput) (err error) {
 	}
 
 	err = service.AlarmRule().Check(ctx, device.ProductKey, device.Key, consts.AlarmTriggerTypeOffline, data)
+	if sceneErr := service.Scene().Check(ctx, device.ProductKey, device.Key, consts.SceneTriggerOffLine, data); sceneErr != nil {
+		g.Log().Errorf(ctx, "场景检测失败: %s", sceneErr.Error())
+	}
 
 	return
 }
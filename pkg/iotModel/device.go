@@ -126,6 +126,16 @@ type (
 	}
 )
 
+// 平台下发配置
+type (
+	ConfigSendMessage struct {
+		ConfigId  string                 `json:"configId"`  //string类型，配置ID
+		Version   int                    `json:"version"`   //int类型，配置版本号
+		Content   map[string]interface{} `json:"content"`   //map类型，配置内容
+		Timestamp int64                  `json:"timestamp"` //int64类型，时间戳，单位为毫秒
+	}
+)
+
 // 平台接收到配置设置响应
 type (
 	ConfigSetReplyMessage struct {
@@ -144,6 +154,16 @@ type (
 	}
 )
 
+// 场景触发自定义事件
+type (
+	SceneEventMessage struct {
+		SceneId   uint64                 `json:"sceneId"`   //uint64类型，场景ID
+		EventKey  string                 `json:"eventKey"`  //string类型，事件标识
+		Data      map[string]interface{} `json:"data"`      //map类型，事件数据
+		Timestamp int64                  `json:"timestamp"` //int64类型，时间戳，单位为毫秒
+	}
+)
+
 // DeviceStatusLog 设备状态动态变化日志
 type DeviceStatusLog struct {
 	DeviceKey string
@@ -0,0 +1,211 @@
+package north
+
+import (
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/frame/g"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// queueSize 待投递消息队列长度
+	queueSize = 4096
+	// workerNum 投递协程数
+	workerNum = 8
+)
+
+var errQueueFull = errors.New("north message queue is full")
+
+// Sink 北向消息出口
+type Sink interface {
+	// Send 投递一条消息，返回错误时按重试策略重试
+	Send(ctx context.Context, topic string, payload []byte) error
+}
+
+// Retry 重试策略，Interval 为首次重试间隔，之后每次翻倍
+type Retry struct {
+	Times    int
+	Interval time.Duration
+}
+
+// Route 消息出口及其匹配条件，ProductKey、Topics 为空时匹配全部
+type Route struct {
+	Id         int
+	ProductKey string
+	Topics     []string
+	Retry      Retry
+	Sink       Sink
+}
+
+func (r Route) match(topic, productKey string) bool {
+	if r.ProductKey != "" && r.ProductKey != productKey {
+		return false
+	}
+	if len(r.Topics) == 0 {
+		return true
+	}
+	for _, t := range r.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// DeadLetter 重试后仍投递失败的消息
+type DeadLetter struct {
+	RouteId    int
+	Topic      string
+	ProductKey string
+	DeviceKey  string
+	Payload    []byte
+	Retries    int
+	Err        error
+}
+
+// DeadLetterHandler 死信处理，由业务层保存死信
+type DeadLetterHandler func(ctx context.Context, letter DeadLetter)
+
+var (
+	routesLock sync.RWMutex
+	routes     []Route
+
+	deadLetterHandler DeadLetterHandler
+)
+
+// SetRoutes 替换全部消息出口
+func SetRoutes(list []Route) {
+	routesLock.Lock()
+	defer routesLock.Unlock()
+	routes = list
+}
+
+// SetDeadLetterHandler 设置死信处理
+func SetDeadLetterHandler(handler DeadLetterHandler) {
+	routesLock.Lock()
+	defer routesLock.Unlock()
+	deadLetterHandler = handler
+}
+
+func matchRoutes(topic, productKey string) (list []Route) {
+	routesLock.RLock()
+	defer routesLock.RUnlock()
+	for _, r := range routes {
+		if r.Sink != nil && r.match(topic, productKey) {
+			list = append(list, r)
+		}
+	}
+	return
+}
+
+func handleDeadLetter(ctx context.Context, letter DeadLetter) {
+	g.Log().Errorf(ctx, "north-message dead letter, route:%d, topic:%s, retries:%d, error:%v", letter.RouteId, letter.Topic, letter.Retries, letter.Err)
+	routesLock.RLock()
+	handler := deadLetterHandler
+	routesLock.RUnlock()
+	if handler != nil {
+		handler(ctx, letter)
+	}
+}
+
+type delivery struct {
+	route   Route
+	message Message
+	payload []byte
+	retries int
+}
+
+// bus 有界队列加固定数量的投递协程，避免上报高峰时阻塞业务。
+// 投递失败时由定时器在重试间隔后重新入队，投递协程不等待重试间隔
+type bus struct {
+	once    sync.Once
+	queue   chan delivery
+	waiting atomic.Int32 // 等待重试的消息数量，最多 queueSize 条
+}
+
+var defaultBus = &bus{}
+
+func (b *bus) dispatch(ctx context.Context, d delivery) {
+	b.once.Do(func() {
+		b.queue = make(chan delivery, queueSize)
+		for i := 0; i < workerNum; i++ {
+			go b.work()
+		}
+	})
+	b.enqueue(ctx, d)
+}
+
+func (b *bus) enqueue(ctx context.Context, d delivery) {
+	select {
+	case b.queue <- d:
+	default:
+		handleDeadLetter(ctx, d.deadLetter(d.retries, errQueueFull))
+	}
+}
+
+func (b *bus) work() {
+	for d := range b.queue {
+		ctx := context.Background()
+		err := d.route.Sink.Send(ctx, d.message.Topic, d.payload)
+		if err == nil {
+			continue
+		}
+		if d.retries >= d.route.Retry.Times {
+			handleDeadLetter(ctx, d.deadLetter(d.retries, err))
+			continue
+		}
+		if b.waiting.Add(1) > queueSize {
+			b.waiting.Add(-1)
+			handleDeadLetter(ctx, d.deadLetter(d.retries, errQueueFull))
+			continue
+		}
+		interval := d.route.Retry.Interval << d.retries
+		d.retries++
+		time.AfterFunc(interval, func() {
+			b.waiting.Add(-1)
+			b.enqueue(ctx, d)
+		})
+	}
+}
+
+func (d delivery) deadLetter(retries int, err error) DeadLetter {
+	return DeadLetter{
+		RouteId:    d.route.Id,
+		Topic:      d.message.Topic,
+		ProductKey: d.message.ProductKey,
+		DeviceKey:  d.message.DeviceKey,
+		Payload:    d.payload,
+		Retries:    retries,
+		Err:        err,
+	}
+}
+
+// Deliver 按重试策略同步投递，最终失败或 ctx 结束时调用 onFail
+func Deliver(ctx context.Context, route Route, topic string, payload []byte, onFail func(retries int, err error)) {
+	interval := route.Retry.Interval
+	var err error
+	for i := 0; ; i++ {
+		if err = route.Sink.Send(ctx, topic, payload); err == nil {
+			return
+		}
+		if i >= route.Retry.Times {
+			if onFail != nil {
+				onFail(i, err)
+			}
+			return
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if onFail != nil {
+				onFail(i, err)
+			}
+			return
+		case <-timer.C:
+		}
+		interval *= 2
+	}
+}
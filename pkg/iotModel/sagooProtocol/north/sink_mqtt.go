@@ -0,0 +1,15 @@
+package north
+
+import (
+	"context"
+	"sagooiot/internal/mqtt"
+)
+
+// MqttSink 发布到系统mqtt的主题，主题为前缀加北向消息主题
+type MqttSink struct {
+	TopicPrefix string
+}
+
+func (s *MqttSink) Send(ctx context.Context, topic string, payload []byte) error {
+	return mqtt.Publish(s.TopicPrefix+topic, payload)
+}
@@ -0,0 +1,139 @@
+package north
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeSink struct {
+	fails atomic.Int32
+	calls atomic.Int32
+}
+
+func (s *fakeSink) Send(ctx context.Context, topic string, payload []byte) error {
+	s.calls.Add(1)
+	if s.fails.Add(-1) >= 0 {
+		return errors.New("send failed")
+	}
+	return nil
+}
+
+func TestRouteMatch(t *testing.T) {
+	r := Route{ProductKey: "p1", Topics: []string{DeviceOnlineMessageTopic}}
+	if !r.match(DeviceOnlineMessageTopic, "p1") {
+		t.Fatalf("route should match")
+	}
+	if r.match(DeviceOfflineMessageTopic, "p1") || r.match(DeviceOnlineMessageTopic, "p2") {
+		t.Fatalf("route should not match")
+	}
+	if !(Route{}).match(PropertyReportMessageTopic, "p2") {
+		t.Fatalf("empty route should match all")
+	}
+}
+
+func TestDeliverRetry(t *testing.T) {
+	sink := &fakeSink{}
+	sink.fails.Store(2)
+	route := Route{Retry: Retry{Times: 2, Interval: time.Millisecond}, Sink: sink}
+	failed := false
+	Deliver(context.Background(), route, DeviceOnlineMessageTopic, []byte("{}"), func(int, error) { failed = true })
+	if failed || sink.calls.Load() != 3 {
+		t.Fatalf("deliver got failed=%v calls=%d, want success after 3 calls", failed, sink.calls.Load())
+	}
+
+	sink = &fakeSink{}
+	sink.fails.Store(10)
+	route.Sink = sink
+	var retries int
+	Deliver(context.Background(), route, DeviceOnlineMessageTopic, []byte("{}"), func(n int, err error) { retries = n })
+	if retries != 2 || sink.calls.Load() != 3 {
+		t.Fatalf("deliver got retries=%d calls=%d, want 2 and 3", retries, sink.calls.Load())
+	}
+}
+
+func TestBusRetry(t *testing.T) {
+	b := &bus{}
+	// 等待重试的消息不占用投递协程
+	slow := &fakeSink{}
+	slow.fails.Store(100)
+	for i := 0; i < workerNum*2; i++ {
+		b.dispatch(context.Background(), delivery{route: Route{Retry: Retry{Times: 1, Interval: time.Hour}, Sink: slow}})
+	}
+	fast := &fakeSink{}
+	b.dispatch(context.Background(), delivery{route: Route{Sink: fast}})
+	deadline := time.Now().Add(time.Second)
+	for fast.calls.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("delivery blocked by pending retries")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	letters := make(chan DeadLetter, 1)
+	SetDeadLetterHandler(func(ctx context.Context, letter DeadLetter) { letters <- letter })
+	defer SetDeadLetterHandler(nil)
+	sink := &fakeSink{}
+	sink.fails.Store(10)
+	b.dispatch(context.Background(), delivery{route: Route{Retry: Retry{Times: 2, Interval: time.Millisecond}, Sink: sink}})
+	select {
+	case letter := <-letters:
+		if letter.Retries != 2 || sink.calls.Load() != 3 {
+			t.Fatalf("dead letter got retries=%d calls=%d, want 2 and 3", letter.Retries, sink.calls.Load())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("dead letter not received")
+	}
+}
+
+func TestWriteMessageDeadLetter(t *testing.T) {
+	sink := &fakeSink{}
+	sink.fails.Store(10)
+	letters := make(chan DeadLetter, 1)
+	SetRoutes([]Route{{Id: 7, Topics: []string{DeviceAddMessageTopic}, Sink: sink}})
+	SetDeadLetterHandler(func(ctx context.Context, letter DeadLetter) { letters <- letter })
+	defer SetRoutes(nil)
+	defer SetDeadLetterHandler(nil)
+
+	WriteMessage(context.Background(), DeviceAddMessageTopic, nil, "p1", "d1", map[string]string{"k": "v"})
+	select {
+	case letter := <-letters:
+		if letter.RouteId != 7 || letter.DeviceKey != "d1" || letter.Topic != DeviceAddMessageTopic {
+			t.Fatalf("dead letter got %+v", letter)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("dead letter not received")
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(HeaderTopic) != PropertyReportMessageTopic || r.Header.Get("X-Custom") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get(HeaderSignature) != Sign("secret", r.Header.Get(HeaderTimestamp), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer svr.Close()
+
+	sink := NewWebhookSink(svr.URL, "secret", map[string]string{"X-Custom": "1"}, time.Second)
+	if err := sink.Send(context.Background(), PropertyReportMessageTopic, []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	status.Store(http.StatusInternalServerError)
+	if err := sink.Send(context.Background(), PropertyReportMessageTopic, []byte(`{"a":1}`)); err == nil {
+		t.Fatalf("send should fail on status 500")
+	}
+}
@@ -0,0 +1,81 @@
+package north
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderTopic     = "X-Sagoo-Topic"
+	HeaderTimestamp = "X-Sagoo-Timestamp"
+	HeaderSignature = "X-Sagoo-Signature"
+
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+// WebhookSink 以POST方式推送到http地址，配置了密钥时对请求签名
+type WebhookSink struct {
+	Url     string
+	Secret  string
+	Headers map[string]string
+	Timeout time.Duration
+
+	client *http.Client
+}
+
+func NewWebhookSink(url, secret string, headers map[string]string, timeout time.Duration) *WebhookSink {
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &WebhookSink{
+		Url:     url,
+		Secret:  secret,
+		Headers: headers,
+		Timeout: timeout,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Sign 签名为 hex(HMAC-SHA256(secret, timestamp + "." + body))
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *WebhookSink) Send(ctx context.Context, topic string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTopic, topic)
+	if s.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(s.Secret, timestamp, payload))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook response status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -27,4 +27,6 @@ const (
 	ConfigSendMessageReplyTopic = "/message/tsl/receive/config/reply"
 	// 平台获取配置
 	ConfigGetMessageTopic = "/message/tsl/receive/config/get"
+	// 场景触发自定义事件
+	SceneEventMessageTopic = "/message/scene/event"
 )
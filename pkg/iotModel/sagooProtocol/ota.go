@@ -0,0 +1,42 @@
+package sagooProtocol
+
+type (
+	// 设备请求升级包信息
+	FirmwareGetRequest struct {
+		Id     string `json:"id"`
+		Params struct {
+			Module string `json:"module"`
+		} `json:"params"`
+	}
+	FirmwareGetReply struct {
+		Id      string                     `json:"id"`
+		Code    int                        `json:"code"`
+		Data    *UpgradeCommandRequestData `json:"data,omitempty"`
+		Message string                     `json:"message"`
+	}
+)
+
+type (
+	// 设备分片下载文件
+	FileDownloadRequest struct {
+		Id     string `json:"id"`
+		Params struct {
+			FirmwareId uint64 `json:"firmwareId"`
+			Offset     int64  `json:"offset"`
+			Size       int64  `json:"size"`
+		} `json:"params"`
+	}
+	FileDownloadReply struct {
+		Id      string           `json:"id"`
+		Code    int              `json:"code"`
+		Data    FileDownloadData `json:"data"`
+		Message string           `json:"message"`
+	}
+	FileDownloadData struct {
+		FirmwareId uint64 `json:"firmwareId"`
+		FileLength int64  `json:"fileLength"`
+		Offset     int64  `json:"offset"`
+		Size       int64  `json:"size"`
+		Content    string `json:"content"` // base64编码的分片内容
+	}
+)
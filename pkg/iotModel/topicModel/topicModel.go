@@ -13,4 +13,5 @@ type TopicHandlerData struct {
 type TopicDownHandlerData struct {
 	PayLoad      []byte
 	DeviceDetail *model.DeviceOutput
+	Timeout      int // 同步请求等待设备响应的超时时间，单位秒，为0时使用默认值
 }
@@ -1,24 +1,203 @@
 package jsinterpreter
 
 import (
+	"errors"
 	"fmt"
-	"github.com/robertkrimen/otto"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/robertkrimen/otto"
 )
 
-// RunScript 运行js脚本
-func RunScript(jsonStr string, jsCode string) (string, error) {
+const (
+	// FuncParse 上行数据解析函数，把设备数据转为默认的消息协议格式
+	FuncParse = "parse"
+	// FuncEncode 下行数据编码函数，把平台下发的数据转为设备的格式
+	FuncEncode = "encode"
+
+	// DefaultTimeout 单次执行的超时时间
+	DefaultTimeout = 3 * time.Second
+	// maxInputSize 传入脚本的数据的最大长度
+	maxInputSize = 1 << 20
+	// maxOutputSize 脚本返回结果的最大长度
+	maxOutputSize = 1 << 20
+	// maxLogs 调试时最多记录的日志条数
+	maxLogs = 100
+)
+
+var (
+	ErrTimeout        = errors.New("script execution timeout")
+	ErrInputTooLarge  = errors.New("script input too large")
+	ErrOutputTooLarge = errors.New("script output too large")
+)
+
+// Script 编译后的脚本，每次执行复制一份只执行过顶层代码的虚拟机，上一次执行修改的全局变量不会影响下一次执行。
+// otto 没有限制单个虚拟机内存的方法，只能限制传入数据和返回结果的长度，脚本中持续分配内存的循环由超时中断
+type Script struct {
+	program *otto.Script
+	timeout time.Duration
+	funcs   map[string]bool
+
+	lock     sync.Mutex // 复制时遍历原始虚拟机的对象，不能并发复制
+	pristine *otto.Otto
+}
+
+// Compile 编译脚本并执行一次顶层代码，记录脚本中定义的函数
+func Compile(source string) (*Script, error) {
+	program, err := otto.New().Compile("", source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile JavaScript code: %v", err)
+	}
+	s := &Script{program: program, timeout: DefaultTimeout, funcs: make(map[string]bool)}
+	vm, err := s.newVM(&logger{})
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range []string{FuncParse, FuncEncode} {
+		if v, getErr := vm.Get(name); getErr == nil && v.IsFunction() {
+			s.funcs[name] = true
+		}
+	}
+	s.pristine = vm
+	return s, nil
+}
+
+// HasFunc 脚本中是否定义了函数
+func (s *Script) HasFunc(name string) bool {
+	return s.funcs[name]
+}
+
+// Call 调用脚本中的函数，返回结果和 console 输出的日志
+func (s *Script) Call(name string, input string) (out string, logs []string, err error) {
+	l := &logger{}
+	if len(input) > maxInputSize {
+		return "", nil, ErrInputTooLarge
+	}
+	vm := s.get(l)
+	var value otto.Value
+	if err = s.guard(vm, func() (callErr error) {
+		value, callErr = vm.Call(name, nil, input)
+		return
+	}); err != nil {
+		return "", l.logs, fmt.Errorf("failed to call %s: %w", name, err)
+	}
+
+	if value.IsString() {
+		out = value.String()
+	} else {
+		data, marshalErr := value.MarshalJSON()
+		if marshalErr != nil {
+			return "", l.logs, fmt.Errorf("failed to MarshalJSON: %v", marshalErr)
+		}
+		out = string(data)
+	}
+	if len(out) > maxOutputSize {
+		return "", l.logs, ErrOutputTooLarge
+	}
+	return out, l.logs, nil
+}
+
+// guard 在超时时间内执行，超时后通过 Interrupt 中断虚拟机
+func (s *Script) guard(vm *otto.Otto, f func() error) (err error) {
+	// 每次执行使用新的中断通道，避免已经触发的中断影响下一次执行
+	interrupt := make(chan func(), 1)
+	vm.Interrupt = interrupt
+	timer := time.AfterFunc(s.timeout, func() {
+		interrupt <- func() {
+			panic(ErrTimeout)
+		}
+	})
+	defer func() {
+		timer.Stop()
+		if r := recover(); r != nil {
+			if r == ErrTimeout {
+				err = ErrTimeout
+				return
+			}
+			err = fmt.Errorf("script panic: %v", r)
+		}
+	}()
+	return f()
+}
+
+func (s *Script) newVM(l *logger) (*otto.Otto, error) {
 	vm := otto.New()
-	if _, err := vm.Run(jsCode); err != nil {
-		return "", fmt.Errorf("failed to run JavaScript code: %v", err)
+	l.bind(vm)
+	if err := s.guard(vm, func() error {
+		_, runErr := vm.Run(s.program)
+		return runErr
+	}); err != nil {
+		return nil, fmt.Errorf("failed to run JavaScript code: %w", err)
 	}
-	value, err := vm.Call("parse", nil, jsonStr)
+	return vm, nil
+}
+
+// get 复制一份干净的虚拟机，绑定本次执行的 console
+func (s *Script) get(l *logger) *otto.Otto {
+	s.lock.Lock()
+	vm := s.pristine.Copy()
+	s.lock.Unlock()
+	l.bind(vm)
+	return vm
+}
+
+// logger 收集 console 输出
+type logger struct {
+	logs []string
+}
+
+func (l *logger) bind(vm *otto.Otto) {
+	console, _ := vm.Object(`({})`)
+	for _, level := range []string{"log", "info", "warn", "error", "debug"} {
+		level := level
+		_ = console.Set(level, func(call otto.FunctionCall) otto.Value {
+			if len(l.logs) < maxLogs {
+				args := make([]string, len(call.ArgumentList))
+				for i, arg := range call.ArgumentList {
+					args[i] = arg.String()
+				}
+				l.logs = append(l.logs, fmt.Sprintf("[%s] %s", level, strings.Join(args, " ")))
+			}
+			return otto.UndefinedValue()
+		})
+	}
+	_ = vm.Set("console", console)
+}
+
+// RunScript 运行js脚本
+func RunScript(jsonStr string, jsCode string) (string, error) {
+	script, err := Compile(jsCode)
 	if err != nil {
-		return "", fmt.Errorf("failed to call: %v", err)
+		return "", err
+	}
+	out, _, err := script.Call(FuncParse, jsonStr)
+	return out, err
+}
+
+// DebugResult 脚本调试结果
+type DebugResult struct {
+	Output   string   `json:"output"`
+	Logs     []string `json:"logs"`
+	Error    string   `json:"error"`
+	Duration int64    `json:"duration"` // 执行耗时，单位毫秒
+}
+
+// Debug 使用样例数据执行脚本中的函数，返回结果和 console 输出的日志
+func Debug(source, name, input string) *DebugResult {
+	start := time.Now()
+	res := &DebugResult{}
+	script, err := Compile(source)
+	if err == nil {
+		if !script.HasFunc(name) {
+			err = fmt.Errorf("function %s is not defined", name)
+		} else {
+			res.Output, res.Logs, err = script.Call(name, input)
+		}
 	}
-	data, err := value.MarshalJSON()
 	if err != nil {
-		return "", fmt.Errorf("failed to MarshalJSON: %v", err)
+		res.Error = err.Error()
 	}
-	return strings.TrimPrefix(strings.TrimSuffix(string(data), "\""), "\""), nil
+	res.Duration = time.Since(start).Milliseconds()
+	return res
 }
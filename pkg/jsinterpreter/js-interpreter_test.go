@@ -0,0 +1,117 @@
+package jsinterpreter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testScript = `
+function parse(payload) {
+	var data = JSON.parse(payload);
+	console.log("temp", data.t);
+	return {id: data.id, params: {temperature: data.t / 10}};
+}
+function encode(payload) {
+	var req = JSON.parse(payload);
+	return "SET:" + req.params.switch;
+}`
+
+func TestScriptParseEncode(t *testing.T) {
+	script, err := Compile(testScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, logs, err := script.Call(FuncParse, `{"id":"1","t":253}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != `{"id":"1","params":{"temperature":25.3}}` {
+		t.Fatalf("parse got %s", out)
+	}
+	if len(logs) != 1 || logs[0] != "[log] temp 253" {
+		t.Fatalf("logs got %v", logs)
+	}
+	// 返回字符串时原样输出
+	out, _, err = script.Call(FuncEncode, `{"params":{"switch":1}}`)
+	if err != nil || out != "SET:1" {
+		t.Fatalf("encode got %s %v", out, err)
+	}
+}
+
+func TestScriptIsolation(t *testing.T) {
+	script, err := Compile(`var count = 0;
+function parse(payload) {
+	count++;
+	leaked = typeof leaked === "undefined" ? 1 : leaked + 1;
+	return count + "," + leaked;
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每次执行都从脚本的初始状态开始
+	for i := 0; i < 3; i++ {
+		if out, _, err := script.Call(FuncParse, "{}"); err != nil || out != "1,1" {
+			t.Fatalf("call %d got %s %v", i, out, err)
+		}
+	}
+	if _, _, err = script.Call(FuncParse, strings.Repeat("x", maxInputSize+1)); !errors.Is(err, ErrInputTooLarge) {
+		t.Fatalf("call got err %v, want input too large", err)
+	}
+}
+
+func TestScriptTimeout(t *testing.T) {
+	script, err := Compile(`function parse(payload) { while (true) {} }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script.timeout = 50 * time.Millisecond
+	start := time.Now()
+	if _, _, err = script.Call(FuncParse, "{}"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("call got err %v, want timeout", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("interrupt took too long")
+	}
+	if _, err = Compile(`while (true) {}`); err == nil {
+		t.Fatal("top level infinite loop should time out")
+	}
+}
+
+func TestScriptCache(t *testing.T) {
+	s1, err := GetScript("p1", testScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2, _ := GetScript("p1", testScript); s2 != s1 {
+		t.Fatal("script should be cached")
+	}
+	// 脚本内容变化时重新编译
+	if s3, _ := GetScript("p1", `function parse(p) { return p; }`); s3 == s1 {
+		t.Fatal("script should be recompiled")
+	}
+	Invalidate("p1")
+	if s4, _ := GetScript("p1", testScript); s4 == s1 {
+		t.Fatal("script should be recompiled after invalidate")
+	}
+
+	// 没有定义 encode 时原样返回
+	out, err := Encode("p2", `function parse(p) { return p; }`, []byte("raw"))
+	if err != nil || string(out) != "raw" {
+		t.Fatalf("encode got %s %v", out, err)
+	}
+}
+
+func TestDebug(t *testing.T) {
+	res := Debug(testScript, FuncParse, `{"id":"1","t":100}`)
+	if res.Error != "" || res.Output != `{"id":"1","params":{"temperature":10}}` || len(res.Logs) != 1 {
+		t.Fatalf("debug got %+v", res)
+	}
+	if res = Debug(`function parse(p) { return p; }`, FuncEncode, "x"); res.Error == "" {
+		t.Fatal("undefined function should fail")
+	}
+	if res = Debug(`function parse(p) {`, FuncParse, "x"); res.Error == "" {
+		t.Fatal("syntax error should fail")
+	}
+}
@@ -0,0 +1,62 @@
+package jsinterpreter
+
+import (
+	"sync"
+)
+
+// 产品脚本缓存，按产品标识缓存编译后的脚本，脚本内容变化时重新编译
+var scripts sync.Map
+
+type cachedScript struct {
+	source string
+	script *Script
+}
+
+// GetScript 获取产品编译后的脚本
+func GetScript(productKey, source string) (*Script, error) {
+	if v, ok := scripts.Load(productKey); ok {
+		if c := v.(*cachedScript); c.source == source {
+			return c.script, nil
+		}
+	}
+	script, err := Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	scripts.Store(productKey, &cachedScript{source: source, script: script})
+	return script, nil
+}
+
+// Invalidate 删除产品缓存的脚本，脚本更新后调用
+func Invalidate(productKey string) {
+	scripts.Delete(productKey)
+}
+
+// Parse 使用产品脚本的 parse 函数解析上行数据，没有定义时原样返回
+func Parse(productKey, source, input string) (string, error) {
+	script, err := GetScript(productKey, source)
+	if err != nil {
+		return "", err
+	}
+	if !script.HasFunc(FuncParse) {
+		return input, nil
+	}
+	out, _, err := script.Call(FuncParse, input)
+	return out, err
+}
+
+// Encode 使用产品脚本的 encode 函数编码下行数据，没有定义时原样返回
+func Encode(productKey, source string, input []byte) ([]byte, error) {
+	script, err := GetScript(productKey, source)
+	if err != nil {
+		return nil, err
+	}
+	if !script.HasFunc(FuncEncode) {
+		return input, nil
+	}
+	out, _, err := script.Call(FuncEncode, string(input))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
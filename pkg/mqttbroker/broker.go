@@ -0,0 +1,647 @@
+package mqttbroker
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 连接返回码，MQTT3.1.1 和 MQTT5 的返回码不同
+const (
+	codeUnacceptableVersion = 0x01
+	codeBadCredentials      = 0x04
+	codeNotAuthorized       = 0x05
+	code5UnsupportedVersion = 0x84
+	code5BadCredentials     = 0x86
+	code5NotAuthorized      = 0x87
+	codeSubscribeFailure    = 0x80
+	codeNoSubscription      = 0x11
+	codeDisconnectWithWill  = 0x04
+)
+
+var ErrBrokerClosed = errors.New("broker closed")
+
+// Hooks 连接认证、主题权限和上下线事件
+type Hooks interface {
+	// Authenticate 校验客户端连接，返回错误时拒绝连接，可以通过 Client.Value 保存身份信息
+	Authenticate(ctx context.Context, c *Client, username string, password []byte) error
+	// CanPublish 客户端是否可以向主题发布消息
+	CanPublish(c *Client, topic string) bool
+	// CanSubscribe 客户端是否可以订阅主题过滤器
+	CanSubscribe(c *Client, filter string) bool
+	// OnConnect 客户端连接成功
+	OnConnect(ctx context.Context, c *Client)
+	// OnDisconnect 客户端断开连接，被同一客户端标识的新连接接管时不会触发
+	OnDisconnect(ctx context.Context, c *Client)
+	// OnActive 收到客户端的报文，包括心跳
+	OnActive(ctx context.Context, c *Client)
+}
+
+type Options struct {
+	MaxPacketSize  int           // 报文的最大长度
+	MaxQueued      int           // 离线或在途消息达到上限时，会话最多缓存的消息数量
+	MaxInflight    int           // 会话最多等待确认的 QoS1 消息数量，MQTT5 客户端的接收最大值更小时以客户端为准
+	MaxOutbound    int           // 客户端待发送报文队列的长度，队列已满时断开连接
+	ConnectTimeout time.Duration // 建立连接后等待CONNECT报文的时间
+	Hooks          Hooks
+}
+
+// Client 已连接的客户端
+type Client struct {
+	Id         string
+	Username   string
+	Version    byte
+	RemoteAddr net.Addr
+	PeerCerts  []*x509.Certificate
+	KeepAlive  time.Duration // 保持连接的时间，超过1.5倍未收到报文时断开连接，为0时不检测
+	Value      interface{}
+
+	conn      net.Conn
+	writeLock sync.Mutex
+	takenOver atomic.Bool
+	session   *session
+	out       chan []byte   // 投递给客户端的报文，由 writeLoop 发送
+	done      chan struct{} // 连接结束后关闭
+}
+
+func (c *Client) write(packet []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+// send 报文加入发送队列，不等待网络写入，避免处理过慢的客户端阻塞发布者；队列已满时断开连接
+func (c *Client) send(packet []byte) {
+	select {
+	case <-c.done:
+	case c.out <- packet:
+	default:
+		_ = c.conn.Close()
+	}
+}
+
+// writeLoop 依次发送队列中的报文，直到连接结束
+func (c *Client) writeLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case packet := <-c.out:
+			if err := c.write(packet); err != nil {
+				_ = c.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// session 客户端会话，持久会话在客户端断开后保留订阅和未确认的消息
+type session struct {
+	id     string
+	clean  bool
+	expiry time.Duration
+	timer  *time.Timer
+
+	lock        sync.Mutex
+	client      *Client
+	subs        map[string]subscription
+	inflight    map[uint16]*Message // 已发送等待确认的 QoS1 消息
+	maxInflight int                 // 在途消息上限
+	queue       []*Message          // 离线期间或在途消息达到上限时缓存的 QoS1 消息
+	nextId      uint16
+	received    map[uint16]struct{} // 收到但未完成的 QoS2 消息
+}
+
+func newSession(id string, clean bool, expiry time.Duration) *session {
+	return &session{
+		id:       id,
+		clean:    clean,
+		expiry:   expiry,
+		subs:     make(map[string]subscription),
+		inflight: make(map[uint16]*Message),
+		received: make(map[uint16]struct{}),
+	}
+}
+
+// packetId 分配未被在途消息占用的报文标识，在途消息不超过上限，总能找到可用的标识
+func (s *session) packetId() uint16 {
+	for i := 0; i < 1<<16; i++ {
+		s.nextId++
+		if s.nextId == 0 {
+			continue
+		}
+		if _, ok := s.inflight[s.nextId]; !ok {
+			return s.nextId
+		}
+	}
+	return 0
+}
+
+// flush 在途消息未达到上限时，依次发出缓存的消息，返回待发送的报文，调用方持有 s.lock
+func (s *session) flush(c *Client) (packets [][]byte) {
+	for len(s.queue) > 0 && len(s.inflight) < s.maxInflight {
+		m := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		id := s.packetId()
+		s.inflight[id] = m
+		packets = append(packets, encodePublish(c.Version, m, 1, id, false))
+	}
+	return
+}
+
+// Broker MQTT 服务端
+type Broker struct {
+	opts Options
+
+	lock      sync.RWMutex
+	sessions  map[string]*session
+	tree      *topicTree
+	retained  map[string]*Message
+	listeners []net.Listener
+	clientSeq atomic.Uint64
+	closed    atomic.Bool
+}
+
+func New(opts Options) *Broker {
+	if opts.MaxPacketSize <= 0 {
+		opts.MaxPacketSize = 1 << 20
+	}
+	if opts.MaxQueued <= 0 {
+		opts.MaxQueued = 1000
+	}
+	if opts.MaxInflight <= 0 {
+		opts.MaxInflight = 100
+	}
+	// 报文标识只有 65535 个
+	opts.MaxInflight = min(opts.MaxInflight, 65535)
+	if opts.MaxOutbound <= 0 {
+		opts.MaxOutbound = 1000
+	}
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = 10 * time.Second
+	}
+	return &Broker{
+		opts:     opts,
+		sessions: make(map[string]*session),
+		tree:     newTopicTree(),
+		retained: make(map[string]*Message),
+	}
+}
+
+// Serve 接收监听器上的连接，直到监听器关闭
+func (b *Broker) Serve(ctx context.Context, l net.Listener) error {
+	b.lock.Lock()
+	b.listeners = append(b.listeners, l)
+	b.lock.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if b.closed.Load() {
+				return ErrBrokerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		go b.handle(ctx, conn)
+	}
+}
+
+// Close 关闭监听器和所有客户端连接
+func (b *Broker) Close() error {
+	b.closed.Store(true)
+	b.lock.Lock()
+	listeners := b.listeners
+	b.listeners = nil
+	var clients []*Client
+	for _, s := range b.sessions {
+		s.lock.Lock()
+		if s.client != nil {
+			clients = append(clients, s.client)
+		}
+		s.lock.Unlock()
+	}
+	b.lock.Unlock()
+	for _, l := range listeners {
+		_ = l.Close()
+	}
+	for _, c := range clients {
+		_ = c.conn.Close()
+	}
+	return nil
+}
+
+// Publish 服务端发布消息
+func (b *Broker) Publish(m *Message) {
+	b.route(m, "")
+}
+
+func (b *Broker) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	_ = conn.SetReadDeadline(time.Now().Add(b.opts.ConnectTimeout))
+	typ, flags, body, err := readPacket(r, b.opts.MaxPacketSize)
+	if err != nil || typ != packetConnect {
+		return
+	}
+	cp, err := decodeConnect(body)
+	if err != nil {
+		return
+	}
+	if cp.ProtocolLevel != version31 && cp.ProtocolLevel != version311 && cp.ProtocolLevel != version5 {
+		_, _ = conn.Write(encodeConnack(version311, false, codeUnacceptableVersion, ""))
+		return
+	}
+
+	c := &Client{
+		Id:         cp.ClientId,
+		Username:   cp.Username,
+		Version:    cp.ProtocolLevel,
+		RemoteAddr: conn.RemoteAddr(),
+		KeepAlive:  time.Duration(cp.KeepAlive) * time.Second,
+		conn:       conn,
+		out:        make(chan []byte, b.opts.MaxOutbound),
+		done:       make(chan struct{}),
+	}
+	defer close(c.done)
+	if tc, ok := conn.(*tls.Conn); ok {
+		if err = tc.HandshakeContext(ctx); err != nil {
+			return
+		}
+		c.PeerCerts = tc.ConnectionState().PeerCertificates
+	}
+	var assignedId string
+	if c.Id == "" {
+		if !cp.CleanSession && c.Version != version5 {
+			_ = c.write(encodeConnack(c.Version, false, 0x02, ""))
+			return
+		}
+		c.Id = fmt.Sprintf("auto-%d-%d", time.Now().UnixNano(), b.clientSeq.Add(1))
+		assignedId = c.Id
+	}
+	if b.opts.Hooks != nil {
+		if err = b.opts.Hooks.Authenticate(ctx, c, cp.Username, cp.Password); err != nil {
+			code := byte(codeBadCredentials)
+			if c.Version == version5 {
+				code = code5BadCredentials
+			}
+			_ = c.write(encodeConnack(c.Version, false, code, ""))
+			return
+		}
+	}
+
+	s, present := b.attach(c, cp)
+	if err = c.write(encodeConnack(c.Version, present, 0, assignedId)); err != nil {
+		b.detach(ctx, c, nil)
+		return
+	}
+	go c.writeLoop()
+	if b.opts.Hooks != nil {
+		b.opts.Hooks.OnConnect(ctx, c)
+	}
+	b.resume(s, c)
+
+	will := cp.Will
+	keepAlive := c.KeepAlive * 3 / 2
+	for {
+		if keepAlive > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(keepAlive))
+		} else {
+			_ = conn.SetReadDeadline(time.Time{})
+		}
+		typ, flags, body, err = readPacket(r, b.opts.MaxPacketSize)
+		if err != nil {
+			break
+		}
+		if b.opts.Hooks != nil {
+			b.opts.Hooks.OnActive(ctx, c)
+		}
+		if typ == packetDisconnect {
+			// MQTT5 可以要求断开时发送遗嘱消息
+			if c.Version != version5 || len(body) == 0 || body[0] != codeDisconnectWithWill {
+				will = nil
+			}
+			break
+		}
+		if err = b.process(c, s, typ, flags, body); err != nil {
+			break
+		}
+	}
+	b.detach(ctx, c, will)
+}
+
+// attach 建立或恢复会话，已有同一客户端标识的连接时断开旧连接
+func (b *Broker) attach(c *Client, cp *connectPacket) (s *session, present bool) {
+	clean := cp.CleanSession
+	var expiry time.Duration
+	if c.Version == version5 {
+		// MQTT5 会话过期时间为0时，断开即结束会话
+		expiry = time.Duration(cp.SessionExpiry) * time.Second
+		clean = cp.SessionExpiry == 0
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	old := b.sessions[c.Id]
+	if old != nil {
+		old.lock.Lock()
+		if old.timer != nil {
+			old.timer.Stop()
+			old.timer = nil
+		}
+		if old.client != nil {
+			old.client.takenOver.Store(true)
+			_ = old.client.conn.Close()
+			old.client = nil
+		}
+		old.lock.Unlock()
+	}
+	if old != nil && !cp.CleanSession && !old.clean {
+		s, present = old, true
+		s.clean, s.expiry = clean, expiry
+	} else {
+		if old != nil {
+			b.removeSubs(old)
+		}
+		s = newSession(c.Id, clean, expiry)
+		b.sessions[c.Id] = s
+	}
+	s.lock.Lock()
+	s.client = c
+	s.maxInflight = b.opts.MaxInflight
+	if cp.ReceiveMaximum > 0 {
+		s.maxInflight = min(s.maxInflight, int(cp.ReceiveMaximum))
+	}
+	s.lock.Unlock()
+	c.session = s
+	return
+}
+
+// resume 重发未确认的消息和离线期间缓存的消息
+func (b *Broker) resume(s *session, c *Client) {
+	s.lock.Lock()
+	var packets [][]byte
+	for id, m := range s.inflight {
+		packets = append(packets, encodePublish(c.Version, m, 1, id, true))
+	}
+	packets = append(packets, s.flush(c)...)
+	s.lock.Unlock()
+	for _, packet := range packets {
+		c.send(packet)
+	}
+}
+
+// detach 连接断开，非持久会话删除订阅
+func (b *Broker) detach(ctx context.Context, c *Client, will *Message) {
+	s := c.session
+	if s == nil {
+		return
+	}
+	if will != nil && !c.takenOver.Load() && validTopic(will.Topic) &&
+		(b.opts.Hooks == nil || b.opts.Hooks.CanPublish(c, will.Topic)) {
+		b.route(will, c.Id)
+	}
+
+	b.lock.Lock()
+	s.lock.Lock()
+	current := s.client == c
+	if current {
+		s.client = nil
+	}
+	s.lock.Unlock()
+	if current && b.sessions[s.id] == s {
+		if s.clean {
+			b.removeSubs(s)
+			delete(b.sessions, s.id)
+		} else if s.expiry > 0 {
+			s.timer = time.AfterFunc(s.expiry, func() { b.expire(s) })
+		}
+	}
+	b.lock.Unlock()
+
+	if !c.takenOver.Load() && b.opts.Hooks != nil {
+		b.opts.Hooks.OnDisconnect(ctx, c)
+	}
+}
+
+func (b *Broker) expire(s *session) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	s.lock.Lock()
+	online := s.client != nil
+	s.lock.Unlock()
+	if !online && b.sessions[s.id] == s {
+		b.removeSubs(s)
+		delete(b.sessions, s.id)
+	}
+}
+
+// removeSubs 删除会话的全部订阅，调用方持有 b.lock
+func (b *Broker) removeSubs(s *session) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for filter := range s.subs {
+		b.tree.unsubscribe(s.id, filter)
+	}
+	s.subs = make(map[string]subscription)
+}
+
+func (b *Broker) process(c *Client, s *session, typ, flags byte, body []byte) error {
+	switch typ {
+	case packetPublish:
+		p, err := decodePublish(c.Version, flags, body)
+		if err != nil || !validTopic(p.Topic) {
+			return errMalformed
+		}
+		allowed := b.opts.Hooks == nil || b.opts.Hooks.CanPublish(c, p.Topic)
+		switch p.Qos {
+		case 0:
+			if allowed {
+				b.route(&p.Message, c.Id)
+			}
+		case 1:
+			if allowed {
+				b.route(&p.Message, c.Id)
+			}
+			return c.write(encodeAck(c.Version, packetPuback, p.PacketId, notAuthorized(c, allowed)))
+		case 2:
+			s.lock.Lock()
+			_, dup := s.received[p.PacketId]
+			if allowed {
+				s.received[p.PacketId] = struct{}{}
+			}
+			s.lock.Unlock()
+			if allowed && !dup {
+				b.route(&p.Message, c.Id)
+			}
+			return c.write(encodeAck(c.Version, packetPubrec, p.PacketId, notAuthorized(c, allowed)))
+		}
+	case packetPuback:
+		d := &decoder{b: body}
+		id := d.uint16()
+		s.lock.Lock()
+		delete(s.inflight, id)
+		packets := s.flush(c)
+		s.lock.Unlock()
+		for _, packet := range packets {
+			c.send(packet)
+		}
+	case packetPubrel:
+		d := &decoder{b: body}
+		id := d.uint16()
+		s.lock.Lock()
+		delete(s.received, id)
+		s.lock.Unlock()
+		return c.write(encodeAck(c.Version, packetPubcomp, id, 0))
+	case packetPubrec, packetPubcomp:
+		// 服务端下发的消息最高为 QoS1，不会收到这两种报文
+	case packetSubscribe:
+		id, subs, err := decodeSubscribe(c.Version, body)
+		if err != nil {
+			return err
+		}
+		codes := make([]byte, len(subs))
+		var granted []subscription
+		for i, sub := range subs {
+			if !validFilter(sub.Filter) || (b.opts.Hooks != nil && !b.opts.Hooks.CanSubscribe(c, sub.Filter)) {
+				codes[i] = codeSubscribeFailure
+				if c.Version == version5 {
+					codes[i] = code5NotAuthorized
+				}
+				continue
+			}
+			// 服务端最高支持 QoS1 下发
+			if sub.Qos > 1 {
+				sub.Qos = 1
+			}
+			codes[i] = sub.Qos
+			granted = append(granted, sub)
+		}
+		b.lock.Lock()
+		s.lock.Lock()
+		for _, sub := range granted {
+			s.subs[sub.Filter] = sub
+			b.tree.subscribe(s.id, sub)
+		}
+		s.lock.Unlock()
+		b.lock.Unlock()
+		if err = c.write(encodeSubAck(c.Version, packetSuback, id, codes)); err != nil {
+			return err
+		}
+		b.sendRetained(c, s, granted)
+	case packetUnsubscribe:
+		id, filters, err := decodeUnsubscribe(c.Version, body)
+		if err != nil {
+			return err
+		}
+		codes := make([]byte, len(filters))
+		b.lock.Lock()
+		s.lock.Lock()
+		for i, filter := range filters {
+			delete(s.subs, filter)
+			if !b.tree.unsubscribe(s.id, filter) {
+				codes[i] = codeNoSubscription
+			}
+		}
+		s.lock.Unlock()
+		b.lock.Unlock()
+		return c.write(encodeSubAck(c.Version, packetUnsuback, id, codes))
+	case packetPingreq:
+		return c.write(encodePacket(packetPingresp, 0, nil))
+	default:
+		return errMalformed
+	}
+	return nil
+}
+
+func notAuthorized(c *Client, allowed bool) byte {
+	if !allowed && c.Version == version5 {
+		return code5NotAuthorized
+	}
+	return 0
+}
+
+// route 把消息发送给匹配的订阅，保存保留消息
+func (b *Broker) route(m *Message, from string) {
+	msg := &Message{Topic: m.Topic, Payload: append([]byte(nil), m.Payload...), Qos: m.Qos}
+	b.lock.Lock()
+	if m.Retain {
+		if len(m.Payload) == 0 {
+			delete(b.retained, m.Topic)
+		} else {
+			b.retained[m.Topic] = &Message{Topic: m.Topic, Payload: msg.Payload, Qos: m.Qos, Retain: true}
+		}
+	}
+	matched := b.tree.match(m.Topic)
+	targets := make(map[*session]subscription, len(matched))
+	for id, sub := range matched {
+		if sub.NoLocal && id == from {
+			continue
+		}
+		if s, ok := b.sessions[id]; ok {
+			targets[s] = sub
+		}
+	}
+	b.lock.Unlock()
+
+	for s, sub := range targets {
+		b.deliver(s, msg, min(msg.Qos, sub.Qos))
+	}
+}
+
+func (b *Broker) sendRetained(c *Client, s *session, subs []subscription) {
+	if len(subs) == 0 {
+		return
+	}
+	b.lock.RLock()
+	var messages []*Message
+	var qos []byte
+	for _, m := range b.retained {
+		for _, sub := range subs {
+			if MatchTopic(sub.Filter, m.Topic) {
+				messages = append(messages, m)
+				qos = append(qos, min(m.Qos, sub.Qos))
+				break
+			}
+		}
+	}
+	b.lock.RUnlock()
+	for i, m := range messages {
+		b.deliver(s, m, qos[i])
+	}
+}
+
+// deliver 向会话发送消息，客户端离线或在途消息达到上限时缓存 QoS1 消息，缓存已满时丢弃
+func (b *Broker) deliver(s *session, m *Message, qos byte) {
+	s.lock.Lock()
+	c := s.client
+	if qos > 0 && (c == nil || len(s.inflight) >= s.maxInflight) {
+		if (c != nil || !s.clean) && len(s.queue) < b.opts.MaxQueued {
+			s.queue = append(s.queue, m)
+		}
+		s.lock.Unlock()
+		return
+	}
+	var id uint16
+	if qos > 0 {
+		id = s.packetId()
+		s.inflight[id] = m
+	}
+	s.lock.Unlock()
+	if c != nil {
+		c.send(encodePublish(c.Version, m, qos, id, false))
+	}
+}
@@ -0,0 +1,243 @@
+package mqttbroker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+)
+
+// testHooks 客户端标识即为设备标识，密码为 secret，只能访问 /sys/{clientId}/ 下的主题
+type testHooks struct {
+	connected    chan string
+	disconnected chan string
+}
+
+func (h *testHooks) Authenticate(_ context.Context, c *Client, _ string, password []byte) error {
+	if string(password) != "secret" {
+		return errors.New("bad password")
+	}
+	c.Value = c.Id
+	return nil
+}
+
+func (h *testHooks) allowed(c *Client, topic string) bool {
+	return strings.HasPrefix(topic, "/sys/"+c.Value.(string)+"/")
+}
+
+func (h *testHooks) CanPublish(c *Client, topic string) bool    { return h.allowed(c, topic) }
+func (h *testHooks) CanSubscribe(c *Client, filter string) bool { return h.allowed(c, filter) }
+func (h *testHooks) OnConnect(_ context.Context, c *Client)     { h.connected <- c.Id }
+func (h *testHooks) OnDisconnect(_ context.Context, c *Client)  { h.disconnected <- c.Id }
+func (h *testHooks) OnActive(context.Context, *Client)          {}
+
+func startBroker(t *testing.T) (addr string, h *testHooks) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = &testHooks{connected: make(chan string, 10), disconnected: make(chan string, 10)}
+	b := New(Options{Hooks: h})
+	go func() { _ = b.Serve(context.Background(), l) }()
+	t.Cleanup(func() { _ = b.Close() })
+	return "tcp://" + l.Addr().String(), h
+}
+
+func connect(t *testing.T, addr, id, password string) (MQTT.Client, error) {
+	opts := MQTT.NewClientOptions().AddBroker(addr).SetClientID(id).SetPassword(password).
+		SetUsername(id).SetAutoReconnect(false).SetConnectRetry(false)
+	c := MQTT.NewClient(opts)
+	token := c.Connect()
+	if !token.WaitTimeout(3 * time.Second) {
+		t.Fatal("connect timeout")
+	}
+	return c, token.Error()
+}
+
+func wait(t *testing.T, ch chan string, want string) {
+	select {
+	case got := <-ch:
+		if got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("timeout waiting for %s", want)
+	}
+}
+
+func TestBrokerAuth(t *testing.T) {
+	addr, h := startBroker(t)
+	if _, err := connect(t, addr, "d1", "wrong"); err == nil {
+		t.Fatal("connect with wrong password should fail")
+	}
+	c, err := connect(t, addr, "d1", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(t, h.connected, "d1")
+	c.Disconnect(100)
+	wait(t, h.disconnected, "d1")
+}
+
+func TestBrokerPublishSubscribe(t *testing.T) {
+	addr, _ := startBroker(t)
+	sub, err := connect(t, addr, "d1", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Disconnect(100)
+	received := make(chan string, 10)
+	token := sub.Subscribe("/sys/d1/+/property/#", 1, func(_ MQTT.Client, m MQTT.Message) {
+		received <- m.Topic() + " " + string(m.Payload())
+	})
+	if token.Wait(); token.Error() != nil {
+		t.Fatal(token.Error())
+	}
+
+	pub, err := connect(t, addr, "d1-pub", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pub.Disconnect(100)
+	// 没有权限的主题不会投递给订阅者
+	pub.Publish("/sys/d1/x/property/post", 1, false, "denied").Wait()
+	pub.Publish("/sys/d1-pub/x/property/post", 1, false, "other").Wait()
+	// 发布者向订阅者自己的主题发布
+	if token = sub.Publish("/sys/d1/x/property/post", 1, false, "hello"); token.Wait() && token.Error() != nil {
+		t.Fatal(token.Error())
+	}
+	wait(t, received, "/sys/d1/x/property/post hello")
+	select {
+	case got := <-received:
+		t.Fatalf("unexpected message %s", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestBrokerSubscribeDenied(t *testing.T) {
+	addr, _ := startBroker(t)
+	c, err := connect(t, addr, "d1", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Disconnect(100)
+	token := c.Subscribe("/sys/d2/#", 1, nil)
+	token.Wait()
+	st := token.(*MQTT.SubscribeToken)
+	if code := st.Result()["/sys/d2/#"]; code != codeSubscribeFailure {
+		t.Fatalf("subscribe result %d, want %d", code, codeSubscribeFailure)
+	}
+}
+
+func TestBrokerTakeover(t *testing.T) {
+	addr, h := startBroker(t)
+	first, err := connect(t, addr, "d1", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(t, h.connected, "d1")
+	second, err := connect(t, addr, "d1", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(t, h.connected, "d1")
+	// 被新连接接管的旧连接不触发断开事件
+	select {
+	case id := <-h.disconnected:
+		t.Fatalf("unexpected disconnect %s", id)
+	case <-time.After(200 * time.Millisecond):
+	}
+	first.Disconnect(0)
+	second.Disconnect(100)
+	wait(t, h.disconnected, "d1")
+}
+
+// testSession 未启动 writeLoop 的会话，发送的报文留在客户端队列中
+func testSession(b *Broker, outbound int) (*session, *Client, net.Conn) {
+	conn, peer := net.Pipe()
+	c := &Client{Id: "d1", Version: version311, conn: conn, out: make(chan []byte, outbound), done: make(chan struct{})}
+	s := newSession("d1", true, 0)
+	s.client, s.maxInflight = c, b.opts.MaxInflight
+	c.session = s
+	return s, c, peer
+}
+
+func TestBrokerInflightWindow(t *testing.T) {
+	b := New(Options{MaxInflight: 2, MaxQueued: 1})
+	s, c, _ := testSession(b, 10)
+	for i := 0; i < 4; i++ {
+		b.deliver(s, &Message{Topic: "/sys/d1/x", Payload: []byte{byte(i)}}, 1)
+	}
+	// 在途消息达到上限后缓存，缓存已满时丢弃
+	if len(c.out) != 2 || len(s.inflight) != 2 || len(s.queue) != 1 {
+		t.Fatalf("got %d sent, %d inflight, %d queued", len(c.out), len(s.inflight), len(s.queue))
+	}
+	// QoS0 消息不占用在途窗口
+	b.deliver(s, &Message{Topic: "/sys/d1/x"}, 0)
+	if len(c.out) != 3 {
+		t.Fatalf("got %d sent", len(c.out))
+	}
+
+	// 确认后发出缓存的消息
+	var id uint16
+	for id = range s.inflight {
+		break
+	}
+	if err := b.process(c, s, packetPuback, 0, []byte{byte(id >> 8), byte(id)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.out) != 4 || len(s.inflight) != 2 || len(s.queue) != 0 {
+		t.Fatalf("got %d sent, %d inflight, %d queued", len(c.out), len(s.inflight), len(s.queue))
+	}
+}
+
+func TestBrokerSlowClient(t *testing.T) {
+	b := New(Options{})
+	s, _, peer := testSession(b, 1)
+	done := make(chan struct{})
+	go func() {
+		// 投递不等待网络写入，队列已满时断开连接
+		b.deliver(s, &Message{Topic: "/sys/d1/x"}, 0)
+		b.deliver(s, &Message{Topic: "/sys/d1/x"}, 0)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deliver blocked by slow client")
+	}
+	if _, err := peer.Read(make([]byte, 1)); err == nil {
+		t.Fatal("expected slow client disconnected")
+	}
+}
+
+func TestTopicTreeMatch(t *testing.T) {
+	tree := newTopicTree()
+	tree.subscribe("a", subscription{Filter: "/sys/+/+/thing/#", Qos: 1})
+	tree.subscribe("b", subscription{Filter: "/sys/p/d/thing/event/property/post"})
+	tree.subscribe("c", subscription{Filter: "#"})
+	tree.subscribe("d", subscription{Filter: "/sys/p/#"})
+
+	got := tree.match("/sys/p/d/thing/event/property/post")
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if _, ok := got[id]; !ok {
+			t.Fatalf("client %s should match, got %v", id, got)
+		}
+	}
+	if got = tree.match("$SYS/uptime"); len(got) != 0 {
+		t.Fatalf("$ topic should not match wildcard, got %v", got)
+	}
+	if got = tree.match("/sys/p"); len(got) != 2 {
+		t.Fatalf("# should match parent level, got %v", got)
+	}
+	if !tree.unsubscribe("d", "/sys/p/#") || tree.unsubscribe("d", "/sys/p/#") {
+		t.Fatal("unsubscribe result error")
+	}
+	if !MatchTopic("/sys/+/d/#", "/sys/p/d/a") || MatchTopic("/sys/+/d", "/sys/p/d/a") {
+		t.Fatal("MatchTopic error")
+	}
+}
@@ -0,0 +1,425 @@
+package mqttbroker
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// 控制报文类型
+const (
+	packetConnect     byte = 1
+	packetConnack     byte = 2
+	packetPublish     byte = 3
+	packetPuback      byte = 4
+	packetPubrec      byte = 5
+	packetPubrel      byte = 6
+	packetPubcomp     byte = 7
+	packetSubscribe   byte = 8
+	packetSuback      byte = 9
+	packetUnsubscribe byte = 10
+	packetUnsuback    byte = 11
+	packetPingreq     byte = 12
+	packetPingresp    byte = 13
+	packetDisconnect  byte = 14
+)
+
+// 协议版本
+const (
+	version31  byte = 3
+	version311 byte = 4
+	version5   byte = 5
+)
+
+// MQTT5 属性标识
+const (
+	propSessionExpiry    byte = 0x11
+	propAssignedClientId byte = 0x12
+	propReceiveMaximum   byte = 0x21
+)
+
+var (
+	errMalformed      = errors.New("malformed packet")
+	errPacketTooLarge = errors.New("packet too large")
+)
+
+// readPacket 读取一个完整的控制报文，返回报文类型、标志位和可变报头及载荷
+func readPacket(r *bufio.Reader, maxSize int) (typ, flags byte, body []byte, err error) {
+	h, err := r.ReadByte()
+	if err != nil {
+		return
+	}
+	typ, flags = h>>4, h&0x0f
+	length, err := readVarint(r)
+	if err != nil {
+		return
+	}
+	if maxSize > 0 && length > maxSize {
+		err = errPacketTooLarge
+		return
+	}
+	body = make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return
+}
+
+// encodePacket 组装控制报文
+func encodePacket(typ, flags byte, body []byte) []byte {
+	out := make([]byte, 0, len(body)+5)
+	out = append(out, typ<<4|flags&0x0f)
+	out = appendVarint(out, len(body))
+	return append(out, body...)
+}
+
+func readVarint(r io.ByteReader) (int, error) {
+	var value, multiplier = 0, 1
+	for i := 0; i < 4; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			return value, nil
+		}
+		multiplier *= 128
+	}
+	return 0, errMalformed
+}
+
+func appendVarint(b []byte, v int) []byte {
+	for {
+		d := byte(v % 128)
+		v /= 128
+		if v > 0 {
+			d |= 0x80
+		}
+		b = append(b, d)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+// decoder 按顺序读取报文字段
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil || len(d.b) < 1 {
+		d.err = errMalformed
+		return 0
+	}
+	v := d.b[0]
+	d.b = d.b[1:]
+	return v
+}
+
+func (d *decoder) uint16() uint16 {
+	if d.err != nil || len(d.b) < 2 {
+		d.err = errMalformed
+		return 0
+	}
+	v := binary.BigEndian.Uint16(d.b)
+	d.b = d.b[2:]
+	return v
+}
+
+func (d *decoder) uint32() uint32 {
+	if d.err != nil || len(d.b) < 4 {
+		d.err = errMalformed
+		return 0
+	}
+	v := binary.BigEndian.Uint32(d.b)
+	d.b = d.b[4:]
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	n := int(d.uint16())
+	if d.err != nil || len(d.b) < n {
+		d.err = errMalformed
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+func (d *decoder) varint() int {
+	if d.err != nil {
+		return 0
+	}
+	r := &sliceReader{b: d.b}
+	v, err := readVarint(r)
+	if err != nil {
+		d.err = errMalformed
+		return 0
+	}
+	d.b = r.b
+	return v
+}
+
+// properties 读取MQTT5属性，只保留会话过期时间，其余属性忽略
+// props 服务端用到的 MQTT5 属性
+type props struct {
+	SessionExpiry  uint32
+	ReceiveMaximum uint16
+}
+
+func (d *decoder) properties() (pr props) {
+	n := d.varint()
+	if d.err != nil || len(d.b) < n {
+		d.err = errMalformed
+		return
+	}
+	p := &decoder{b: d.b[:n]}
+	d.b = d.b[n:]
+	for len(p.b) > 0 && p.err == nil {
+		id := p.byte()
+		switch id {
+		case 0x01, 0x17, 0x19, 0x24, 0x25, 0x28, 0x29, 0x2a:
+			p.byte()
+		case 0x13, 0x22, 0x23:
+			p.uint16()
+		case propReceiveMaximum:
+			pr.ReceiveMaximum = p.uint16()
+		case 0x02, 0x18, 0x27:
+			p.uint32()
+		case propSessionExpiry:
+			pr.SessionExpiry = p.uint32()
+		case 0x0b:
+			p.varint()
+		case 0x03, 0x08, 0x12, 0x15, 0x1a, 0x1c, 0x1f, 0x09, 0x16:
+			p.bytes()
+		case 0x26:
+			p.bytes()
+			p.bytes()
+		default:
+			p.err = fmt.Errorf("unknown property 0x%02x", id)
+		}
+	}
+	if p.err != nil {
+		d.err = p.err
+	}
+	return
+}
+
+type sliceReader struct {
+	b []byte
+}
+
+func (r *sliceReader) ReadByte() (byte, error) {
+	if len(r.b) == 0 {
+		return 0, io.EOF
+	}
+	v := r.b[0]
+	r.b = r.b[1:]
+	return v, nil
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendString(b []byte, s string) []byte {
+	b = appendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// connectPacket 连接请求
+type connectPacket struct {
+	ProtocolName  string
+	ProtocolLevel byte
+	CleanSession  bool
+	KeepAlive     uint16
+	SessionExpiry uint32
+	// ReceiveMaximum MQTT5 客户端同时处理的 QoS1、QoS2 消息数量，为0时不限制
+	ReceiveMaximum uint16
+	ClientId       string
+	Will           *Message
+	Username       string
+	Password       []byte
+}
+
+func decodeConnect(body []byte) (*connectPacket, error) {
+	d := &decoder{b: body}
+	p := &connectPacket{}
+	p.ProtocolName = d.string()
+	p.ProtocolLevel = d.byte()
+	flags := d.byte()
+	p.KeepAlive = d.uint16()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if p.ProtocolLevel == version5 {
+		pr := d.properties()
+		p.SessionExpiry, p.ReceiveMaximum = pr.SessionExpiry, pr.ReceiveMaximum
+	}
+	p.CleanSession = flags&0x02 != 0
+	p.ClientId = d.string()
+	if flags&0x04 != 0 {
+		if p.ProtocolLevel == version5 {
+			d.properties()
+		}
+		p.Will = &Message{
+			Topic:  d.string(),
+			Qos:    flags >> 3 & 0x03,
+			Retain: flags&0x20 != 0,
+		}
+		p.Will.Payload = append([]byte(nil), d.bytes()...)
+	}
+	if flags&0x80 != 0 {
+		p.Username = d.string()
+	}
+	if flags&0x40 != 0 {
+		p.Password = append([]byte(nil), d.bytes()...)
+	}
+	return p, d.err
+}
+
+func encodeConnack(version byte, sessionPresent bool, code byte, assignedId string) []byte {
+	var flags byte
+	if sessionPresent {
+		flags = 1
+	}
+	body := []byte{flags, code}
+	if version == version5 {
+		var props []byte
+		if assignedId != "" {
+			props = append(props, propAssignedClientId)
+			props = appendString(props, assignedId)
+		}
+		body = appendVarint(body, len(props))
+		body = append(body, props...)
+	}
+	return encodePacket(packetConnack, 0, body)
+}
+
+// Message 发布的消息
+type Message struct {
+	Topic   string
+	Payload []byte
+	Qos     byte
+	Retain  bool
+}
+
+type publishPacket struct {
+	Message
+	PacketId uint16
+	Dup      bool
+}
+
+func decodePublish(version, flags byte, body []byte) (*publishPacket, error) {
+	d := &decoder{b: body}
+	p := &publishPacket{}
+	p.Qos = flags >> 1 & 0x03
+	p.Retain = flags&0x01 != 0
+	p.Dup = flags&0x08 != 0
+	if p.Qos > 2 {
+		return nil, errMalformed
+	}
+	p.Topic = d.string()
+	if p.Qos > 0 {
+		p.PacketId = d.uint16()
+	}
+	if version == version5 {
+		d.properties()
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	p.Payload = d.b
+	return p, nil
+}
+
+func encodePublish(version byte, m *Message, qos byte, packetId uint16, dup bool) []byte {
+	flags := qos << 1
+	if m.Retain {
+		flags |= 0x01
+	}
+	if dup {
+		flags |= 0x08
+	}
+	body := appendString(make([]byte, 0, len(m.Topic)+len(m.Payload)+5), m.Topic)
+	if qos > 0 {
+		body = appendUint16(body, packetId)
+	}
+	if version == version5 {
+		body = append(body, 0)
+	}
+	body = append(body, m.Payload...)
+	return encodePacket(packetPublish, flags, body)
+}
+
+// encodeAck 组装 PUBACK/PUBREC/PUBREL/PUBCOMP，原因码为0时省略
+func encodeAck(version, typ byte, packetId uint16, code byte) []byte {
+	var flags byte
+	if typ == packetPubrel {
+		flags = 0x02
+	}
+	body := appendUint16(nil, packetId)
+	if version == version5 && code != 0 {
+		body = append(body, code)
+	}
+	return encodePacket(typ, flags, body)
+}
+
+// subscription 订阅请求中的主题过滤器
+type subscription struct {
+	Filter  string
+	Qos     byte
+	NoLocal bool
+}
+
+func decodeSubscribe(version byte, body []byte) (packetId uint16, subs []subscription, err error) {
+	d := &decoder{b: body}
+	packetId = d.uint16()
+	if version == version5 {
+		d.properties()
+	}
+	for len(d.b) > 0 && d.err == nil {
+		filter := d.string()
+		opts := d.byte()
+		subs = append(subs, subscription{Filter: filter, Qos: opts & 0x03, NoLocal: version == version5 && opts&0x04 != 0})
+	}
+	if d.err == nil && len(subs) == 0 {
+		d.err = errMalformed
+	}
+	return packetId, subs, d.err
+}
+
+func decodeUnsubscribe(version byte, body []byte) (packetId uint16, filters []string, err error) {
+	d := &decoder{b: body}
+	packetId = d.uint16()
+	if version == version5 {
+		d.properties()
+	}
+	for len(d.b) > 0 && d.err == nil {
+		filters = append(filters, d.string())
+	}
+	if d.err == nil && len(filters) == 0 {
+		d.err = errMalformed
+	}
+	return packetId, filters, d.err
+}
+
+// encodeSubAck 组装 SUBACK/UNSUBACK，MQTT3.1.1的 UNSUBACK 没有原因码
+func encodeSubAck(version, typ byte, packetId uint16, codes []byte) []byte {
+	body := appendUint16(nil, packetId)
+	if version == version5 {
+		body = append(body, 0)
+	}
+	if typ == packetSuback || version == version5 {
+		body = append(body, codes...)
+	}
+	return encodePacket(typ, 0, body)
+}
@@ -0,0 +1,140 @@
+package mqttbroker
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// validTopic 发布的主题不能为空，不能包含通配符
+func validTopic(topic string) bool {
+	return topic != "" && utf8.ValidString(topic) && !strings.ContainsAny(topic, "+#\x00")
+}
+
+// validFilter 订阅的主题过滤器，+ 和 # 必须占据整个层级，# 只能在最后一级
+func validFilter(filter string) bool {
+	if filter == "" || !utf8.ValidString(filter) || strings.Contains(filter, "\x00") {
+		return false
+	}
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		if strings.Contains(level, "#") && (level != "#" || i != len(levels)-1) {
+			return false
+		}
+		if strings.Contains(level, "+") && level != "+" {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchTopic 判断主题是否匹配主题过滤器
+func MatchTopic(filter, topic string) bool {
+	// $ 开头的主题不匹配以通配符开头的过滤器
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+	f, t := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, level := range f {
+		if level == "#" {
+			return true
+		}
+		if i >= len(t) {
+			return false
+		}
+		if level != "+" && level != t[i] {
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
+
+// topicTree 按主题层级保存订阅，发布时按层级查找匹配的订阅
+type topicTree struct {
+	root *topicNode
+}
+
+type topicNode struct {
+	children map[string]*topicNode
+	// 订阅该过滤器的客户端及其订阅选项
+	subs map[string]subscription
+}
+
+func newTopicNode() *topicNode {
+	return &topicNode{children: make(map[string]*topicNode), subs: make(map[string]subscription)}
+}
+
+func newTopicTree() *topicTree {
+	return &topicTree{root: newTopicNode()}
+}
+
+func (t *topicTree) subscribe(clientId string, sub subscription) {
+	node := t.root
+	for _, level := range strings.Split(sub.Filter, "/") {
+		child, ok := node.children[level]
+		if !ok {
+			child = newTopicNode()
+			node.children[level] = child
+		}
+		node = child
+	}
+	node.subs[clientId] = sub
+}
+
+// unsubscribe 取消订阅，返回订阅是否存在
+func (t *topicTree) unsubscribe(clientId, filter string) bool {
+	return unsubscribeNode(t.root, strings.Split(filter, "/"), clientId)
+}
+
+func unsubscribeNode(node *topicNode, levels []string, clientId string) bool {
+	if len(levels) == 0 {
+		_, ok := node.subs[clientId]
+		delete(node.subs, clientId)
+		return ok
+	}
+	child, ok := node.children[levels[0]]
+	if !ok {
+		return false
+	}
+	ok = unsubscribeNode(child, levels[1:], clientId)
+	if len(child.subs) == 0 && len(child.children) == 0 {
+		delete(node.children, levels[0])
+	}
+	return ok
+}
+
+// match 查找匹配主题的订阅，同一客户端有多个匹配的订阅时取最大的服务质量
+func (t *topicTree) match(topic string) map[string]subscription {
+	out := make(map[string]subscription)
+	levels := strings.Split(topic, "/")
+	matchNode(t.root, levels, 0, strings.HasPrefix(topic, "$"), out)
+	return out
+}
+
+func matchNode(node *topicNode, levels []string, i int, system bool, out map[string]subscription) {
+	collect := func(n *topicNode) {
+		for id, sub := range n.subs {
+			if old, ok := out[id]; !ok || sub.Qos > old.Qos {
+				out[id] = sub
+			}
+		}
+	}
+	wildcard := !(system && i == 0)
+	if wildcard {
+		// # 同时匹配父级本身
+		if n, ok := node.children["#"]; ok {
+			collect(n)
+		}
+	}
+	if i == len(levels) {
+		collect(node)
+		return
+	}
+	if n, ok := node.children[levels[i]]; ok {
+		matchNode(n, levels, i+1, system, out)
+	}
+	if wildcard {
+		if n, ok := node.children["+"]; ok {
+			matchNode(n, levels, i+1, system, out)
+		}
+	}
+}
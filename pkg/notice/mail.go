@@ -0,0 +1,138 @@
+package notice
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+// mailConfig 邮件通道配置，ssl 为 true 时使用 SSL 连接(一般为465端口)，否则在服务器支持时使用 STARTTLS
+type mailConfig struct {
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	From       string `json:"from"`
+	SSL        bool   `json:"ssl"`
+	SkipVerify bool   `json:"skipVerify"`
+	Timeout    int    `json:"timeout"`
+}
+
+type mail struct {
+	mailConfig
+}
+
+func newMail(config []byte) (Sender, error) {
+	m := &mail{}
+	if err := decodeConfig(config, &m.mailConfig); err != nil {
+		return nil, err
+	}
+	if m.Host == "" {
+		return nil, gerror.New("邮件服务器地址不能为空")
+	}
+	if m.Port == 0 {
+		m.Port = 25
+		if m.SSL {
+			m.Port = 465
+		}
+	}
+	if m.From == "" {
+		m.From = m.Username
+	}
+	if m.From == "" {
+		return nil, gerror.New("发件人不能为空")
+	}
+	return m, nil
+}
+
+func (m *mail) Send(ctx context.Context, msg Message) (err error) {
+	if len(msg.To) == 0 {
+		return gerror.New("收件人不能为空")
+	}
+	d := timeout(m.Timeout)
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	tlsConfig := &tls.Config{ServerName: m.Host, InsecureSkipVerify: m.SkipVerify}
+
+	dialer := &net.Dialer{Timeout: d}
+	var conn net.Conn
+	if m.SSL {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return
+	}
+	_ = conn.SetDeadline(time.Now().Add(d))
+
+	c, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	defer c.Close()
+
+	if !m.SSL {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err = c.StartTLS(tlsConfig); err != nil {
+				return
+			}
+		}
+	}
+	if m.Username != "" {
+		if ok, _ := c.Extension("AUTH"); ok {
+			if err = c.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+				return
+			}
+		}
+	}
+	if err = c.Mail(m.From); err != nil {
+		return
+	}
+	for _, to := range msg.To {
+		if err = c.Rcpt(to); err != nil {
+			return
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return
+	}
+	if _, err = w.Write(m.build(msg)); err != nil {
+		return
+	}
+	if err = w.Close(); err != nil {
+		return
+	}
+	return c.Quit()
+}
+
+// build 生成邮件内容，标题和正文使用 UTF-8 编码
+func (m *mail) build(msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", m.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	body := base64.StdEncoding.EncodeToString([]byte(msg.Content))
+	for len(body) > 76 {
+		buf.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	buf.WriteString(body + "\r\n")
+	return buf.Bytes()
+}
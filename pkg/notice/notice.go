@@ -0,0 +1,150 @@
+package notice
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+)
+
+// 内置的通知通道，通道配置保存在通知配置中，没有内置通道时由通知插件发送
+
+const (
+	GatewayMail     = "mail"        // 邮件
+	GatewayWebhook  = "webhook"     // 通用Webhook
+	GatewayDingTalk = "dingding"    // 钉钉机器人
+	GatewayWeCom    = "work_weixin" // 企业微信机器人
+	GatewayFeishu   = "feishu"      // 飞书机器人
+
+	defaultTimeout = 10 * time.Second
+
+	SecretMask = "******" // 查询时密钥的占位符，修改时提交占位符表示保持原值
+)
+
+// secretFields 通道配置中的密钥字段：邮件密码，Webhook、机器人的签名密钥，
+// 以及机器人的Webhook地址，地址中带有机器人的 key 或 access_token
+var secretFields = []string{"password", "secret", "webhook"}
+
+// secretMapField 值均为密钥的字段，Webhook的请求头通常带有认证令牌
+const secretMapField = "headers"
+
+// Message 通知内容，To 为收信人，邮件时为邮箱地址，机器人时为需要@的手机号
+type Message struct {
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	To      []string `json:"to"`
+}
+
+// Sender 通知通道
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var senders = map[string]func(config []byte) (Sender, error){
+	GatewayMail:     newMail,
+	GatewayWebhook:  newWebhook,
+	GatewayDingTalk: newDingTalk,
+	GatewayWeCom:    newWeCom,
+	"wework":        newWeCom,
+	GatewayFeishu:   newFeishu,
+}
+
+// Supported 是否为内置的通知通道
+func Supported(gateway string) bool {
+	_, ok := senders[gateway]
+	return ok
+}
+
+// New 按通道配置创建通知通道，config 为JSON格式的通道配置
+func New(gateway string, config string) (Sender, error) {
+	f, ok := senders[gateway]
+	if !ok {
+		return nil, gerror.Newf("不支持的通知通道：%s", gateway)
+	}
+	if config == "" {
+		return nil, gerror.Newf("通知通道 %s 未配置", gateway)
+	}
+	return f([]byte(config))
+}
+
+// Send 按通道配置发送通知
+func Send(ctx context.Context, gateway string, config string, msg Message) error {
+	s, err := New(gateway, config)
+	if err != nil {
+		return err
+	}
+	return s.Send(ctx, msg)
+}
+
+func decodeConfig(config []byte, v interface{}) error {
+	if err := json.Unmarshal(config, v); err != nil {
+		return gerror.Wrap(err, "通知通道配置格式错误")
+	}
+	return nil
+}
+
+func timeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return defaultTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// MaskConfig 把通道配置中的密钥替换为占位符，配置不是JSON对象时原样返回
+func MaskConfig(config string) string {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(config), &m); err != nil {
+		return config
+	}
+	masked := false
+	for _, k := range secretFields {
+		if v, ok := m[k].(string); ok && v != "" {
+			m[k] = SecretMask
+			masked = true
+		}
+	}
+	if headers, ok := m[secretMapField].(map[string]interface{}); ok {
+		for k, v := range headers {
+			if s, ok := v.(string); ok && s != "" {
+				headers[k] = SecretMask
+				masked = true
+			}
+		}
+	}
+	if !masked {
+		return config
+	}
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+// RestoreConfig 修改时提交的密钥为占位符的，使用已保存配置中的值
+func RestoreConfig(config string, stored string) string {
+	var m, old map[string]interface{}
+	if err := json.Unmarshal([]byte(config), &m); err != nil {
+		return config
+	}
+	_ = json.Unmarshal([]byte(stored), &old)
+	restored := false
+	for _, k := range secretFields {
+		if m[k] == SecretMask {
+			m[k] = old[k]
+			restored = true
+		}
+	}
+	if headers, ok := m[secretMapField].(map[string]interface{}); ok {
+		oldHeaders, _ := old[secretMapField].(map[string]interface{})
+		for k, v := range headers {
+			if v == SecretMask {
+				headers[k] = oldHeaders[k]
+				restored = true
+			}
+		}
+	}
+	if !restored {
+		return config
+	}
+	b, _ := json.Marshal(m)
+	return string(b)
+}
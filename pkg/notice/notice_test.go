@@ -0,0 +1,273 @@
+package notice
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// smtpServer 本地的SMTP服务，记录收到的命令和邮件内容
+type smtpServer struct {
+	listener net.Listener
+	commands []string
+	data     string
+	done     chan struct{}
+}
+
+func newSmtpServer(t *testing.T) *smtpServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{listener: l, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { _ = l.Close() })
+	return s
+}
+
+func (s *smtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = fmt.Fprintf(conn, "%s\r\n", line) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		s.commands = append(s.commands, line)
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			reply("235 ok")
+		case "MAIL", "RCPT":
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown")
+		}
+	}
+}
+
+func TestMail(t *testing.T) {
+	s := newSmtpServer(t)
+	config := fmt.Sprintf(`{"host":"127.0.0.1","port":%d,"username":"iot@example.com","password":"secret"}`, s.port())
+	err := Send(context.Background(), GatewayMail, config, Message{
+		Title:   "设备告警",
+		Content: "温度过高",
+		To:      []string{"a@example.com", "b@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-s.done
+
+	auth := base64.StdEncoding.EncodeToString([]byte("\x00iot@example.com\x00secret"))
+	want := []string{"AUTH PLAIN " + auth, "MAIL FROM:<iot@example.com>", "RCPT TO:<a@example.com>", "RCPT TO:<b@example.com>", "DATA", "QUIT"}
+	if got := s.commands[1:]; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected commands %q", got)
+	}
+	if !strings.Contains(s.data, "Subject: =?UTF-8?b?6K6+5aSH5ZGK6K2m?=") {
+		t.Fatalf("unexpected subject in %q", s.data)
+	}
+	if !strings.Contains(s.data, base64.StdEncoding.EncodeToString([]byte("温度过高"))) {
+		t.Fatalf("unexpected body in %q", s.data)
+	}
+}
+
+func TestMailConfig(t *testing.T) {
+	if _, err := New(GatewayMail, `{"port":25}`); err == nil {
+		t.Fatal("expected host error")
+	}
+	if _, err := New(GatewayMail, ""); err == nil {
+		t.Fatal("expected empty config error")
+	}
+	if _, err := New("sms", `{}`); err == nil || Supported("sms") {
+		t.Fatal("sms should not be built in")
+	}
+}
+
+func TestMaskConfig(t *testing.T) {
+	stored := `{"host":"smtp.example.com","password":"p@ss","secret":""}`
+	masked := MaskConfig(stored)
+	if strings.Contains(masked, "p@ss") || !strings.Contains(masked, `"password":"******"`) || !strings.Contains(masked, `"secret":""`) {
+		t.Fatalf("masked config got %s", masked)
+	}
+	if got := RestoreConfig(masked, stored); got != `{"host":"smtp.example.com","password":"p@ss","secret":""}` {
+		t.Fatalf("restored config got %s", got)
+	}
+	if got := RestoreConfig(`{"password":"new"}`, stored); got != `{"password":"new"}` {
+		t.Fatalf("new password got %s", got)
+	}
+	if got := MaskConfig("not json"); got != "not json" {
+		t.Fatalf("invalid config got %s", got)
+	}
+}
+
+func TestMaskRobotConfig(t *testing.T) {
+	stored := `{"secret":"SEC1","webhook":"https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=k1"}`
+	masked := MaskConfig(stored)
+	if strings.Contains(masked, "k1") || strings.Contains(masked, "SEC1") || !strings.Contains(masked, `"webhook":"******"`) {
+		t.Fatalf("masked config got %s", masked)
+	}
+	if got := RestoreConfig(masked, stored); got != stored {
+		t.Fatalf("restored config got %s", got)
+	}
+	changed := `{"secret":"******","webhook":"https://oapi.dingtalk.com/robot/send?access_token=t2"}`
+	if got := RestoreConfig(changed, stored); got != `{"secret":"SEC1","webhook":"https://oapi.dingtalk.com/robot/send?access_token=t2"}` {
+		t.Fatalf("changed webhook got %s", got)
+	}
+}
+
+func TestMaskWebhookConfig(t *testing.T) {
+	stored := `{"headers":{"Authorization":"Bearer abc","X-Tenant":"t1"},"secret":"","url":"http://example.com/hook"}`
+	masked := MaskConfig(stored)
+	if strings.Contains(masked, "Bearer abc") || strings.Contains(masked, "t1") || !strings.Contains(masked, "http://example.com/hook") {
+		t.Fatalf("masked config got %s", masked)
+	}
+	if got := RestoreConfig(masked, stored); got != stored {
+		t.Fatalf("restored config got %s", got)
+	}
+	changed := `{"headers":{"Authorization":"******","X-New":"n"},"url":"http://example.com/hook"}`
+	if got := RestoreConfig(changed, stored); got != `{"headers":{"Authorization":"Bearer abc","X-New":"n"},"url":"http://example.com/hook"}` {
+		t.Fatalf("changed headers got %s", got)
+	}
+}
+
+// received 记录收到的HTTP请求
+type received struct {
+	Path    string
+	Query   map[string][]string
+	Headers http.Header
+	Body    map[string]interface{}
+	raw     []byte
+}
+
+func newHttpServer(t *testing.T, response string) (*httptest.Server, chan received) {
+	ch := make(chan received, 1)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		req := received{Path: r.URL.Path, Query: r.URL.Query(), Headers: r.Header, raw: raw}
+		_ = json.Unmarshal(raw, &req.Body)
+		ch <- req
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(s.Close)
+	return s, ch
+}
+
+func TestWebhook(t *testing.T) {
+	s, ch := newHttpServer(t, "ok")
+	config := fmt.Sprintf(`{"url":"%s/hook","headers":{"X-Token":"abc"},"secret":"key"}`, s.URL)
+	if err := Send(context.Background(), GatewayWebhook, config, Message{Title: "t", Content: "c", To: []string{"u1"}}); err != nil {
+		t.Fatal(err)
+	}
+	req := <-ch
+	if req.Path != "/hook" || req.Headers.Get("X-Token") != "abc" || req.Body["title"] != "t" || req.Body["content"] != "c" {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	ts := req.Headers.Get(webhookTimestampHeader)
+	if sign := req.Headers.Get(webhookSignatureHeader); sign == "" || sign != webhookSign("key", ts, req.raw) {
+		t.Fatalf("invalid signature %s", sign)
+	}
+
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failed.Close()
+	if err := Send(context.Background(), GatewayWebhook, `{"url":"`+failed.URL+`"}`, Message{}); err == nil {
+		t.Fatal("expected status error")
+	}
+}
+
+func TestDingTalk(t *testing.T) {
+	s, ch := newHttpServer(t, `{"errcode":0,"errmsg":"ok"}`)
+	config := fmt.Sprintf(`{"webhook":"%s/robot/send?access_token=abc","secret":"SEC123"}`, s.URL)
+	if err := Send(context.Background(), GatewayDingTalk, config, Message{Title: "t", Content: "c", To: []string{"13800000000"}}); err != nil {
+		t.Fatal(err)
+	}
+	req := <-ch
+	if req.Query["access_token"][0] != "abc" {
+		t.Fatalf("unexpected query %v", req.Query)
+	}
+	if ts := req.Query["timestamp"][0]; req.Query["sign"][0] != dingTalkSign("SEC123", ts) {
+		t.Fatalf("invalid sign %v", req.Query)
+	}
+	text := req.Body["text"].(map[string]interface{})
+	at := req.Body["at"].(map[string]interface{})
+	if text["content"] != "t\nc" || at["atMobiles"].([]interface{})[0] != "13800000000" {
+		t.Fatalf("unexpected body %s", req.raw)
+	}
+
+	s, _ = newHttpServer(t, `{"errcode":310000,"errmsg":"sign not match"}`)
+	if err := Send(context.Background(), GatewayDingTalk, `{"webhook":"`+s.URL+`"}`, Message{Content: "c"}); err == nil || !strings.Contains(err.Error(), "310000") {
+		t.Fatalf("expected robot error, got %v", err)
+	}
+}
+
+func TestWeCom(t *testing.T) {
+	s, ch := newHttpServer(t, `{"errcode":0,"errmsg":"ok"}`)
+	if err := Send(context.Background(), GatewayWeCom, `{"webhook":"`+s.URL+`/send?key=k"}`, Message{Content: "c", To: []string{"138"}}); err != nil {
+		t.Fatal(err)
+	}
+	req := <-ch
+	text := req.Body["text"].(map[string]interface{})
+	if req.Body["msgtype"] != "text" || text["content"] != "c" || text["mentioned_mobile_list"].([]interface{})[0] != "138" {
+		t.Fatalf("unexpected body %s", req.raw)
+	}
+}
+
+func TestFeishu(t *testing.T) {
+	s, ch := newHttpServer(t, `{"code":0,"msg":"success"}`)
+	if err := Send(context.Background(), GatewayFeishu, `{"webhook":"`+s.URL+`","secret":"fs"}`, Message{Title: "t", Content: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	req := <-ch
+	ts, _ := req.Body["timestamp"].(string)
+	if req.Body["sign"] != feishuSign("fs", ts) || req.Body["msg_type"] != "text" {
+		t.Fatalf("unexpected body %s", req.raw)
+	}
+
+	s, _ = newHttpServer(t, `{"code":19021,"msg":"sign match fail"}`)
+	if err := Send(context.Background(), GatewayFeishu, `{"webhook":"`+s.URL+`"}`, Message{Content: "c"}); err == nil {
+		t.Fatal("expected robot error")
+	}
+}
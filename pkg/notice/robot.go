@@ -0,0 +1,164 @@
+package notice
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// robotConfig 群机器人通道配置，secret 为机器人的加签密钥，未开启加签时为空
+type robotConfig struct {
+	Webhook string `json:"webhook"`
+	Secret  string `json:"secret"`
+	Timeout int    `json:"timeout"`
+}
+
+// robotResult 机器人接口的返回结果，钉钉和企业微信使用 errcode，飞书使用 code
+type robotResult struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	Code    int    `json:"code"`
+	Msg     string `json:"msg"`
+}
+
+func newRobotConfig(config []byte) (c robotConfig, err error) {
+	if err = decodeConfig(config, &c); err != nil {
+		return
+	}
+	if c.Webhook == "" {
+		err = gerror.New("机器人Webhook地址不能为空")
+	}
+	return
+}
+
+// post 发送机器人消息并检查返回结果
+func (c robotConfig) post(ctx context.Context, webhook string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	data, err := request(ctx, http.MethodPost, webhook, nil, body, timeout(c.Timeout))
+	if err != nil {
+		return err
+	}
+	var res robotResult
+	if err = json.Unmarshal(data, &res); err != nil {
+		return gerror.Wrapf(err, "机器人返回结果解析失败：%s", data)
+	}
+	if res.ErrCode != 0 {
+		return gerror.Newf("机器人发送失败：%d %s", res.ErrCode, res.ErrMsg)
+	}
+	if res.Code != 0 {
+		return gerror.Newf("机器人发送失败：%d %s", res.Code, res.Msg)
+	}
+	return nil
+}
+
+func robotText(msg Message) string {
+	if msg.Title == "" {
+		return msg.Content
+	}
+	return msg.Title + "\n" + msg.Content
+}
+
+// dingTalk 钉钉群机器人
+type dingTalk struct {
+	robotConfig
+}
+
+func newDingTalk(config []byte) (Sender, error) {
+	c, err := newRobotConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &dingTalk{c}, nil
+}
+
+func (d *dingTalk) Send(ctx context.Context, msg Message) error {
+	webhook := d.Webhook
+	if d.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		sep := "?"
+		if strings.Contains(webhook, "?") {
+			sep = "&"
+		}
+		webhook += sep + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(dingTalkSign(d.Secret, timestamp))
+	}
+	return d.post(ctx, webhook, g.Map{
+		"msgtype": "text",
+		"text":    g.Map{"content": robotText(msg)},
+		"at":      g.Map{"atMobiles": msg.To},
+	})
+}
+
+// dingTalkSign 钉钉加签：HmacSHA256(secret, 时间戳 + "\n" + secret) 的Base64
+func dingTalkSign(secret, timestamp string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// weCom 企业微信群机器人，地址中的key即为密钥，没有单独的加签
+type weCom struct {
+	robotConfig
+}
+
+func newWeCom(config []byte) (Sender, error) {
+	c, err := newRobotConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &weCom{c}, nil
+}
+
+func (w *weCom) Send(ctx context.Context, msg Message) error {
+	return w.post(ctx, w.Webhook, g.Map{
+		"msgtype": "text",
+		"text": g.Map{
+			"content":               robotText(msg),
+			"mentioned_mobile_list": msg.To,
+		},
+	})
+}
+
+// feishu 飞书群机器人
+type feishu struct {
+	robotConfig
+}
+
+func newFeishu(config []byte) (Sender, error) {
+	c, err := newRobotConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &feishu{c}, nil
+}
+
+func (f *feishu) Send(ctx context.Context, msg Message) error {
+	payload := g.Map{
+		"msg_type": "text",
+		"content":  g.Map{"text": robotText(msg)},
+	}
+	if f.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		payload["timestamp"] = timestamp
+		payload["sign"] = feishuSign(f.Secret, timestamp)
+	}
+	return f.post(ctx, f.Webhook, payload)
+}
+
+// feishuSign 飞书加签：以 时间戳 + "\n" + secret 为密钥对空字符串做HmacSHA256，结果Base64
+func feishuSign(secret, timestamp string) string {
+	h := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
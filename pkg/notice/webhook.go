@@ -0,0 +1,99 @@
+package notice
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const (
+	webhookTimestampHeader = "X-Sagoo-Timestamp" // 请求时间，毫秒
+	webhookSignatureHeader = "X-Sagoo-Signature" // HmacSHA256(secret, 时间戳 + "." + 请求内容) 的十六进制
+)
+
+// webhookConfig 通用Webhook通道配置，设置 secret 时在请求头中附带签名
+type webhookConfig struct {
+	Url     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Secret  string            `json:"secret"`
+	Timeout int               `json:"timeout"`
+}
+
+type webhook struct {
+	webhookConfig
+}
+
+func newWebhook(config []byte) (Sender, error) {
+	w := &webhook{}
+	if err := decodeConfig(config, &w.webhookConfig); err != nil {
+		return nil, err
+	}
+	if w.Url == "" {
+		return nil, gerror.New("Webhook地址不能为空")
+	}
+	w.Method = strings.ToUpper(w.Method)
+	if w.Method == "" {
+		w.Method = http.MethodPost
+	}
+	return w, nil
+}
+
+func (w *webhook) Send(ctx context.Context, msg Message) error {
+	timestamp := time.Now().UnixMilli()
+	body, err := json.Marshal(g.Map{
+		"title":     msg.Title,
+		"content":   msg.Content,
+		"to":        msg.To,
+		"timestamp": timestamp,
+	})
+	if err != nil {
+		return err
+	}
+	headers := make(map[string]string, len(w.Headers)+2)
+	for k, v := range w.Headers {
+		headers[k] = v
+	}
+	if w.Secret != "" {
+		ts := strconv.FormatInt(timestamp, 10)
+		headers[webhookTimestampHeader] = ts
+		headers[webhookSignatureHeader] = webhookSign(w.Secret, ts, body)
+	}
+	_, err = request(ctx, w.Method, w.Url, headers, body, timeout(w.Timeout))
+	return err
+}
+
+// webhookSign Webhook请求签名，接收方使用相同的方法校验
+func webhookSign(secret, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp + "."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// request 发送JSON请求，响应状态不是2xx时返回错误
+func request(ctx context.Context, method, url string, headers map[string]string, body []byte, timeout time.Duration) ([]byte, error) {
+	client := g.Client().Timeout(timeout).ContentJson()
+	if len(headers) > 0 {
+		client = client.Header(headers)
+	}
+	res, err := client.DoRequest(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	data := res.ReadAll()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, gerror.Newf("通知服务响应异常：%s %s", res.Status, data)
+	}
+	return data, nil
+}
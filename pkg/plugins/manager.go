@@ -8,6 +8,7 @@ import (
 	"github.com/gogf/gf/v2/errors/gerror"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/hashicorp/go-plugin"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"sagooiot/internal/consts"
@@ -16,6 +17,7 @@ import (
 	"sagooiot/pkg/plugins/module"
 	"strings"
 	"sync"
+	"time"
 )
 
 // PluginInfo 插件信息
@@ -24,6 +26,20 @@ type PluginInfo struct {
 	Path   string
 	Stats  bool
 	Client *plugin.Client
+
+	lock      sync.Mutex
+	started   bool      // 当前进程是否已经启动
+	crashed   bool      // 进程已经退出或者启动失败，等待重启
+	startedAt time.Time // 当前进程的启动时间
+	restarts  int       // 重启次数
+	failures  int       // 连续失败次数，用于计算重启的退避时间
+	retryAt   time.Time // 下次允许重启的时间
+	lastError string
+	errorAt   time.Time
+	modTime   time.Time // 插件文件的修改时间
+	size      int64     // 插件文件的大小
+	decode    callStats
+	encode    callStats
 }
 
 // Manager 插件管理器， 为不同类型的插件，管理的生命周期
@@ -34,6 +50,9 @@ type Manager struct {
 	Plugins     map[string]*PluginInfo // 插件信息列表
 	initialized bool                   // 是否初始化
 	pluginImpl  plugin.Plugin          // 插件实现虚拟接口
+
+	lock    sync.RWMutex
+	pending map[string]fileStat // 目录扫描发现的变化，文件稳定后再加载
 }
 
 // Init 初始化插件管理器
@@ -45,18 +64,21 @@ func (m *Manager) Init() error {
 		return err
 	}
 
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	//获取所有插件信息
 	for _, p := range plugins {
-		_, file := filepath.Split(p)
-		globAster := strings.LastIndex(m.Glob, "*")
-		trim := m.Glob[0:globAster]
-		id := strings.TrimPrefix(file, trim)
+		id := m.pluginId(p)
 
 		//添加到插件信息
-		m.Plugins[id] = &PluginInfo{
+		info := &PluginInfo{
 			ID:   id,
 			Path: p,
 		}
+		if stat, statErr := os.Stat(p); statErr == nil {
+			info.modTime, info.size = stat.ModTime(), stat.Size()
+		}
+		m.Plugins[id] = info
 	}
 
 	m.initialized = true
@@ -64,24 +86,35 @@ func (m *Manager) Init() error {
 	return nil
 }
 
+// pluginId 插件文件名去掉类型前缀后作为插件ID
+func (m *Manager) pluginId(path string) string {
+	_, file := filepath.Split(path)
+	globAster := strings.LastIndex(m.Glob, "*")
+	trim := m.Glob[0:globAster]
+	return strings.TrimPrefix(file, trim)
+}
+
+// newClient 创建插件客户端，第一次获取连接时才会启动插件进程
+func (m *Manager) newClient(id, path string) *plugin.Client {
+	// 以exec.Command方式启动插件进程，并创建宿主机进程和插件进程的连接，通信方式由插件选择
+	return plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig:  module.HandshakeConfig,
+		Plugins:          m.pluginMap(id),
+		Cmd:              exec.Command(path),
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolNetRPC, plugin.ProtocolGRPC},
+	})
+}
+
 // Launch 启动所有插件
 func (m *Manager) Launch() error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	for id, info := range m.Plugins {
 		g.Log().Debugf(context.Background(), "注册插件 type=%s, id=%s, impl=%s", m.Type, id, info.Path)
 		// 创建新的客户端
-		// 以exec.Command方式启动插件进程，并创建宿主机进程和插件进程的连接
-		client := plugin.NewClient(&plugin.ClientConfig{
-			HandshakeConfig: module.HandshakeConfig,
-			Plugins:         m.pluginMap(id),
-			Cmd:             exec.Command(info.Path),
-		})
-
-		if _, ok := m.Plugins[id]; !ok {
-			// 如果没有找到，忽略？
-			continue
-		}
-		m.Plugins[id].Client = client
-
+		info.lock.Lock()
+		info.Client = m.newClient(id, info.Path)
+		info.lock.Unlock()
 	}
 
 	return nil
@@ -89,9 +122,17 @@ func (m *Manager) Launch() error {
 
 // Dispose 释放插件资源
 func (m *Manager) Dispose() {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	var wg sync.WaitGroup
 	for _, p := range m.Plugins {
-		if p == nil || p.Client == nil {
+		if p == nil {
+			continue
+		}
+		p.lock.Lock()
+		client := p.Client
+		p.lock.Unlock()
+		if client == nil {
 			continue
 		}
 		wg.Add(1)
@@ -99,25 +140,33 @@ func (m *Manager) Dispose() {
 			// 关闭client，释放相关资源，终止插件子程序的运行
 			client.Kill()
 			wg.Done()
-		}(p.Client)
+		}(client)
 	}
 	wg.Wait()
 }
 
+// getPlugin 获取插件信息
+func (m *Manager) getPlugin(id string) *PluginInfo {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.Plugins[id]
+}
+
 // GetInterface 获取插件接口
 func (m *Manager) GetInterface(id string) (interface{}, error) {
-
-	if _, ok := m.Plugins[id]; !ok {
+	p := m.getPlugin(id)
+	if p == nil {
 		return nil, fmt.Errorf("在注册的插件中找不到插件ID:%s", id)
 	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
 	//获取注册插件客户端 plugin.Client
-	client := m.Plugins[id].Client
-	if client == nil {
+	if p.Client == nil {
 		return nil, gerror.Newf("插件尚未启动: %s", id)
 	}
 
-	// 返回协议客户端，如rpc客户端或grpc客户端，用于后续通信
-	rpcClient, err := client.Client()
+	// 返回协议客户端，如rpc客户端或grpc客户端，用于后续通信，插件进程已经退出时会先重启
+	rpcClient, err := m.connect(p)
 	if err != nil {
 		return nil, err
 	}
@@ -171,19 +220,27 @@ func (m *Manager) StartPlugin(id string) (err error) {
 	if m == nil {
 		return gerror.New("插件管理器为空")
 	}
+	m.lock.Lock()
 	if m.Plugins == nil {
 		m.Plugins = map[string]*PluginInfo{}
 	}
 
 	p := m.Plugins[id]
-	if p != nil && p.Client != nil {
-		p.Client.Kill()
+	if p == nil {
+		p = &PluginInfo{
+			ID: id,
+		}
+		m.Plugins[id] = p
 	}
+	m.lock.Unlock()
 
-	path := ""
-	if p != nil {
-		path = p.Path
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.Client != nil {
+		p.Client.Kill()
 	}
+
+	path := p.Path
 	if path == "" {
 		path, err = m.buildPluginPath(id)
 		if err != nil {
@@ -191,21 +248,9 @@ func (m *Manager) StartPlugin(id string) (err error) {
 		}
 	}
 
-	client := plugin.NewClient(&plugin.ClientConfig{
-		HandshakeConfig: module.HandshakeConfig,
-		Plugins:         m.pluginMap(id),
-		Cmd:             exec.Command(path),
-	})
-	if p == nil {
-		p = &PluginInfo{
-			ID:   id,
-			Path: path,
-		}
-		m.Plugins[id] = p
-	}
-
-	p.Client = client
+	p.Client = m.newClient(id, path)
 	p.Path = path
+	p.reset()
 	g.Log().Debugf(context.Background(), "注册插件 type=%s, id=%s, impl=%s", m.Type, id, p.Path)
 
 	return
@@ -216,14 +261,17 @@ func (m *Manager) StopPlugin(id string) (err error) {
 	if m == nil {
 		return gerror.New("插件管理器为空")
 	}
-	p := m.Plugins[id]
+	p := m.getPlugin(id)
 	if p == nil {
 		return errors.New("插件不存在")
 	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
 	if p.Client != nil {
 		p.Client.Kill()
 		p.Client = nil
 	}
+	p.reset()
 	return
 }
 
@@ -232,6 +280,8 @@ func (m *Manager) AddPlugin(Type string, id string) (err error) {
 	if m == nil {
 		return gerror.New("插件管理器为空")
 	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	if m.Plugins == nil {
 		m.Plugins = map[string]*PluginInfo{}
 	}
@@ -266,16 +316,22 @@ func (m *Manager) RemovePlugin(id string) (err error) {
 	if m == nil {
 		return gerror.New("插件管理器为空")
 	}
+	m.lock.Lock()
 	p := m.Plugins[id]
 	if p == nil {
+		m.lock.Unlock()
 		err = errors.New("插件不存在")
 		return
 	}
+	// 从插件列表中移除
+	delete(m.Plugins, id)
+	m.lock.Unlock()
+
 	// 关闭client，释放相关资源，终止插件子程序的运行
+	p.lock.Lock()
 	if p.Client != nil {
 		p.Client.Kill()
 	}
-	// 从插件列表中移除
-	delete(m.Plugins, id)
+	p.lock.Unlock()
 	return
 }
@@ -0,0 +1,179 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"sagooiot/pkg/plugins/model"
+	"sagooiot/pkg/plugins/proto"
+)
+
+// gRPC 方式的插件与 net/rpc 方式的插件实现相同的业务接口，数据结构在两种方式之间转换
+
+func infoFromProto(in *proto.PluginInfo) (out model.PluginInfo) {
+	if in == nil {
+		return
+	}
+	out = model.PluginInfo{
+		Types:       in.Types,
+		HandleType:  in.HandleType,
+		Name:        in.Name,
+		Title:       in.Title,
+		Description: in.Description,
+		Version:     in.Version,
+		Author:      in.Author,
+		Icon:        in.Icon,
+		Link:        in.Link,
+		Command:     in.Command,
+		Args:        in.Args,
+		Root:        in.Root,
+	}
+	if in.Frontend != nil {
+		out.Frontend = model.Frontend{
+			Ui:            in.Frontend.Ui,
+			Url:           in.Frontend.Url,
+			Configuration: in.Frontend.Configuration,
+		}
+	}
+	return
+}
+
+func infoToProto(in model.PluginInfo) *proto.PluginInfo {
+	return &proto.PluginInfo{
+		Types:       in.Types,
+		HandleType:  in.HandleType,
+		Name:        in.Name,
+		Title:       in.Title,
+		Description: in.Description,
+		Version:     in.Version,
+		Author:      in.Author,
+		Icon:        in.Icon,
+		Link:        in.Link,
+		Command:     in.Command,
+		Args:        in.Args,
+		Root:        in.Root,
+		Frontend: &proto.Frontend{
+			Ui:            in.Frontend.Ui,
+			Url:           in.Frontend.Url,
+			Configuration: in.Frontend.Configuration,
+		},
+	}
+}
+
+// jsonResFromProto data 为 JSON 时原样保留，调用方再次序列化时结果不变；不是 JSON 时作为字符串
+func jsonResFromProto(in *proto.JsonResponse) (out model.JsonRes) {
+	out.Code = int(in.Code)
+	out.Message = in.Message
+	if len(in.Data) > 0 {
+		if json.Valid(in.Data) {
+			out.Data = json.RawMessage(in.Data)
+		} else {
+			out.Data = string(in.Data)
+		}
+	}
+	return
+}
+
+func jsonResToProto(in model.JsonRes) (*proto.JsonResponse, error) {
+	out := &proto.JsonResponse{
+		Code:    int32(in.Code),
+		Message: in.Message,
+	}
+	if in.Data != nil {
+		data, err := json.Marshal(in.Data)
+		if err != nil {
+			return nil, err
+		}
+		out.Data = data
+	}
+	return out, nil
+}
+
+func errorRes(message string, err error) model.JsonRes {
+	return model.JsonRes{Code: 1, Message: message + " " + err.Error()}
+}
+
+// ProtocolGRPC 基于gRPC实现
+type ProtocolGRPC struct {
+	Client proto.ProtocolClient
+}
+
+func (p *ProtocolGRPC) Info() model.PluginInfo {
+	ctx, cancel := callContext()
+	defer cancel()
+	resp, err := p.Client.Info(ctx, &proto.Empty{})
+	if err != nil {
+		return model.PluginInfo{}
+	}
+	return infoFromProto(resp)
+}
+func (p *ProtocolGRPC) Encode(args model.DataReq) model.JsonRes {
+	ctx, cancel := callContext()
+	defer cancel()
+	resp, err := p.Client.Encode(ctx, &proto.DataRequest{Data: args.Data, DataIdent: args.DataIdent})
+	if err != nil {
+		return errorRes("protocol grpc Encode", err)
+	}
+	return jsonResFromProto(resp)
+}
+func (p *ProtocolGRPC) Decode(data model.DataReq) model.JsonRes {
+	ctx, cancel := callContext()
+	defer cancel()
+	resp, err := p.Client.Decode(ctx, &proto.DataRequest{Data: data.Data, DataIdent: data.DataIdent})
+	if err != nil {
+		return errorRes("protocol grpc Decode", err)
+	}
+	return jsonResFromProto(resp)
+}
+
+// ProtocolGRPCServer 协议插件的gRPC服务，在插件进程中运行
+type ProtocolGRPCServer struct {
+	proto.UnimplementedProtocolServer
+	Impl Protocol
+}
+
+func (s *ProtocolGRPCServer) Info(context.Context, *proto.Empty) (*proto.PluginInfo, error) {
+	return infoToProto(s.Impl.Info()), nil
+}
+func (s *ProtocolGRPCServer) Encode(_ context.Context, req *proto.DataRequest) (*proto.JsonResponse, error) {
+	return jsonResToProto(s.Impl.Encode(model.DataReq{Data: req.Data, DataIdent: req.DataIdent}))
+}
+func (s *ProtocolGRPCServer) Decode(_ context.Context, req *proto.DataRequest) (*proto.JsonResponse, error) {
+	return jsonResToProto(s.Impl.Decode(model.DataReq{Data: req.Data, DataIdent: req.DataIdent}))
+}
+
+// NoticeGRPC 基于gRPC实现
+type NoticeGRPC struct {
+	Client proto.NoticeClient
+}
+
+func (n *NoticeGRPC) Info() model.PluginInfo {
+	ctx, cancel := callContext()
+	defer cancel()
+	resp, err := n.Client.Info(ctx, &proto.Empty{})
+	if err != nil {
+		return model.PluginInfo{}
+	}
+	return infoFromProto(resp)
+}
+func (n *NoticeGRPC) Send(data []byte) model.JsonRes {
+	ctx, cancel := callContext()
+	defer cancel()
+	resp, err := n.Client.Send(ctx, &proto.SendRequest{Data: data})
+	if err != nil {
+		return errorRes("notice grpc Send", err)
+	}
+	return jsonResFromProto(resp)
+}
+
+// NoticeGRPCServer 通知插件的gRPC服务，在插件进程中运行
+type NoticeGRPCServer struct {
+	proto.UnimplementedNoticeServer
+	Impl Notice
+}
+
+func (s *NoticeGRPCServer) Info(context.Context, *proto.Empty) (*proto.PluginInfo, error) {
+	return infoToProto(s.Impl.Info()), nil
+}
+func (s *NoticeGRPCServer) Send(_ context.Context, req *proto.SendRequest) (*proto.JsonResponse, error) {
+	return jsonResToProto(s.Impl.Send(req.Data))
+}
@@ -0,0 +1,186 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sagooiot/pkg/plugins/model"
+	"sagooiot/pkg/plugins/proto"
+	"strings"
+	"testing"
+
+	gplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// envForeignPlugin 设置后测试进程作为插件运行
+const envForeignPlugin = "SAGOO_TEST_FOREIGN_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(envForeignPlugin) == "1" {
+		if err := serveForeignPlugin(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// foreignProtocol 只使用 plugin.proto 生成的代码实现的协议服务，与其它语言实现插件的方式相同
+type foreignProtocol struct {
+	proto.UnimplementedProtocolServer
+}
+
+func (foreignProtocol) Info(context.Context, *proto.Empty) (*proto.PluginInfo, error) {
+	return &proto.PluginInfo{Types: "protocol", Name: "foreign", Title: "foreign protocol"}, nil
+}
+
+func (foreignProtocol) Encode(_ context.Context, req *proto.DataRequest) (*proto.JsonResponse, error) {
+	return &proto.JsonResponse{Data: []byte(fmt.Sprintf("%q", strings.ToUpper(string(req.Data))))}, nil
+}
+
+func (foreignProtocol) Decode(_ context.Context, req *proto.DataRequest) (*proto.JsonResponse, error) {
+	values := strings.Split(string(req.Data), ";")
+	if len(values) != 2 {
+		return &proto.JsonResponse{Code: 1, Message: "invalid data"}, nil
+	}
+	data, _ := json.Marshal(map[string]string{values[0]: values[1]})
+	return &proto.JsonResponse{Data: data}, nil
+}
+
+// serveForeignPlugin 按 plugin.proto 中描述的步骤启动插件，不使用 go-plugin 的服务端
+func serveForeignPlugin() error {
+	if os.Getenv(HandshakeConfig.MagicCookieKey) != HandshakeConfig.MagicCookieValue {
+		return fmt.Errorf("not started by host")
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	s := grpc.NewServer()
+	proto.RegisterProtocolServer(s, foreignProtocol{})
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("plugin", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(s, healthServer)
+
+	fmt.Printf("%d|%d|tcp|%s|grpc\n", gplugin.CoreProtocolVersion, HandshakeConfig.ProtocolVersion, l.Addr().String())
+	_ = os.Stdout.Sync()
+	return s.Serve(l)
+}
+
+func TestForeignGRPCPlugin(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = []string{envForeignPlugin + "=1"}
+	client := gplugin.NewClient(&gplugin.ClientConfig{
+		HandshakeConfig:  HandshakeConfig,
+		Plugins:          map[string]gplugin.Plugin{"foreign": &ProtocolPlugin{}},
+		Cmd:              cmd,
+		AllowedProtocols: []gplugin.Protocol{gplugin.ProtocolNetRPC, gplugin.ProtocolGRPC},
+	})
+	defer client.Kill()
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.NegotiatedVersion() != int(HandshakeConfig.ProtocolVersion) {
+		t.Fatalf("negotiated version %d", client.NegotiatedVersion())
+	}
+	if err = rpcClient.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := rpcClient.Dispense("foreign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, ok := raw.(Protocol)
+	if !ok {
+		t.Fatalf("unexpected plugin type %T", raw)
+	}
+	if _, ok = raw.(*ProtocolGRPC); !ok {
+		t.Fatalf("expected grpc client, got %T", raw)
+	}
+
+	if info := p.Info(); info.Name != "foreign" || info.Types != "protocol" {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	res := p.Decode(model.DataReq{Data: []byte("temperature;25.5")})
+	if res.Code != 0 {
+		t.Fatalf("decode failed: %s", res.Message)
+	}
+	if data, _ := json.Marshal(res.Data); string(data) != `{"temperature":"25.5"}` {
+		t.Fatalf("unexpected decode data %s", data)
+	}
+	if res = p.Decode(model.DataReq{Data: []byte("bad")}); res.Code != 1 || res.Message != "invalid data" {
+		t.Fatalf("unexpected decode result %+v", res)
+	}
+	if data, _ := json.Marshal(p.Encode(model.DataReq{Data: []byte("on")}).Data); string(data) != `"ON"` {
+		t.Fatalf("unexpected encode data %s", data)
+	}
+}
+
+type goProtocol struct{}
+
+func (goProtocol) Info() model.PluginInfo {
+	return model.PluginInfo{Name: "go", Types: "protocol", Author: "sagoo", Args: []string{"-v"}}
+}
+func (goProtocol) Encode(args model.DataReq) model.JsonRes {
+	return model.JsonRes{Data: map[string]string{"ident": args.DataIdent}}
+}
+func (goProtocol) Decode(data model.DataReq) model.JsonRes {
+	return model.JsonRes{Data: string(data.Data)}
+}
+
+type goNotice struct{}
+
+func (goNotice) Info() model.PluginInfo {
+	return model.PluginInfo{Name: "mail", Types: "notice"}
+}
+func (goNotice) Send(data []byte) model.JsonRes {
+	if len(data) == 0 {
+		return model.JsonRes{Code: 1, Message: "empty"}
+	}
+	return model.JsonRes{Message: "sent " + string(data)}
+}
+
+func TestGoGRPCPlugin(t *testing.T) {
+	client, server := gplugin.TestPluginGRPCConn(t, false, map[string]gplugin.Plugin{
+		"go":   &ProtocolPlugin{Impl: goProtocol{}},
+		"mail": &NoticePlugin{Impl: goNotice{}},
+	})
+	defer client.Close()
+	defer server.Stop()
+
+	raw, err := client.Dispense("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := raw.(Protocol)
+	if info := p.Info(); info.Name != "go" || info.Author != "sagoo" || len(info.Args) != 1 {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if data, _ := json.Marshal(p.Encode(model.DataReq{DataIdent: "a"}).Data); string(data) != `{"ident":"a"}` {
+		t.Fatalf("unexpected encode data %s", data)
+	}
+	if data, _ := json.Marshal(p.Decode(model.DataReq{Data: []byte("raw")}).Data); string(data) != `"raw"` {
+		t.Fatalf("unexpected decode data %s", data)
+	}
+
+	raw, err = client.Dispense("mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := raw.(Notice)
+	if res := n.Send([]byte("hi")); res.Code != 0 || res.Message != "sent hi" {
+		t.Fatalf("unexpected send result %+v", res)
+	}
+	if res := n.Send(nil); res.Code != 1 {
+		t.Fatalf("unexpected send result %+v", res)
+	}
+}
@@ -1,11 +1,37 @@
 package module
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/hashicorp/go-plugin"
+	"net/rpc"
 	"sagooiot/pkg/plugins/model"
+	"time"
 )
 
+// callTimeout 调用插件方法的超时时间，net/rpc 和 gRPC 两种方式相同
+const callTimeout = 30 * time.Second
+
+var errCallTimeout = errors.New("plugin call timeout")
+
+// call 以 net/rpc 方式调用插件方法，超时后不再等待插件返回
+func call(client *rpc.Client, method string, args, reply interface{}) error {
+	timer := time.NewTimer(callTimeout)
+	defer timer.Stop()
+	select {
+	case c := <-client.Go(method, args, reply, make(chan *rpc.Call, 1)).Done:
+		return c.Error
+	case <-timer.C:
+		return errCallTimeout
+	}
+}
+
+// callContext gRPC 方式调用插件方法的上下文
+func callContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), callTimeout)
+}
+
 // HandshakeConfig 握手配置，插件进程和宿主机进程，都需要保持一致
 var HandshakeConfig = plugin.HandshakeConfig{
 	ProtocolVersion:  1,
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"github.com/gogf/gf/v2/frame/g"
 	gplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
 	"net/rpc"
 	"sagooiot/pkg/plugins/model"
+	"sagooiot/pkg/plugins/proto"
 )
 
 // Notice 通知服务插件接口
@@ -22,7 +24,7 @@ type NoticeRPC struct {
 
 func (n *NoticeRPC) Info() model.PluginInfo {
 	var resp model.PluginInfo
-	err := n.Client.Call("Plugin.Info", new(interface{}), &resp)
+	err := call(n.Client, "Plugin.Info", new(interface{}), &resp)
 	if err != nil {
 		g.Log().Error(context.Background(), err)
 	}
@@ -30,7 +32,7 @@ func (n *NoticeRPC) Info() model.PluginInfo {
 }
 func (n *NoticeRPC) Send(data []byte) model.JsonRes {
 	var resp model.JsonRes
-	err := n.Client.Call("Plugin.Send", data, &resp)
+	err := call(n.Client, "Plugin.Send", data, &resp)
 	if err != nil {
 		resp.Code = 1
 		resp.Message = fmt.Sprintf("notice.go Send %s", err.Error())
@@ -55,15 +57,31 @@ func (s *NoticeRPCServer) Send(data []byte, resp *model.JsonRes) error {
 }
 
 // NoticePlugin 插件的虚拟实现。用于PluginMap的插件接口。在运行时，来自插件实现的实际实现会覆盖
-type NoticePlugin struct{}
+// 同时支持 net/rpc 和 gRPC 两种通信方式，由插件进程选择
+type NoticePlugin struct {
+	// Impl 插件进程中的业务实现，宿主进程不需要设置
+	Impl Notice
+}
 
 // Server 此方法由插件进程延迟的调用
-func (NoticePlugin) Server(*gplugin.MuxBroker) (interface{}, error) {
+func (n NoticePlugin) Server(*gplugin.MuxBroker) (interface{}, error) {
 	checkParentAlive()
-	return &NoticeRPCServer{}, nil
+	return &NoticeRPCServer{Impl: n.Impl}, nil
 }
 
 // Client 此方法由宿主进程调用
 func (NoticePlugin) Client(b *gplugin.MuxBroker, c *rpc.Client) (interface{}, error) {
 	return &NoticeRPC{Client: c}, nil
 }
+
+// GRPCServer 插件进程使用gRPC方式时调用，注册通知服务
+func (n NoticePlugin) GRPCServer(b *gplugin.GRPCBroker, s *grpc.Server) error {
+	checkParentAlive()
+	proto.RegisterNoticeServer(s, &NoticeGRPCServer{Impl: n.Impl})
+	return nil
+}
+
+// GRPCClient 插件进程使用gRPC方式时由宿主进程调用
+func (NoticePlugin) GRPCClient(ctx context.Context, b *gplugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &NoticeGRPC{Client: proto.NewNoticeClient(c)}, nil
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"github.com/gogf/gf/v2/frame/g"
 	gplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
 	"net/rpc"
 	"os"
 	"sagooiot/pkg/plugins/model"
+	"sagooiot/pkg/plugins/proto"
 	"time"
 )
 
@@ -31,7 +33,7 @@ type ProtocolRPC struct {
 
 func (p *ProtocolRPC) Info() model.PluginInfo {
 	var resp model.PluginInfo
-	err := p.Client.Call("Plugin.Info", new(interface{}), &resp)
+	err := call(p.Client, "Plugin.Info", new(interface{}), &resp)
 	if err != nil {
 		//希望接口返回错误
 		//这里没有太多其他选择。
@@ -42,7 +44,7 @@ func (p *ProtocolRPC) Info() model.PluginInfo {
 }
 func (p *ProtocolRPC) Encode(args model.DataReq) model.JsonRes {
 	var resp model.JsonRes
-	err := p.Client.Call("Plugin.Encode", args, &resp)
+	err := call(p.Client, "Plugin.Encode", args, &resp)
 	//fmt.Println(args)
 	if err != nil {
 		//希望接口返回错误
@@ -57,7 +59,7 @@ func (p *ProtocolRPC) Encode(args model.DataReq) model.JsonRes {
 }
 func (p *ProtocolRPC) Decode(data model.DataReq) model.JsonRes {
 	var resp model.JsonRes
-	err := p.Client.Call("Plugin.Decode", data, &resp)
+	err := call(p.Client, "Plugin.Decode", data, &resp)
 	if err != nil {
 		//希望接口返回错误
 		//这里没有太多其他选择。
@@ -90,12 +92,16 @@ func (s *ProtocolRPCServer) Decode(args model.DataReq, resp *model.JsonRes) erro
 }
 
 // ProtocolPlugin 插件的虚拟实现。用于PluginMap的插件接口。在运行时，来自插件实现的实际实现会覆盖
-type ProtocolPlugin struct{}
+// 同时支持 net/rpc 和 gRPC 两种通信方式，由插件进程选择
+type ProtocolPlugin struct {
+	// Impl 插件进程中的业务实现，宿主进程不需要设置
+	Impl Protocol
+}
 
 // Server 此方法由插件进程延迟的调用
 func (p *ProtocolPlugin) Server(*gplugin.MuxBroker) (interface{}, error) {
 	checkParentAlive()
-	return &ProtocolRPCServer{}, nil
+	return &ProtocolRPCServer{Impl: p.Impl}, nil
 	//return interface{}, nil
 }
 
@@ -105,6 +111,18 @@ func (p *ProtocolPlugin) Client(b *gplugin.MuxBroker, c *rpc.Client) (interface{
 	//return interface{}, nil
 }
 
+// GRPCServer 插件进程使用gRPC方式时调用，注册协议服务
+func (p *ProtocolPlugin) GRPCServer(b *gplugin.GRPCBroker, s *grpc.Server) error {
+	checkParentAlive()
+	proto.RegisterProtocolServer(s, &ProtocolGRPCServer{Impl: p.Impl})
+	return nil
+}
+
+// GRPCClient 插件进程使用gRPC方式时由宿主进程调用
+func (p *ProtocolPlugin) GRPCClient(ctx context.Context, b *gplugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &ProtocolGRPC{Client: proto.NewProtocolClient(c)}, nil
+}
+
 // checkParentAlive 检查父进程(也就是 client )是否退出，如果退出了，自己也需要退出。
 func checkParentAlive() {
 	go func() {
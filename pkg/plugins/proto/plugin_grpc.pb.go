@@ -0,0 +1,320 @@
+// 插件的 gRPC 接口定义，非 Go 语言的插件 (Python、C/C++ 等) 使用本文件生成代码。
+//
+// 插件进程启动后需要：
+//   1. 校验环境变量 SAGOO_PLUGIN=sagoo_plugin，不一致时直接退出；
+//   2. 在本机监听端口，注册 Protocol 或 Notice 服务，以及 grpc.health.v1.Health 服务，
+//      服务名 "plugin" 的状态为 SERVING；
+//   3. 向标准输出打印一行握手信息并刷新：1|1|tcp|127.0.0.1:端口|grpc
+//
+// Go 语言的插件使用 sagooiot/pkg/plugins/sdk 中的 ServeProtocol 和 ServeNotice 即可。
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: plugin.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Protocol_Info_FullMethodName   = "/sagoo.plugin.Protocol/Info"
+	Protocol_Encode_FullMethodName = "/sagoo.plugin.Protocol/Encode"
+	Protocol_Decode_FullMethodName = "/sagoo.plugin.Protocol/Decode"
+)
+
+// ProtocolClient is the client API for Protocol service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ProtocolClient interface {
+	Info(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PluginInfo, error)
+	Encode(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*JsonResponse, error)
+	Decode(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*JsonResponse, error)
+}
+
+type protocolClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProtocolClient(cc grpc.ClientConnInterface) ProtocolClient {
+	return &protocolClient{cc}
+}
+
+func (c *protocolClient) Info(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PluginInfo, error) {
+	out := new(PluginInfo)
+	err := c.cc.Invoke(ctx, Protocol_Info_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *protocolClient) Encode(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*JsonResponse, error) {
+	out := new(JsonResponse)
+	err := c.cc.Invoke(ctx, Protocol_Encode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *protocolClient) Decode(ctx context.Context, in *DataRequest, opts ...grpc.CallOption) (*JsonResponse, error) {
+	out := new(JsonResponse)
+	err := c.cc.Invoke(ctx, Protocol_Decode_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProtocolServer is the server API for Protocol service.
+// All implementations must embed UnimplementedProtocolServer
+// for forward compatibility
+type ProtocolServer interface {
+	Info(context.Context, *Empty) (*PluginInfo, error)
+	Encode(context.Context, *DataRequest) (*JsonResponse, error)
+	Decode(context.Context, *DataRequest) (*JsonResponse, error)
+	mustEmbedUnimplementedProtocolServer()
+}
+
+// UnimplementedProtocolServer must be embedded to have forward compatible implementations.
+type UnimplementedProtocolServer struct {
+}
+
+func (UnimplementedProtocolServer) Info(context.Context, *Empty) (*PluginInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Info not implemented")
+}
+func (UnimplementedProtocolServer) Encode(context.Context, *DataRequest) (*JsonResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Encode not implemented")
+}
+func (UnimplementedProtocolServer) Decode(context.Context, *DataRequest) (*JsonResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Decode not implemented")
+}
+func (UnimplementedProtocolServer) mustEmbedUnimplementedProtocolServer() {}
+
+// UnsafeProtocolServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProtocolServer will
+// result in compilation errors.
+type UnsafeProtocolServer interface {
+	mustEmbedUnimplementedProtocolServer()
+}
+
+func RegisterProtocolServer(s grpc.ServiceRegistrar, srv ProtocolServer) {
+	s.RegisterService(&Protocol_ServiceDesc, srv)
+}
+
+func _Protocol_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProtocolServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Protocol_Info_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProtocolServer).Info(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Protocol_Encode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProtocolServer).Encode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Protocol_Encode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProtocolServer).Encode(ctx, req.(*DataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Protocol_Decode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProtocolServer).Decode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Protocol_Decode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProtocolServer).Decode(ctx, req.(*DataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Protocol_ServiceDesc is the grpc.ServiceDesc for Protocol service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Protocol_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sagoo.plugin.Protocol",
+	HandlerType: (*ProtocolServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Info",
+			Handler:    _Protocol_Info_Handler,
+		},
+		{
+			MethodName: "Encode",
+			Handler:    _Protocol_Encode_Handler,
+		},
+		{
+			MethodName: "Decode",
+			Handler:    _Protocol_Decode_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugin.proto",
+}
+
+const (
+	Notice_Info_FullMethodName = "/sagoo.plugin.Notice/Info"
+	Notice_Send_FullMethodName = "/sagoo.plugin.Notice/Send"
+)
+
+// NoticeClient is the client API for Notice service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NoticeClient interface {
+	Info(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PluginInfo, error)
+	Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*JsonResponse, error)
+}
+
+type noticeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNoticeClient(cc grpc.ClientConnInterface) NoticeClient {
+	return &noticeClient{cc}
+}
+
+func (c *noticeClient) Info(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PluginInfo, error) {
+	out := new(PluginInfo)
+	err := c.cc.Invoke(ctx, Notice_Info_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *noticeClient) Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*JsonResponse, error) {
+	out := new(JsonResponse)
+	err := c.cc.Invoke(ctx, Notice_Send_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NoticeServer is the server API for Notice service.
+// All implementations must embed UnimplementedNoticeServer
+// for forward compatibility
+type NoticeServer interface {
+	Info(context.Context, *Empty) (*PluginInfo, error)
+	Send(context.Context, *SendRequest) (*JsonResponse, error)
+	mustEmbedUnimplementedNoticeServer()
+}
+
+// UnimplementedNoticeServer must be embedded to have forward compatible implementations.
+type UnimplementedNoticeServer struct {
+}
+
+func (UnimplementedNoticeServer) Info(context.Context, *Empty) (*PluginInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Info not implemented")
+}
+func (UnimplementedNoticeServer) Send(context.Context, *SendRequest) (*JsonResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Send not implemented")
+}
+func (UnimplementedNoticeServer) mustEmbedUnimplementedNoticeServer() {}
+
+// UnsafeNoticeServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NoticeServer will
+// result in compilation errors.
+type UnsafeNoticeServer interface {
+	mustEmbedUnimplementedNoticeServer()
+}
+
+func RegisterNoticeServer(s grpc.ServiceRegistrar, srv NoticeServer) {
+	s.RegisterService(&Notice_ServiceDesc, srv)
+}
+
+func _Notice_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NoticeServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Notice_Info_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NoticeServer).Info(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Notice_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NoticeServer).Send(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Notice_Send_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NoticeServer).Send(ctx, req.(*SendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Notice_ServiceDesc is the grpc.ServiceDesc for Notice service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Notice_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sagoo.plugin.Notice",
+	HandlerType: (*NoticeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Info",
+			Handler:    _Notice_Info_Handler,
+		},
+		{
+			MethodName: "Send",
+			Handler:    _Notice_Send_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugin.proto",
+}
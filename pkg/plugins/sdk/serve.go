@@ -0,0 +1,28 @@
+package sdk
+
+import (
+	"github.com/hashicorp/go-plugin"
+	"sagooiot/pkg/plugins/module"
+)
+
+// ServeProtocol 启动协议解析插件，在插件的 main 函数中调用，使用gRPC方式与平台通信
+func ServeProtocol(impl module.Protocol) {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: module.HandshakeConfig,
+		Plugins: map[string]plugin.Plugin{
+			impl.Info().Name: &module.ProtocolPlugin{Impl: impl},
+		},
+		GRPCServer: plugin.DefaultGRPCServer,
+	})
+}
+
+// ServeNotice 启动通知服务插件，在插件的 main 函数中调用，使用gRPC方式与平台通信
+func ServeNotice(impl module.Notice) {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: module.HandshakeConfig,
+		Plugins: map[string]plugin.Plugin{
+			impl.Info().Name: &module.NoticePlugin{Impl: impl},
+		},
+		GRPCServer: plugin.DefaultGRPCServer,
+	})
+}
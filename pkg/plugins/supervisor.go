@@ -0,0 +1,379 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/hashicorp/go-plugin"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// supervisorInterval 健康检查和目录扫描的间隔
+	supervisorInterval = 5 * time.Second
+	// pingTimeout 健康检查的超时时间
+	pingTimeout = 5 * time.Second
+	// minBackoff 和 maxBackoff 重启的退避时间，连续失败时翻倍
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+	// stableDuration 重启后持续运行超过这个时间，重新计算退避时间
+	stableDuration = time.Minute
+)
+
+// 插件运行状态
+const (
+	StatusStopped = "stopped" // 已停用
+	StatusIdle    = "idle"    // 已加载，还没有启动进程
+	StatusRunning = "running" // 运行中
+	StatusCrashed = "crashed" // 进程已退出，等待重启
+)
+
+// PluginStatus 插件运行状态
+type PluginStatus struct {
+	Type            string    `json:"type"`
+	ID              string    `json:"id"`
+	Path            string    `json:"path"`
+	Status          string    `json:"status"`
+	StartedAt       time.Time `json:"startedAt"`
+	Restarts        int       `json:"restarts"`
+	LastError       string    `json:"lastError"`
+	LastErrorAt     time.Time `json:"lastErrorAt"`
+	DecodeTotal     int64     `json:"decodeTotal"`
+	DecodeErrors    int64     `json:"decodeErrors"`
+	DecodeErrorRate float64   `json:"decodeErrorRate"`
+	EncodeTotal     int64     `json:"encodeTotal"`
+	EncodeErrors    int64     `json:"encodeErrors"`
+	EncodeErrorRate float64   `json:"encodeErrorRate"`
+}
+
+// callStats 插件调用次数统计
+type callStats struct {
+	total  atomic.Int64
+	errors atomic.Int64
+}
+
+func (c *callStats) add(failed bool) {
+	c.total.Add(1)
+	if failed {
+		c.errors.Add(1)
+	}
+}
+
+func (c *callStats) load() (total, errs int64, rate float64) {
+	total, errs = c.total.Load(), c.errors.Load()
+	if total > 0 {
+		rate = float64(errs) / float64(total)
+	}
+	return
+}
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+// backoff 第n次连续失败后的重启等待时间
+func backoff(failures int) time.Duration {
+	d := minBackoff
+	for i := 1; i < failures && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// reset 插件重新创建客户端后清除运行状态，需持有p.lock
+func (p *PluginInfo) reset() {
+	p.started = false
+	p.crashed = false
+	p.failures = 0
+	p.retryAt = time.Time{}
+}
+
+// crash 记录插件的异常，按连续失败次数计算下次重启的时间，需持有p.lock
+func (p *PluginInfo) crash(err error) {
+	p.crashed = true
+	p.started = false
+	p.failures++
+	p.retryAt = time.Now().Add(backoff(p.failures))
+	p.lastError = err.Error()
+	p.errorAt = time.Now()
+}
+
+// connect 获取插件的连接，进程没有启动时启动进程，进程已经退出时在退避时间后重启，需持有p.lock
+func (m *Manager) connect(p *PluginInfo) (plugin.ClientProtocol, error) {
+	if p.started && p.Client.Exited() {
+		p.crash(errors.New("插件进程已退出"))
+		g.Log().Warningf(context.Background(), "插件进程已退出 type=%s, id=%s", m.Type, p.ID)
+	}
+	if p.crashed {
+		if wait := time.Until(p.retryAt); wait > 0 {
+			return nil, gerror.Newf("插件%s已退出，%s后重启", p.ID, wait.Round(time.Second))
+		}
+		p.Client.Kill()
+		p.Client = m.newClient(p.ID, p.Path)
+	}
+
+	rpcClient, err := p.Client.Client()
+	if err != nil {
+		// 启动失败的客户端不能再次启动，下次重启时重新创建
+		p.Client.Kill()
+		restart := p.crashed
+		p.crash(err)
+		if restart {
+			g.Log().Warningf(context.Background(), "插件重启失败 type=%s, id=%s, 第%d次, err=%v", m.Type, p.ID, p.failures, err)
+		}
+		return nil, err
+	}
+	if p.crashed {
+		p.crashed = false
+		p.restarts++
+		g.Log().Infof(context.Background(), "插件已重启 type=%s, id=%s, 共重启%d次", m.Type, p.ID, p.restarts)
+	}
+	if !p.started {
+		p.started = true
+		p.startedAt = time.Now()
+	}
+	return rpcClient, nil
+}
+
+// ping 检查插件是否能正常响应，插件进程卡住时rpc调用不会返回，需要超时
+func ping(rpcClient plugin.ClientProtocol) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- rpcClient.Ping()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(pingTimeout):
+		return errors.New("插件健康检查超时")
+	}
+}
+
+// check 检查已经启动的插件，没有响应的插件结束进程，已退出的插件按退避时间重启。
+// 健康检查可能等待到超时，检查期间不持有p.lock，避免阻塞插件调用；检查后插件已被重新加载或停用时不处理
+func (m *Manager) check(p *PluginInfo) {
+	p.lock.Lock()
+	// 停用和没有使用过的插件不检查
+	if p.Client == nil || !(p.started || p.crashed) {
+		p.lock.Unlock()
+		return
+	}
+	client := p.Client
+	alive := p.started && !client.Exited()
+	var rpcClient plugin.ClientProtocol
+	var err error
+	if alive {
+		rpcClient, err = client.Client()
+	}
+	p.lock.Unlock()
+
+	if alive && err == nil {
+		err = ping(rpcClient)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.Client != client {
+		return
+	}
+	if alive {
+		if err == nil {
+			if p.failures > 0 && time.Since(p.startedAt) >= stableDuration {
+				p.failures = 0
+			}
+			return
+		}
+		g.Log().Warningf(context.Background(), "插件健康检查失败 type=%s, id=%s, err=%v", m.Type, p.ID, err)
+		p.Client.Kill()
+		p.crash(err)
+	}
+	_, _ = m.connect(p)
+}
+
+// scan 扫描插件目录，加载新增的插件，重新加载文件有变化的插件。文件可能还在写入，连续两次扫描一致后再加载
+func (m *Manager) scan() {
+	files, err := plugin.Discover(m.Glob, m.Path)
+	if err != nil {
+		return
+	}
+	if m.pending == nil {
+		m.pending = map[string]fileStat{}
+	}
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		id := m.pluginId(path)
+		stat := fileStat{modTime: info.ModTime(), size: info.Size()}
+		if p := m.getPlugin(id); p != nil {
+			p.lock.Lock()
+			unchanged := p.modTime.Equal(stat.modTime) && p.size == stat.size
+			if p.modTime.IsZero() {
+				// 通过接口添加的插件还没有记录文件信息，不需要重新加载
+				p.Path, p.modTime, p.size = path, stat.modTime, stat.size
+				unchanged = true
+			}
+			p.lock.Unlock()
+			if unchanged {
+				delete(m.pending, id)
+				continue
+			}
+		}
+		if last, ok := m.pending[id]; !ok || last != stat {
+			m.pending[id] = stat
+			continue
+		}
+		delete(m.pending, id)
+		m.reload(id, path, stat)
+	}
+}
+
+// reload 加载新的插件文件，正在运行的插件使用新文件重启
+func (m *Manager) reload(id, path string, stat fileStat) {
+	m.lock.Lock()
+	p := m.Plugins[id]
+	if p == nil {
+		m.Plugins[id] = &PluginInfo{
+			ID:      id,
+			Path:    path,
+			Client:  m.newClient(id, path),
+			modTime: stat.modTime,
+			size:    stat.size,
+		}
+		m.lock.Unlock()
+		g.Log().Infof(context.Background(), "发现新插件 type=%s, id=%s, impl=%s", m.Type, id, path)
+		return
+	}
+	m.lock.Unlock()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.Path, p.modTime, p.size = path, stat.modTime, stat.size
+	// 已停用的插件在启用时使用新文件
+	if p.Client == nil {
+		return
+	}
+	running := p.started || p.crashed
+	p.Client.Kill()
+	p.Client = m.newClient(id, path)
+	p.reset()
+	if !running {
+		return
+	}
+	if _, err := m.connect(p); err != nil {
+		g.Log().Warningf(context.Background(), "插件重新加载失败 type=%s, id=%s, err=%v", m.Type, id, err)
+		return
+	}
+	g.Log().Infof(context.Background(), "插件已重新加载 type=%s, id=%s, impl=%s", m.Type, id, path)
+}
+
+// supervise 执行一次目录扫描和健康检查
+func (m *Manager) supervise() {
+	m.scan()
+	m.lock.RLock()
+	list := make([]*PluginInfo, 0, len(m.Plugins))
+	for _, p := range m.Plugins {
+		list = append(list, p)
+	}
+	m.lock.RUnlock()
+	for _, p := range list {
+		m.check(p)
+	}
+}
+
+// record 记录插件编解码的结果
+func (m *Manager) record(id string, encode bool, failed bool) {
+	p := m.getPlugin(id)
+	if p == nil {
+		return
+	}
+	if encode {
+		p.encode.add(failed)
+	} else {
+		p.decode.add(failed)
+	}
+}
+
+// Status 获取所有插件的运行状态
+func (m *Manager) Status() []PluginStatus {
+	m.lock.RLock()
+	list := make([]*PluginInfo, 0, len(m.Plugins))
+	for _, p := range m.Plugins {
+		list = append(list, p)
+	}
+	m.lock.RUnlock()
+
+	out := make([]PluginStatus, 0, len(list))
+	for _, p := range list {
+		s := PluginStatus{Type: m.Type, ID: p.ID}
+		p.lock.Lock()
+		s.Path = p.Path
+		switch {
+		case p.Client == nil:
+			s.Status = StatusStopped
+		case p.crashed || (p.started && p.Client.Exited()):
+			s.Status = StatusCrashed
+		case p.started:
+			s.Status = StatusRunning
+			s.StartedAt = p.startedAt
+		default:
+			s.Status = StatusIdle
+		}
+		s.Restarts = p.restarts
+		s.LastError, s.LastErrorAt = p.lastError, p.errorAt
+		p.lock.Unlock()
+		s.DecodeTotal, s.DecodeErrors, s.DecodeErrorRate = p.decode.load()
+		s.EncodeTotal, s.EncodeErrors, s.EncodeErrorRate = p.encode.load()
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// StartSupervisor 启动插件守护，定时检查插件的健康状态并重启已退出的插件，扫描插件目录加载新增和更新的插件
+func StartSupervisor(ctx context.Context) (stop func(context.Context) error) {
+	ctx, cancel := context.WithCancel(gctx.NeverDone(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(supervisorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			for _, m := range managers() {
+				m.supervise()
+			}
+		}
+	}()
+	return func(ctx context.Context) error {
+		cancel()
+		<-done
+		return nil
+	}
+}
+
+// managers 已经初始化的插件管理器
+func managers() (list []*Manager) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, ins := range insMap {
+		if ins != nil && ins.pluginManager != nil {
+			list = append(list, ins.pluginManager)
+		}
+	}
+	return
+}
@@ -0,0 +1,103 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"sagooiot/pkg/plugins/module"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		7:  maxBackoff,
+		20: maxBackoff,
+	}
+	for failures, want := range cases {
+		if got := backoff(failures); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestCallStats(t *testing.T) {
+	var c callStats
+	c.add(false)
+	c.add(true)
+	c.add(false)
+	c.add(true)
+	total, errs, rate := c.load()
+	if total != 4 || errs != 2 || rate != 0.5 {
+		t.Fatalf("load() = %d, %d, %v", total, errs, rate)
+	}
+}
+
+func TestManagerScan(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager("protocol", "protocol-*", dir, &module.ProtocolPlugin{})
+	if err := m.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "protocol-demo")
+	if err := os.WriteFile(path, []byte("v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 第一次扫描只记录文件，文件稳定后再加载
+	m.scan()
+	if m.getPlugin("demo") != nil {
+		t.Fatal("plugin loaded before file is stable")
+	}
+	m.scan()
+	p := m.getPlugin("demo")
+	if p == nil || p.Client == nil {
+		t.Fatal("new plugin not loaded")
+	}
+	client := p.Client
+
+	// 文件更新后重新创建客户端，没有启动过的插件不启动进程
+	if err := os.WriteFile(path, []byte("v2-updated"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	m.scan()
+	m.scan()
+	if p.Client == client {
+		t.Fatal("updated plugin not reloaded")
+	}
+	if status := m.Status(); len(status) != 1 || status[0].Status != StatusIdle {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	m.Dispose()
+}
+
+func TestManagerConnectBackoff(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager("protocol", "protocol-*", dir, &module.ProtocolPlugin{})
+	// 插件文件不存在，启动失败后进入退避
+	if err := m.StartPlugin("missing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetInterface("missing"); err == nil {
+		t.Fatal("expected start error")
+	}
+	p := m.getPlugin("missing")
+	if !p.crashed || p.failures != 1 || p.lastError == "" {
+		t.Fatalf("unexpected state crashed=%v failures=%d", p.crashed, p.failures)
+	}
+	// 退避时间内不会再次启动
+	if _, err := m.GetInterface("missing"); err == nil || p.failures != 1 {
+		t.Fatalf("restarted within backoff, failures=%d err=%v", p.failures, err)
+	}
+	if status := m.Status(); status[0].Status != StatusCrashed {
+		t.Fatalf("unexpected status %s", status[0].Status)
+	}
+	if err := m.StopPlugin("missing"); err != nil {
+		t.Fatal(err)
+	}
+	if status := m.Status(); status[0].Status != StatusStopped {
+		t.Fatalf("unexpected status %s", status[0].Status)
+	}
+}
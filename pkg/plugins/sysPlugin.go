@@ -98,12 +98,14 @@ func (pm *SysPlugin) GetProtocolDecodeData(protocolName string, data []byte) (re
 	//获取插件
 	p, err := pm.pluginManager.GetInterface(protocolName)
 	if err != nil {
+		pm.pluginManager.record(protocolName, false, true)
 		return
 	}
 
 	var rd = model.DataReq{}
 	rd.Data = data
 	resData := p.(module.Protocol).Decode(rd)
+	pm.pluginManager.record(protocolName, false, resData.Code != 0)
 	return resData, err
 }
 
@@ -116,12 +118,14 @@ func (pm *SysPlugin) GetProtocolEncodeData(protocolName string, reqData model.Da
 	//获取插件
 	p, err := pm.pluginManager.GetInterface(protocolName)
 	if err != nil {
+		pm.pluginManager.record(protocolName, true, true)
 		return
 	}
 
 	//var rd = model.DataReq{}
 	//rd.Data = data
 	resData := p.(module.Protocol).Encode(reqData)
+	pm.pluginManager.record(protocolName, true, resData.Code != 0)
 	return resData, err
 }
 
@@ -141,6 +145,14 @@ func (pm *SysPlugin) StopPlugin(pluginId string) (err error) {
 	return pm.pluginManager.StopPlugin(pluginId)
 }
 
+// Status 获取插件的运行状态
+func (pm *SysPlugin) Status() (list []PluginStatus, err error) {
+	if err = pm.ensureManager(); err != nil {
+		return
+	}
+	return pm.pluginManager.Status(), nil
+}
+
 // NoticeSend 通过插件发送通知信息。noticeName 为通知插件名称；msg为通知内容
 func (pm *SysPlugin) NoticeSend(noticeName string, msg model.NoticeInfoData) (res model.JsonRes, err error) {
 	if err = pm.ensureManager(); err != nil {
@@ -0,0 +1,149 @@
+package comm
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+)
+
+// 聚合函数
+const (
+	AggAvg    = "avg"
+	AggMin    = "min"
+	AggMax    = "max"
+	AggSum    = "sum"
+	AggCount  = "count"
+	AggFirst  = "first"
+	AggLast   = "last"
+	AggSpread = "spread" // 最大值与最小值的差
+	AggDiff   = "diff"   // 相邻两条数据的差，不能按时间窗口聚合
+)
+
+// 窗口内没有数据时的填充方式
+const (
+	FillNone   = "none"   // 不返回空窗口
+	FillNull   = "null"   // 填充空值
+	FillPrev   = "prev"   // 使用前一个窗口的值
+	FillLinear = "linear" // 线性插值
+	FillValue  = "value"  // 填充指定值
+)
+
+const (
+	// QueryMaxDevices 单次查询最多指定的设备数
+	QueryMaxDevices = 1000
+	// QueryMaxLimit 单次查询最多返回的记录数
+	QueryMaxLimit = 10000
+)
+
+var (
+	keyPattern      = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	propertyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	aggregates = map[string]bool{AggAvg: true, AggMin: true, AggMax: true, AggSum: true, AggCount: true, AggFirst: true, AggLast: true, AggSpread: true, AggDiff: true}
+	fills      = map[string]bool{FillNone: true, FillNull: true, FillPrev: true, FillLinear: true, FillValue: true}
+)
+
+// Query 时序数据查询，由各时序数据库编译为参数化的查询语句。
+// 按设备查询时指定 DeviceKeys，多个设备时需指定所属产品；只指定产品时查询产品下所有设备。
+// 结果中 ts 为数据时间或窗口开始时间，device 为设备标识，属性按原始标识返回
+type Query struct {
+	ProductKey string        // 产品标识
+	DeviceKeys []string      // 设备标识
+	Properties []string      // 查询的属性标识
+	Start      time.Time     // 开始时间，包含
+	End        time.Time     // 结束时间，不包含
+	Aggregate  string        // 聚合函数，为空时查询原始数据
+	Interval   time.Duration // 聚合窗口，为0时对整个时间范围聚合
+	Fill       string        // 空窗口填充方式，默认不返回空窗口
+	FillValue  float64       // Fill 为 value 时的填充值
+	Asc        bool          // 按时间升序，默认降序
+	Limit      int           // 返回记录数
+	Offset     int           // 跳过的记录数
+}
+
+// Validate 校验查询条件，标识只允许字母、数字、下划线和中划线，编译时可直接作为表名和字段名
+func (q *Query) Validate() error {
+	if q.ProductKey == "" && len(q.DeviceKeys) == 0 {
+		return errors.New("请指定产品或设备")
+	}
+	if q.ProductKey != "" && !keyPattern.MatchString(q.ProductKey) {
+		return fmt.Errorf("产品标识不正确：%s", q.ProductKey)
+	}
+	if q.ProductKey == "" && len(q.DeviceKeys) > 1 {
+		return errors.New("查询多个设备时请指定产品")
+	}
+	if len(q.DeviceKeys) > QueryMaxDevices {
+		return fmt.Errorf("最多查询%d个设备", QueryMaxDevices)
+	}
+	for _, k := range q.DeviceKeys {
+		if !keyPattern.MatchString(k) {
+			return fmt.Errorf("设备标识不正确：%s", k)
+		}
+	}
+	if len(q.Properties) == 0 {
+		return errors.New("请选择属性")
+	}
+	for _, p := range q.Properties {
+		if !propertyPattern.MatchString(p) || strings.EqualFold(p, "ts") || strings.EqualFold(p, "device") {
+			return fmt.Errorf("属性标识不正确：%s", p)
+		}
+	}
+	if q.Start.IsZero() || q.End.IsZero() || !q.End.After(q.Start) {
+		return errors.New("时间范围不正确")
+	}
+	if q.Aggregate != "" && !aggregates[q.Aggregate] {
+		return fmt.Errorf("不支持的聚合函数：%s", q.Aggregate)
+	}
+	if q.Interval != 0 {
+		if q.Aggregate == "" || q.Aggregate == AggDiff {
+			return errors.New("按时间窗口查询时请选择聚合函数，且不能为diff")
+		}
+		if q.Interval < time.Second || q.Interval%time.Second != 0 {
+			return errors.New("聚合窗口需为整数秒")
+		}
+	}
+	if q.Fill != "" && !fills[q.Fill] {
+		return fmt.Errorf("不支持的填充方式：%s", q.Fill)
+	}
+	if q.Fill != "" && q.Fill != FillNone && q.Interval == 0 {
+		return errors.New("填充方式需配合聚合窗口使用")
+	}
+	if q.Limit <= 0 || q.Limit > QueryMaxLimit || q.Offset < 0 {
+		return fmt.Errorf("分页参数不正确，每页最多%d条", QueryMaxLimit)
+	}
+	return nil
+}
+
+// Windowed 是否按时间窗口聚合
+func (q *Query) Windowed() bool {
+	return q.Interval > 0
+}
+
+// Grouped 是否对整个时间范围聚合，结果中没有时间列
+func (q *Query) Grouped() bool {
+	return q.Aggregate != "" && q.Aggregate != AggDiff && q.Interval == 0
+}
+
+// Restore 把查询结果中的属性字段还原为原始的属性标识，tsd字段名均为小写并带有前缀
+func (q *Query) Restore(rs gdb.Result) gdb.Result {
+	names := make(map[string]string, len(q.Properties)*2)
+	for _, p := range q.Properties {
+		names[TsdColumnName(p)] = p
+		names[strings.ToLower(p)] = p
+	}
+	for i, rc := range rs {
+		newRc := make(gdb.Record, len(rc))
+		for k, v := range rc {
+			if name, ok := names[k]; ok {
+				k = name
+			}
+			newRc[k] = v
+		}
+		rs[i] = newRc
+	}
+	return rs
+}
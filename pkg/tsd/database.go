@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"sagooiot/pkg/iotModel"
+	"sagooiot/pkg/tsd/comm"
 
 	"github.com/gogf/gf/v2/database/gdb"
 )
@@ -11,6 +12,8 @@ import (
 // Database 接口，所有数据库都应该实现这个接口
 type Database interface {
 	Close()
+	//服务停止时释放连接和后台任务，tsd.DB 返回的实例是进程共享的，使用后只调用 Close
+	Shutdown()
 	Query(sql string) (rows *sql.Rows, err error)
 	Count(table string) (int, error)
 	InsertLogData(log iotModel.DeviceLog) (result sql.Result, err error)
@@ -32,4 +35,6 @@ type Database interface {
 	GetTableDataOne(ctx context.Context, sqlStr string, args ...any) (rs gdb.Record, err error)
 	//获取超级表的多条数据
 	GetTableDataAll(ctx context.Context, sqlStr string, args ...any) (rs gdb.Result, err error)
+	//按查询条件查询时序数据
+	QueryData(ctx context.Context, q *comm.Query) (list gdb.Result, total int, err error)
 }
@@ -20,6 +20,13 @@ func DB() Database {
 	return instances
 }
 
+// Shutdown 服务停止时释放数据库单例的连接和后台任务
+func Shutdown() {
+	if instances != nil {
+		instances.Shutdown()
+	}
+}
+
 // GetDB 返回数据库实例
 func GetDB() (db Database) {
 	var option = comm.Option{}
@@ -3,80 +3,282 @@ package influxdb
 import (
 	"context"
 	"database/sql"
-	"github.com/gogf/gf/v2/database/gdb"
+	"errors"
+	"net/http"
 	"sagooiot/pkg/iotModel"
 	"sagooiot/pkg/tsd/comm"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
 )
 
+// ErrSqlNotSupported influxdb 不支持SQL查询，需使用Flux或按查询条件查询的 QueryData
+var ErrSqlNotSupported = errors.New("influxdb does not support sql query, use flux or QueryData")
+
+// sqlKeywords tdengine SQL语句的开头，Flux查询不会以这些关键字开头
+var sqlKeywords = []string{"select ", "show ", "desc ", "describe ", "insert ", "create ", "drop ", "alter ", "delete "}
+
 type Influxdb struct {
 	Option   comm.Option
 	Database string
+
+	// WatchInterval 监听设备数据的轮询间隔
+	WatchInterval time.Duration
+
+	once       sync.Once
+	httpClient *http.Client
+	stop       chan struct{}
+	closeOnce  sync.Once
 }
 
-type ReportReq struct {
-	Timestamp  int64 //
-	Metric     string
-	Dimensions map[string]string
-	Value      float64
+// writeResult 写入结果，influxdb 不返回自增ID
+type writeResult int64
+
+func (r writeResult) LastInsertId() (int64, error) {
+	return 0, nil
 }
 
-func (m *Influxdb) client() {
+func (r writeResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
 
-	return
+func (m *Influxdb) client() *http.Client {
+	m.once.Do(func() {
+		m.httpClient = &http.Client{Timeout: defaultTimeout}
+		m.stop = make(chan struct{})
+	})
+	return m.httpClient
 }
 
+// bucket 数据写入的bucket，对应配置中的数据库名称
+func (m *Influxdb) bucket() string {
+	if m.Database != "" {
+		return m.Database
+	}
+	return m.Option.Database
+}
+
+// from 指定bucket的Flux查询开头
+func (m *Influxdb) from() string {
+	return "from(bucket: " + fluxString(m.bucket()) + ")"
+}
+
+// Close 释放空闲连接。tsd.DB 返回的是进程共享的实例，每次使用后都会调用 Close，监听等后台任务在 Shutdown 时停止
 func (m *Influxdb) Close() {
-	return
+	m.client().CloseIdleConnections()
 }
-func (m *Influxdb) Query(sql string) (rows *sql.Rows, err error) {
 
-	return
+// Shutdown 停止监听设备数据的后台任务并释放连接，仅在服务停止时调用
+func (m *Influxdb) Shutdown() {
+	m.client()
+	m.closeOnce.Do(func() {
+		close(m.stop)
+		m.httpClient.CloseIdleConnections()
+	})
+}
+
+func (m *Influxdb) Query(sql string) (rows *sql.Rows, err error) {
+	return nil, ErrSqlNotSupported
 }
-func (m *Influxdb) Count(table string) (int, error) {
 
-	return 50, nil
+// Count 统计表中数据条数，按时间点去重计数
+func (m *Influxdb) Count(table string) (int, error) {
+	flux := m.from() + ` |> range(start: 0) |> filter(fn: (r) => r._measurement == ` + fluxString(table) + `)` +
+		` |> group() |> keep(columns: ["_time"]) |> distinct(column: "_time") |> count()`
+	rs, err := m.query(context.Background(), flux)
+	if err != nil || rs.IsEmpty() {
+		return 0, err
+	}
+	return rs[0]["_value"].Int(), nil
 }
-func (m *Influxdb) InsertLogData(log iotModel.DeviceLog) (result sql.Result, err error) {
 
-	return
+// InsertLogData 插入日志数据
+func (m *Influxdb) InsertLogData(log iotModel.DeviceLog) (result sql.Result, err error) {
+	ts := time.Now().UnixMilli()
+	if log.Ts != nil {
+		ts = log.Ts.TimestampMilli()
+	}
+	if err = m.write(context.Background(), []string{logLine(log, ts)}); err != nil {
+		return
+	}
+	return writeResult(1), nil
+}
 
+// BatchInsertLogData 批量插入日志数据
+func (m *Influxdb) BatchInsertLogData(deviceLogList map[string][]iotModel.DeviceLog) (resultNum int, err error) {
+	if len(deviceLogList) == 0 {
+		return
+	}
+	ts := time.Now().UnixMilli()
+	lines := make([]string, 0, len(deviceLogList))
+	for deviceKey, list := range deviceLogList {
+		for _, log := range list {
+			if log.Device == "" {
+				log.Device = deviceKey
+			}
+			// 同一设备同一毫秒的数据会被覆盖，时间依次递增
+			ts++
+			lines = append(lines, logLine(log, ts))
+		}
+	}
+	if err = m.write(context.Background(), lines); err != nil {
+		return
+	}
+	return len(lines), nil
 }
-func (m *Influxdb) BatchInsertLogData(ddeviceLogList map[string][]iotModel.DeviceLog) (resultNum int, err error) {
 
-	return
+func logLine(log iotModel.DeviceLog, ts int64) string {
+	return line(comm.DeviceLogTable(log.Device), map[string]string{"device": log.Device}, map[string]interface{}{
+		"type":    log.Type,
+		"content": log.Content,
+	}, ts)
 }
 
 // GetAllDatabaseName 获取所有数据库名称
 func (m *Influxdb) GetAllDatabaseName(ctx context.Context) (names []string, err error) {
-
+	rs, err := m.query(ctx, `buckets()`)
+	if err != nil {
+		return
+	}
+	for _, rc := range rs {
+		names = append(names, rc["name"].String())
+	}
 	return
 }
 
 // GetTableListByDatabase 获取指定的数据库下所有的表
 func (m *Influxdb) GetTableListByDatabase(ctx context.Context, dbName string) (tableList []iotModel.TsdTables, err error) {
-
+	rs, err := m.query(ctx, `import "influxdata/influxdb/schema" schema.measurements(bucket: `+fluxString(dbName)+`)`)
+	if err != nil {
+		return
+	}
+	for _, rc := range rs {
+		tableList = append(tableList, iotModel.TsdTables{
+			DbName:    dbName,
+			TableName: rc["_value"].String(),
+		})
+	}
 	return
 }
 
 // GetTableInfo 获取指定数据表结构信息
 func (m *Influxdb) GetTableInfo(ctx context.Context, tableName string) (table []*iotModel.TsdTableInfo, err error) {
+	bucket := fluxString(m.bucket())
+	measurement := fluxString(tableName)
+	table = append(table, &iotModel.TsdTableInfo{Field: "ts", Type: "TIMESTAMP"})
+
+	fields, err := m.query(ctx, `import "influxdata/influxdb/schema" schema.measurementFieldKeys(bucket: `+bucket+`, measurement: `+measurement+`, start: 0)`)
+	if err != nil {
+		return
+	}
+	for _, rc := range fields {
+		table = append(table, &iotModel.TsdTableInfo{Field: rc["_value"].String(), Type: "FIELD"})
+	}
+	tags, err := m.query(ctx, `import "influxdata/influxdb/schema" schema.measurementTagKeys(bucket: `+bucket+`, measurement: `+measurement+`, start: 0)`)
+	if err != nil {
+		return
+	}
+	for _, rc := range tags {
+		name := rc["_value"].String()
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+		table = append(table, &iotModel.TsdTableInfo{Field: name, Type: "TAG", Note: "TAG"})
+	}
 	return
 }
 
 // GetTableData 获取指定数据表数据信息
 func (m *Influxdb) GetTableData(ctx context.Context, tableName string) (table *iotModel.TsdTableDataInfo, err error) {
-
+	flux := m.from() + ` |> range(start: 0) |> filter(fn: (r) => r._measurement == ` + fluxString(tableName) + `)` +
+		` |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")` +
+		` |> group() |> sort(columns: ["_time"], desc: true) |> limit(n: 1000)`
+	rs, err := m.query(ctx, flux)
+	if err != nil {
+		return
+	}
+	table = new(iotModel.TsdTableDataInfo)
+	fieldSet := map[string]struct{}{}
+	for _, rc := range normalize(rs) {
+		row := make(map[string]interface{}, len(rc))
+		for k, v := range rc {
+			if _, ok := fieldSet[k]; !ok {
+				fieldSet[k] = struct{}{}
+				table.Filed = append(table.Filed, k)
+			}
+			row[k] = v.Val()
+		}
+		table.Info = append(table.Info, row)
+	}
+	sort.Strings(table.Filed)
 	return
 }
 
-// GetTableDataOne 获取超级表的单条数据
+// GetTableDataOne 获取超级表的单条数据，sqlStr 为Flux查询语句
 func (m *Influxdb) GetTableDataOne(ctx context.Context, sqlStr string, args ...any) (rs gdb.Record, err error) {
-
-	return
+	list, err := m.GetTableDataAll(ctx, sqlStr, args...)
+	if err != nil || list.IsEmpty() {
+		return
+	}
+	return list[0], nil
 }
 
-// GetTableDataAll 获取超级表的多条数据
+// GetTableDataAll 获取超级表的多条数据，sqlStr 为Flux查询语句，? 依次替换为参数，SQL语句直接返回 ErrSqlNotSupported
 func (m *Influxdb) GetTableDataAll(ctx context.Context, sqlStr string, args ...any) (rs gdb.Result, err error) {
+	if isSql(sqlStr) {
+		return nil, ErrSqlNotSupported
+	}
+	list, err := m.query(ctx, bindArgs(sqlStr, args...))
+	if err != nil {
+		return
+	}
+	return normalize(list), nil
+}
 
+// normalize 与tdengine的查询结果保持一致：_time 转为 ts，去除属性前缀和influxdb的内部列
+func normalize(list gdb.Result) (rs gdb.Result) {
+	for _, rc := range list {
+		newRc := make(gdb.Record, len(rc))
+		for k, v := range rc {
+			switch k {
+			case "_time":
+				if t, ok := v.Val().(time.Time); ok {
+					newRc["ts"] = g.NewVar(gtime.New(t))
+					continue
+				}
+				newRc["ts"] = v
+				continue
+			case "_start", "_stop", "_measurement":
+				continue
+			}
+			newRc[strings.TrimPrefix(k, comm.TdPropertyPrefix)] = v
+		}
+		rs = append(rs, newRc)
+	}
 	return
 }
+
+// isSql 查询语句是否为SQL
+func isSql(s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, k := range sqlKeywords {
+		if strings.HasPrefix(s, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,276 @@
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// write 通过 /api/v2/write 写入行协议数据，时间精度为毫秒
+func (m *Influxdb) write(ctx context.Context, lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	params := url.Values{}
+	params.Set("org", m.Option.Org)
+	params.Set("bucket", m.bucket())
+	params.Set("precision", "ms")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint("/api/v2/write", params), strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	return m.do(req, nil)
+}
+
+// query 通过 /api/v2/query 执行Flux查询，结果按带数据类型注解的csv解析
+func (m *Influxdb) query(ctx context.Context, flux string) (gdb.Result, error) {
+	body, _ := json.Marshal(g.Map{
+		"query": flux,
+		"type":  "flux",
+		"dialect": g.Map{
+			"header":      true,
+			"annotations": []string{"datatype"},
+		},
+	})
+	params := url.Values{}
+	params.Set("org", m.Option.Org)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint("/api/v2/query", params), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/csv")
+	var rs gdb.Result
+	err = m.do(req, func(r io.Reader) (parseErr error) {
+		rs, parseErr = parseCsv(r)
+		return
+	})
+	return rs, err
+}
+
+func (m *Influxdb) endpoint(path string, params url.Values) string {
+	return strings.TrimRight(m.Option.Link, "/") + path + "?" + params.Encode()
+}
+
+func (m *Influxdb) do(req *http.Request, parse func(io.Reader) error) error {
+	if m.Option.Token != "" {
+		req.Header.Set("Authorization", "Token "+m.Option.Token)
+	}
+	resp, err := m.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &e) == nil && e.Message != "" {
+			return fmt.Errorf("influxdb %d %s: %s", resp.StatusCode, e.Code, e.Message)
+		}
+		return fmt.Errorf("influxdb %d: %s", resp.StatusCode, string(data))
+	}
+	if parse == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return parse(resp.Body)
+}
+
+// parseCsv 解析查询结果，多个表之间以新的注解行和表头分隔
+func parseCsv(r io.Reader) (rs gdb.Result, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = false
+
+	var types, header []string
+	for {
+		row, readErr := reader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+		if len(row) == 0 {
+			continue
+		}
+		if row[0] == "#datatype" {
+			types, header = row, nil
+			continue
+		}
+		if strings.HasPrefix(row[0], "#") {
+			continue
+		}
+		if header == nil {
+			header = row
+			continue
+		}
+		if row[0] == "error" || (len(header) > 1 && header[1] == "error") {
+			return nil, fmt.Errorf("influxdb query error: %s", strings.Join(row, ","))
+		}
+		record := make(gdb.Record, len(header))
+		for i, name := range header {
+			if i >= len(row) || name == "" || name == "result" || name == "table" {
+				continue
+			}
+			var t string
+			if i < len(types) {
+				t = types[i]
+			}
+			record[name] = g.NewVar(convertValue(t, row[i]))
+		}
+		rs = append(rs, record)
+	}
+	return
+}
+
+// convertValue 按注解中的数据类型转换csv中的值
+func convertValue(t, s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	switch t {
+	case "long":
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return v
+		}
+	case "unsignedLong":
+		if v, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return v
+		}
+	case "double":
+		if v, err := strconv.ParseFloat(s, 64); err == nil {
+			return v
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(s); err == nil {
+			return v
+		}
+	case "dateTime:RFC3339", "dateTime:RFC3339Nano":
+		if v, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return v.Local()
+		}
+	}
+	return s
+}
+
+// escapeKey 转义行协议中的measurement、tag和field名称
+func escapeKey(s string) string {
+	return keyEscaper.Replace(s)
+}
+
+var keyEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+
+var stringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// fieldValue 行协议的字段值，数值统一按浮点写入，避免同一字段类型冲突
+func fieldValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return `""`
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case int:
+		return strconv.FormatInt(int64(val), 10)
+	case int8:
+		return strconv.FormatInt(int64(val), 10)
+	case int16:
+		return strconv.FormatInt(int64(val), 10)
+	case int32:
+		return strconv.FormatInt(int64(val), 10)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case uint:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint64:
+		return strconv.FormatUint(val, 10)
+	case string:
+		return `"` + stringEscaper.Replace(val) + `"`
+	case json.Number:
+		if _, err := val.Float64(); err == nil {
+			return val.String()
+		}
+		return `"` + stringEscaper.Replace(val.String()) + `"`
+	}
+	data, _ := json.Marshal(v)
+	return `"` + stringEscaper.Replace(string(data)) + `"`
+}
+
+// line 生成一行行协议数据，字段按名称排序保证输出稳定
+func line(measurement string, tags map[string]string, fields map[string]interface{}, ts int64) string {
+	var b strings.Builder
+	b.WriteString(escapeKey(measurement))
+	for _, k := range sortedKeys(tags) {
+		if tags[k] == "" {
+			continue
+		}
+		b.WriteString("," + escapeKey(k) + "=" + escapeKey(tags[k]))
+	}
+	for i, k := range sortedKeys(fields) {
+		if i == 0 {
+			b.WriteString(" ")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(escapeKey(k) + "=" + fieldValue(fields[k]))
+	}
+	b.WriteString(" " + strconv.FormatInt(ts, 10))
+	return b.String()
+}
+
+// fluxString Flux字符串字面量
+func fluxString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`).Replace(s) + `"`
+}
+
+// bindArgs 把查询语句中的 ? 依次替换为参数，字符串参数按Flux字符串转义
+func bindArgs(query string, args ...any) string {
+	if len(args) == 0 {
+		return query
+	}
+	var b strings.Builder
+	i := 0
+	for _, c := range query {
+		if c == '?' && i < len(args) {
+			switch v := args[i].(type) {
+			case string:
+				b.WriteString(fluxString(v))
+			case time.Time:
+				b.WriteString(v.UTC().Format(time.RFC3339Nano))
+			default:
+				b.WriteString(fmt.Sprint(v))
+			}
+			i++
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -1,27 +1,155 @@
 package influxdb
 
 import (
+	"context"
+	"errors"
 	"sagooiot/pkg/iotModel"
+	"sagooiot/pkg/tsd/comm"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gtime"
 )
 
+// defaultWatchInterval 监听设备数据的默认轮询间隔
+const defaultWatchInterval = 5 * time.Second
+
+// deviceLine 设备数据的行协议，字段与tdengine一致：属性值 p_xxx 和上报时间 p_xxx_time
+func deviceLine(deviceKey string, data iotModel.ReportPropertyData, ts int64) string {
+	fields := make(map[string]interface{}, len(data)*2)
+	for k, v := range data {
+		column := comm.TsdColumnName(k)
+		fields[column] = v.Value
+		fields[column+"_time"] = gtime.New(v.CreateTime).Format("Y-m-d H:i:s")
+	}
+	return line(comm.DeviceTableName(deviceKey), map[string]string{"device": deviceKey}, fields, ts)
+}
+
 // InsertDeviceData 插入设备数据
 func (m *Influxdb) InsertDeviceData(deviceKey string, data iotModel.ReportPropertyData, subKey ...string) (err error) {
-
-	return
+	if len(data) == 0 {
+		err = errors.New("数据为空")
+		return
+	}
+	if len(subKey) > 0 {
+		// 子设备
+		deviceKey = subKey[0]
+	}
+	return m.write(context.Background(), []string{deviceLine(deviceKey, data, time.Now().UnixMilli())})
 }
 
 // BatchInsertDeviceData 批量插入单设备的数据
 func (m *Influxdb) BatchInsertDeviceData(deviceKey string, deviceDataList []iotModel.ReportPropertyData) (resultNum int, err error) {
-	return
+	if len(deviceDataList) == 0 {
+		err = errors.New("数据为空")
+		return
+	}
+	return m.BatchInsertMultiDeviceData(map[string][]iotModel.ReportPropertyData{deviceKey: deviceDataList})
 }
 
 // BatchInsertMultiDeviceData 批量插入多设备的数据
 func (m *Influxdb) BatchInsertMultiDeviceData(multiDeviceDataList map[string][]iotModel.ReportPropertyData) (resultNum int, err error) {
-	return
+	if len(multiDeviceDataList) == 0 {
+		err = errors.New("数据为空")
+		return
+	}
+	ts := time.Now().UnixMilli()
+	var lines []string
+	for deviceKey, list := range multiDeviceDataList {
+		for _, data := range list {
+			if len(data) == 0 {
+				continue
+			}
+			// 同一设备同一毫秒的数据会被覆盖，时间依次递增
+			ts++
+			lines = append(lines, deviceLine(deviceKey, data, ts))
+		}
+	}
+	if err = m.write(context.Background(), lines); err != nil {
+		return
+	}
+	return len(lines), nil
 }
 
-// 监听设备数据日志
+// WatchDeviceData 监听设备数据日志，influxdb 没有订阅能力，按间隔轮询新写入的数据，Shutdown 后停止
 func (m *Influxdb) WatchDeviceData(deviceKey string, callback func(data iotModel.ReportPropertyData)) (err error) {
+	if callback == nil {
+		return errors.New("callback is nil")
+	}
+	m.client()
+	interval := m.WatchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	go func() {
+		last := time.Now()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+			}
+			list, queryErr := m.deviceDataSince(deviceKey, last)
+			if queryErr != nil {
+				g.Log().Errorf(context.Background(), "watch influxdb device data error: %v, deviceKey:%s", queryErr, deviceKey)
+				continue
+			}
+			for _, item := range list {
+				if item.ts.After(last) {
+					last = item.ts
+				}
+				callback(item.data)
+			}
+		}
+	}()
+	return
+}
+
+type deviceData struct {
+	ts   time.Time
+	data iotModel.ReportPropertyData
+}
 
+// deviceDataSince 查询指定时间之后写入的设备数据
+func (m *Influxdb) deviceDataSince(deviceKey string, since time.Time) (list []deviceData, err error) {
+	flux := m.from() + ` |> range(start: ` + since.Add(time.Millisecond).UTC().Format(time.RFC3339Nano) + `)` +
+		` |> filter(fn: (r) => r._measurement == ` + fluxString(comm.DeviceTableName(deviceKey)) + `)` +
+		` |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")` +
+		` |> group() |> sort(columns: ["_time"])`
+	rs, err := m.query(context.Background(), flux)
+	if err != nil {
+		return
+	}
+	for _, rc := range rs {
+		ts, ok := rc["_time"].Val().(time.Time)
+		if !ok {
+			continue
+		}
+		data := make(iotModel.ReportPropertyData)
+		for k, v := range rc {
+			if !strings.HasPrefix(k, comm.TdPropertyPrefix) || v.IsNil() {
+				continue
+			}
+			// 跳过属性的上报时间列 p_xxx_time，属性本身以 _time 结尾时（如 p_run_time）仍保留
+			if prop := strings.TrimSuffix(k, "_time"); prop != k {
+				if _, ok := rc[prop]; ok {
+					continue
+				}
+			}
+			node := iotModel.ReportPropertyNode{Value: v.Val(), CreateTime: ts.Unix()}
+			if t := rc[k+"_time"]; t != nil && !t.IsNil() {
+				if ct, parseErr := gtime.StrToTime(t.String()); parseErr == nil {
+					node.CreateTime = ct.Unix()
+				}
+			}
+			data[strings.TrimPrefix(k, comm.TdPropertyPrefix)] = node
+		}
+		if len(data) > 0 {
+			list = append(list, deviceData{ts: ts, data: data})
+		}
+	}
 	return
 }
@@ -0,0 +1,220 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sagooiot/pkg/iotModel"
+	"sagooiot/pkg/tsd/comm"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeInfluxdb 模拟influxdb的写入和查询接口
+type fakeInfluxdb struct {
+	sync.Mutex
+	writes  []string
+	queries []string
+	csv     string
+	// csvs 依次返回的查询结果，为空时返回 csv
+	csvs []string
+}
+
+func (f *fakeInfluxdb) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/write", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":"unauthorized","message":"unauthorized access"}`))
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("org") != "sagoo" || q.Get("bucket") != "sagooiot" || q.Get("precision") != "ms" {
+			t.Errorf("write params got %s", r.URL.RawQuery)
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.Lock()
+		f.writes = append(f.writes, strings.Split(string(body), "\n")...)
+		f.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/api/v2/query", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string `json:"query"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.Lock()
+		f.queries = append(f.queries, req.Query)
+		csv := f.csv
+		if len(f.csvs) > 0 {
+			csv, f.csvs = f.csvs[0], f.csvs[1:]
+		}
+		f.Unlock()
+		w.Header().Set("Content-Type", "text/csv")
+		_, _ = w.Write([]byte(csv))
+	})
+	return mux
+}
+
+func newTestDb(t *testing.T) (*Influxdb, *fakeInfluxdb) {
+	fake := &fakeInfluxdb{}
+	svr := httptest.NewServer(fake.handler(t))
+	t.Cleanup(svr.Close)
+	db := &Influxdb{Option: comm.Option{Link: svr.URL, Org: "sagoo", Token: "token", Database: "sagooiot"}}
+	t.Cleanup(db.Shutdown)
+	return db, fake
+}
+
+func TestInsertDeviceData(t *testing.T) {
+	db, fake := newTestDb(t)
+	err := db.InsertDeviceData("Dev-1", iotModel.ReportPropertyData{
+		"temp": {Value: 25.5, CreateTime: 1700000000},
+		"name": {Value: `a "b"`, CreateTime: 1700000000},
+	})
+	if err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+	if len(fake.writes) != 1 {
+		t.Fatalf("got %d lines, want 1", len(fake.writes))
+	}
+	l := fake.writes[0]
+	if !strings.HasPrefix(l, "device_dev_1,device=Dev-1 p_name=\"a \\\"b\\\"\",p_name_time=") || !strings.Contains(l, ",p_temp=25.5,p_temp_time=") {
+		t.Fatalf("line got %s", l)
+	}
+	if err = db.InsertDeviceData("Dev-1", nil); err == nil {
+		t.Fatalf("insert empty data should fail")
+	}
+}
+
+func TestBatchInsert(t *testing.T) {
+	db, fake := newTestDb(t)
+	n, err := db.BatchInsertMultiDeviceData(map[string][]iotModel.ReportPropertyData{
+		"d1": {{"a": {Value: 1}}, {"a": {Value: 2}}},
+		"d2": {{"b": {Value: true}}},
+	})
+	if err != nil || n != 3 {
+		t.Fatalf("batch insert got %d, %v", n, err)
+	}
+	// 同一批次的时间戳依次递增
+	seen := map[string]bool{}
+	for _, l := range fake.writes {
+		ts := l[strings.LastIndex(l, " ")+1:]
+		if seen[ts] {
+			t.Fatalf("duplicate timestamp in %q", fake.writes)
+		}
+		seen[ts] = true
+	}
+	n, err = db.BatchInsertDeviceData("d3", []iotModel.ReportPropertyData{{"c": {Value: 1}}})
+	if err != nil || n != 1 {
+		t.Fatalf("batch insert device got %d, %v", n, err)
+	}
+
+	n, err = db.BatchInsertLogData(map[string][]iotModel.DeviceLog{"d1": {{Type: "属性上报", Content: "{}"}}})
+	if err != nil || n != 1 {
+		t.Fatalf("batch insert log got %d, %v", n, err)
+	}
+	if l := fake.writes[len(fake.writes)-1]; !strings.HasPrefix(l, `log_d1,device=d1 content="{}",type="属性上报" `) {
+		t.Fatalf("log line got %s", l)
+	}
+}
+
+func TestInsertLogDataUnauthorized(t *testing.T) {
+	db, _ := newTestDb(t)
+	db.Option.Token = "bad"
+	_, err := db.InsertLogData(iotModel.DeviceLog{Device: "d1", Type: "t", Content: "c"})
+	if err == nil || !strings.Contains(err.Error(), "unauthorized access") {
+		t.Fatalf("insert log got err %v", err)
+	}
+}
+
+const queryCsv = "#datatype,string,long,dateTime:RFC3339,double,string,boolean\r\n" +
+	",result,table,_time,p_temp,device,p_on\r\n" +
+	",_result,0,2024-01-01T00:00:00Z,25.5,d1,true\r\n" +
+	",_result,0,2024-01-01T00:00:01Z,26,d1,false\r\n" +
+	"\r\n" +
+	"#datatype,string,long,dateTime:RFC3339,double,string,boolean\r\n" +
+	",result,table,_time,p_temp,device,p_on\r\n" +
+	",_result,1,2024-01-01T00:00:02Z,27,d2,\r\n"
+
+func TestGetTableDataAll(t *testing.T) {
+	db, fake := newTestDb(t)
+	fake.csv = queryCsv
+	rs, err := db.GetTableDataAll(context.Background(), `from(bucket: "sagooiot") |> filter(fn: (r) => r.device == ?)`, `d"1`)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if want := `from(bucket: "sagooiot") |> filter(fn: (r) => r.device == "d\"1")`; fake.queries[0] != want {
+		t.Fatalf("query got %s, want %s", fake.queries[0], want)
+	}
+	if len(rs) != 3 {
+		t.Fatalf("got %d records, want 3", len(rs))
+	}
+	if rs[0]["temp"].Float64() != 25.5 || !rs[0]["on"].Bool() || rs[1]["on"].Bool() {
+		t.Fatalf("record got %v", rs[0])
+	}
+	if rs[0]["ts"].GTime().Time.UTC().Format("2006-01-02 15:04:05") != "2024-01-01 00:00:00" {
+		t.Fatalf("ts got %v", rs[0]["ts"])
+	}
+	if !rs[2]["on"].IsNil() || rs[2]["device"].String() != "d2" {
+		t.Fatalf("record got %v", rs[2])
+	}
+
+	one, err := db.GetTableDataOne(context.Background(), `from(bucket: "sagooiot")`)
+	if err != nil || one["temp"].Float64() != 25.5 {
+		t.Fatalf("query one got %v, %v", one, err)
+	}
+
+	n := len(fake.queries)
+	if _, err = db.GetTableDataOne(context.Background(), "select p_temp from device_d1 where p_temp is not null"); !errors.Is(err, ErrSqlNotSupported) {
+		t.Fatalf("sql query got %v", err)
+	}
+	if len(fake.queries) != n {
+		t.Fatal("sql query should not be sent")
+	}
+
+	fake.csv = "#datatype,string,long,long\r\n,result,table,_value\r\n,_result,0,42\r\n"
+	if n, err := db.Count("device_d1"); err != nil || n != 42 {
+		t.Fatalf("count got %d, %v", n, err)
+	}
+}
+
+func TestWatchDeviceData(t *testing.T) {
+	db, fake := newTestDb(t)
+	db.WatchInterval = 10 * time.Millisecond
+	fake.csv = queryCsv
+
+	got := make(chan iotModel.ReportPropertyData, 3)
+	if err := db.WatchDeviceData("d1", func(data iotModel.ReportPropertyData) { got <- data }); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	select {
+	case data := <-got:
+		if data["temp"].Value != 25.5 || data["on"].Value != true {
+			t.Fatalf("watch data got %v", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("watch data not received")
+	}
+}
+
+func TestDeviceDataSinceTimeProperty(t *testing.T) {
+	db, fake := newTestDb(t)
+	fake.csv = "#datatype,string,long,dateTime:RFC3339,double,string,long,string\r\n" +
+		",result,table,_time,p_temp,p_temp_time,p_run_time,p_run_time_time\r\n" +
+		",_result,0,2024-01-01T00:00:00Z,25.5,2024-01-01 08:00:00,3600,2024-01-01 08:00:00\r\n"
+	list, err := db.deviceDataSince("d1", time.Unix(0, 0))
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(list) != 1 || len(list[0].data) != 2 {
+		t.Fatalf("device data got %v", list)
+	}
+	if _, ok := list[0].data["run_time"]; !ok {
+		t.Fatalf("property run_time dropped: %v", list[0].data)
+	}
+}
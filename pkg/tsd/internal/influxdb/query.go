@@ -0,0 +1,118 @@
+package influxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sagooiot/pkg/tsd/comm"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+)
+
+// fluxAggregates 聚合函数对应的Flux函数
+var fluxAggregates = map[string]string{
+	comm.AggAvg:    "mean",
+	comm.AggMin:    "min",
+	comm.AggMax:    "max",
+	comm.AggSum:    "sum",
+	comm.AggCount:  "count",
+	comm.AggFirst:  "first",
+	comm.AggLast:   "last",
+	comm.AggSpread: "spread",
+}
+
+// QueryData 按查询条件查询时序数据，返回当前页数据和总数
+func (m *Influxdb) QueryData(ctx context.Context, q *comm.Query) (list gdb.Result, total int, err error) {
+	flux, countFlux, args, err := m.compileQuery(q)
+	if err != nil {
+		return
+	}
+	rs, err := m.GetTableDataOne(ctx, countFlux, args[:len(args)-2]...)
+	if err != nil {
+		return
+	}
+	if rs != nil {
+		total = rs["num"].Int()
+	}
+	if list, err = m.GetTableDataAll(ctx, flux, args...); err != nil {
+		return
+	}
+	if q.Grouped() {
+		for _, rc := range list {
+			delete(rc, "ts")
+		}
+	}
+	return q.Restore(list), total, nil
+}
+
+// compileQuery 把查询条件编译为Flux查询，时间、设备、属性和分页均作为参数按Flux字面量绑定。
+// influxdb 中没有产品标签，需指定设备；返回数据查询、总数查询和数据查询的参数，总数查询使用去掉最后两个分页参数的参数
+func (m *Influxdb) compileQuery(q *comm.Query) (flux, countFlux string, args []any, err error) {
+	if err = q.Validate(); err != nil {
+		return
+	}
+	if len(q.DeviceKeys) == 0 {
+		return "", "", nil, errors.New("influxdb 查询时请指定设备")
+	}
+
+	var b strings.Builder
+	if q.Fill == comm.FillLinear {
+		b.WriteString(`import "interpolate" `)
+	}
+	b.WriteString(m.from() + ` |> range(start: ?, stop: ?)`)
+	args = append(args, q.Start, q.End)
+	b.WriteString(` |> filter(fn: (r) => contains(value: r.device, set: [` + placeholders(len(q.DeviceKeys)) + `]))`)
+	for _, k := range q.DeviceKeys {
+		args = append(args, k)
+	}
+	b.WriteString(` |> filter(fn: (r) => contains(value: r._field, set: [` + placeholders(len(q.Properties)) + `]))`)
+	for _, p := range q.Properties {
+		args = append(args, comm.TsdColumnName(p))
+	}
+
+	fn := fluxAggregates[q.Aggregate]
+	switch {
+	case q.Windowed():
+		every := strconv.FormatInt(int64(q.Interval/time.Second), 10) + "s"
+		createEmpty := q.Fill != "" && q.Fill != comm.FillNone && q.Fill != comm.FillLinear
+		b.WriteString(fmt.Sprintf(` |> aggregateWindow(every: %s, fn: %s, createEmpty: %t, timeSrc: "_start")`, every, fn, createEmpty))
+		switch q.Fill {
+		case comm.FillPrev:
+			b.WriteString(` |> fill(usePrevious: true)`)
+		case comm.FillValue:
+			b.WriteString(` |> fill(value: ` + fillValue(q) + `)`)
+		case comm.FillLinear:
+			b.WriteString(` |> interpolate.linear(every: ` + every + `)`)
+		}
+	case q.Aggregate == comm.AggDiff:
+		b.WriteString(` |> difference()`)
+	case q.Grouped():
+		b.WriteString(` |> ` + fn + `() |> map(fn: (r) => ({r with _time: r._start}))`)
+	}
+	b.WriteString(` |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value") |> group()`)
+
+	countFlux = b.String() + ` |> count(column: "_time") |> rename(columns: {_time: "num"})`
+	b.WriteString(fmt.Sprintf(` |> sort(columns: ["_time"], desc: %t) |> limit(n: ?, offset: ?)`, !q.Asc))
+	args = append(args, q.Limit, q.Offset)
+	return b.String(), countFlux, args, nil
+}
+
+// placeholders n个以逗号分隔的参数占位符
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// fillValue 填充值字面量，count 的结果为整数，其他聚合结果为浮点数
+func fillValue(q *comm.Query) string {
+	if q.Aggregate == comm.AggCount {
+		return strconv.FormatInt(int64(q.FillValue), 10)
+	}
+	s := strconv.FormatFloat(q.FillValue, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
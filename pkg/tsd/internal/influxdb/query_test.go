@@ -0,0 +1,110 @@
+package influxdb
+
+import (
+	"context"
+	"sagooiot/pkg/tsd/comm"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryData(t *testing.T) {
+	db, fake := newTestDb(t)
+	fake.csvs = []string{
+		"#datatype,string,long,long\r\n,result,table,num\r\n,_result,0,2\r\n",
+		"#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,string,double\r\n" +
+			",result,table,_start,_stop,_time,device,p_temp\r\n" +
+			",_result,0,2024-01-01T00:00:00Z,2024-01-01T01:00:00Z,2024-01-01T00:01:00Z,d1,25.5\r\n" +
+			",_result,0,2024-01-01T00:00:00Z,2024-01-01T01:00:00Z,2024-01-01T00:00:00Z,d1,26\r\n",
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := &comm.Query{
+		ProductKey: "p1",
+		DeviceKeys: []string{"d1", `d"2`},
+		Properties: []string{"Temp"},
+		Start:      start,
+		End:        start.Add(time.Hour),
+		Aggregate:  comm.AggAvg,
+		Interval:   time.Minute,
+		Fill:       comm.FillPrev,
+		Limit:      10,
+		Offset:     20,
+	}
+	if _, _, err := db.QueryData(context.Background(), q); err == nil {
+		t.Fatal("expected invalid device key error")
+	}
+
+	q.DeviceKeys = []string{"d1", "d-2"}
+	list, total, err := db.QueryData(context.Background(), q)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if total != 2 || len(list) != 2 {
+		t.Fatalf("got total %d, %d records", total, len(list))
+	}
+	if list[0]["Temp"].Float64() != 25.5 || list[0]["device"].String() != "d1" || list[0]["ts"].IsNil() {
+		t.Fatalf("record got %v", list[0])
+	}
+
+	base := `from(bucket: "sagooiot") |> range(start: 2024-01-01T00:00:00Z, stop: 2024-01-01T01:00:00Z)` +
+		` |> filter(fn: (r) => contains(value: r.device, set: ["d1", "d-2"]))` +
+		` |> filter(fn: (r) => contains(value: r._field, set: ["p_temp"]))` +
+		` |> aggregateWindow(every: 60s, fn: mean, createEmpty: true, timeSrc: "_start") |> fill(usePrevious: true)` +
+		` |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value") |> group()`
+	if want := base + ` |> count(column: "_time") |> rename(columns: {_time: "num"})`; fake.queries[0] != want {
+		t.Errorf("count query got\n%s\nwant\n%s", fake.queries[0], want)
+	}
+	if want := base + ` |> sort(columns: ["_time"], desc: true) |> limit(n: 10, offset: 20)`; fake.queries[1] != want {
+		t.Errorf("query got\n%s\nwant\n%s", fake.queries[1], want)
+	}
+}
+
+func TestCompileQuery(t *testing.T) {
+	db, _ := newTestDb(t)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := comm.Query{DeviceKeys: []string{"d1"}, Properties: []string{"temp"}, Start: start, End: start.Add(time.Hour), Limit: 10}
+
+	cases := []struct {
+		set  func(q *comm.Query)
+		want string
+	}{
+		{func(q *comm.Query) {}, `|> pivot(`},
+		{func(q *comm.Query) { q.Aggregate = comm.AggDiff }, `|> difference() |> pivot(`},
+		{func(q *comm.Query) { q.Aggregate = comm.AggMax }, `|> max() |> map(fn: (r) => ({r with _time: r._start})) |> pivot(`},
+		{
+			set:  func(q *comm.Query) { q.Aggregate, q.Interval, q.Fill = comm.AggCount, time.Hour, comm.FillValue },
+			want: `|> aggregateWindow(every: 3600s, fn: count, createEmpty: true, timeSrc: "_start") |> fill(value: 0) |> pivot(`,
+		},
+		{
+			set: func(q *comm.Query) {
+				q.Aggregate, q.Interval, q.Fill, q.FillValue = comm.AggMin, time.Second, comm.FillValue, 2
+			},
+			want: `|> fill(value: 2.0) |> pivot(`,
+		},
+		{
+			set:  func(q *comm.Query) { q.Aggregate, q.Interval, q.Fill = comm.AggLast, time.Minute, comm.FillLinear },
+			want: `createEmpty: false, timeSrc: "_start") |> interpolate.linear(every: 60s) |> pivot(`,
+		},
+	}
+	for i, c := range cases {
+		query := q
+		c.set(&query)
+		flux, _, _, err := db.compileQuery(&query)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if !strings.Contains(flux, c.want) {
+			t.Errorf("case %d: %s not in %s", i, c.want, flux)
+		}
+		if strings.HasPrefix(flux, `import "interpolate"`) != (query.Fill == comm.FillLinear) {
+			t.Errorf("case %d: unexpected import in %s", i, flux)
+		}
+	}
+
+	q.DeviceKeys = nil
+	q.ProductKey = "p1"
+	if _, _, _, err := db.compileQuery(&q); err == nil {
+		t.Fatal("expected device keys required")
+	}
+}
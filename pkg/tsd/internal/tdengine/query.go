@@ -0,0 +1,112 @@
+package tdengine
+
+import (
+	"context"
+	"fmt"
+	"sagooiot/pkg/tsd/comm"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/database/gdb"
+)
+
+// QueryData 按查询条件查询时序数据，返回当前页数据和总数
+func (m *TdEngine) QueryData(ctx context.Context, q *comm.Query) (list gdb.Result, total int, err error) {
+	sqlStr, countSql, args, err := compileQuery(q)
+	if err != nil {
+		return
+	}
+	rs, err := m.GetTableDataOne(ctx, countSql, args[:len(args)-2]...)
+	if err != nil {
+		return
+	}
+	if rs != nil {
+		total = rs["num"].Int()
+	}
+	if list, err = m.GetTableDataAll(ctx, sqlStr, args...); err != nil {
+		return
+	}
+	return q.Restore(list), total, nil
+}
+
+// compileQuery 把查询条件编译为参数化的SQL，表名和字段名已在校验时限定字符，时间、设备和分页均作为参数传入。
+// 产品范围查询超级表，聚合时按设备分区；返回数据查询、总数查询和数据查询的参数，总数查询使用去掉最后两个分页参数的参数
+func compileQuery(q *comm.Query) (sqlStr, countSql string, args []any, err error) {
+	if err = q.Validate(); err != nil {
+		return
+	}
+	super := q.ProductKey != ""
+	var table string
+	if super {
+		table = comm.ProductTableName(q.ProductKey)
+	} else {
+		table = comm.DeviceTableName(q.DeviceKeys[0])
+	}
+
+	var fields []string
+	switch {
+	case q.Windowed():
+		fields = append(fields, "_wstart AS ts")
+	case q.Aggregate == comm.AggDiff:
+		fields = append(fields, "_rowts AS ts")
+	case q.Aggregate == "":
+		fields = append(fields, "ts")
+	}
+	if super {
+		fields = append(fields, "device")
+	}
+	for _, p := range q.Properties {
+		column := comm.TsdColumnName(p)
+		if q.Aggregate == "" {
+			fields = append(fields, column)
+		} else {
+			fields = append(fields, fmt.Sprintf("%s(%s) AS %s", q.Aggregate, column, column))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT " + strings.Join(fields, ", ") + " FROM " + table + " WHERE ts >= ? AND ts < ?")
+	args = append(args, q.Start.UnixMilli(), q.End.UnixMilli())
+	if super && len(q.DeviceKeys) > 0 {
+		b.WriteString(" AND device IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(q.DeviceKeys)), ", ") + ")")
+		for _, k := range q.DeviceKeys {
+			args = append(args, k)
+		}
+	}
+	if super && q.Aggregate != "" {
+		b.WriteString(" PARTITION BY device")
+	}
+	if q.Windowed() {
+		b.WriteString(fmt.Sprintf(" INTERVAL(%ds)", int64(q.Interval/time.Second)))
+		b.WriteString(fill(q))
+	}
+
+	countSql = "SELECT count(*) AS num FROM (" + b.String() + ")"
+	if !q.Grouped() {
+		if q.Asc {
+			b.WriteString(" ORDER BY ts ASC")
+		} else {
+			b.WriteString(" ORDER BY ts DESC")
+		}
+	}
+	b.WriteString(" LIMIT ? OFFSET ?")
+	args = append(args, q.Limit, q.Offset)
+	return b.String(), countSql, args, nil
+}
+
+// fill 空窗口的填充子句，填充值按属性个数重复
+func fill(q *comm.Query) string {
+	switch q.Fill {
+	case comm.FillNull:
+		return " FILL(NULL)"
+	case comm.FillPrev:
+		return " FILL(PREV)"
+	case comm.FillLinear:
+		return " FILL(LINEAR)"
+	case comm.FillValue:
+		v := strconv.FormatFloat(q.FillValue, 'f', -1, 64)
+		return " FILL(VALUE, " + strings.TrimSuffix(strings.Repeat(v+", ", len(q.Properties)), ", ") + ")"
+	}
+	return ""
+}
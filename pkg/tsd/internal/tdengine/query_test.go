@@ -0,0 +1,91 @@
+package tdengine
+
+import (
+	"reflect"
+	"sagooiot/pkg/tsd/comm"
+	"testing"
+	"time"
+)
+
+func TestCompileQuery(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	cases := []struct {
+		q     comm.Query
+		sql   string
+		count string
+		args  []any
+	}{
+		{
+			q:     comm.Query{DeviceKeys: []string{"d-1"}, Properties: []string{"Temp"}, Start: start, End: end, Limit: 10},
+			sql:   "SELECT ts, p_temp FROM device_d_1 WHERE ts >= ? AND ts < ? ORDER BY ts DESC LIMIT ? OFFSET ?",
+			count: "SELECT count(*) AS num FROM (SELECT ts, p_temp FROM device_d_1 WHERE ts >= ? AND ts < ?)",
+			args:  []any{start.UnixMilli(), end.UnixMilli(), 10, 0},
+		},
+		{
+			q: comm.Query{ProductKey: "p1", DeviceKeys: []string{"d1", "d2"}, Properties: []string{"temp", "hum"}, Start: start, End: end,
+				Aggregate: comm.AggAvg, Interval: time.Minute, Fill: comm.FillValue, FillValue: 1.5, Asc: true, Limit: 20, Offset: 40},
+			sql: "SELECT _wstart AS ts, device, avg(p_temp) AS p_temp, avg(p_hum) AS p_hum FROM product_p1 WHERE ts >= ? AND ts < ? AND device IN (?, ?)" +
+				" PARTITION BY device INTERVAL(60s) FILL(VALUE, 1.5, 1.5) ORDER BY ts ASC LIMIT ? OFFSET ?",
+			count: "SELECT count(*) AS num FROM (SELECT _wstart AS ts, device, avg(p_temp) AS p_temp, avg(p_hum) AS p_hum FROM product_p1" +
+				" WHERE ts >= ? AND ts < ? AND device IN (?, ?) PARTITION BY device INTERVAL(60s) FILL(VALUE, 1.5, 1.5))",
+			args: []any{start.UnixMilli(), end.UnixMilli(), "d1", "d2", 20, 40},
+		},
+		{
+			q:     comm.Query{ProductKey: "p1", Properties: []string{"temp"}, Start: start, End: end, Aggregate: comm.AggSpread, Limit: 10},
+			sql:   "SELECT device, spread(p_temp) AS p_temp FROM product_p1 WHERE ts >= ? AND ts < ? PARTITION BY device LIMIT ? OFFSET ?",
+			count: "SELECT count(*) AS num FROM (SELECT device, spread(p_temp) AS p_temp FROM product_p1 WHERE ts >= ? AND ts < ? PARTITION BY device)",
+			args:  []any{start.UnixMilli(), end.UnixMilli(), 10, 0},
+		},
+		{
+			q:     comm.Query{DeviceKeys: []string{"d1"}, Properties: []string{"energy"}, Start: start, End: end, Aggregate: comm.AggDiff, Limit: 10},
+			sql:   "SELECT _rowts AS ts, diff(p_energy) AS p_energy FROM device_d1 WHERE ts >= ? AND ts < ? ORDER BY ts DESC LIMIT ? OFFSET ?",
+			count: "SELECT count(*) AS num FROM (SELECT _rowts AS ts, diff(p_energy) AS p_energy FROM device_d1 WHERE ts >= ? AND ts < ?)",
+			args:  []any{start.UnixMilli(), end.UnixMilli(), 10, 0},
+		},
+	}
+	for i, c := range cases {
+		sqlStr, countSql, args, err := compileQuery(&c.q)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if sqlStr != c.sql {
+			t.Errorf("case %d: sql got\n%s\nwant\n%s", i, sqlStr, c.sql)
+		}
+		if countSql != c.count {
+			t.Errorf("case %d: count sql got\n%s\nwant\n%s", i, countSql, c.count)
+		}
+		if !reflect.DeepEqual(args, c.args) {
+			t.Errorf("case %d: args got %v, want %v", i, args, c.args)
+		}
+	}
+}
+
+func TestCompileQueryInvalid(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	valid := comm.Query{DeviceKeys: []string{"d1"}, Properties: []string{"temp"}, Start: start, End: start.Add(time.Hour), Limit: 10}
+
+	invalid := []func(q *comm.Query){
+		func(q *comm.Query) { q.DeviceKeys = nil },
+		func(q *comm.Query) { q.DeviceKeys = []string{"d1; drop table x"} },
+		func(q *comm.Query) { q.DeviceKeys = []string{"d1", "d2"} },
+		func(q *comm.Query) { q.ProductKey = "p 1" },
+		func(q *comm.Query) { q.Properties = []string{"temp) FROM x --"} },
+		func(q *comm.Query) { q.Properties = []string{"ts"} },
+		func(q *comm.Query) { q.End = q.Start },
+		func(q *comm.Query) { q.Aggregate = "mode" },
+		func(q *comm.Query) { q.Interval = time.Minute },
+		func(q *comm.Query) { q.Aggregate, q.Interval = comm.AggDiff, time.Minute },
+		func(q *comm.Query) { q.Aggregate, q.Interval = comm.AggAvg, 1500*time.Millisecond },
+		func(q *comm.Query) { q.Fill = comm.FillPrev },
+		func(q *comm.Query) { q.Limit = comm.QueryMaxLimit + 1 },
+	}
+	for i, f := range invalid {
+		q := valid
+		f(&q)
+		if _, _, _, err := compileQuery(&q); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
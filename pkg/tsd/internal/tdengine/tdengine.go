@@ -44,6 +44,11 @@ func (m *TdEngine) Close() {
 	//fmt.Println("Closed TdEngine database connection.")
 }
 
+// Shutdown 服务停止时关闭数据库连接
+func (m *TdEngine) Shutdown() {
+	m.Close()
+}
+
 // Query 查询
 func (m *TdEngine) Query(sql string) (rows *sql.Rows, err error) {
 	if m.db == nil {
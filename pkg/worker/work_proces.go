@@ -2,8 +2,12 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"time"
+
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/util/guid"
+	"github.com/hibiken/asynq"
 )
 
 // Scheduled 任务调度器
@@ -49,6 +53,24 @@ func (s *Scheduled) Push(ctx context.Context, topic string, data []byte, timeout
 	return
 }
 
+// PushAt 在指定时间执行任务，uid 相同的任务只会加入一次，可用于多实例下的去重
+func (s *Scheduled) PushAt(ctx context.Context, topic, uid string, data []byte, at time.Time, timeout int) (err error) {
+	err = s.w.Once(
+		WithRunUuid(uid),
+		WithRunPayload(data),
+		WithRunGroup(topic),
+		WithRunAt(at),
+		WithRunTimeout(timeout),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil
+	}
+	if err != nil {
+		g.Log().Debug(ctx, "Run Delay TaskWorker %s Error: %v", topic, err)
+	}
+	return
+}
+
 // Cron 采用定时任务的方式执行任务
 func (s *Scheduled) Cron(ctx context.Context, topic, cronExpr string, data []byte) (err error) {
 	s.topic = topic